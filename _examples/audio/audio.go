@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"io"
-	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -12,7 +10,7 @@ import (
 	"time"
 
 	"github.com/disgoorg/audio"
-	"github.com/disgoorg/audio/mp3"
+	"github.com/disgoorg/audio/httpstream"
 	"github.com/disgoorg/audio/pcm"
 	"github.com/disgoorg/disgo"
 	"github.com/disgoorg/disgo/bot"
@@ -78,27 +76,15 @@ func play(client bot.Client) {
 		panic("error connecting to voice channel: " + err.Error())
 	}
 
-	rs, err := http.Get("https://p.scdn.co/mp3-preview/029f4fba66c0b2cfddfe53fc14b95fa2982e423a")
+	streamProvider, err := httpstream.NewPCMFrameProvider(context.Background(), "https://p.scdn.co/mp3-preview/029f4fba66c0b2cfddfe53fc14b95fa2982e423a")
 	if err != nil {
-		panic("error getting audio: " + err.Error())
+		panic("error opening audio stream: " + err.Error())
 	}
 
-	mp3Provider, writer, err := mp3.NewPCMFrameProvider(nil)
-	if err != nil {
-		panic("error creating mp3 provider: " + err.Error())
-	}
-
-	buffer := pcm.NewBufferPCMProvider(mp3Provider)
-
-	opusProvider, err := pcm.NewOpusProvider(nil, buffer)
+	opusProvider, err := pcm.NewOpusProvider(nil, streamProvider)
 	if err != nil {
 		panic("error creating opus provider: " + err.Error())
 	}
 
 	conn.SetOpusFrameProvider(opusProvider)
-
-	defer rs.Body.Close()
-	if _, err = io.Copy(writer, rs.Body); err != nil {
-		panic("error reading audio: " + err.Error())
-	}
 }
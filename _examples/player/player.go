@@ -125,13 +125,15 @@ func (p *TrackPlayer) next() {
 	}
 	defer rs.Body.Close()
 
-	var w io.Writer
+	var w io.WriteCloser
 	p.provider, w, err = mp3.NewPCMFrameProvider(nil)
 	if err != nil {
 		panic("error creating mp3 provider: " + err.Error())
 		return
 	}
 	_, _ = io.Copy(w, rs.Body)
+	// signal the end of the track once everything is written
+	_ = w.Close()
 }
 
 func (p *TrackPlayer) OnPause(player audio.Player) {
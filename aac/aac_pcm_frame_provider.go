@@ -0,0 +1,197 @@
+package aac
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/disgoorg/audio/channelconverter"
+	"github.com/disgoorg/audio/internal/pcmconv"
+	"github.com/disgoorg/audio/mp4"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// ErrUnsupportedTrack is returned when the selected mp4 track does not contain AAC.
+var ErrUnsupportedTrack = errors.New("aac: mp4 track does not contain aac")
+
+// NewMP4PCMFrameProvider returns a SeekableFrameProvider that decodes the selected AAC track of the given mp4.Demuxer into pcm frames.
+// Use mp4.Open to create the mp4.Demuxer and read the iTunes metadata of the file.
+func NewMP4PCMFrameProvider(decoder *Decoder, demuxer *mp4.Demuxer) (pcm.SeekableFrameProvider, error) {
+	return NewCustomMP4PCMFrameProvider(decoder, demuxer, 48000, 2)
+}
+
+// NewCustomMP4PCMFrameProvider returns a SeekableFrameProvider that decodes the selected AAC track of the given mp4.Demuxer into pcm frames.
+// You can specify the rate and channels of the output PCM frames. The AAC is resampled and channel converted as needed.
+func NewCustomMP4PCMFrameProvider(decoder *Decoder, demuxer *mp4.Demuxer, rate int, channels int) (pcm.SeekableFrameProvider, error) {
+	track := demuxer.Track()
+	if !track.IsAAC() {
+		return nil, ErrUnsupportedTrack
+	}
+	if decoder == nil {
+		var err error
+		decoder, err = CreateDecoder(TransportRaw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create aac decoder: %w", err)
+		}
+	}
+	if err := decoder.ConfigRaw(track.DecoderConfig); err != nil {
+		_ = decoder.Close()
+		return nil, fmt.Errorf("failed to configure aac decoder: %w", err)
+	}
+
+	return &mp4PCMFrameProvider{
+		decoder:   decoder,
+		demuxer:   demuxer,
+		converter: pcmconv.NewConverter(rate, channels, channelconverter.SMPTELayout),
+		pcmBuff:   make([]int16, MaxFrameSize*MaxChannels),
+	}, nil
+}
+
+type mp4PCMFrameProvider struct {
+	decoder   *Decoder
+	demuxer   *mp4.Demuxer
+	converter *pcmconv.Converter
+	pcmBuff   []int16
+	flags     DecodeFlags
+}
+
+func (p *mp4PCMFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	for !p.converter.Ready() {
+		sample, err := p.demuxer.ReadSample()
+		if err == io.EOF {
+			return p.converter.Flush()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err = p.decoder.Fill(sample); err != nil {
+			return nil, err
+		}
+
+		n, err := p.decoder.DecodeFrame(p.pcmBuff, p.flags)
+		p.flags = 0
+		if aacErr, ok := err.(Error); ok && (aacErr.IsDecodeError() || aacErr == ErrNotEnoughBits) {
+			// skip broken access units
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		info := p.decoder.StreamInfo()
+		if err = p.converter.Convert(p.pcmBuff[:n], info.SampleRate, info.Channels); err != nil {
+			return nil, err
+		}
+	}
+	return p.converter.Next(), nil
+}
+
+func (p *mp4PCMFrameProvider) Seek(position time.Duration) error {
+	if err := p.demuxer.Seek(position); err != nil {
+		return err
+	}
+	p.flags = DecodeFlagInterrupt
+	return p.converter.Reset()
+}
+
+func (p *mp4PCMFrameProvider) Position() time.Duration {
+	position := p.demuxer.Position()
+	// subtract what was decoded but not returned yet
+	return position - p.converter.Pending()
+}
+
+func (p *mp4PCMFrameProvider) Duration() time.Duration {
+	return p.demuxer.Duration()
+}
+
+func (p *mp4PCMFrameProvider) Close() {
+	p.converter.Close()
+	_ = p.decoder.Close()
+}
+
+// NewADTSPCMFrameProvider returns a FrameProvider that decodes an ADTS stream from the given io.Reader into pcm frames.
+func NewADTSPCMFrameProvider(decoder *Decoder, r io.Reader) (pcm.FrameProvider, error) {
+	return NewCustomADTSPCMFrameProvider(decoder, r, 48000, 2)
+}
+
+// NewCustomADTSPCMFrameProvider returns a FrameProvider that decodes an ADTS stream from the given io.Reader into pcm frames.
+// You can specify the rate and channels of the output PCM frames. The AAC is resampled and channel converted as needed.
+func NewCustomADTSPCMFrameProvider(decoder *Decoder, r io.Reader, rate int, channels int) (pcm.FrameProvider, error) {
+	if decoder == nil {
+		var err error
+		decoder, err = CreateDecoder(TransportADTS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create aac decoder: %w", err)
+		}
+	}
+
+	return &adtsPCMFrameProvider{
+		decoder:   decoder,
+		reader:    r,
+		converter: pcmconv.NewConverter(rate, channels, channelconverter.SMPTELayout),
+		readBuff:  make([]byte, 4096),
+		pcmBuff:   make([]int16, MaxFrameSize*MaxChannels),
+	}, nil
+}
+
+type adtsPCMFrameProvider struct {
+	decoder   *Decoder
+	reader    io.Reader
+	converter *pcmconv.Converter
+	readBuff  []byte
+	// input is the part of readBuff which was not consumed by the Decoder yet
+	input   []byte
+	pcmBuff []int16
+	eof     bool
+}
+
+func (p *adtsPCMFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	for !p.converter.Ready() {
+		n, err := p.decoder.DecodeFrame(p.pcmBuff, 0)
+		if err == ErrNotEnoughBits {
+			if p.eof && len(p.input) == 0 {
+				return p.converter.Flush()
+			}
+			if err = p.fill(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if aacErr, ok := err.(Error); ok && (aacErr.IsDecodeError() || aacErr.IsSyncError()) {
+			// the decoder resyncs on the next ADTS header
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		info := p.decoder.StreamInfo()
+		if err = p.converter.Convert(p.pcmBuff[:n], info.SampleRate, info.Channels); err != nil {
+			return nil, err
+		}
+	}
+	return p.converter.Next(), nil
+}
+
+// fill passes more data to the Decoder.
+func (p *adtsPCMFrameProvider) fill() error {
+	if len(p.input) == 0 {
+		n, err := p.reader.Read(p.readBuff)
+		if err == io.EOF {
+			p.eof = true
+		} else if err != nil {
+			return err
+		}
+		p.input = p.readBuff[:n]
+	}
+	n, err := p.decoder.Fill(p.input)
+	if err != nil {
+		return err
+	}
+	p.input = p.input[n:]
+	return nil
+}
+
+func (p *adtsPCMFrameProvider) Close() {
+	p.converter.Close()
+	_ = p.decoder.Close()
+}
@@ -0,0 +1,137 @@
+package aac
+
+/*
+#cgo pkg-config: fdk-aac
+#include <aacdecoder_lib.h>
+
+static AAC_DECODER_ERROR aac_config_raw(HANDLE_AACDECODER decoder, UCHAR *config, UINT length) {
+	UCHAR *configs[1] = {config};
+	UINT lengths[1] = {length};
+	return aacDecoder_ConfigRaw(decoder, configs, lengths);
+}
+
+static AAC_DECODER_ERROR aac_fill(HANDLE_AACDECODER decoder, UCHAR *buffer, UINT size, UINT *valid) {
+	UCHAR *buffers[1] = {buffer};
+	UINT sizes[1] = {size};
+	*valid = size;
+	return aacDecoder_Fill(decoder, buffers, sizes, valid);
+}
+*/
+import "C"
+import "unsafe"
+
+// Transport is the transport format of the AAC stream.
+type Transport int
+
+const (
+	// TransportRaw is used for raw access units, like the samples of a mp4 file. Call ConfigRaw with the AudioSpecificConfig before decoding.
+	TransportRaw  Transport = C.TT_MP4_RAW
+	TransportADIF Transport = C.TT_MP4_ADIF
+	TransportADTS Transport = C.TT_MP4_ADTS
+	TransportLOAS Transport = C.TT_MP4_LOAS
+)
+
+// DecodeFlags modify the behaviour of DecodeFrame.
+type DecodeFlags int
+
+const (
+	// DecodeFlagConceal conceals a lost frame.
+	DecodeFlagConceal DecodeFlags = C.AACDEC_CONCEAL
+	// DecodeFlagFlush flushes the internal buffers.
+	DecodeFlagFlush DecodeFlags = C.AACDEC_FLUSH
+	// DecodeFlagInterrupt signals a discontinuity of the input, e.g. after seeking.
+	DecodeFlagInterrupt DecodeFlags = C.AACDEC_INTR
+	// DecodeFlagClearHistory clears the decoder history.
+	DecodeFlagClearHistory DecodeFlags = C.AACDEC_CLRHIST
+)
+
+// MaxFrameSize is the maximum amount of samples DecodeFrame outputs per channel.
+const MaxFrameSize = 4096
+
+// MaxChannels is the maximum amount of channels DecodeFrame outputs.
+const MaxChannels = 8
+
+// StreamInfo describes the decoded output.
+type StreamInfo struct {
+	SampleRate int
+	// FrameSize is the amount of samples per channel of the last decoded frame.
+	FrameSize int
+	Channels  int
+	// AudioObjectType is the audio object type of the stream, e.g. 2 for AAC-LC, 5 for HE-AAC and 29 for HE-AACv2.
+	AudioObjectType int
+	Bitrate         int
+}
+
+// CreateDecoder creates a new fdk-aac Decoder for the given Transport.
+func CreateDecoder(transport Transport) (*Decoder, error) {
+	decoder := C.aacDecoder_Open(C.TRANSPORT_TYPE(transport), 1)
+	if decoder == nil {
+		return nil, ErrOutOfMemory
+	}
+	return &Decoder{
+		decoder: decoder,
+	}, nil
+}
+
+// Decoder decodes AAC using fdk-aac.
+type Decoder struct {
+	decoder C.HANDLE_AACDECODER
+}
+
+// ConfigRaw configures the Decoder with the AudioSpecificConfig of a raw stream.
+func (d *Decoder) ConfigRaw(config []byte) error {
+	if len(config) == 0 {
+		return ErrUnsupportedFormat
+	}
+	if err := C.aac_config_raw(d.decoder, (*C.UCHAR)(unsafe.Pointer(&config[0])), C.UINT(len(config))); err != C.AAC_DEC_OK {
+		return Error(err)
+	}
+	return nil
+}
+
+// Fill copies data into the internal input buffer of the Decoder and returns how many bytes were consumed.
+func (d *Decoder) Fill(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	var valid C.UINT
+	if err := C.aac_fill(d.decoder, (*C.UCHAR)(unsafe.Pointer(&data[0])), C.UINT(len(data)), &valid); err != C.AAC_DEC_OK {
+		return 0, Error(err)
+	}
+	return len(data) - int(valid), nil
+}
+
+// DecodeFrame decodes a frame into interleaved signed 16 bit PCM and returns the amount of samples written over all channels.
+// pcm should have room for MaxFrameSize * MaxChannels samples. ErrNotEnoughBits is returned if the Decoder needs more data.
+func (d *Decoder) DecodeFrame(pcm []int16, flags DecodeFlags) (int, error) {
+	if err := C.aacDecoder_DecodeFrame(d.decoder, (*C.INT_PCM)(unsafe.Pointer(&pcm[0])), C.INT(len(pcm)), C.UINT(flags)); err != C.AAC_DEC_OK {
+		return 0, Error(err)
+	}
+	info := d.StreamInfo()
+	return info.FrameSize * info.Channels, nil
+}
+
+// StreamInfo returns the format of the last decoded frame.
+func (d *Decoder) StreamInfo() StreamInfo {
+	info := C.aacDecoder_GetStreamInfo(d.decoder)
+	if info == nil {
+		return StreamInfo{}
+	}
+	return StreamInfo{
+		SampleRate:      int(info.sampleRate),
+		FrameSize:       int(info.frameSize),
+		Channels:        int(info.numChannels),
+		AudioObjectType: int(info.aot),
+		Bitrate:         int(info.bitRate),
+	}
+}
+
+// Close frees the Decoder.
+func (d *Decoder) Close() error {
+	if d.decoder == nil {
+		return nil
+	}
+	C.aacDecoder_Close(d.decoder)
+	d.decoder = nil
+	return nil
+}
@@ -0,0 +1,73 @@
+package aac
+
+/*
+#cgo pkg-config: fdk-aac
+#include <aacdecoder_lib.h>
+*/
+import "C"
+import "fmt"
+
+var _ error = Error(0)
+
+// Error is an AAC_DECODER_ERROR returned by fdk-aac.
+type Error int
+
+func (e Error) Error() string {
+	if message, ok := errorMessages[e]; ok {
+		return fmt.Sprintf("aac: %s", message)
+	}
+	return fmt.Sprintf("aac: error 0x%04x", int(e))
+}
+
+// IsDecodeError returns whether the error only affects the current frame. Decoding can continue with the next frame.
+func (e Error) IsDecodeError() bool {
+	return e >= C.aac_dec_decode_error_start && e <= C.aac_dec_decode_error_end
+}
+
+// IsSyncError returns whether the decoder lost sync or needs more data.
+func (e Error) IsSyncError() bool {
+	return e >= C.aac_dec_sync_error_start && e <= C.aac_dec_sync_error_end
+}
+
+const (
+	ErrOK                    Error = C.AAC_DEC_OK
+	ErrOutOfMemory           Error = C.AAC_DEC_OUT_OF_MEMORY
+	ErrUnknown               Error = C.AAC_DEC_UNKNOWN
+	ErrTransportSyncError    Error = C.AAC_DEC_TRANSPORT_SYNC_ERROR
+	ErrNotEnoughBits         Error = C.AAC_DEC_NOT_ENOUGH_BITS
+	ErrInvalidHandle         Error = C.AAC_DEC_INVALID_HANDLE
+	ErrUnsupportedAOT        Error = C.AAC_DEC_UNSUPPORTED_AOT
+	ErrUnsupportedFormat     Error = C.AAC_DEC_UNSUPPORTED_FORMAT
+	ErrUnsupportedChannels   Error = C.AAC_DEC_UNSUPPORTED_CHANNELCONFIG
+	ErrUnsupportedSampleRate Error = C.AAC_DEC_UNSUPPORTED_SAMPLINGRATE
+	ErrNeedToRestart         Error = C.AAC_DEC_NEED_TO_RESTART
+	ErrOutputBufferTooSmall  Error = C.AAC_DEC_OUTPUT_BUFFER_TOO_SMALL
+	ErrTransportError        Error = C.AAC_DEC_TRANSPORT_ERROR
+	ErrParseError            Error = C.AAC_DEC_PARSE_ERROR
+	ErrDecodeFrameError      Error = C.AAC_DEC_DECODE_FRAME_ERROR
+	ErrCRCError              Error = C.AAC_DEC_CRC_ERROR
+	ErrUnsupportedExtension  Error = C.AAC_DEC_UNSUPPORTED_EXTENSION_PAYLOAD
+	ErrInvalidCodeBook       Error = C.AAC_DEC_INVALID_CODE_BOOK
+)
+
+// fdk-aac has no strerror equivalent
+var errorMessages = map[Error]string{
+	ErrOK:                    "no error",
+	ErrOutOfMemory:           "out of memory",
+	ErrUnknown:               "unknown error",
+	ErrTransportSyncError:    "transport sync error",
+	ErrNotEnoughBits:         "not enough bits to decode a frame",
+	ErrInvalidHandle:         "invalid decoder handle",
+	ErrUnsupportedAOT:        "unsupported audio object type",
+	ErrUnsupportedFormat:     "unsupported format",
+	ErrUnsupportedChannels:   "unsupported channel configuration",
+	ErrUnsupportedSampleRate: "unsupported sample rate",
+	ErrNeedToRestart:         "decoder needs to be restarted",
+	ErrOutputBufferTooSmall:  "output buffer too small",
+	ErrTransportError:        "transport error",
+	ErrParseError:            "bitstream parse error",
+	ErrDecodeFrameError:      "frame decode error",
+	ErrCRCError:              "crc mismatch",
+	ErrUnsupportedExtension:  "unsupported extension payload",
+	ErrInvalidCodeBook:       "invalid codebook",
+}
@@ -0,0 +1,278 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+	"github.com/disgoorg/disgo/voice"
+)
+
+// ErrNotCached is returned when seeking a CachedOpusProvider which is not served from the cache.
+var ErrNotCached = errors.New("frames are not cached")
+
+// CacheKey identifies cached Opus frames. Frames of the same source encoded with different settings are cached separately.
+type CacheKey struct {
+	SourceID string
+	// EncoderSettings describes the opus.EncoderConfig which produced the frames.
+	EncoderSettings string
+}
+
+func (k CacheKey) String() string {
+	return k.SourceID + "@" + k.EncoderSettings
+}
+
+// NewCacheKey returns the CacheKey of the given source encoded with the given opus.EncoderConfig.
+func NewCacheKey(sourceID string, config opus.EncoderConfig) CacheKey {
+	var app string
+	switch config.Application {
+	case opus.ApplicationVoip:
+		app = "voip"
+	case opus.ApplicationAudio:
+		app = "audio"
+	case opus.ApplicationRestrictedLowdelay:
+		app = "lowdelay"
+	default:
+		app = fmt.Sprint(int(config.Application))
+	}
+	settings := fmt.Sprintf("%d/%d/%s/%d/complexity=%d/signal=%s/bandwidth=%s/vbr=%t/cvbr=%t/fec=%t/loss=%d/dtx=%t",
+		config.SampleRate, config.Channels, app, config.Bitrate, config.Complexity, config.Signal, config.MaxBandwidth,
+		config.VBR, config.VBRConstraint, config.InbandFEC, config.PacketLossPerc, config.DTX,
+	)
+	if config.Adaptive != nil {
+		// the settings change at runtime, so the frames depend on the connection they were encoded for
+		settings += "/adaptive"
+	}
+	return CacheKey{
+		SourceID:        sourceID,
+		EncoderSettings: settings,
+	}
+}
+
+// NewCache returns a Cache which stores the Opus frames of fully played tracks in the given CacheStore.
+func NewCache(store CacheStore, opts ...CacheConfigOpt) *Cache {
+	config := DefaultCacheConfig()
+	config.Apply(opts)
+
+	maxEntrySize := config.MaxEntrySize
+	if limitedStore, ok := store.(sizeLimitedCacheStore); ok && limitedStore.maxEntrySize() < maxEntrySize {
+		maxEntrySize = limitedStore.maxEntrySize()
+	}
+	return &Cache{
+		store:        store,
+		maxEntrySize: maxEntrySize,
+	}
+}
+
+// Cache serves Opus frames of previously played tracks instead of decoding and encoding them again.
+type Cache struct {
+	store        CacheStore
+	maxEntrySize int64
+}
+
+// Store returns the CacheStore of the Cache.
+func (c *Cache) Store() CacheStore {
+	return c.store
+}
+
+// CachedOpusProvider is a voice.OpusFrameProvider returned by Cache.NewOpusProvider.
+type CachedOpusProvider interface {
+	voice.OpusFrameProvider
+
+	// Cached returns whether the frames are served from the cache.
+	Cached() bool
+
+	// Frame returns the index of the next frame.
+	Frame() int
+
+	// Frames returns the amount of cached frames or 0 if the frames are not served from the cache.
+	Frames() int
+
+	// SeekFrame seeks to the frame with the given index. It returns ErrNotCached if the frames are not served from the cache.
+	SeekFrame(index int) error
+}
+
+// CachedFrameProvider is a pcm.FrameProvider returned by Cache.NewFrameProvider.
+// On a cache hit a Player takes the Opus frames from ProvideOpusFrame instead of encoding the PCM frames, ProvidePCMFrame returns io.EOF.
+// On a cache miss ProvidePCMFrame provides the frames of the source and ProvideOpusFrame returns ErrNotCached.
+type CachedFrameProvider interface {
+	pcm.FrameProvider
+	CachedOpusProvider
+}
+
+// opusFrameRecorder is implemented by the CachedFrameProvider(s) of a Cache to record the Opus frames a Player encoded from them.
+type opusFrameRecorder interface {
+	recordOpusFrame(frame []byte, err error) ([]byte, error)
+}
+
+// NewOpusProvider returns a CachedOpusProvider which serves the frames of the given source from the cache.
+// On a cache miss it opens the pcm.FrameProvider returned by providerFunc, encodes it using pcm.NewConfiguredOpusProvider and tees the frames into the cache once the track was played to the end.
+// While pausedFunc returns true, no frames are provided. pausedFunc may be nil.
+func (c *Cache) NewOpusProvider(sourceID string, encoderConfig opus.EncoderConfig, providerFunc func() (pcm.FrameProvider, error), pausedFunc func() bool) (CachedOpusProvider, error) {
+	provider, err := c.newProvider(NewCacheKey(sourceID, encoderConfig), providerFunc)
+	if err != nil {
+		return nil, err
+	}
+	provider.pausedFunc = pausedFunc
+	if provider.pcmProvider != nil {
+		if provider.opusProvider, err = pcm.NewConfiguredOpusProvider(encoderConfig, provider.pcmProvider); err != nil {
+			provider.pcmProvider.Close()
+			return nil, err
+		}
+	}
+	return provider, nil
+}
+
+// NewFrameProvider returns a CachedFrameProvider for a Player encoding with the given opus.EncoderConfig, see WithEncoderConfig.
+// On a cache hit the Player serves the cached frames, otherwise it plays the pcm.FrameProvider returned by providerFunc and tees the encoded frames into the cache once the track was played to the end.
+// The frames are recorded after the volume and pcm.Filter(s) of the Player and served from the cache without applying them again.
+func (c *Cache) NewFrameProvider(sourceID string, encoderConfig opus.EncoderConfig, providerFunc func() (pcm.FrameProvider, error)) (CachedFrameProvider, error) {
+	return c.newProvider(NewCacheKey(sourceID, encoderConfig), providerFunc)
+}
+
+func (c *Cache) newProvider(key CacheKey, providerFunc func() (pcm.FrameProvider, error)) (*cachedProvider, error) {
+	frames, ok, err := c.store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frames from cache: %w", err)
+	}
+	provider := &cachedProvider{
+		cache: c,
+		key:   key,
+	}
+	if ok {
+		provider.frames = frames
+		return provider, nil
+	}
+
+	if provider.pcmProvider, err = providerFunc(); err != nil {
+		return nil, err
+	}
+	provider.recording = true
+	return provider, nil
+}
+
+type cachedProvider struct {
+	cache      *Cache
+	key        CacheKey
+	pausedFunc func() bool
+
+	mu sync.Mutex
+	// frames and index are used on a cache hit
+	frames [][]byte
+	index  int
+	// pcmProvider, opusProvider and recorded are used on a cache miss. opusProvider is only set by Cache.NewOpusProvider
+	pcmProvider  pcm.FrameProvider
+	opusProvider voice.OpusFrameProvider
+	recorded     [][]byte
+	recordedSize int64
+	recording    bool
+}
+
+func (p *cachedProvider) ProvidePCMFrame() ([]int16, error) {
+	if p.pcmProvider == nil {
+		return nil, io.EOF
+	}
+	return p.pcmProvider.ProvidePCMFrame()
+}
+
+func (p *cachedProvider) ProvideOpusFrame() ([]byte, error) {
+	if p.pausedFunc != nil && p.pausedFunc() {
+		return nil, nil
+	}
+	if p.pcmProvider == nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.index >= len(p.frames) {
+			return nil, io.EOF
+		}
+		frame := p.frames[p.index]
+		p.index++
+		return frame, nil
+	}
+
+	if p.opusProvider == nil {
+		return nil, ErrNotCached
+	}
+	return p.recordOpusFrame(p.opusProvider.ProvideOpusFrame())
+}
+
+// recordOpusFrame records the given frame and puts the recorded frames into the cache once the track ended with io.EOF.
+func (p *cachedProvider) recordOpusFrame(frame []byte, err error) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == io.EOF && p.recording {
+		recorded := p.recorded
+		p.stopRecording()
+		if err = p.cache.store.Put(p.key, recorded); err != nil {
+			return nil, fmt.Errorf("failed to put frames into cache: %w", err)
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if frame != nil {
+		p.index++
+		if p.recording {
+			if p.recordedSize += int64(len(frame)); p.recordedSize > p.cache.maxEntrySize {
+				p.stopRecording()
+				return frame, nil
+			}
+			// the encoder reuses its buffer
+			p.recorded = append(p.recorded, append([]byte(nil), frame...))
+		}
+	}
+	return frame, nil
+}
+
+func (p *cachedProvider) stopRecording() {
+	p.recording = false
+	p.recorded = nil
+	p.recordedSize = 0
+}
+
+func (p *cachedProvider) Cached() bool {
+	return p.pcmProvider == nil
+}
+
+func (p *cachedProvider) Frame() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.index
+}
+
+func (p *cachedProvider) Frames() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.frames)
+}
+
+func (p *cachedProvider) SeekFrame(index int) error {
+	if p.pcmProvider != nil {
+		return ErrNotCached
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if index < 0 {
+		index = 0
+	} else if index > len(p.frames) {
+		index = len(p.frames)
+	}
+	p.index = index
+	return nil
+}
+
+func (p *cachedProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// incomplete tracks are not cached
+	p.stopRecording()
+	if p.opusProvider != nil {
+		p.opusProvider.Close()
+	} else if p.pcmProvider != nil {
+		p.pcmProvider.Close()
+	}
+}
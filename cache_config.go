@@ -0,0 +1,33 @@
+package audio
+
+// DefaultCacheConfig returns a CacheConfig with sensible defaults.
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		// about 2 hours of audio at 64kbps
+		MaxEntrySize: 64 << 20,
+	}
+}
+
+// CacheConfig is used to configure a Cache created by NewCache.
+type CacheConfig struct {
+	// MaxEntrySize is the maximum size in bytes of the Opus frames recorded for a single track.
+	// Tracks exceeding it, like live streams, are played without being cached.
+	MaxEntrySize int64
+}
+
+// CacheConfigOpt is used to functionally configure a CacheConfig.
+type CacheConfigOpt func(config *CacheConfig)
+
+// Apply applies the CacheConfigOpt(s) to the CacheConfig.
+func (c *CacheConfig) Apply(opts []CacheConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithMaxCacheEntrySize sets the maximum size in bytes of the Opus frames recorded for a single track.
+func WithMaxCacheEntrySize(size int64) CacheConfigOpt {
+	return func(config *CacheConfig) {
+		config.MaxEntrySize = size
+	}
+}
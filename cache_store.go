@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/disgoorg/audio/opus"
+)
+
+// ErrCacheEntryTooLarge is returned by the memory CacheStore when the frames of a single entry exceed its size limit.
+var ErrCacheEntryTooLarge = errors.New("cache entry exceeds the cache size limit")
+
+// CacheStore stores the Opus frames of cached tracks. It has to be safe for concurrent use.
+// The returned frames must not be modified.
+type CacheStore interface {
+	// Get returns the frames stored for the given key and whether they were found.
+	Get(key CacheKey) ([][]byte, bool, error)
+
+	// Put stores the frames for the given key.
+	Put(key CacheKey, frames [][]byte) error
+
+	// Delete removes the frames stored for the given key.
+	Delete(key CacheKey) error
+}
+
+// sizeLimitedCacheStore is implemented by CacheStore(s) which reject entries above a size, so the Cache stops recording tracks once they exceed it.
+type sizeLimitedCacheStore interface {
+	maxEntrySize() int64
+}
+
+// NewMemoryCacheStore returns a CacheStore which keeps up to maxSize bytes of Opus frames in memory and evicts the least recently used entries first.
+func NewMemoryCacheStore(maxSize int64) CacheStore {
+	return &memoryCacheStore{
+		maxSize: maxSize,
+		entries: map[CacheKey]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+type memoryCacheEntry struct {
+	key    CacheKey
+	frames [][]byte
+	size   int64
+}
+
+type memoryCacheStore struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	entries map[CacheKey]*list.Element
+	// lru holds the entries with the most recently used one at the front
+	lru *list.List
+}
+
+func (s *memoryCacheStore) maxEntrySize() int64 {
+	return s.maxSize
+}
+
+func (s *memoryCacheStore) Get(key CacheKey) ([][]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).frames, true, nil
+}
+
+func (s *memoryCacheStore) Put(key CacheKey, frames [][]byte) error {
+	var size int64
+	for _, frame := range frames {
+		size += int64(len(frame))
+	}
+	if size > s.maxSize {
+		return ErrCacheEntryTooLarge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	for s.size+size > s.maxSize {
+		s.remove(s.lru.Back())
+	}
+	s.entries[key] = s.lru.PushFront(&memoryCacheEntry{
+		key:    key,
+		frames: frames,
+		size:   size,
+	})
+	s.size += size
+	return nil
+}
+
+func (s *memoryCacheStore) Delete(key CacheKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	return nil
+}
+
+func (s *memoryCacheStore) remove(element *list.Element) {
+	entry := s.lru.Remove(element).(*memoryCacheEntry)
+	delete(s.entries, entry.key)
+	s.size -= entry.size
+}
+
+// NewDirCacheStore returns a CacheStore which stores each entry as DCA file in the given directory. The directory is created if it does not exist.
+func NewDirCacheStore(dir string) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &dirCacheStore{
+		dir: dir,
+	}, nil
+}
+
+type dirCacheStore struct {
+	dir string
+}
+
+// path returns the file of the given key. Keys are hashed as source ids may contain any character.
+func (s *dirCacheStore) path(key CacheKey) string {
+	hash := sha256.Sum256([]byte(key.String()))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".dca")
+}
+
+func (s *dirCacheStore) Get(key CacheKey) ([][]byte, bool, error) {
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	provider, err := opus.NewDCAFrameProvider(file)
+	if err != nil {
+		return nil, false, err
+	}
+	var frames [][]byte
+	for {
+		frame, err := provider.ProvideOpusFrame()
+		if err == io.EOF {
+			return frames, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		// the provider reuses its buffer
+		frames = append(frames, append([]byte(nil), frame...))
+	}
+}
+
+func (s *dirCacheStore) Put(key CacheKey, frames [][]byte) error {
+	// write to a temporary file first so readers never see partial entries
+	file, err := os.CreateTemp(s.dir, ".tmp-*.dca")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer, err := opus.NewDCAWriter(file, nil)
+	if err == nil {
+		for _, frame := range frames {
+			if err = writer.WriteFrame(frame); err != nil {
+				break
+			}
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.path(key))
+}
+
+func (s *dirCacheStore) Delete(key CacheKey) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// countingFrameProvider provides the given number of frames whose only sample is the index of the frame.
+type countingFrameProvider struct {
+	frames int
+	index  int
+	closed bool
+}
+
+func (p *countingFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	if p.index >= p.frames {
+		return nil, io.EOF
+	}
+	p.index++
+	return []int16{int16(p.index)}, nil
+}
+
+func (p *countingFrameProvider) Close() {
+	p.closed = true
+}
+
+// play plays the given CachedFrameProvider like a Player with an encoder which turns every sample into one byte.
+func play(t *testing.T, provider CachedFrameProvider, frames int) [][]byte {
+	t.Helper()
+	recorder := provider.(opusFrameRecorder)
+	var played [][]byte
+	for i := 0; i < frames; i++ {
+		var frame []byte
+		pcmFrame, err := provider.ProvidePCMFrame()
+		if err == nil {
+			frame = []byte{byte(pcmFrame[0])}
+		}
+		if frame, err = recorder.recordOpusFrame(frame, err); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("record frame %d: %v", i, err)
+		}
+		played = append(played, frame)
+	}
+	return played
+}
+
+func testFrames(n int) [][]byte {
+	frames := make([][]byte, n)
+	for i := range frames {
+		frames[i] = []byte{byte(i + 1)}
+	}
+	return frames
+}
+
+func TestNewCacheKey(t *testing.T) {
+	key := NewCacheKey("track", opus.DefaultEncoderConfig())
+	if key != NewCacheKey("track", opus.DefaultEncoderConfig()) {
+		t.Fatal("got different keys for the same settings")
+	}
+
+	for name, modify := range map[string]func(config *opus.EncoderConfig){
+		"bitrate":    func(config *opus.EncoderConfig) { config.Bitrate = opus.BitrateMusic },
+		"complexity": func(config *opus.EncoderConfig) { config.Complexity = 5 },
+		"signal":     func(config *opus.EncoderConfig) { config.Signal = opus.SignalMusic },
+		"vbr":        func(config *opus.EncoderConfig) { config.VBR = false },
+		"fec":        func(config *opus.EncoderConfig) { config.InbandFEC = true },
+		"dtx":        func(config *opus.EncoderConfig) { config.DTX = true },
+		"adaptive":   func(config *opus.EncoderConfig) { config.Adaptive = opus.DefaultAdaptiveConfig(nil, 0) },
+	} {
+		config := opus.DefaultEncoderConfig()
+		modify(&config)
+		if NewCacheKey("track", config) == key {
+			t.Fatalf("got the same key after changing the %s", name)
+		}
+	}
+}
+
+func TestMemoryCacheStoreEviction(t *testing.T) {
+	store := NewMemoryCacheStore(10)
+	a, b, c := CacheKey{SourceID: "a"}, CacheKey{SourceID: "b"}, CacheKey{SourceID: "c"}
+	for _, key := range []CacheKey{a, b} {
+		if err := store.Put(key, [][]byte{make([]byte, 4)}); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	// a becomes the most recently used entry
+	if _, ok, _ := store.Get(a); !ok {
+		t.Fatal("a is not cached")
+	}
+	if err := store.Put(c, [][]byte{make([]byte, 2), make([]byte, 2)}); err != nil {
+		t.Fatalf("put c: %v", err)
+	}
+
+	for key, cached := range map[CacheKey]bool{a: true, b: false, c: true} {
+		if _, ok, _ := store.Get(key); ok != cached {
+			t.Fatalf("got cached %t for %s, want %t", ok, key, cached)
+		}
+	}
+	if err := store.Put(CacheKey{SourceID: "d"}, [][]byte{make([]byte, 11)}); err != ErrCacheEntryTooLarge {
+		t.Fatalf("got %v for an entry exceeding the size limit, want %v", err, ErrCacheEntryTooLarge)
+	}
+	if err := store.Delete(a); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok, _ := store.Get(a); ok {
+		t.Fatal("a is still cached after deleting it")
+	}
+}
+
+func TestDirCacheStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDirCacheStore(dir)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	key := NewCacheKey("https://example.com/track.mp3?a=b", opus.DefaultEncoderConfig())
+	if _, ok, err := store.Get(key); ok || err != nil {
+		t.Fatalf("got %t, %v before putting, want false, nil", ok, err)
+	}
+
+	frames := testFrames(3)
+	if err = store.Put(key, frames); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	// a failed write leaves the previous entry untouched
+	if err = store.Put(key, [][]byte{make([]byte, 1<<16)}); err != opus.ErrInvalidDCAFrame {
+		t.Fatalf("got %v for an invalid frame, want %v", err, opus.ErrInvalidDCAFrame)
+	}
+
+	got, ok, err := store.Get(key)
+	if !ok || err != nil {
+		t.Fatalf("got %t, %v after putting, want true, nil", ok, err)
+	}
+	if len(got) != len(frames) || got[0][0] != 1 || got[2][0] != 3 {
+		t.Fatalf("got frames %v, want %v", got, frames)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".dca" || entries[0].Name()[0] == '.' {
+		t.Fatalf("got %d files in the cache directory, want only the entry", len(entries))
+	}
+
+	if err = store.Delete(key); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err = store.Delete(key); err != nil {
+		t.Fatalf("delete a missing entry: %v", err)
+	}
+}
+
+func TestCacheMissRecordsFrames(t *testing.T) {
+	cache := NewCache(NewMemoryCacheStore(1 << 20))
+	encoderConfig := opus.DefaultEncoderConfig()
+	source := &countingFrameProvider{frames: 5}
+	provider, err := cache.NewFrameProvider("track", encoderConfig, func() (pcm.FrameProvider, error) {
+		return source, nil
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if provider.Cached() {
+		t.Fatal("got a cache hit for an empty cache")
+	}
+	if err = provider.SeekFrame(1); err != ErrNotCached {
+		t.Fatalf("got %v seeking a cache miss, want %v", err, ErrNotCached)
+	}
+	if _, err = provider.ProvideOpusFrame(); err != ErrNotCached {
+		t.Fatalf("got %v providing opus frames of a cache miss, want %v", err, ErrNotCached)
+	}
+
+	if played := play(t, provider, 10); len(played) != 5 {
+		t.Fatalf("played %d frames, want 5", len(played))
+	}
+	provider.Close()
+	if !source.closed {
+		t.Fatal("source not closed")
+	}
+
+	// the second play is served from the cache
+	provider, err = cache.NewFrameProvider("track", encoderConfig, func() (pcm.FrameProvider, error) {
+		t.Fatal("source opened on a cache hit")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !provider.Cached() || provider.Frames() != 5 {
+		t.Fatalf("got cached %t with %d frames, want a cache hit with 5 frames", provider.Cached(), provider.Frames())
+	}
+	for i := 1; i <= 5; i++ {
+		frame, err := provider.ProvideOpusFrame()
+		if err != nil || frame[0] != byte(i) {
+			t.Fatalf("got %v, %v, want frame %d", frame, err, i)
+		}
+	}
+	if _, err = provider.ProvideOpusFrame(); err != io.EOF {
+		t.Fatalf("got %v after the last frame, want io.EOF", err)
+	}
+	if _, err = provider.ProvidePCMFrame(); err != io.EOF {
+		t.Fatalf("got %v providing pcm frames of a cache hit, want io.EOF", err)
+	}
+}
+
+func TestCacheIncompleteTrackNotCached(t *testing.T) {
+	store := NewMemoryCacheStore(1 << 20)
+	cache := NewCache(store)
+	provider, err := cache.NewFrameProvider("track", opus.DefaultEncoderConfig(), func() (pcm.FrameProvider, error) {
+		return &countingFrameProvider{frames: 5}, nil
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	play(t, provider, 3)
+	provider.Close()
+
+	if _, ok, _ := store.Get(NewCacheKey("track", opus.DefaultEncoderConfig())); ok {
+		t.Fatal("cached a track which was closed before its end")
+	}
+}
+
+func TestCacheStopsRecordingAboveMaxEntrySize(t *testing.T) {
+	for name, cache := range map[string]*Cache{
+		"max entry size": NewCache(NewMemoryCacheStore(1<<20), WithMaxCacheEntrySize(3)),
+		"store limit":    NewCache(NewMemoryCacheStore(3)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider, err := cache.NewFrameProvider("live", opus.DefaultEncoderConfig(), func() (pcm.FrameProvider, error) {
+				return &countingFrameProvider{frames: 5}, nil
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			// playback goes on after the recording stopped
+			if played := play(t, provider, 10); len(played) != 5 {
+				t.Fatalf("played %d frames, want 5", len(played))
+			}
+			if p := provider.(*cachedProvider); p.recorded != nil || p.recording {
+				t.Fatalf("still holding %d recorded frames", len(p.recorded))
+			}
+			if _, ok, _ := cache.Store().Get(NewCacheKey("live", opus.DefaultEncoderConfig())); ok {
+				t.Fatal("cached a track exceeding the size limit")
+			}
+		})
+	}
+}
+
+func TestCachedOpusProviderPauseAndSeek(t *testing.T) {
+	encoderConfig := opus.DefaultEncoderConfig()
+	store := NewMemoryCacheStore(1 << 20)
+	if err := store.Put(NewCacheKey("track", encoderConfig), testFrames(5)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	paused := false
+	provider, err := NewCache(store).NewOpusProvider("track", encoderConfig, func() (pcm.FrameProvider, error) {
+		return nil, errors.New("source opened on a cache hit")
+	}, func() bool {
+		return paused
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	paused = true
+	if frame, err := provider.ProvideOpusFrame(); frame != nil || err != nil {
+		t.Fatalf("got %v, %v while paused, want no frame", frame, err)
+	}
+	paused = false
+	if frame, _ := provider.ProvideOpusFrame(); frame[0] != 1 || provider.Frame() != 1 {
+		t.Fatalf("got frame %v at index %d after resuming, want the first frame", frame, provider.Frame())
+	}
+
+	if err = provider.SeekFrame(3); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if frame, _ := provider.ProvideOpusFrame(); frame[0] != 4 {
+		t.Fatalf("got frame %v after seeking, want the fourth frame", frame)
+	}
+	if err = provider.SeekFrame(100); err != nil || provider.Frame() != 5 {
+		t.Fatalf("got %v and index %d seeking past the end, want nil and 5", err, provider.Frame())
+	}
+	if err = provider.SeekFrame(-1); err != nil || provider.Frame() != 0 {
+		t.Fatalf("got %v and index %d seeking before the start, want nil and 0", err, provider.Frame())
+	}
+}
@@ -1,44 +1,117 @@
 package channelconverter
 
-import "errors"
+import (
+	"errors"
+	"io"
+)
 
 var ErrInvalidChannelCount = errors.New("invalid channel count")
 
+// CreateChannelConverter creates a new *ChannelConverter using the DownmixMatrix between the Vorbis layouts of the given channel counts.
+// Mono is duplicated to stereo, stereo is averaged to mono and 3 to 8 channels in the Vorbis channel order (see opus.MappingFamilyVorbis) are downmixed following ITU-R BS.775.
+// Other channel counts return ErrInvalidChannelCount from Convert.
 func CreateChannelConverter(inputChannels int, outputChannels int) *ChannelConverter {
+	var matrix Matrix
+	if inputChannels == outputChannels && inputChannels > 0 {
+		matrix = IdentityMatrix(inputChannels, outputChannels)
+	} else if input, output := VorbisLayout(inputChannels), VorbisLayout(outputChannels); input != nil && output != nil {
+		matrix = DownmixMatrix(input, output)
+	}
 	return &ChannelConverter{
 		inputChannels:  inputChannels,
 		outputChannels: outputChannels,
+		matrix:         matrix,
+	}
+}
+
+// CreateLayoutChannelConverter creates a new *ChannelConverter using the DownmixMatrix between the given Layout(s).
+// An empty Layout returns ErrInvalidChannelCount from Convert.
+func CreateLayoutChannelConverter(input Layout, output Layout) *ChannelConverter {
+	var matrix Matrix
+	if len(input) > 0 && len(output) > 0 {
+		matrix = DownmixMatrix(input, output)
+	}
+	return &ChannelConverter{
+		inputChannels:  len(input),
+		outputChannels: len(output),
+		matrix:         matrix,
+	}
+}
+
+// CreateMatrixChannelConverter creates a new *ChannelConverter using the given Matrix.
+func CreateMatrixChannelConverter(matrix Matrix) (*ChannelConverter, error) {
+	if !matrix.valid() {
+		return nil, ErrInvalidChannelCount
+	}
+	return &ChannelConverter{
+		inputChannels:  matrix.InputChannels(),
+		outputChannels: matrix.OutputChannels(),
+		matrix:         matrix,
+	}, nil
+}
+
+// CreateSwapChannelConverter creates a new *ChannelConverter which swaps the left and right channel of a stereo signal.
+func CreateSwapChannelConverter() *ChannelConverter {
+	converter, _ := CreateMatrixChannelConverter(SwapMatrix())
+	return converter
+}
+
+// CreateExtractChannelConverter creates a new *ChannelConverter which copies the given input channel to all output channels.
+// Use channel 0 to extract the left and channel 1 to extract the right channel of a stereo signal.
+func CreateExtractChannelConverter(inputChannels int, channel int, outputChannels int) (*ChannelConverter, error) {
+	if channel < 0 || channel >= inputChannels {
+		return nil, ErrInvalidChannelCount
 	}
+	return CreateMatrixChannelConverter(ExtractMatrix(inputChannels, channel, outputChannels))
 }
 
 type ChannelConverter struct {
 	inputChannels  int
 	outputChannels int
+	matrix         Matrix
 }
 
+// Convert converts the interleaved samples of input into output, which needs room for len(input)/InputChannels()*OutputChannels() samples.
 func (c *ChannelConverter) Convert(input []int16, output []int16) error {
-	for i := 0; i < len(input); i += c.inputChannels {
-		if c.inputChannels == 1 && c.outputChannels == 2 {
-			output[i*2] = input[i]
-			output[i*2+1] = input[i]
-		} else if c.inputChannels == 2 && c.outputChannels == 1 {
-			newOutput := (int32(input[i]) + int32(input[i+1])) / 2
-			if newOutput > 32767 {
-				newOutput = 32767
-			} else if newOutput < -32768 {
-				newOutput = -32768
+	if c.matrix == nil {
+		return ErrInvalidChannelCount
+	}
+	frames := len(input) / c.inputChannels
+	if len(output) < frames*c.outputChannels {
+		return io.ErrShortBuffer
+	}
+	for i, o := 0, 0; i < frames*c.inputChannels; i, o = i+c.inputChannels, o+c.outputChannels {
+		for out, row := range c.matrix {
+			var sample float64
+			for in, coefficient := range row {
+				if coefficient != 0 {
+					sample += float64(input[i+in]) * coefficient
+				}
 			}
-			output[i/2] = int16(newOutput)
-		} else {
-			return ErrInvalidChannelCount
+			output[o+out] = clamp(sample)
 		}
 	}
 	return nil
 }
+
 func (c *ChannelConverter) InputChannels() int {
 	return c.inputChannels
 }
 
 func (c *ChannelConverter) OutputChannels() int {
-	return c.inputChannels
+	return c.outputChannels
+}
+
+// Matrix returns the Matrix used by the ChannelConverter or nil if the channel counts can't be converted.
+func (c *ChannelConverter) Matrix() Matrix {
+	return c.matrix
+}
+
+func clamp(sample float64) int16 {
+	if sample > 32767 {
+		return 32767
+	} else if sample < -32768 {
+		return -32768
+	}
+	return int16(sample)
 }
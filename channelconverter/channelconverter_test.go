@@ -0,0 +1,112 @@
+package channelconverter
+
+import (
+	"io"
+	"testing"
+)
+
+func convert(t *testing.T, converter *ChannelConverter, input []int16) []int16 {
+	t.Helper()
+	output := make([]int16, len(input)/converter.InputChannels()*converter.OutputChannels())
+	if err := converter.Convert(input, output); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	return output
+}
+
+func equal(a []int16, b []int16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMonoToStereo(t *testing.T) {
+	got := convert(t, CreateChannelConverter(1, 2), []int16{100, -200})
+	if want := []int16{100, 100, -200, -200}; !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestStereoToMono(t *testing.T) {
+	got := convert(t, CreateChannelConverter(2, 1), []int16{100, 300, 32767, 32767})
+	if want := []int16{200, 32767}; !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSurroundStereoDownmix(t *testing.T) {
+	for channels := 3; channels <= 8; channels++ {
+		converter := CreateChannelConverter(channels, 2)
+		if converter.OutputChannels() != 2 {
+			t.Fatalf("%d channels: output has %d channels, want 2", channels, converter.OutputChannels())
+		}
+
+		// a full scale signal on all channels doesn't clip
+		input := make([]int16, channels)
+		for i := range input {
+			input[i] = 32767
+		}
+		got := convert(t, converter, input)
+		if got[0] != got[1] || got[0] > 32767 || got[0] < 32000 {
+			t.Fatalf("%d channels: got %v for a full scale signal, want both sides just below full scale", channels, got)
+		}
+
+		// the front left channel only plays on the left side, the LFE channel is dropped
+		input = make([]int16, channels)
+		input[0] = 10000
+		got = convert(t, converter, input)
+		if got[0] <= 0 || got[1] != 0 {
+			t.Fatalf("%d channels: got %v for the front left channel, want only the left side", channels, got)
+		}
+		if channels >= 6 {
+			input = make([]int16, channels)
+			input[channels-1] = 10000
+			if got = convert(t, converter, input); got[0] != 0 || got[1] != 0 {
+				t.Fatalf("%d channels: got %v for the LFE channel, want silence", channels, got)
+			}
+		}
+	}
+}
+
+func TestSMPTELayoutDownmix(t *testing.T) {
+	converter := CreateLayoutChannelConverter(Layout5_1, LayoutStereo)
+	// the center channel is third in SMPTE order and plays on both sides
+	got := convert(t, converter, []int16{0, 0, 10000, 0, 0, 0})
+	if got[0] != got[1] || got[0] == 0 {
+		t.Fatalf("got %v for the center channel, want both sides", got)
+	}
+}
+
+func TestSwapAndExtract(t *testing.T) {
+	if got := convert(t, CreateSwapChannelConverter(), []int16{1, 2}); !equal(got, []int16{2, 1}) {
+		t.Fatalf("swap: got %v, want [2 1]", got)
+	}
+	extract, err := CreateExtractChannelConverter(2, 1, 2)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if got := convert(t, extract, []int16{1, 2}); !equal(got, []int16{2, 2}) {
+		t.Fatalf("extract: got %v, want [2 2]", got)
+	}
+	if _, err = CreateExtractChannelConverter(2, 2, 2); err != ErrInvalidChannelCount {
+		t.Fatalf("got %v for an invalid channel, want %v", err, ErrInvalidChannelCount)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if err := CreateChannelConverter(9, 2).Convert(make([]int16, 9), make([]int16, 2)); err != ErrInvalidChannelCount {
+		t.Fatalf("got %v for 9 channels, want %v", err, ErrInvalidChannelCount)
+	}
+	if err := CreateChannelConverter(1, 2).Convert(make([]int16, 4), make([]int16, 4)); err != io.ErrShortBuffer {
+		t.Fatalf("got %v for a short output, want %v", err, io.ErrShortBuffer)
+	}
+	if _, err := CreateMatrixChannelConverter(Matrix{{1, 0}, {1}}); err != ErrInvalidChannelCount {
+		t.Fatalf("got %v for a ragged matrix, want %v", err, ErrInvalidChannelCount)
+	}
+}
@@ -0,0 +1,145 @@
+package channelconverter
+
+import "math"
+
+const minus3dB = math.Sqrt2 / 2
+
+// Channel is a speaker position in a Layout.
+type Channel int
+
+const (
+	FrontLeft Channel = iota
+	FrontRight
+	FrontCenter
+	LowFrequency
+	BackLeft
+	BackRight
+	BackCenter
+	SideLeft
+	SideRight
+)
+
+// Layout is the order of interleaved channels.
+type Layout []Channel
+
+func (l Layout) index(channel Channel) int {
+	for i, c := range l {
+		if c == channel {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l Layout) has(channel Channel) bool {
+	return l.index(channel) >= 0
+}
+
+var (
+	LayoutMono   = Layout{FrontCenter}
+	LayoutStereo = Layout{FrontLeft, FrontRight}
+	// Layout5_1 is the 5.1 layout in SMPTE/WAV order used by FLAC and AAC.
+	Layout5_1 = Layout{FrontLeft, FrontRight, FrontCenter, LowFrequency, BackLeft, BackRight}
+	// Layout7_1 is the 7.1 layout in SMPTE/WAV order used by FLAC and AAC.
+	Layout7_1 = Layout{FrontLeft, FrontRight, FrontCenter, LowFrequency, BackLeft, BackRight, SideLeft, SideRight}
+)
+
+var vorbisLayouts = [...]Layout{
+	LayoutMono,
+	LayoutStereo,
+	{FrontLeft, FrontCenter, FrontRight},
+	{FrontLeft, FrontRight, BackLeft, BackRight},
+	{FrontLeft, FrontCenter, FrontRight, BackLeft, BackRight},
+	{FrontLeft, FrontCenter, FrontRight, BackLeft, BackRight, LowFrequency},
+	{FrontLeft, FrontCenter, FrontRight, SideLeft, SideRight, BackCenter, LowFrequency},
+	{FrontLeft, FrontCenter, FrontRight, SideLeft, SideRight, BackLeft, BackRight, LowFrequency},
+}
+
+var smpteLayouts = [...]Layout{
+	LayoutMono,
+	LayoutStereo,
+	{FrontLeft, FrontRight, FrontCenter},
+	{FrontLeft, FrontRight, BackLeft, BackRight},
+	{FrontLeft, FrontRight, FrontCenter, BackLeft, BackRight},
+	Layout5_1,
+	{FrontLeft, FrontRight, FrontCenter, LowFrequency, BackCenter, SideLeft, SideRight},
+	Layout7_1,
+}
+
+// VorbisLayout returns the Layout of 1 to 8 channels in the Vorbis order used by Vorbis and Opus (mapping family 1) or nil.
+func VorbisLayout(channels int) Layout {
+	if channels < 1 || channels > len(vorbisLayouts) {
+		return nil
+	}
+	return vorbisLayouts[channels-1]
+}
+
+// SMPTELayout returns the Layout of 1 to 8 channels in the SMPTE/WAV order used by FLAC and AAC or nil.
+func SMPTELayout(channels int) Layout {
+	if channels < 1 || channels > len(smpteLayouts) {
+		return nil
+	}
+	return smpteLayouts[channels-1]
+}
+
+// DownmixMatrix returns the Matrix converting the input Layout into the output Layout.
+// Channels missing in the output are folded into the remaining ones following ITU-R BS.775: center and surround channels are mixed into the front channels at -3dB and the LFE channel is dropped.
+// Downmixes are normalized so a full scale signal on all channels doesn't clip.
+func DownmixMatrix(input Layout, output Layout) Matrix {
+	if len(output) == 1 && len(input) > 1 {
+		// fold into stereo first and average both sides
+		stereo := DownmixMatrix(input, LayoutStereo)
+		matrix := NewMatrix(len(input), 1)
+		for i := range input {
+			matrix[0][i] = (stereo[0][i] + stereo[1][i]) / 2
+		}
+		return matrix
+	}
+
+	matrix := NewMatrix(len(input), len(output))
+	mix := func(in int, channel Channel, coefficient float64) bool {
+		if out := output.index(channel); out >= 0 {
+			matrix[out][in] += coefficient
+			return true
+		}
+		return false
+	}
+	mixPair := func(in int, left Channel, right Channel, coefficient float64) bool {
+		if output.has(left) && output.has(right) {
+			mix(in, left, coefficient)
+			mix(in, right, coefficient)
+			return true
+		}
+		return false
+	}
+
+	for in, channel := range input {
+		if mix(in, channel, 1) {
+			continue
+		}
+		switch channel {
+		case FrontCenter:
+			if len(input) == 1 {
+				// mono is duplicated at full level
+				mixPair(in, FrontLeft, FrontRight, 1)
+				continue
+			}
+			mixPair(in, FrontLeft, FrontRight, minus3dB)
+		case BackLeft:
+			_ = mix(in, SideLeft, 1) || mix(in, FrontLeft, minus3dB)
+		case BackRight:
+			_ = mix(in, SideRight, 1) || mix(in, FrontRight, minus3dB)
+		case SideLeft:
+			_ = mix(in, BackLeft, 1) || mix(in, FrontLeft, minus3dB)
+		case SideRight:
+			_ = mix(in, BackRight, 1) || mix(in, FrontRight, minus3dB)
+		case BackCenter:
+			_ = mixPair(in, BackLeft, BackRight, minus3dB) || mixPair(in, SideLeft, SideRight, minus3dB) || mixPair(in, FrontLeft, FrontRight, 0.5)
+		}
+	}
+
+	if len(input) > len(output) {
+		matrix.normalize()
+	}
+	return matrix
+}
@@ -0,0 +1,87 @@
+package channelconverter
+
+import "math"
+
+// Matrix contains the coefficient of each input channel for each output channel, indexed by Matrix[output][input].
+type Matrix [][]float64
+
+// NewMatrix creates a new Matrix with all coefficients set to 0.
+func NewMatrix(inputChannels int, outputChannels int) Matrix {
+	matrix := make(Matrix, outputChannels)
+	for i := range matrix {
+		matrix[i] = make([]float64, inputChannels)
+	}
+	return matrix
+}
+
+// IdentityMatrix returns a Matrix which copies the first channels of the input to the output and leaves the remaining output channels silent.
+func IdentityMatrix(inputChannels int, outputChannels int) Matrix {
+	matrix := NewMatrix(inputChannels, outputChannels)
+	for i := 0; i < inputChannels && i < outputChannels; i++ {
+		matrix[i][i] = 1
+	}
+	return matrix
+}
+
+// SwapMatrix returns a Matrix which swaps the left and right channel of a stereo signal.
+func SwapMatrix() Matrix {
+	return Matrix{
+		{0, 1},
+		{1, 0},
+	}
+}
+
+// ExtractMatrix returns a Matrix which copies the given input channel to all output channels.
+// For example ExtractMatrix(2, 0, 2) plays the left channel of a stereo signal on both sides.
+func ExtractMatrix(inputChannels int, channel int, outputChannels int) Matrix {
+	matrix := NewMatrix(inputChannels, outputChannels)
+	for i := range matrix {
+		matrix[i][channel] = 1
+	}
+	return matrix
+}
+
+func (m Matrix) InputChannels() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+func (m Matrix) OutputChannels() int {
+	return len(m)
+}
+
+func (m Matrix) valid() bool {
+	if len(m) == 0 || len(m[0]) == 0 {
+		return false
+	}
+	for _, row := range m {
+		if len(row) != len(m[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize scales all coefficients so no output row sums up to more than 1.
+func (m Matrix) normalize() {
+	var maxSum float64
+	for _, row := range m {
+		var sum float64
+		for _, coefficient := range row {
+			sum += math.Abs(coefficient)
+		}
+		if sum > maxSum {
+			maxSum = sum
+		}
+	}
+	if maxSum <= 1 {
+		return
+	}
+	for _, row := range m {
+		for i := range row {
+			row[i] /= maxSum
+		}
+	}
+}
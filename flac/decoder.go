@@ -0,0 +1,220 @@
+package flac
+
+/*
+#cgo pkg-config: flac
+#include <stdint.h>
+#include <FLAC/stream_decoder.h>
+
+extern int goFLACRead(void *handle, FLAC__byte *buffer, size_t *bytes);
+extern int goFLACSeek(void *handle, FLAC__uint64 offset);
+extern int goFLACTell(void *handle, FLAC__uint64 *offset);
+extern int goFLACLength(void *handle, FLAC__uint64 *length);
+extern int goFLACEOF(void *handle);
+extern int goFLACWrite(void *handle, FLAC__Frame *frame, FLAC__int32 **buffer);
+extern void goFLACMetadata(void *handle, FLAC__StreamMetadata *metadata);
+extern void goFLACError(void *handle, int status);
+
+static FLAC__StreamDecoderReadStatus flac_read(const FLAC__StreamDecoder *decoder, FLAC__byte buffer[], size_t *bytes, void *handle) {
+	return goFLACRead(handle, buffer, bytes);
+}
+
+static FLAC__StreamDecoderSeekStatus flac_seek(const FLAC__StreamDecoder *decoder, FLAC__uint64 offset, void *handle) {
+	return goFLACSeek(handle, offset);
+}
+
+static FLAC__StreamDecoderTellStatus flac_tell(const FLAC__StreamDecoder *decoder, FLAC__uint64 *offset, void *handle) {
+	return goFLACTell(handle, offset);
+}
+
+static FLAC__StreamDecoderLengthStatus flac_length(const FLAC__StreamDecoder *decoder, FLAC__uint64 *length, void *handle) {
+	return goFLACLength(handle, length);
+}
+
+static FLAC__bool flac_eof(const FLAC__StreamDecoder *decoder, void *handle) {
+	return goFLACEOF(handle);
+}
+
+static FLAC__StreamDecoderWriteStatus flac_write(const FLAC__StreamDecoder *decoder, const FLAC__Frame *frame, const FLAC__int32 *const buffer[], void *handle) {
+	return goFLACWrite(handle, (FLAC__Frame *)frame, (FLAC__int32 **)buffer);
+}
+
+static void flac_metadata(const FLAC__StreamDecoder *decoder, const FLAC__StreamMetadata *metadata, void *handle) {
+	goFLACMetadata(handle, (FLAC__StreamMetadata *)metadata);
+}
+
+static void flac_error(const FLAC__StreamDecoder *decoder, FLAC__StreamDecoderErrorStatus status, void *handle) {
+	goFLACError(handle, status);
+}
+
+static FLAC__StreamDecoderInitStatus flac_init_go_reader(FLAC__StreamDecoder *decoder, uintptr_t handle) {
+	return FLAC__stream_decoder_init_stream(decoder, flac_read, flac_seek, flac_tell, flac_length, flac_eof, flac_write, flac_metadata, flac_error, (void *)handle);
+}
+*/
+import "C"
+import (
+	"errors"
+	"io"
+	"runtime/cgo"
+)
+
+// ErrAlreadyOpen is returned when Open is called on a Decoder which already decodes a stream.
+var ErrAlreadyOpen = errors.New("flac: decoder is already open")
+
+// CreateDecoder creates a new Decoder. Call Open to start decoding a stream.
+func CreateDecoder() (*Decoder, error) {
+	decoder := C.FLAC__stream_decoder_new()
+	if decoder == nil {
+		return nil, ErrMemoryAllocationError
+	}
+	C.FLAC__stream_decoder_set_metadata_respond(decoder, C.FLAC__METADATA_TYPE_SEEKTABLE)
+	C.FLAC__stream_decoder_set_metadata_respond(decoder, C.FLAC__METADATA_TYPE_VORBIS_COMMENT)
+	C.FLAC__stream_decoder_set_metadata_respond(decoder, C.FLAC__METADATA_TYPE_PICTURE)
+
+	return &Decoder{
+		decoder: decoder,
+	}, nil
+}
+
+// Decoder decodes a native flac stream using libFLAC.
+type Decoder struct {
+	decoder *C.FLAC__StreamDecoder
+	handle  cgo.Handle
+
+	reader       io.Reader
+	eof          bool
+	readErr      error
+	decodeErrors int
+
+	metadata Metadata
+	frame    Frame
+	hasFrame bool
+}
+
+// Frame is a decoded flac frame.
+type Frame struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	// SampleNumber is the number of the first sample in the frame.
+	SampleNumber uint64
+	// Samples holds the samples of each channel.
+	Samples [][]int32
+}
+
+// BlockSize returns the number of samples per channel.
+func (f *Frame) BlockSize() int {
+	if len(f.Samples) == 0 {
+		return 0
+	}
+	return len(f.Samples[0])
+}
+
+// AppendInt16 appends the interleaved samples scaled to 16 bit to buff.
+func (f *Frame) AppendInt16(buff []int16) []int16 {
+	blockSize := f.BlockSize()
+	for i := 0; i < blockSize; i++ {
+		for _, samples := range f.Samples {
+			sample := samples[i]
+			if f.BitsPerSample > 16 {
+				sample >>= f.BitsPerSample - 16
+			} else {
+				sample <<= 16 - f.BitsPerSample
+			}
+			buff = append(buff, int16(sample))
+		}
+	}
+	return buff
+}
+
+// Open starts decoding the given io.Reader and reads all metadata blocks.
+// If the io.Reader also implements io.Seeker, the Decoder supports seeking.
+func (d *Decoder) Open(r io.Reader) error {
+	if d.handle != 0 {
+		return ErrAlreadyOpen
+	}
+	d.reader = r
+	d.handle = cgo.NewHandle(d)
+	if status := C.flac_init_go_reader(d.decoder, C.uintptr_t(d.handle)); status != C.FLAC__STREAM_DECODER_INIT_STATUS_OK {
+		d.handle.Delete()
+		d.handle = 0
+		return InitError(status)
+	}
+	if C.FLAC__stream_decoder_process_until_end_of_metadata(d.decoder) == 0 {
+		return d.err()
+	}
+	return nil
+}
+
+// Metadata returns the metadata blocks read by Open.
+func (d *Decoder) Metadata() Metadata {
+	return d.metadata
+}
+
+// StreamInfo returns the STREAMINFO metadata block read by Open.
+func (d *Decoder) StreamInfo() StreamInfo {
+	return d.metadata.StreamInfo
+}
+
+// State returns the current state of the Decoder.
+func (d *Decoder) State() Error {
+	return Error(C.FLAC__stream_decoder_get_state(d.decoder))
+}
+
+// DecodeErrors returns the number of recoverable errors, like lost syncs or CRC mismatches, which were skipped so far.
+func (d *Decoder) DecodeErrors() int {
+	return d.decodeErrors
+}
+
+// ReadFrame decodes the next frame. It returns io.EOF at the end of the stream.
+// The returned Frame is only valid until the next call to ReadFrame or SeekSample.
+func (d *Decoder) ReadFrame() (*Frame, error) {
+	for !d.hasFrame {
+		if d.State() == ErrEndOfStream {
+			return nil, io.EOF
+		}
+		if C.FLAC__stream_decoder_process_single(d.decoder) == 0 {
+			return nil, d.err()
+		}
+	}
+	d.hasFrame = false
+	return &d.frame, nil
+}
+
+// SeekSample seeks to the given sample per channel. libFLAC uses the seek table if present and bisects the stream otherwise.
+// The next call to ReadFrame returns the frame starting at the given sample.
+func (d *Decoder) SeekSample(sample uint64) error {
+	d.hasFrame = false
+	if C.FLAC__stream_decoder_seek_absolute(d.decoder, C.FLAC__uint64(sample)) == 0 {
+		err := d.err()
+		if d.State() == ErrSeekError {
+			// the decoder needs to be flushed to be usable again
+			C.FLAC__stream_decoder_flush(d.decoder)
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *Decoder) err() error {
+	if d.readErr != nil {
+		err := d.readErr
+		d.readErr = nil
+		return err
+	}
+	return d.State()
+}
+
+// Close finishes decoding and frees the Decoder.
+func (d *Decoder) Close() error {
+	if d.decoder == nil {
+		return nil
+	}
+	C.FLAC__stream_decoder_finish(d.decoder)
+	C.FLAC__stream_decoder_delete(d.decoder)
+	d.decoder = nil
+	if d.handle != 0 {
+		d.handle.Delete()
+		d.handle = 0
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package flac
+
+import (
+	"testing"
+)
+
+func TestFrameAppendInt16(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		frame Frame
+		want  []int16
+	}{
+		{
+			name:  "16 bit",
+			frame: Frame{Channels: 2, BitsPerSample: 16, Samples: [][]int32{{1, -2}, {3, -4}}},
+			want:  []int16{1, 3, -2, -4},
+		},
+		{
+			name:  "24 bit",
+			frame: Frame{Channels: 1, BitsPerSample: 24, Samples: [][]int32{{0x7fffff, -0x800000, 0x100}}},
+			want:  []int16{0x7fff, -0x8000, 1},
+		},
+		{
+			name:  "8 bit",
+			frame: Frame{Channels: 1, BitsPerSample: 8, Samples: [][]int32{{0x7f, -0x80}}},
+			want:  []int16{0x7f00, -0x8000},
+		},
+		{
+			name:  "empty",
+			frame: Frame{Channels: 2, BitsPerSample: 16},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buff := []int16{42}
+			got := tt.frame.AppendInt16(buff)
+			if len(got) != len(tt.want)+1 || got[0] != 42 {
+				t.Fatalf("got %v, want %v appended to [42]", got, tt.want)
+			}
+			for i, sample := range tt.want {
+				if got[i+1] != sample {
+					t.Fatalf("got %v, want %v appended to [42]", got, tt.want)
+				}
+			}
+		})
+	}
+}
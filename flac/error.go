@@ -0,0 +1,48 @@
+package flac
+
+/*
+#cgo pkg-config: flac
+#include <FLAC/stream_decoder.h>
+
+static const char *flac_state_string(int state) {
+	return FLAC__StreamDecoderStateString[state];
+}
+
+static const char *flac_init_status_string(int status) {
+	return FLAC__StreamDecoderInitStatusString[status];
+}
+*/
+import "C"
+import "fmt"
+
+var (
+	_ error = Error(0)
+	_ error = InitError(0)
+)
+
+// Error is the state of a Decoder which stopped decoding.
+type Error int
+
+func (e Error) Error() string {
+	return fmt.Sprintf("flac: %s", C.GoString(C.flac_state_string(C.int(e))))
+}
+
+const (
+	ErrSearchForMetadata     Error = C.FLAC__STREAM_DECODER_SEARCH_FOR_METADATA
+	ErrReadMetadata          Error = C.FLAC__STREAM_DECODER_READ_METADATA
+	ErrSearchForFrameSync    Error = C.FLAC__STREAM_DECODER_SEARCH_FOR_FRAME_SYNC
+	ErrReadFrame             Error = C.FLAC__STREAM_DECODER_READ_FRAME
+	ErrEndOfStream           Error = C.FLAC__STREAM_DECODER_END_OF_STREAM
+	ErrOggError              Error = C.FLAC__STREAM_DECODER_OGG_ERROR
+	ErrSeekError             Error = C.FLAC__STREAM_DECODER_SEEK_ERROR
+	ErrAborted               Error = C.FLAC__STREAM_DECODER_ABORTED
+	ErrMemoryAllocationError Error = C.FLAC__STREAM_DECODER_MEMORY_ALLOCATION_ERROR
+	ErrUninitialized         Error = C.FLAC__STREAM_DECODER_UNINITIALIZED
+)
+
+// InitError is returned when a Decoder could not be initialized.
+type InitError int
+
+func (e InitError) Error() string {
+	return fmt.Sprintf("flac: %s", C.GoString(C.flac_init_status_string(C.int(e))))
+}
@@ -0,0 +1,104 @@
+package flac
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/disgoorg/audio/channelconverter"
+	"github.com/disgoorg/audio/internal/pcmconv"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// NewPCMFrameProvider returns a SeekableFrameProvider that reads flac from the given io.Reader and converts it into pcm frames.
+// Seeking is only supported if the io.Reader also implements io.Seeker.
+func NewPCMFrameProvider(decoder *Decoder, r io.Reader) (pcm.SeekableFrameProvider, error) {
+	return NewCustomPCMFrameProvider(decoder, r, 48000, 2)
+}
+
+// NewCustomPCMFrameProvider returns a SeekableFrameProvider that reads flac from the given io.Reader and converts it into pcm frames.
+// You can specify the rate and channels of the output PCM frames. Samples of any bit depth are scaled to 16 bit and resampled and channel converted as needed.
+func NewCustomPCMFrameProvider(decoder *Decoder, r io.Reader, rate int, channels int) (pcm.SeekableFrameProvider, error) {
+	if decoder == nil {
+		var err error
+		decoder, err = CreateDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create flac decoder: %w", err)
+		}
+	}
+
+	if err := decoder.Open(r); err != nil {
+		_ = decoder.Close()
+		return nil, fmt.Errorf("failed to open flac decoder: %w", err)
+	}
+
+	_, seekable := r.(io.Seeker)
+	return &pcmFrameProvider{
+		decoder:   decoder,
+		seekable:  seekable,
+		converter: pcmconv.NewConverter(rate, channels, channelconverter.SMPTELayout),
+	}, nil
+}
+
+type pcmFrameProvider struct {
+	decoder   *Decoder
+	seekable  bool
+	converter *pcmconv.Converter
+
+	// position is the sample number after the last decoded frame
+	position uint64
+	pcmBuff  []int16
+}
+
+func (p *pcmFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	for !p.converter.Ready() {
+		frame, err := p.decoder.ReadFrame()
+		if err == io.EOF {
+			return p.converter.Flush()
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.position = frame.SampleNumber + uint64(frame.BlockSize())
+		p.pcmBuff = frame.AppendInt16(p.pcmBuff[:0])
+		if err = p.converter.Convert(p.pcmBuff, frame.SampleRate, frame.Channels); err != nil {
+			return nil, err
+		}
+	}
+	return p.converter.Next(), nil
+}
+
+func (p *pcmFrameProvider) Seek(position time.Duration) error {
+	if !p.seekable {
+		return pcm.ErrNotSeekable
+	}
+	info := p.decoder.StreamInfo()
+	sample := uint64(position.Seconds() * float64(info.SampleRate))
+	if info.TotalSamples > 0 && sample >= info.TotalSamples {
+		sample = info.TotalSamples - 1
+	}
+	if err := p.decoder.SeekSample(sample); err != nil {
+		return fmt.Errorf("failed to seek flac decoder: %w", err)
+	}
+	p.position = sample
+	return p.converter.Reset()
+}
+
+func (p *pcmFrameProvider) Position() time.Duration {
+	rate := p.decoder.StreamInfo().SampleRate
+	if rate == 0 {
+		return 0
+	}
+	position := pcmconv.SamplesToDuration(int64(p.position), rate)
+	// subtract what was decoded but not returned yet
+	return position - p.converter.Pending()
+}
+
+func (p *pcmFrameProvider) Duration() time.Duration {
+	return p.decoder.StreamInfo().Duration()
+}
+
+func (p *pcmFrameProvider) Close() {
+	p.converter.Close()
+	_ = p.decoder.Close()
+}
@@ -0,0 +1,218 @@
+package flac
+
+/*
+#cgo pkg-config: flac
+#include <FLAC/format.h>
+*/
+import "C"
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// StreamInfo is the STREAMINFO metadata block which every flac stream starts with.
+type StreamInfo struct {
+	MinBlockSize  int
+	MaxBlockSize  int
+	MinFrameSize  int
+	MaxFrameSize  int
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	// TotalSamples is the number of samples per channel or 0 if unknown.
+	TotalSamples uint64
+	MD5          [16]byte
+}
+
+// Duration returns the playback duration or 0 if the number of samples is unknown.
+func (i StreamInfo) Duration() time.Duration {
+	if i.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(i.TotalSamples * uint64(time.Second) / uint64(i.SampleRate))
+}
+
+// SeekPoint is a single entry of the SEEKTABLE metadata block.
+type SeekPoint struct {
+	SampleNumber uint64
+	// Offset is the byte offset of the target frame relative to the first frame.
+	Offset       uint64
+	FrameSamples int
+}
+
+// IsPlaceholder returns whether the SeekPoint is a placeholder which should be ignored.
+func (p SeekPoint) IsPlaceholder() bool {
+	return p.SampleNumber == 0xffffffffffffffff
+}
+
+// VorbisComment is the VORBIS_COMMENT metadata block which holds the tags of a flac stream.
+type VorbisComment struct {
+	Vendor string
+	// Comments are the raw "FIELD=value" entries.
+	Comments []string
+}
+
+// Get returns the first value of the given field. Field names are case-insensitive.
+func (c VorbisComment) Get(field string) string {
+	values := c.GetAll(field)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// GetAll returns all values of the given field. Field names are case-insensitive.
+func (c VorbisComment) GetAll(field string) []string {
+	var values []string
+	for _, comment := range c.Comments {
+		name, value, ok := strings.Cut(comment, "=")
+		if ok && strings.EqualFold(name, field) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Track returns the track number or 0 if it is not set.
+func (c VorbisComment) Track() int {
+	track, _ := strconv.Atoi(strings.TrimSpace(c.Get("TRACKNUMBER")))
+	return track
+}
+
+// ReplayGain returns the REPLAYGAIN_* fields in dB and as linear peak. ok is false if the field is not set.
+func (c VorbisComment) ReplayGain(field string) (value float64, ok bool) {
+	raw := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(c.Get(field)), "dB"))
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	return value, err == nil
+}
+
+// PictureType is the type of a Picture as defined by ID3v2 APIC.
+type PictureType int
+
+const (
+	PictureTypeOther PictureType = iota
+	PictureTypeFileIcon
+	PictureTypeOtherFileIcon
+	PictureTypeFrontCover
+	PictureTypeBackCover
+	PictureTypeLeafletPage
+	PictureTypeMedia
+	PictureTypeLeadArtist
+	PictureTypeArtist
+	PictureTypeConductor
+	PictureTypeBand
+	PictureTypeComposer
+	PictureTypeLyricist
+	PictureTypeRecordingLocation
+	PictureTypeDuringRecording
+	PictureTypeDuringPerformance
+	PictureTypeScreenCapture
+	PictureTypeBrightColouredFish
+	PictureTypeIllustration
+	PictureTypeBandLogo
+	PictureTypePublisherLogo
+)
+
+// Picture is a PICTURE metadata block.
+type Picture struct {
+	Type        PictureType
+	MIMEType    string
+	Description string
+	Width       int
+	Height      int
+	Depth       int
+	Colors      int
+	Data        []byte
+}
+
+// Metadata holds all metadata blocks the Decoder read.
+type Metadata struct {
+	StreamInfo    StreamInfo
+	SeekTable     []SeekPoint
+	VorbisComment VorbisComment
+	Pictures      []Picture
+}
+
+// CoverArt returns the front cover or the first picture if there is no front cover.
+func (m Metadata) CoverArt() *Picture {
+	for i := range m.Pictures {
+		if m.Pictures[i].Type == PictureTypeFrontCover {
+			return &m.Pictures[i]
+		}
+	}
+	if len(m.Pictures) > 0 {
+		return &m.Pictures[0]
+	}
+	return nil
+}
+
+func (m *Metadata) parse(metadata *C.FLAC__StreamMetadata) {
+	data := unsafe.Pointer(&metadata.data)
+	switch metadata._type {
+	case C.FLAC__METADATA_TYPE_STREAMINFO:
+		info := (*C.FLAC__StreamMetadata_StreamInfo)(data)
+		m.StreamInfo = StreamInfo{
+			MinBlockSize:  int(info.min_blocksize),
+			MaxBlockSize:  int(info.max_blocksize),
+			MinFrameSize:  int(info.min_framesize),
+			MaxFrameSize:  int(info.max_framesize),
+			SampleRate:    int(info.sample_rate),
+			Channels:      int(info.channels),
+			BitsPerSample: int(info.bits_per_sample),
+			TotalSamples:  uint64(info.total_samples),
+		}
+		copy(m.StreamInfo.MD5[:], C.GoBytes(unsafe.Pointer(&info.md5sum[0]), 16))
+
+	case C.FLAC__METADATA_TYPE_SEEKTABLE:
+		table := (*C.FLAC__StreamMetadata_SeekTable)(data)
+		if table.num_points == 0 {
+			return
+		}
+		points := unsafe.Slice(table.points, int(table.num_points))
+		m.SeekTable = make([]SeekPoint, len(points))
+		for i, point := range points {
+			m.SeekTable[i] = SeekPoint{
+				SampleNumber: uint64(point.sample_number),
+				Offset:       uint64(point.stream_offset),
+				FrameSamples: int(point.frame_samples),
+			}
+		}
+
+	case C.FLAC__METADATA_TYPE_VORBIS_COMMENT:
+		comment := (*C.FLAC__StreamMetadata_VorbisComment)(data)
+		m.VorbisComment.Vendor = vorbisCommentEntry(comment.vendor_string)
+		if comment.num_comments == 0 {
+			return
+		}
+		entries := unsafe.Slice(comment.comments, int(comment.num_comments))
+		m.VorbisComment.Comments = make([]string, len(entries))
+		for i, entry := range entries {
+			m.VorbisComment.Comments[i] = vorbisCommentEntry(entry)
+		}
+
+	case C.FLAC__METADATA_TYPE_PICTURE:
+		picture := (*C.FLAC__StreamMetadata_Picture)(data)
+		m.Pictures = append(m.Pictures, Picture{
+			Type:        PictureType(picture._type),
+			MIMEType:    C.GoString(picture.mime_type),
+			Description: C.GoString((*C.char)(unsafe.Pointer(picture.description))),
+			Width:       int(picture.width),
+			Height:      int(picture.height),
+			Depth:       int(picture.depth),
+			Colors:      int(picture.colors),
+			Data:        C.GoBytes(unsafe.Pointer(picture.data), C.int(picture.data_length)),
+		})
+	}
+}
+
+func vorbisCommentEntry(entry C.FLAC__StreamMetadata_VorbisComment_Entry) string {
+	if entry.length == 0 {
+		return ""
+	}
+	return string(C.GoBytes(unsafe.Pointer(entry.entry), C.int(entry.length)))
+}
@@ -0,0 +1,92 @@
+package flac
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVorbisComment(t *testing.T) {
+	comment := VorbisComment{
+		Vendor: "reference libFLAC 1.4.3",
+		Comments: []string{
+			"TITLE=Title",
+			"artist=First",
+			"Artist=Second",
+			"TRACKNUMBER= 7 ",
+			"REPLAYGAIN_TRACK_GAIN=-6.50 dB",
+			"REPLAYGAIN_TRACK_PEAK=0.988",
+			"INVALID",
+		},
+	}
+
+	if got := comment.Get("title"); got != "Title" {
+		t.Fatalf("got title %q, want %q", got, "Title")
+	}
+	if got := comment.GetAll("ARTIST"); len(got) != 2 || got[0] != "First" || got[1] != "Second" {
+		t.Fatalf("got artists %q, want [First Second]", got)
+	}
+	if got := comment.Get("ALBUM"); got != "" {
+		t.Fatalf("got album %q, want none", got)
+	}
+	if got := comment.Track(); got != 7 {
+		t.Fatalf("got track %d, want 7", got)
+	}
+
+	for _, tt := range []struct {
+		field string
+		value float64
+		ok    bool
+	}{
+		{field: "REPLAYGAIN_TRACK_GAIN", value: -6.5, ok: true},
+		{field: "REPLAYGAIN_TRACK_PEAK", value: 0.988, ok: true},
+		{field: "REPLAYGAIN_ALBUM_GAIN"},
+	} {
+		value, ok := comment.ReplayGain(tt.field)
+		if value != tt.value || ok != tt.ok {
+			t.Fatalf("got %v, %t for %s, want %v, %t", value, ok, tt.field, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestStreamInfoDuration(t *testing.T) {
+	for _, tt := range []struct {
+		info     StreamInfo
+		duration time.Duration
+	}{
+		{info: StreamInfo{SampleRate: 44100, TotalSamples: 44100 * 90}, duration: 90 * time.Second},
+		{info: StreamInfo{SampleRate: 48000, TotalSamples: 480}, duration: 10 * time.Millisecond},
+		{info: StreamInfo{SampleRate: 48000}, duration: 0},
+		{info: StreamInfo{TotalSamples: 480}, duration: 0},
+	} {
+		if got := tt.info.Duration(); got != tt.duration {
+			t.Fatalf("got %s for %+v, want %s", got, tt.info, tt.duration)
+		}
+	}
+}
+
+func TestSeekPointIsPlaceholder(t *testing.T) {
+	if (SeekPoint{SampleNumber: 4096}).IsPlaceholder() {
+		t.Fatal("got a placeholder for a regular seek point")
+	}
+	if !(SeekPoint{SampleNumber: 0xffffffffffffffff}).IsPlaceholder() {
+		t.Fatal("got no placeholder for a placeholder seek point")
+	}
+}
+
+func TestMetadataCoverArt(t *testing.T) {
+	if (Metadata{}).CoverArt() != nil {
+		t.Fatal("got cover art without pictures")
+	}
+
+	metadata := Metadata{Pictures: []Picture{
+		{Type: PictureTypeArtist, MIMEType: "image/png"},
+		{Type: PictureTypeFrontCover, MIMEType: "image/jpeg"},
+	}}
+	if got := metadata.CoverArt(); got == nil || got.Type != PictureTypeFrontCover {
+		t.Fatalf("got %+v, want the front cover", got)
+	}
+	metadata.Pictures = metadata.Pictures[:1]
+	if got := metadata.CoverArt(); got == nil || got.Type != PictureTypeArtist {
+		t.Fatalf("got %+v, want the first picture without a front cover", got)
+	}
+}
@@ -0,0 +1,152 @@
+package flac
+
+/*
+#cgo pkg-config: flac
+#include <FLAC/stream_decoder.h>
+*/
+import "C"
+import (
+	"io"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/disgoorg/audio/internal/readutil"
+)
+
+func decoderFromHandle(handle unsafe.Pointer) *Decoder {
+	return cgo.Handle(uintptr(handle)).Value().(*Decoder)
+}
+
+// goFLACRead is the read callback of the stream decoder. Read errors, including io.ErrNoProgress after repeated empty reads, abort the decoder and are returned by the next decode call.
+//
+//export goFLACRead
+func goFLACRead(handle unsafe.Pointer, buffer *C.FLAC__byte, bytes *C.size_t) C.int {
+	d := decoderFromHandle(handle)
+	if *bytes == 0 {
+		return C.FLAC__STREAM_DECODER_READ_STATUS_ABORT
+	}
+	p := unsafe.Slice((*byte)(buffer), int(*bytes))
+	n, err := readutil.ReadSome(d.reader, p)
+	if n > 0 {
+		*bytes = C.size_t(n)
+		return C.FLAC__STREAM_DECODER_READ_STATUS_CONTINUE
+	}
+	*bytes = 0
+	if err == io.EOF {
+		d.eof = true
+		return C.FLAC__STREAM_DECODER_READ_STATUS_END_OF_STREAM
+	}
+	d.readErr = err
+	return C.FLAC__STREAM_DECODER_READ_STATUS_ABORT
+}
+
+// goFLACSeek is the seek callback of the stream decoder. Seeking is only supported if the reader is an io.Seeker.
+//
+//export goFLACSeek
+func goFLACSeek(handle unsafe.Pointer, offset C.FLAC__uint64) C.int {
+	d := decoderFromHandle(handle)
+	seeker, ok := d.reader.(io.Seeker)
+	if !ok {
+		return C.FLAC__STREAM_DECODER_SEEK_STATUS_UNSUPPORTED
+	}
+	if _, err := seeker.Seek(int64(offset), io.SeekStart); err != nil {
+		return C.FLAC__STREAM_DECODER_SEEK_STATUS_ERROR
+	}
+	d.eof = false
+	return C.FLAC__STREAM_DECODER_SEEK_STATUS_OK
+}
+
+// goFLACTell is the tell callback of the stream decoder.
+//
+//export goFLACTell
+func goFLACTell(handle unsafe.Pointer, offset *C.FLAC__uint64) C.int {
+	d := decoderFromHandle(handle)
+	seeker, ok := d.reader.(io.Seeker)
+	if !ok {
+		return C.FLAC__STREAM_DECODER_TELL_STATUS_UNSUPPORTED
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return C.FLAC__STREAM_DECODER_TELL_STATUS_ERROR
+	}
+	*offset = C.FLAC__uint64(current)
+	return C.FLAC__STREAM_DECODER_TELL_STATUS_OK
+}
+
+// goFLACLength is the length callback of the stream decoder.
+//
+//export goFLACLength
+func goFLACLength(handle unsafe.Pointer, length *C.FLAC__uint64) C.int {
+	d := decoderFromHandle(handle)
+	seeker, ok := d.reader.(io.Seeker)
+	if !ok {
+		return C.FLAC__STREAM_DECODER_LENGTH_STATUS_UNSUPPORTED
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return C.FLAC__STREAM_DECODER_LENGTH_STATUS_ERROR
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return C.FLAC__STREAM_DECODER_LENGTH_STATUS_UNSUPPORTED
+	}
+	if _, err = seeker.Seek(current, io.SeekStart); err != nil {
+		return C.FLAC__STREAM_DECODER_LENGTH_STATUS_ERROR
+	}
+	*length = C.FLAC__uint64(end)
+	return C.FLAC__STREAM_DECODER_LENGTH_STATUS_OK
+}
+
+// goFLACEOF is the eof callback of the stream decoder.
+//
+//export goFLACEOF
+func goFLACEOF(handle unsafe.Pointer) C.int {
+	if decoderFromHandle(handle).eof {
+		return 1
+	}
+	return 0
+}
+
+// goFLACWrite is the write callback of the stream decoder. It copies the decoded samples of a frame into the Decoder.
+//
+//export goFLACWrite
+func goFLACWrite(handle unsafe.Pointer, frame *C.FLAC__Frame, buffer **C.FLAC__int32) C.int {
+	d := decoderFromHandle(handle)
+	channels := int(frame.header.channels)
+	blockSize := int(frame.header.blocksize)
+
+	d.frame.SampleRate = int(frame.header.sample_rate)
+	d.frame.Channels = channels
+	d.frame.BitsPerSample = int(frame.header.bits_per_sample)
+	// libFLAC always converts the frame number to a sample number before calling the write callback
+	d.frame.SampleNumber = uint64(*(*C.FLAC__uint64)(unsafe.Pointer(&frame.header.number)))
+	if cap(d.frame.Samples) < channels {
+		d.frame.Samples = make([][]int32, channels)
+	}
+	d.frame.Samples = d.frame.Samples[:channels]
+
+	buffers := unsafe.Slice(buffer, channels)
+	for i := range d.frame.Samples {
+		if cap(d.frame.Samples[i]) < blockSize {
+			d.frame.Samples[i] = make([]int32, blockSize)
+		}
+		d.frame.Samples[i] = d.frame.Samples[i][:blockSize]
+		copy(d.frame.Samples[i], unsafe.Slice((*int32)(unsafe.Pointer(buffers[i])), blockSize))
+	}
+	d.hasFrame = true
+	return C.FLAC__STREAM_DECODER_WRITE_STATUS_CONTINUE
+}
+
+// goFLACMetadata is the metadata callback of the stream decoder.
+//
+//export goFLACMetadata
+func goFLACMetadata(handle unsafe.Pointer, metadata *C.FLAC__StreamMetadata) {
+	decoderFromHandle(handle).metadata.parse(metadata)
+}
+
+// goFLACError is the error callback of the stream decoder. These errors are recoverable, libFLAC resyncs to the next frame on its own.
+//
+//export goFLACError
+func goFLACError(handle unsafe.Pointer, status C.int) {
+	decoderFromHandle(handle).decodeErrors++
+}
@@ -0,0 +1,183 @@
+package httpstream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var errBufferStopped = errors.New("read ahead buffer stopped")
+
+// newReadAheadBuffer starts reading from the Stream in the background until size bytes are buffered.
+func newReadAheadBuffer(stream *Stream, size int) *readAheadBuffer {
+	b := &readAheadBuffer{
+		stream: stream,
+		size:   size,
+		chunk:  make([]byte, 32*1024),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	b.start()
+	return b
+}
+
+// readAheadBuffer is an io.ReadSeeker which reads ahead of its consumer.
+// Seeking within the buffered data is free, seeking outside of it restarts the Stream at the new offset.
+type readAheadBuffer struct {
+	stream *Stream
+	size   int
+	chunk  []byte
+	done   chan struct{}
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buff    bytes.Buffer
+	offset  int64
+	err     error
+	stopped bool
+}
+
+func (b *readAheadBuffer) start() {
+	b.done = make(chan struct{})
+	b.stopped = false
+	go b.process(b.done)
+}
+
+func (b *readAheadBuffer) process(done chan struct{}) {
+	defer close(done)
+	for {
+		b.mu.Lock()
+		for b.buff.Len() >= b.size && !b.stopped {
+			b.cond.Wait()
+		}
+		stopped := b.stopped
+		b.mu.Unlock()
+		if stopped {
+			return
+		}
+
+		n, err := b.stream.Read(b.chunk)
+
+		b.mu.Lock()
+		if b.stopped {
+			b.mu.Unlock()
+			return
+		}
+		b.buff.Write(b.chunk[:n])
+		if err != nil {
+			b.err = err
+		}
+		b.cond.Broadcast()
+		b.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Read reads from the buffer. It only blocks if the buffer is empty.
+func (b *readAheadBuffer) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.buff.Len() == 0 && b.err == nil && !b.stopped {
+		b.cond.Wait()
+	}
+	if b.buff.Len() == 0 {
+		if b.stopped {
+			return 0, errBufferStopped
+		}
+		return 0, b.err
+	}
+	n, _ := b.buff.Read(p)
+	b.offset += int64(n)
+	b.cond.Broadcast()
+	return n, nil
+}
+
+// Seek seeks the consumer offset. Seeks within the buffered data are served from the buffer.
+func (b *readAheadBuffer) Seek(offset int64, whence int) (int64, error) {
+	b.mu.Lock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		if b.stream.Length() < 0 {
+			b.mu.Unlock()
+			return 0, ErrNotSeekable
+		}
+		offset += b.stream.Length()
+	default:
+		b.mu.Unlock()
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset >= b.offset && offset <= b.offset+int64(b.buff.Len()) {
+		b.buff.Next(int(offset - b.offset))
+		b.offset = offset
+		b.cond.Broadcast()
+		b.mu.Unlock()
+		return offset, nil
+	}
+	if !b.stream.Seekable() {
+		b.mu.Unlock()
+		return 0, ErrNotSeekable
+	}
+	b.mu.Unlock()
+
+	// cancel the in-flight request instead of waiting for it to return data we don't need
+	b.Stop()
+	b.stream.interrupt()
+	b.Wait()
+	b.stream.resume()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newOffset, err := b.stream.Seek(offset, io.SeekStart)
+	if err != nil {
+		// keep reading at the old offset
+		b.start()
+		return 0, err
+	}
+	b.buff.Reset()
+	b.offset = newOffset
+	b.err = nil
+	b.start()
+	return newOffset, nil
+}
+
+// Peek blocks until n bytes are buffered or the underlying reader returned an error and returns the buffered bytes.
+func (b *readAheadBuffer) Peek(n int) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.buff.Len() < n && b.err == nil && !b.stopped {
+		b.cond.Wait()
+	}
+	if b.buff.Len() < n {
+		n = b.buff.Len()
+	}
+	return b.buff.Bytes()[:n]
+}
+
+// Buffered returns the amount of buffered bytes and whether the underlying reader is exhausted.
+func (b *readAheadBuffer) Buffered() (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buff.Len(), b.err != nil
+}
+
+// Stop stops reading ahead. The caller has to unblock a pending read on the Stream itself.
+func (b *readAheadBuffer) Stop() {
+	b.mu.Lock()
+	b.stopped = true
+	b.cond.Broadcast()
+	b.mu.Unlock()
+}
+
+// Wait waits for the background reader to exit after Stop was called.
+func (b *readAheadBuffer) Wait() {
+	b.mu.Lock()
+	done := b.done
+	b.mu.Unlock()
+	<-done
+}
@@ -0,0 +1,99 @@
+package httpstream
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReadAheadBufferSeekWithinBuffer(t *testing.T) {
+	data := testData(4096)
+	server := newRangeServer(t, &rangeServer{data: data})
+	stream, err := Open(testContext(t), server.URL)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	buffer := newReadAheadBuffer(stream, len(data))
+	defer buffer.Wait()
+	defer stream.Close()
+	defer buffer.Stop()
+
+	buffer.Peek(len(data))
+	if _, err = buffer.Seek(1000, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	p := make([]byte, 16)
+	if _, err = io.ReadFull(buffer, p); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(p, data[1000:1016]) {
+		t.Fatal("read wrong data after seeking within the buffer")
+	}
+	if ranges := server.Config.Handler.(*rangeServer).Ranges(); len(ranges) != 0 {
+		t.Fatalf("made range requests %v, want none", ranges)
+	}
+}
+
+func TestReadAheadBufferSeekCancelsPendingRead(t *testing.T) {
+	data := testData(64 * 1024)
+	server := newRangeServer(t, &rangeServer{data: data, stall: 1024})
+	stream, err := Open(testContext(t), server.URL)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	buffer := newReadAheadBuffer(stream, len(data))
+	defer buffer.Wait()
+	defer stream.Close()
+	defer buffer.Stop()
+
+	// the background reader is now waiting for the stalled response
+	buffer.Peek(1024)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := buffer.Seek(32*1024, io.SeekStart)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatalf("seek: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("seek blocked on the pending read")
+	}
+
+	p := make([]byte, 16)
+	if _, err = io.ReadFull(buffer, p); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(p, data[32*1024:32*1024+16]) {
+		t.Fatal("read wrong data after seeking")
+	}
+}
+
+func TestReadAheadBufferSeekNotSeekable(t *testing.T) {
+	data := testData(64 * 1024)
+	server := newRangeServer(t, &rangeServer{data: data, noRanges: true})
+	stream, err := Open(testContext(t), server.URL)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	buffer := newReadAheadBuffer(stream, 1024)
+	defer buffer.Wait()
+	defer stream.Close()
+	defer buffer.Stop()
+
+	if _, err = buffer.Seek(32*1024, io.SeekStart); err != ErrNotSeekable {
+		t.Fatalf("got %v, want %v", err, ErrNotSeekable)
+	}
+	// the buffer keeps reading from the old offset
+	p := make([]byte, 16)
+	if _, err = io.ReadFull(buffer, p); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(p, data[:16]) {
+		t.Fatal("read wrong data after a failed seek")
+	}
+}
@@ -0,0 +1,84 @@
+package httpstream
+
+import (
+	"bytes"
+	"mime"
+)
+
+// Codec is the audio format of a stream.
+type Codec int
+
+const (
+	CodecUnknown Codec = iota
+	CodecMP3
+	CodecAAC
+	CodecMP4
+	CodecFLAC
+	CodecOgg
+	CodecWebM
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecMP3:
+		return "mp3"
+	case CodecAAC:
+		return "aac"
+	case CodecMP4:
+		return "mp4"
+	case CodecFLAC:
+		return "flac"
+	case CodecOgg:
+		return "ogg"
+	case CodecWebM:
+		return "webm"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectCodec detects the Codec of a stream by its magic bytes and falls back to the Content-Type header.
+// header should contain at least the first 12 bytes of the stream.
+func DetectCodec(contentType string, header []byte) Codec {
+	if codec := detectMagic(header); codec != CodecUnknown {
+		return codec
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "audio/mpeg", "audio/mp3", "audio/mpeg3", "audio/x-mpeg":
+		return CodecMP3
+	case "audio/aac", "audio/aacp", "audio/x-aac":
+		return CodecAAC
+	case "audio/mp4", "audio/x-m4a", "audio/m4a", "video/mp4":
+		return CodecMP4
+	case "audio/flac", "audio/x-flac":
+		return CodecFLAC
+	case "audio/ogg", "application/ogg", "audio/vorbis", "audio/opus":
+		return CodecOgg
+	case "audio/webm", "video/webm", "audio/x-matroska", "video/x-matroska":
+		return CodecWebM
+	}
+	return CodecUnknown
+}
+
+func detectMagic(header []byte) Codec {
+	switch {
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return CodecMP3
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return CodecFLAC
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return CodecOgg
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return CodecWebM
+	case len(header) >= 8 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return CodecMP4
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xF0 == 0xF0 && header[1]&0x06 == 0:
+		// ADTS sync word with layer 0
+		return CodecAAC
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		// MPEG audio frame sync with layer I, II or III
+		return CodecMP3
+	}
+	return CodecUnknown
+}
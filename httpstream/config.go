@@ -0,0 +1,126 @@
+package httpstream
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/disgoorg/log"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Logger:           log.Default(),
+		HTTPClient:       http.DefaultClient,
+		Header:           http.Header{},
+		MaxRetries:       5,
+		RetryDelay:       time.Second,
+		BufferSize:       512 * 1024,
+		BufferThreshold:  64 * 1024,
+		Decoders:         defaultDecoders(),
+		SeekableDecoders: defaultSeekableDecoders(),
+	}
+}
+
+// Config is used to configure a Stream and the FrameProvider created by NewPCMFrameProvider.
+type Config struct {
+	Logger     log.Logger
+	HTTPClient *http.Client
+	Header     http.Header
+
+	// MaxRetries is the number of consecutive reconnect attempts before giving up.
+	MaxRetries int
+	// RetryDelay is the delay before the first reconnect attempt. It is multiplied by the attempt number for each following attempt.
+	RetryDelay time.Duration
+
+	// BufferSize is the maximum amount of bytes read ahead of the decoder.
+	BufferSize int
+	// BufferThreshold is the amount of bytes which need to be buffered before playback resumes after the buffer ran empty.
+	BufferThreshold int
+
+	// Decoders maps a Codec to the DecoderFunc used to decode it.
+	// By default MP3, ADTS AAC, FLAC and Ogg Vorbis are decoded. Ogg Opus and WebM need a custom DecoderFunc.
+	Decoders map[Codec]DecoderFunc
+	// SeekableDecoders maps a Codec to the SeekableDecoderFunc used to decode it if the server supports Range requests.
+	// By default MP3, MP4 AAC, FLAC and Ogg Vorbis are decoded.
+	SeekableDecoders map[Codec]SeekableDecoderFunc
+
+	// ICYMetadataFunc is called when the StreamTitle of an Icecast/SHOUTcast stream changes.
+	// If set, ICY metadata is requested from the server and stripped from the audio data.
+	ICYMetadataFunc ICYMetadataFunc
+}
+
+// ConfigOpt is used to functionally configure a Config.
+type ConfigOpt func(config *Config)
+
+// Apply applies the ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithLogger sets the Logger of the Config.
+func WithLogger(logger log.Logger) ConfigOpt {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithHTTPClient sets the http.Client used to request the stream.
+func WithHTTPClient(httpClient *http.Client) ConfigOpt {
+	return func(config *Config) {
+		config.HTTPClient = httpClient
+	}
+}
+
+// WithHeader adds a header which is sent with every request.
+func WithHeader(key string, value string) ConfigOpt {
+	return func(config *Config) {
+		config.Header.Add(key, value)
+	}
+}
+
+// WithMaxRetries sets the number of consecutive reconnect attempts before giving up.
+func WithMaxRetries(maxRetries int) ConfigOpt {
+	return func(config *Config) {
+		config.MaxRetries = maxRetries
+	}
+}
+
+// WithRetryDelay sets the delay before the first reconnect attempt.
+func WithRetryDelay(retryDelay time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.RetryDelay = retryDelay
+	}
+}
+
+// WithBuffer sets the maximum read ahead size and the amount of bytes needed to resume playback after buffering.
+func WithBuffer(size int, threshold int) ConfigOpt {
+	return func(config *Config) {
+		config.BufferSize = size
+		config.BufferThreshold = threshold
+	}
+}
+
+// WithDecoder sets the DecoderFunc used to decode the given Codec.
+func WithDecoder(codec Codec, decoderFunc DecoderFunc) ConfigOpt {
+	return func(config *Config) {
+		config.Decoders[codec] = decoderFunc
+	}
+}
+
+// WithSeekableDecoder sets the SeekableDecoderFunc used to decode the given Codec if the server supports Range requests.
+func WithSeekableDecoder(codec Codec, decoderFunc SeekableDecoderFunc) ConfigOpt {
+	return func(config *Config) {
+		config.SeekableDecoders[codec] = decoderFunc
+	}
+}
+
+// WithICYMetadata requests ICY metadata from Icecast/SHOUTcast servers and calls the given ICYMetadataFunc when the StreamTitle changes.
+// SHOUTcast v1 servers additionally require a http.Client created by NewICYHTTPClient.
+func WithICYMetadata(metadataFunc ICYMetadataFunc) ConfigOpt {
+	return func(config *Config) {
+		config.ICYMetadataFunc = metadataFunc
+	}
+}
@@ -0,0 +1,26 @@
+package httpstream
+
+import "testing"
+
+func TestDefaultConfigDecoders(t *testing.T) {
+	config := DefaultConfig()
+	for _, codec := range []Codec{CodecMP3, CodecAAC, CodecMP4, CodecFLAC, CodecOgg} {
+		_, ok := config.Decoders[codec]
+		_, seekable := config.SeekableDecoders[codec]
+		if !ok && !seekable {
+			t.Errorf("no decoder registered for %s", codec)
+		}
+	}
+	for _, codec := range []Codec{CodecMP3, CodecMP4, CodecFLAC, CodecOgg} {
+		if _, ok := config.SeekableDecoders[codec]; !ok {
+			t.Errorf("no seekable decoder registered for %s", codec)
+		}
+	}
+}
+
+func TestDefaultConfigNotShared(t *testing.T) {
+	WithDecoder(CodecWebM, newMP3Decoder)(DefaultConfig())
+	if _, ok := DefaultConfig().Decoders[CodecWebM]; ok {
+		t.Fatal("WithDecoder modified the decoders of other configs")
+	}
+}
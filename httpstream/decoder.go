@@ -0,0 +1,116 @@
+package httpstream
+
+import (
+	"context"
+	"io"
+
+	"github.com/disgoorg/audio/aac"
+	"github.com/disgoorg/audio/flac"
+	"github.com/disgoorg/audio/mp3"
+	"github.com/disgoorg/audio/mp4"
+	"github.com/disgoorg/audio/pcm"
+	"github.com/disgoorg/audio/vorbis"
+)
+
+// defaultDecoders returns the DecoderFunc(s) registered by DefaultConfig.
+// MP4 needs to seek to find the moov box and is only decoded by a SeekableDecoderFunc.
+func defaultDecoders() map[Codec]DecoderFunc {
+	return map[Codec]DecoderFunc{
+		CodecMP3:  newMP3Decoder,
+		CodecAAC:  newADTSDecoder,
+		CodecFLAC: newFLACDecoder,
+		CodecOgg:  newVorbisDecoder,
+	}
+}
+
+// defaultSeekableDecoders returns the SeekableDecoderFunc(s) registered by DefaultConfig.
+func defaultSeekableDecoders() map[Codec]SeekableDecoderFunc {
+	return map[Codec]SeekableDecoderFunc{
+		CodecMP3:  newSeekableMP3Decoder,
+		CodecMP4:  newMP4Decoder,
+		CodecFLAC: newSeekableFLACDecoder,
+		CodecOgg:  newSeekableVorbisDecoder,
+	}
+}
+
+func newADTSDecoder(r io.Reader) (pcm.FrameProvider, error) {
+	return aac.NewADTSPCMFrameProvider(nil, r)
+}
+
+func newMP4Decoder(r io.ReadSeeker) (pcm.SeekableFrameProvider, error) {
+	demuxer, err := mp4.Open(r)
+	if err != nil {
+		return nil, err
+	}
+	return aac.NewMP4PCMFrameProvider(nil, demuxer)
+}
+
+func newFLACDecoder(r io.Reader) (pcm.FrameProvider, error) {
+	return flac.NewPCMFrameProvider(nil, r)
+}
+
+func newSeekableFLACDecoder(r io.ReadSeeker) (pcm.SeekableFrameProvider, error) {
+	return flac.NewPCMFrameProvider(nil, r)
+}
+
+func newVorbisDecoder(r io.Reader) (pcm.FrameProvider, error) {
+	return vorbis.NewPCMFrameProvider(nil, r)
+}
+
+func newSeekableVorbisDecoder(r io.ReadSeeker) (pcm.SeekableFrameProvider, error) {
+	return vorbis.NewPCMFrameProvider(nil, r)
+}
+
+func newMP3Decoder(r io.Reader) (pcm.FrameProvider, error) {
+	// the data is fed from ProvidePCMFrame, so the provider must not block while it needs more
+	provider, w, err := mp3.NewNonBlockingPCMFrameProvider(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &feedFrameProvider{
+		provider: provider,
+		w:        w,
+		r:        r,
+		buff:     make([]byte, 4096),
+	}, nil
+}
+
+func newSeekableMP3Decoder(r io.ReadSeeker) (pcm.SeekableFrameProvider, error) {
+	return mp3.NewSeekablePCMFrameProvider(nil, r)
+}
+
+// feedFrameProvider feeds data from an io.Reader to a feed based decoder whenever it runs dry.
+type feedFrameProvider struct {
+	provider pcm.FrameProvider
+	w        io.WriteCloser
+	r        io.Reader
+	buff     []byte
+	eof      bool
+}
+
+func (p *feedFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	for {
+		frame, err := p.provider.ProvidePCMFrame()
+		if frame != nil || err != nil || p.eof {
+			return frame, err
+		}
+
+		n, err := p.r.Read(p.buff)
+		if n > 0 {
+			if _, err := p.w.Write(p.buff[:n]); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF {
+			// closing the writer flushes the remaining frames of the decoder
+			p.eof = true
+			_ = p.w.Close()
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *feedFrameProvider) Close() {
+	p.provider.Close()
+}
@@ -0,0 +1,215 @@
+package httpstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// ErrUnsupportedCodec is returned when no DecoderFunc is registered for the detected Codec.
+var ErrUnsupportedCodec = errors.New("unsupported codec")
+
+// DecoderFunc creates a pcm.FrameProvider which decodes the data read from the given io.Reader into 48000hz stereo PCM frames.
+type DecoderFunc func(r io.Reader) (pcm.FrameProvider, error)
+
+// SeekableDecoderFunc creates a pcm.SeekableFrameProvider which decodes the data read from the given io.ReadSeeker into 48000hz stereo PCM frames.
+// Seeking the io.ReadSeeker outside the buffered data issues a new Range request.
+type SeekableDecoderFunc func(r io.ReadSeeker) (pcm.SeekableFrameProvider, error)
+
+// FrameProvider is a pcm.BufferingFrameProvider which streams audio from an HTTP(S) url.
+// It is also a pcm.TitleFrameProvider which returns the StreamTitle of the ICY metadata, so audio.TitleListener(s) get notified about title changes.
+type FrameProvider interface {
+	pcm.BufferingFrameProvider
+	pcm.TitleFrameProvider
+	io.Seeker
+
+	// Codec returns the detected Codec of the stream.
+	Codec() Codec
+
+	// Stream returns the underlying Stream.
+	Stream() *Stream
+
+	// SeekPosition seeks to the given position. It returns pcm.ErrNotSeekable if the stream wasn't decoded by a SeekableDecoderFunc.
+	SeekPosition(position time.Duration) error
+
+	// Position returns the position of the next frame or 0 if it is unknown.
+	Position() time.Duration
+
+	// Duration returns the total duration or 0 if it is unknown.
+	Duration() time.Duration
+}
+
+// NewPCMFrameProvider opens a Stream to the given url, detects its Codec and returns a FrameProvider decoding it.
+// While not enough data is buffered the FrameProvider returns silent frames and reports that it is buffering.
+// Seeking is done in bytes and requires the server to support Range requests.
+// If the server supports Range requests and a SeekableDecoderFunc is registered for the Codec, it is preferred over the DecoderFunc and SeekPosition seeks by time.
+func NewPCMFrameProvider(ctx context.Context, url string, opts ...ConfigOpt) (FrameProvider, error) {
+	config := DefaultConfig()
+	config.Apply(opts)
+
+	stream, err := open(ctx, url, *config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	p := &frameProvider{
+		stream:          stream,
+		buffer:          newReadAheadBuffer(stream, config.BufferSize),
+		bufferThreshold: config.BufferThreshold,
+		buffering:       1,
+		silence:         make([]int16, opus.GetOutputBuffSize(48000, 2)),
+	}
+
+	p.codec = DetectCodec(stream.ContentType(), p.buffer.Peek(12))
+	if seekableDecoderFunc, ok := config.SeekableDecoders[p.codec]; ok && stream.Seekable() {
+		p.newDecoder = func() (pcm.FrameProvider, error) {
+			return seekableDecoderFunc(p.buffer)
+		}
+	} else if decoderFunc, ok := config.Decoders[p.codec]; ok {
+		p.newDecoder = func() (pcm.FrameProvider, error) {
+			return decoderFunc(p.buffer)
+		}
+	} else {
+		p.Close()
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCodec, p.codec)
+	}
+
+	if p.provider, err = p.newDecoder(); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("failed to create %s decoder: %w", p.codec, err)
+	}
+	return p, nil
+}
+
+// NewRadioPCMFrameProvider returns a FrameProvider for an Icecast/SHOUTcast internet radio stream.
+// The ICY metadata is stripped from the audio data and the given ICYMetadataFunc is called whenever the StreamTitle changes.
+// The ICYMetadataFunc may be nil if the title is only needed through FrameProvider.Title or an audio.TitleListener.
+func NewRadioPCMFrameProvider(ctx context.Context, url string, metadataFunc ICYMetadataFunc, opts ...ConfigOpt) (FrameProvider, error) {
+	if metadataFunc == nil {
+		metadataFunc = func(ICYMetadata) {}
+	}
+	return NewPCMFrameProvider(ctx, url, append([]ConfigOpt{
+		WithHTTPClient(NewICYHTTPClient()),
+		WithICYMetadata(metadataFunc),
+	}, opts...)...)
+}
+
+type frameProvider struct {
+	codec           Codec
+	newDecoder      func() (pcm.FrameProvider, error)
+	stream          *Stream
+	buffer          *readAheadBuffer
+	bufferThreshold int
+	buffering       int32
+	silence         []int16
+
+	mu       sync.Mutex
+	provider pcm.FrameProvider
+}
+
+func (p *frameProvider) ProvidePCMFrame() ([]int16, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	buffered, exhausted := p.buffer.Buffered()
+	if atomic.LoadInt32(&p.buffering) == 1 {
+		if buffered < p.bufferThreshold && !exhausted {
+			return p.silence, nil
+		}
+		atomic.StoreInt32(&p.buffering, 0)
+	} else if buffered == 0 && !exhausted {
+		atomic.StoreInt32(&p.buffering, 1)
+		return p.silence, nil
+	}
+	return p.provider.ProvidePCMFrame()
+}
+
+func (p *frameProvider) Buffering() bool {
+	return atomic.LoadInt32(&p.buffering) == 1
+}
+
+func (p *frameProvider) Title() string {
+	return p.stream.Metadata().StreamTitle
+}
+
+func (p *frameProvider) Codec() Codec {
+	return p.codec
+}
+
+func (p *frameProvider) Stream() *Stream {
+	return p.stream
+}
+
+func (p *frameProvider) Seek(offset int64, whence int) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.stream.Seekable() {
+		return 0, ErrNotSeekable
+	}
+
+	newOffset, err := p.buffer.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+
+	// the decoder state is invalid after jumping around in the stream
+	provider, err := p.newDecoder()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s decoder: %w", p.codec, err)
+	}
+	p.provider.Close()
+	p.provider = provider
+	atomic.StoreInt32(&p.buffering, 1)
+	return newOffset, nil
+}
+
+func (p *frameProvider) SeekPosition(position time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	provider, ok := p.provider.(pcm.SeekableFrameProvider)
+	if !ok || !p.stream.Seekable() {
+		return pcm.ErrNotSeekable
+	}
+	if err := provider.Seek(position); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.buffering, 1)
+	return nil
+}
+
+func (p *frameProvider) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if provider, ok := p.provider.(pcm.SeekableFrameProvider); ok {
+		return provider.Position()
+	}
+	return 0
+}
+
+func (p *frameProvider) Duration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if provider, ok := p.provider.(pcm.SeekableFrameProvider); ok {
+		return provider.Duration()
+	}
+	return 0
+}
+
+func (p *frameProvider) Close() {
+	p.buffer.Stop()
+	_ = p.stream.Close()
+	p.buffer.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider != nil {
+		p.provider.Close()
+	}
+}
@@ -0,0 +1,168 @@
+package httpstream
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/audio/pcm"
+)
+
+// byteDecoder provides a frame with a single sample for every byte read.
+type byteDecoder struct {
+	r      io.Reader
+	closed bool
+}
+
+func (d *byteDecoder) ProvidePCMFrame() ([]int16, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return nil, err
+	}
+	return []int16{int16(b[0])}, nil
+}
+
+func (d *byteDecoder) Close() {
+	d.closed = true
+}
+
+// seekableByteDecoder is a byteDecoder where every millisecond is one byte.
+type seekableByteDecoder struct {
+	byteDecoder
+	rs io.ReadSeeker
+}
+
+func (d *seekableByteDecoder) Seek(position time.Duration) error {
+	_, err := d.rs.Seek(position.Milliseconds(), io.SeekStart)
+	return err
+}
+
+func (d *seekableByteDecoder) Position() time.Duration {
+	offset, _ := d.rs.Seek(0, io.SeekCurrent)
+	return time.Duration(offset) * time.Millisecond
+}
+
+func (d *seekableByteDecoder) Duration() time.Duration {
+	return 0
+}
+
+// testDecoders registers byte decoders for mp3 and records the created decoders.
+func testDecoders(decoders *[]pcm.FrameProvider) []ConfigOpt {
+	return []ConfigOpt{
+		WithBuffer(1024, 1),
+		WithDecoder(CodecMP3, func(r io.Reader) (pcm.FrameProvider, error) {
+			decoder := &byteDecoder{r: r}
+			*decoders = append(*decoders, decoder)
+			return decoder, nil
+		}),
+		WithSeekableDecoder(CodecMP3, func(rs io.ReadSeeker) (pcm.SeekableFrameProvider, error) {
+			decoder := &seekableByteDecoder{byteDecoder: byteDecoder{r: rs}, rs: rs}
+			*decoders = append(*decoders, decoder)
+			return decoder, nil
+		}),
+	}
+}
+
+// nextFrame skips the silent frames returned while buffering.
+func nextFrame(t *testing.T, p FrameProvider) []int16 {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		frame, err := p.ProvidePCMFrame()
+		if err != nil {
+			t.Fatalf("provide: %v", err)
+		}
+		if !p.Buffering() && len(frame) == 1 {
+			return frame
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("still buffering")
+	return nil
+}
+
+func TestFrameProviderSeekBytes(t *testing.T) {
+	data := testData(64 * 1024)
+	server := newRangeServer(t, &rangeServer{data: data})
+	var decoders []pcm.FrameProvider
+	p, err := NewPCMFrameProvider(testContext(t), server.URL, testDecoders(&decoders)...)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer p.Close()
+
+	if p.Codec() != CodecMP3 {
+		t.Fatalf("codec is %s, want %s", p.Codec(), CodecMP3)
+	}
+	if _, ok := decoders[0].(*seekableByteDecoder); !ok {
+		t.Fatal("seekable stream not decoded by the SeekableDecoderFunc")
+	}
+
+	offset, err := p.Seek(40000, io.SeekStart)
+	if err != nil || offset != 40000 {
+		t.Fatalf("got %d, %v, want 40000, nil", offset, err)
+	}
+	if len(decoders) != 2 || !decoders[0].(*seekableByteDecoder).closed {
+		t.Fatal("decoder not recreated after seeking")
+	}
+	if frame := nextFrame(t, p); frame[0] != int16(data[40000]) {
+		t.Fatalf("got sample %d after seeking, want %d", frame[0], data[40000])
+	}
+}
+
+func TestFrameProviderSeekPosition(t *testing.T) {
+	data := testData(64 * 1024)
+	server := newRangeServer(t, &rangeServer{data: data})
+	var decoders []pcm.FrameProvider
+	p, err := NewPCMFrameProvider(testContext(t), server.URL, testDecoders(&decoders)...)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer p.Close()
+
+	if err = p.SeekPosition(50 * time.Second); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if frame := nextFrame(t, p); frame[0] != int16(data[50000]) {
+		t.Fatalf("got sample %d after seeking, want %d", frame[0], data[50000])
+	}
+	if position := p.Position(); position != 50001*time.Millisecond {
+		t.Fatalf("position is %s, want 50.001s", position)
+	}
+}
+
+func TestFrameProviderNotSeekable(t *testing.T) {
+	data := testData(64 * 1024)
+	server := newRangeServer(t, &rangeServer{data: data, noRanges: true})
+	var decoders []pcm.FrameProvider
+	p, err := NewPCMFrameProvider(testContext(t), server.URL, testDecoders(&decoders)...)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer p.Close()
+
+	if _, ok := decoders[0].(*byteDecoder); !ok {
+		t.Fatal("stream without range support not decoded by the DecoderFunc")
+	}
+	if err = p.SeekPosition(time.Second); !errors.Is(err, pcm.ErrNotSeekable) {
+		t.Fatalf("got %v, want %v", err, pcm.ErrNotSeekable)
+	}
+	if _, err = p.Seek(1000, io.SeekStart); err != ErrNotSeekable {
+		t.Fatalf("got %v, want %v", err, ErrNotSeekable)
+	}
+	if frame := nextFrame(t, p); frame[0] != int16(data[0]) {
+		t.Fatalf("got sample %d, want %d", frame[0], data[0])
+	}
+}
+
+func TestFrameProviderUnsupportedCodec(t *testing.T) {
+	server := newRangeServer(t, &rangeServer{data: []byte("not audio at all")})
+	_, err := NewPCMFrameProvider(testContext(t), server.URL, func(config *Config) {
+		delete(config.Decoders, CodecMP3)
+		delete(config.SeekableDecoders, CodecMP3)
+	})
+	if !errors.Is(err, ErrUnsupportedCodec) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedCodec)
+	}
+}
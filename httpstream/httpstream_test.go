@@ -0,0 +1,98 @@
+package httpstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testData returns size bytes of data where every byte is its offset modulo 251, so any offset can be verified.
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+// rangeServer serves data with support for Range requests.
+// If stall is not 0, responses starting at offset 0 stall after stall bytes until the request is canceled.
+// If drop is not 0, every response is cut off after drop bytes.
+type rangeServer struct {
+	data     []byte
+	stall    int
+	drop     int
+	noRanges bool
+	requests int32
+
+	mu     sync.Mutex
+	ranges []int64
+}
+
+// Ranges returns the offsets of all Range requests.
+func (s *rangeServer) Ranges() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.ranges...)
+}
+
+func newRangeServer(t *testing.T, s *rangeServer) *httptest.Server {
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	var offset int64
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && !s.noRanges {
+		offset, _ = strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"), 10, 64)
+		s.mu.Lock()
+		s.ranges = append(s.ranges, offset)
+		s.mu.Unlock()
+	}
+	if offset >= int64(len(s.data)) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/mpeg")
+	if !s.noRanges {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(int64(len(s.data))-offset, 10))
+	if offset > 0 {
+		w.Header().Set("Content-Range", "bytes "+strconv.FormatInt(offset, 10)+"-"+strconv.Itoa(len(s.data)-1)+"/"+strconv.Itoa(len(s.data)))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+
+	data := s.data[offset:]
+	switch {
+	case s.stall > 0 && offset == 0:
+		_, _ = w.Write(data[:s.stall])
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	case s.drop > 0 && len(data) > s.drop:
+		_, _ = w.Write(data[:s.drop])
+		w.(http.Flusher).Flush()
+		// hijack to drop the connection without finishing the response
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	default:
+		_, _ = w.Write(data)
+	}
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
@@ -0,0 +1,172 @@
+package httpstream
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ICYMetadataFunc is called when the StreamTitle of an Icecast/SHOUTcast stream changes.
+// It is called from the goroutine reading the stream and should not block.
+type ICYMetadataFunc func(metadata ICYMetadata)
+
+// ICYMetadata is a metadata block sent inline by Icecast/SHOUTcast servers.
+type ICYMetadata struct {
+	// StreamTitle is the currently playing title, usually in the form "Artist - Title".
+	StreamTitle string
+	// StreamURL is an optional url sent by some stations.
+	StreamURL string
+	// Fields contains all key value pairs of the metadata block.
+	Fields map[string]string
+}
+
+// ICYStation holds the station information sent in the response headers of an Icecast/SHOUTcast stream.
+type ICYStation struct {
+	Name        string
+	Description string
+	Genre       string
+	URL         string
+	Bitrate     int
+	// MetaInterval is the amount of audio bytes between two metadata blocks or 0 if the server sends no metadata.
+	MetaInterval int
+}
+
+// ParseICYMetadata parses a metadata block in the form StreamTitle='...';StreamUrl='...';
+func ParseICYMetadata(data []byte) ICYMetadata {
+	metadata := ICYMetadata{
+		Fields: map[string]string{},
+	}
+	s := string(bytes.TrimRight(data, "\x00"))
+	for len(s) > 0 {
+		eq := strings.Index(s, "='")
+		if eq == -1 {
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		// values may contain ' or ; themselves, so only '; terminates them
+		end := strings.Index(s, "';")
+		var value string
+		if end == -1 {
+			value, s = strings.TrimSuffix(s, "'"), ""
+		} else {
+			value, s = s[:end], s[end+2:]
+		}
+		metadata.Fields[key] = value
+	}
+	metadata.StreamTitle = metadata.Fields["StreamTitle"]
+	metadata.StreamURL = metadata.Fields["StreamUrl"]
+	return metadata
+}
+
+func parseICYStation(header http.Header) ICYStation {
+	bitrate, _ := strconv.Atoi(strings.SplitN(header.Get("icy-br"), ",", 2)[0])
+	metaInt, _ := strconv.Atoi(header.Get("icy-metaint"))
+	return ICYStation{
+		Name:         header.Get("icy-name"),
+		Description:  header.Get("icy-description"),
+		Genre:        header.Get("icy-genre"),
+		URL:          header.Get("icy-url"),
+		Bitrate:      bitrate,
+		MetaInterval: metaInt,
+	}
+}
+
+// newICYReader strips the metadata blocks from an Icecast/SHOUTcast response body.
+func newICYReader(r io.ReadCloser, metaInterval int, metadataFunc func(data []byte)) io.ReadCloser {
+	return &icyReader{
+		r:            r,
+		metaInterval: metaInterval,
+		remaining:    metaInterval,
+		metadataFunc: metadataFunc,
+	}
+}
+
+type icyReader struct {
+	r            io.ReadCloser
+	metaInterval int
+	remaining    int
+	metadataFunc func(data []byte)
+}
+
+func (r *icyReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		var length [1]byte
+		if _, err := io.ReadFull(r.r, length[:]); err != nil {
+			return 0, err
+		}
+		if size := int(length[0]) * 16; size > 0 {
+			data := make([]byte, size)
+			if _, err := io.ReadFull(r.r, data); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return 0, err
+			}
+			r.metadataFunc(data)
+		}
+		r.remaining = r.metaInterval
+	}
+	if len(p) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.r.Read(p)
+	r.remaining -= n
+	return n, err
+}
+
+func (r *icyReader) Close() error {
+	return r.r.Close()
+}
+
+// NewICYHTTPClient returns a http.Client which also accepts the "ICY 200 OK" status line sent by SHOUTcast v1 servers.
+// The status line is only rewritten for plain HTTP connections, https urls still fail on SHOUTcast v1 servers as the TLS connection is established on top of the dialed net.Conn.
+func NewICYHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &icyConn{Conn: conn}, nil
+	}
+	return &http.Client{
+		Transport: transport,
+	}
+}
+
+// icyConn rewrites a leading "ICY " status line to "HTTP/1.0 " so net/http can parse the response.
+// For https the net.Conn carries the encrypted TLS records, so icyConn never sees the status line and can't rewrite it.
+type icyConn struct {
+	net.Conn
+	checked bool
+	pending []byte
+}
+
+func (c *icyConn) Read(p []byte) (int, error) {
+	if !c.checked {
+		c.checked = true
+		prefix := make([]byte, 4)
+		n, err := io.ReadFull(c.Conn, prefix)
+		if n == 4 && string(prefix) == "ICY " {
+			c.pending = []byte("HTTP/1.0 ")
+		} else {
+			c.pending = prefix[:n]
+		}
+		if n == 0 && err != nil {
+			return 0, err
+		}
+	}
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
@@ -0,0 +1,226 @@
+package httpstream
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"testing/iotest"
+
+	"github.com/disgoorg/audio/pcm"
+)
+
+// icyBlock builds a metadata block with its length byte, padded to a multiple of 16 bytes.
+func icyBlock(metadata string) []byte {
+	size := (len(metadata) + 15) / 16
+	block := make([]byte, 1+size*16)
+	block[0] = byte(size)
+	copy(block[1:], metadata)
+	return block
+}
+
+func TestParseICYMetadata(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		data   string
+		title  string
+		url    string
+		fields int
+	}{
+		{name: "title and url", data: "StreamTitle='Artist - Title';StreamUrl='https://example.com';", title: "Artist - Title", url: "https://example.com", fields: 2},
+		{name: "padding", data: "StreamTitle='Title';\x00\x00\x00\x00", title: "Title", fields: 1},
+		{name: "quote in title", data: "StreamTitle='Don't Stop';", title: "Don't Stop", fields: 1},
+		{name: "semicolon in title", data: "StreamTitle='Part 1; Part 2';StreamUrl='';", title: "Part 1; Part 2", fields: 2},
+		{name: "unterminated", data: "StreamTitle='Title'", title: "Title", fields: 1},
+		{name: "empty title", data: "StreamTitle='';", fields: 1},
+		{name: "empty block", data: "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"},
+		{name: "garbage", data: "no metadata"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := ParseICYMetadata([]byte(tt.data))
+			if metadata.StreamTitle != tt.title || metadata.StreamURL != tt.url {
+				t.Fatalf("got title %q and url %q, want %q and %q", metadata.StreamTitle, metadata.StreamURL, tt.title, tt.url)
+			}
+			if len(metadata.Fields) != tt.fields {
+				t.Fatalf("got fields %v, want %d fields", metadata.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseICYStation(t *testing.T) {
+	header := http.Header{}
+	header.Set("icy-name", "Radio")
+	header.Set("icy-description", "Description")
+	header.Set("icy-genre", "Rock")
+	header.Set("icy-url", "https://example.com")
+	header.Set("icy-br", "128,128")
+	header.Set("icy-metaint", "16000")
+
+	want := ICYStation{Name: "Radio", Description: "Description", Genre: "Rock", URL: "https://example.com", Bitrate: 128, MetaInterval: 16000}
+	if station := parseICYStation(header); station != want {
+		t.Fatalf("got %+v, want %+v", station, want)
+	}
+	if station := parseICYStation(http.Header{}); station != (ICYStation{}) {
+		t.Fatalf("got %+v without icy headers, want an empty ICYStation", station)
+	}
+}
+
+func TestICYReader(t *testing.T) {
+	data := bytes.Join([][]byte{
+		[]byte("abcd"),
+		icyBlock("StreamTitle='First';"),
+		[]byte("efgh"),
+		// an empty block keeps the last metadata
+		{0},
+		[]byte("ijkl"),
+		icyBlock("StreamTitle='Second';"),
+		[]byte("mn"),
+	}, nil)
+
+	for _, tt := range []struct {
+		name string
+		r    func(r io.Reader) io.Reader
+	}{
+		{name: "full reads", r: func(r io.Reader) io.Reader { return r }},
+		{name: "single bytes", r: iotest.OneByteReader},
+		{name: "half reads", r: iotest.HalfReader},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var metadata []string
+			r := newICYReader(io.NopCloser(tt.r(bytes.NewReader(data))), 4, func(data []byte) {
+				metadata = append(metadata, ParseICYMetadata(data).StreamTitle)
+			})
+			got, err := io.ReadAll(iotest.OneByteReader(r))
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(got) != "abcdefghijklmn" {
+				t.Fatalf("got %q, want the audio data without metadata", got)
+			}
+			if len(metadata) != 2 || metadata[0] != "First" || metadata[1] != "Second" {
+				t.Fatalf("got metadata %q, want [First Second]", metadata)
+			}
+		})
+	}
+}
+
+func TestICYReaderTruncatedMetadata(t *testing.T) {
+	data := append([]byte("abcd"), icyBlock("StreamTitle='Title';")[:10]...)
+	r := newICYReader(io.NopCloser(bytes.NewReader(data)), 4, func([]byte) {
+		t.Fatal("got metadata of a truncated block")
+	})
+	if _, err := io.ReadAll(r); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestICYConn(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		response string
+		status   string
+	}{
+		{name: "icy", response: "ICY 200 OK\r\nicy-name: Radio\r\n\r\n", status: "200 OK"},
+		{name: "http", response: "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n", status: "404 Not Found"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			go func() {
+				_, _ = server.Write([]byte(tt.response))
+				_ = server.Close()
+			}()
+
+			rs, err := http.ReadResponse(bufio.NewReader(&icyConn{Conn: client}), nil)
+			if err != nil {
+				t.Fatalf("read response: %v", err)
+			}
+			if rs.Status != tt.status {
+				t.Fatalf("got status %q, want %q", rs.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestICYConnShortResponse(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		_, _ = server.Write([]byte("IC"))
+		_ = server.Close()
+	}()
+
+	got, err := io.ReadAll(&icyConn{Conn: client})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "IC" {
+		t.Fatalf("got %q, want the data read before the connection closed", got)
+	}
+}
+
+func TestStreamStripsICYMetadata(t *testing.T) {
+	audio := testData(64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Icy-MetaData") != "1" {
+			_, _ = w.Write(audio)
+			return
+		}
+		w.Header().Set("icy-metaint", strconv.Itoa(32))
+		_, _ = w.Write(bytes.Join([][]byte{audio[:32], icyBlock("StreamTitle='Title';"), audio[32:]}, nil))
+	}))
+	defer server.Close()
+
+	var titles []string
+	stream, err := Open(testContext(t), server.URL, WithICYMetadata(func(metadata ICYMetadata) {
+		titles = append(titles, metadata.StreamTitle)
+	}))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer stream.Close()
+
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, audio) {
+		t.Fatalf("got %d bytes, want the %d audio bytes without metadata", len(got), len(audio))
+	}
+	if len(titles) != 1 || titles[0] != "Title" || stream.Metadata().StreamTitle != "Title" {
+		t.Fatalf("got titles %q and metadata %+v, want the title Title", titles, stream.Metadata())
+	}
+	if station := stream.Station(); station.MetaInterval != 32 {
+		t.Fatalf("got meta interval %d, want 32", station.MetaInterval)
+	}
+}
+
+func TestRadioFrameProviderTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("icy-metaint", "4")
+		_, _ = w.Write(bytes.Join([][]byte{{1, 2, 3, 4}, icyBlock("StreamTitle='Title';"), {5, 6, 7, 8}}, nil))
+	}))
+	defer server.Close()
+
+	var decoders []pcm.FrameProvider
+	p, err := NewRadioPCMFrameProvider(testContext(t), server.URL, nil, testDecoders(&decoders)...)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer p.Close()
+
+	for i := 1; i <= 8; i++ {
+		if frame := nextFrame(t, p); frame[0] != int16(i) {
+			t.Fatalf("got sample %d, want %d", frame[0], i)
+		}
+	}
+	if title := p.Title(); title != "Title" {
+		t.Fatalf("got title %q, want %q", title, "Title")
+	}
+}
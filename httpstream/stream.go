@@ -0,0 +1,378 @@
+package httpstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotSeekable is returned when seeking a Stream whose server does not support range requests.
+	ErrNotSeekable = errors.New("stream is not seekable")
+
+	// ErrStreamClosed is returned when reading from a closed Stream.
+	ErrStreamClosed = errors.New("stream closed")
+
+	errStreamInterrupted = errors.New("stream interrupted")
+)
+
+var _ io.ReadSeekCloser = (*Stream)(nil)
+
+// StatusError is returned when the server responds with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("unexpected status: %s", e.Status)
+}
+
+// Open opens a Stream to the given url. The first request is made immediately to validate the url and read the response headers.
+// The given context.Context is used for all requests made by the Stream.
+func Open(ctx context.Context, url string, opts ...ConfigOpt) (*Stream, error) {
+	config := DefaultConfig()
+	config.Apply(opts)
+	return open(ctx, url, *config)
+}
+
+func open(ctx context.Context, url string, config Config) (*Stream, error) {
+	s := &Stream{
+		ctx:         ctx,
+		config:      config,
+		url:         url,
+		length:      -1,
+		interrupted: make(chan struct{}),
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Stream is an io.ReadSeekCloser which reads from an HTTP(S) url.
+// If the connection drops it reconnects and resumes at the current offset using Range requests.
+// A Stream is not safe for concurrent use, except for Close which may be called at any time to abort a pending Read.
+type Stream struct {
+	ctx    context.Context
+	config Config
+	url    string
+
+	mu            sync.Mutex
+	body          io.ReadCloser
+	cancelRequest context.CancelFunc
+	closed        bool
+	interrupted   chan struct{}
+
+	connected    bool
+	offset       int64
+	length       int64
+	contentType  string
+	acceptRanges bool
+	station      ICYStation
+
+	metadataMu sync.Mutex
+	metadata   ICYMetadata
+}
+
+// URL returns the url of the Stream.
+func (s *Stream) URL() string {
+	return s.url
+}
+
+// ContentType returns the Content-Type of the first response.
+func (s *Stream) ContentType() string {
+	return s.contentType
+}
+
+// Length returns the total length of the Stream in bytes or -1 if it is unknown.
+func (s *Stream) Length() int64 {
+	return s.length
+}
+
+// Station returns the ICY station information of the first response.
+func (s *Stream) Station() ICYStation {
+	return s.station
+}
+
+// Metadata returns the last ICY metadata received.
+func (s *Stream) Metadata() ICYMetadata {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	return s.metadata
+}
+
+// Seekable returns whether the server supports Range requests.
+func (s *Stream) Seekable() bool {
+	return s.acceptRanges && s.length >= 0
+}
+
+func (s *Stream) Read(p []byte) (int, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := s.currentBody()
+		if err != nil {
+			return 0, err
+		}
+		if body == nil {
+			if err = s.connect(); err != nil {
+				if !s.retry(attempt, err) {
+					return 0, err
+				}
+				continue
+			}
+			body, _ = s.currentBody()
+		}
+
+		n, err := body.Read(p)
+		s.offset += int64(n)
+		if err == nil {
+			return n, nil
+		}
+		if errors.Is(err, io.EOF) && (s.length < 0 || s.offset >= s.length) {
+			return n, io.EOF
+		}
+
+		if _, closedErr := s.currentBody(); closedErr != nil {
+			return n, closedErr
+		}
+
+		// the connection dropped before we got everything, reconnect on the next read
+		s.closeBody()
+		if n > 0 {
+			return n, nil
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if !s.retry(attempt, err) {
+			return 0, err
+		}
+	}
+}
+
+// Seek sets the offset for the next Read. The next Read reconnects to the server using a Range request.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		if s.length < 0 {
+			return 0, ErrNotSeekable
+		}
+		offset += s.length
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset: %d", offset)
+	}
+	if offset == s.offset {
+		return offset, nil
+	}
+	if !s.Seekable() {
+		return 0, ErrNotSeekable
+	}
+	s.closeBody()
+	s.offset = offset
+	return offset, nil
+}
+
+// Close closes the Stream and aborts any pending Read.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.closeBodyLocked()
+}
+
+// interrupt cancels the in-flight request and makes a pending Read return errStreamInterrupted until resume is called.
+// Unlike Close it may be called concurrently with Read.
+func (s *Stream) interrupt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.interrupted:
+	default:
+		close(s.interrupted)
+	}
+	_ = s.closeBodyLocked()
+}
+
+// resume allows reading again after interrupt. The next Read reconnects at the current offset.
+func (s *Stream) resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.interrupted:
+		s.interrupted = make(chan struct{})
+	default:
+	}
+}
+
+func (s *Stream) currentBody() (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrStreamClosed
+	}
+	select {
+	case <-s.interrupted:
+		return nil, errStreamInterrupted
+	default:
+	}
+	return s.body, nil
+}
+
+func (s *Stream) closeBody() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.closeBodyLocked()
+}
+
+func (s *Stream) closeBodyLocked() error {
+	var err error
+	if s.body != nil {
+		err = s.body.Close()
+		s.body = nil
+	}
+	if s.cancelRequest != nil {
+		s.cancelRequest()
+		s.cancelRequest = nil
+	}
+	return err
+}
+
+func (s *Stream) retry(attempt int, err error) bool {
+	var statusErr StatusError
+	if attempt >= s.config.MaxRetries || s.ctx.Err() != nil || errors.Is(err, io.EOF) || errors.Is(err, errStreamInterrupted) || errors.Is(err, context.Canceled) || (errors.As(err, &statusErr) && statusErr.StatusCode < 500) {
+		return false
+	}
+	delay := s.config.RetryDelay * time.Duration(attempt+1)
+	s.config.Logger.Warnf("stream %s interrupted at offset %d, reconnecting in %s: %s", s.url, s.offset, delay, err)
+
+	s.mu.Lock()
+	interrupted := s.interrupted
+	s.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-interrupted:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *Stream) connect() (err error) {
+	// the request is canceled by Close, interrupt and once its body is closed
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrStreamClosed
+	}
+	s.cancelRequest = cancel
+	s.mu.Unlock()
+
+	rq, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range s.config.Header {
+		rq.Header[key] = values
+	}
+	if s.config.ICYMetadataFunc != nil {
+		rq.Header.Set("Icy-MetaData", "1")
+	}
+	// live streams have no length and can't be resumed, just reconnect to the current position
+	resume := s.offset > 0 && s.length >= 0
+	if resume {
+		rq.Header.Set("Range", "bytes="+strconv.FormatInt(s.offset, 10)+"-")
+	}
+
+	rs, err := s.config.HTTPClient.Do(rq)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case rs.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		_ = rs.Body.Close()
+		return io.EOF
+	case rs.StatusCode != http.StatusOK && rs.StatusCode != http.StatusPartialContent:
+		_ = rs.Body.Close()
+		return StatusError{StatusCode: rs.StatusCode, Status: rs.Status}
+	}
+
+	metaInterval, _ := strconv.Atoi(rs.Header.Get("icy-metaint"))
+	icy := metaInterval > 0 && s.config.ICYMetadataFunc != nil
+	if !s.connected {
+		s.connected = true
+		s.contentType = rs.Header.Get("Content-Type")
+		s.acceptRanges = strings.EqualFold(rs.Header.Get("Accept-Ranges"), "bytes")
+		s.length = rs.ContentLength
+		if icy {
+			// the length and ranges include the stripped metadata blocks
+			s.length = -1
+		}
+		s.station = parseICYStation(rs.Header)
+	}
+	if rs.StatusCode == http.StatusPartialContent {
+		s.acceptRanges = true
+	}
+
+	if resume && rs.StatusCode == http.StatusOK {
+		// the server ignored our range request, skip what we already have
+		if _, err = io.CopyN(io.Discard, rs.Body, s.offset); err != nil {
+			_ = rs.Body.Close()
+			return err
+		}
+	}
+
+	body := rs.Body
+	if icy {
+		body = newICYReader(body, metaInterval, s.onICYMetadata)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		_ = body.Close()
+		return ErrStreamClosed
+	}
+	select {
+	case <-s.interrupted:
+		_ = body.Close()
+		return errStreamInterrupted
+	default:
+	}
+	s.body = body
+	s.cancelRequest = cancel
+	return nil
+}
+
+func (s *Stream) onICYMetadata(data []byte) {
+	metadata := ParseICYMetadata(data)
+	s.metadataMu.Lock()
+	changed := metadata.StreamTitle != s.metadata.StreamTitle
+	s.metadata = metadata
+	s.metadataMu.Unlock()
+	if changed && s.config.ICYMetadataFunc != nil {
+		s.config.ICYMetadataFunc(metadata)
+	}
+}
@@ -0,0 +1,173 @@
+package httpstream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamResumesAfterDroppedConnection(t *testing.T) {
+	data := testData(16 * 1024)
+	rs := &rangeServer{data: data, drop: 5000}
+	server := newRangeServer(t, rs)
+
+	stream, err := Open(testContext(t), server.URL, WithRetryDelay(time.Millisecond))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer stream.Close()
+
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes with different data, want the %d bytes served", len(got), len(data))
+	}
+	ranges := rs.Ranges()
+	if len(ranges) != 3 || ranges[0] != 5000 || ranges[1] != 10000 || ranges[2] != 15000 {
+		t.Fatalf("resumed at %v, want [5000 10000 15000]", ranges)
+	}
+}
+
+func TestStreamSkipsIgnoredRange(t *testing.T) {
+	data := testData(16 * 1024)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server never responds with partial content and drops the first connection
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if atomic.AddInt32(&requests, 1) == 1 {
+			_, _ = w.Write(data[:5000])
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	stream, err := Open(testContext(t), server.URL, WithRetryDelay(time.Millisecond))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer stream.Close()
+	if stream.Seekable() {
+		t.Fatal("stream without range support is seekable")
+	}
+
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes with different data, want the %d bytes served", len(got), len(data))
+	}
+	if requests := atomic.LoadInt32(&requests); requests != 2 {
+		t.Fatalf("made %d requests, want 2", requests)
+	}
+}
+
+func TestStreamSeek(t *testing.T) {
+	data := testData(16 * 1024)
+	rs := &rangeServer{data: data}
+	server := newRangeServer(t, rs)
+
+	stream, err := Open(testContext(t), server.URL)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer stream.Close()
+	if !stream.Seekable() || stream.Length() != int64(len(data)) || stream.ContentType() != "audio/mpeg" {
+		t.Fatalf("got seekable %t, length %d, content type %s", stream.Seekable(), stream.Length(), stream.ContentType())
+	}
+
+	offset, err := stream.Seek(-100, io.SeekEnd)
+	if err != nil || offset != int64(len(data)-100) {
+		t.Fatalf("got %d, %v, want %d", offset, err, len(data)-100)
+	}
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, data[len(data)-100:]) {
+		t.Fatal("read wrong data after seeking")
+	}
+}
+
+func TestStreamGivesUpAfterMaxRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Content-Length", "1000")
+			_, _ = w.Write(make([]byte, 100))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	stream, err := Open(testContext(t), server.URL, WithMaxRetries(2), WithRetryDelay(time.Millisecond))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer stream.Close()
+
+	_, err = io.ReadAll(stream)
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want a %d StatusError", err, http.StatusServiceUnavailable)
+	}
+	// the first request followed by 2 reconnect attempts
+	if requests := atomic.LoadInt32(&requests); requests != 3 {
+		t.Fatalf("made %d requests, want 3", requests)
+	}
+}
+
+func TestStreamDoesNotRetryClientErrors(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := Open(testContext(t), server.URL, WithRetryDelay(time.Millisecond))
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %v, want a %d StatusError", err, http.StatusNotFound)
+	}
+	if requests := atomic.LoadInt32(&requests); requests != 1 {
+		t.Fatalf("made %d requests, want 1", requests)
+	}
+}
+
+func TestStreamCloseAbortsRead(t *testing.T) {
+	data := testData(16 * 1024)
+	server := newRangeServer(t, &rangeServer{data: data, stall: 100})
+
+	stream, err := Open(testContext(t), server.URL)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(stream, make([]byte, 1000))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	_ = stream.Close()
+
+	select {
+	case err = <-done:
+		if err != ErrStreamClosed {
+			t.Fatalf("got %v, want %v", err, ErrStreamClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("close didn't abort the pending read")
+	}
+}
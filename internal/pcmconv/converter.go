@@ -0,0 +1,200 @@
+// Package pcmconv converts decoded PCM of any sample rate and channel count into 20ms frames of a fixed sample rate and channel count.
+// It is shared by the decoder packages, whose streams can change their format mid-stream.
+package pcmconv
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/disgoorg/audio/channelconverter"
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/samplerate"
+)
+
+// NewConverter creates a new *Converter which outputs frames of the given rate and channels.
+// layout returns the channelconverter.Layout of the decoded channels. If it is nil, channelconverter.CreateChannelConverter is used.
+func NewConverter(rate int, channels int, layout func(channels int) channelconverter.Layout) *Converter {
+	return &Converter{
+		rate:     rate,
+		channels: channels,
+		layout:   layout,
+		frame:    make([]int16, opus.GetOutputBuffSize(rate, channels)),
+	}
+}
+
+// Converter re-routes decoded PCM through a samplerate.Resampler and channelconverter.ChannelConverter as needed and splits it into frames.
+type Converter struct {
+	rate     int
+	channels int
+	layout   func(channels int) channelconverter.Layout
+
+	inputRate     int
+	inputChannels int
+	converter     *channelconverter.ChannelConverter
+	convertBuff   []int16
+	resampler     *samplerate.Resampler
+	resampleBuff  []int16
+
+	pcmBuff []int16
+	pending []int16
+	frame   []int16
+	// flushed is set once the Resampler was drained at the end of the stream
+	flushed bool
+}
+
+// Ready returns whether a full frame is pending.
+func (c *Converter) Ready() bool {
+	return len(c.pending) >= len(c.frame)
+}
+
+// Next returns the next pending frame. Check Ready before calling it.
+// The returned frame is reused by the next call.
+func (c *Converter) Next() []int16 {
+	copy(c.frame, c.pending)
+	c.pending = append(c.pending[:0], c.pending[len(c.frame):]...)
+	return c.frame
+}
+
+// Flush is called at the end of the stream. It drains the samplerate.Resampler once and returns the remaining PCM frame by frame.
+// The last frame is padded with silence, io.EOF is returned once there is nothing left.
+func (c *Converter) Flush() ([]int16, error) {
+	if !c.flushed {
+		c.flushed = true
+		if err := c.drainResampler(); err != nil {
+			return nil, err
+		}
+	}
+	if c.Ready() {
+		return c.Next(), nil
+	}
+	if len(c.pending) == 0 {
+		return nil, io.EOF
+	}
+	n := copy(c.frame, c.pending)
+	for i := n; i < len(c.frame); i++ {
+		c.frame[i] = 0
+	}
+	c.pending = c.pending[:0]
+	return c.frame, nil
+}
+
+// Reset drops all pending PCM, use it after seeking.
+func (c *Converter) Reset() error {
+	c.pending = c.pending[:0]
+	c.flushed = false
+	if c.resampler != nil {
+		return c.resampler.Reset()
+	}
+	return nil
+}
+
+// Pending returns the duration of the pending PCM, which was decoded but not returned yet.
+func (c *Converter) Pending() time.Duration {
+	return SamplesToDuration(int64(len(c.pending)/c.channels), c.rate)
+}
+
+// ConvertBytes converts signed 16 bit little endian PCM of the given rate and channels.
+func (c *Converter) ConvertBytes(data []byte, rate int, channels int) error {
+	c.pcmBuff = grow(c.pcmBuff, len(data)/2)
+	for i := range c.pcmBuff {
+		c.pcmBuff[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return c.Convert(c.pcmBuff, rate, channels)
+}
+
+// Convert converts PCM of the given rate and channels and appends it to the pending PCM.
+func (c *Converter) Convert(pcm []int16, rate int, channels int) error {
+	if err := c.updateFormat(rate, channels); err != nil {
+		return err
+	}
+
+	if c.converter != nil {
+		c.convertBuff = grow(c.convertBuff, len(pcm)/c.inputChannels*c.channels)
+		if err := c.converter.Convert(pcm, c.convertBuff); err != nil {
+			return err
+		}
+		pcm = c.convertBuff
+	}
+
+	if c.resampler != nil && len(pcm) > 0 {
+		// leave some room for samples buffered inside the resampler
+		c.resampleBuff = grow(c.resampleBuff, (len(pcm)/c.channels*c.rate/c.inputRate+256)*c.channels)
+		var inputFrames, outputFrames int64
+		if err := c.resampler.Process(pcm, c.resampleBuff, c.inputRate, c.rate, 0, &inputFrames, &outputFrames); err != nil {
+			return err
+		}
+		pcm = c.resampleBuff[:outputFrames*int64(c.channels)]
+	}
+
+	c.pending = append(c.pending, pcm...)
+	return nil
+}
+
+func (c *Converter) updateFormat(rate int, channels int) error {
+	if channels != c.inputChannels {
+		c.inputChannels = channels
+		c.converter = nil
+		if channels != c.channels {
+			if c.layout != nil {
+				c.converter = channelconverter.CreateLayoutChannelConverter(c.layout(channels), c.layout(c.channels))
+			} else {
+				c.converter = channelconverter.CreateChannelConverter(channels, c.channels)
+			}
+		}
+	}
+
+	if rate != c.inputRate {
+		// keep the samples of the previous format which are still buffered inside the resampler
+		if err := c.drainResampler(); err != nil {
+			return err
+		}
+		c.inputRate = rate
+		if c.resampler != nil {
+			c.resampler.Destroy()
+			c.resampler = nil
+		}
+		if c.inputRate != c.rate {
+			c.resampler = samplerate.CreateResampler(samplerate.ConverterTypeSincBestQuality, c.channels)
+		}
+	}
+	return nil
+}
+
+// drainResampler appends the samples still buffered inside the samplerate.Resampler to the pending PCM.
+func (c *Converter) drainResampler() error {
+	if c.resampler == nil {
+		return nil
+	}
+	c.resampleBuff = grow(c.resampleBuff, len(c.frame))
+	for {
+		var inputFrames, outputFrames int64
+		if err := c.resampler.Process(nil, c.resampleBuff, c.inputRate, c.rate, 1, &inputFrames, &outputFrames); err != nil {
+			return err
+		}
+		if outputFrames == 0 {
+			return nil
+		}
+		c.pending = append(c.pending, c.resampleBuff[:outputFrames*int64(c.channels)]...)
+	}
+}
+
+// Close destroys the samplerate.Resampler.
+func (c *Converter) Close() {
+	if c.resampler != nil {
+		c.resampler.Destroy()
+		c.resampler = nil
+	}
+}
+
+// SamplesToDuration returns the duration of the given samples per channel at the given rate.
+func SamplesToDuration(samples int64, rate int) time.Duration {
+	return time.Duration(samples * int64(time.Second) / int64(rate))
+}
+
+func grow(buff []int16, size int) []int16 {
+	if cap(buff) < size {
+		return make([]int16, size)
+	}
+	return buff[:size]
+}
@@ -0,0 +1,129 @@
+package pcmconv
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestConverterFlushDrainsAllPending(t *testing.T) {
+	c := NewConverter(48000, 2, nil)
+	frameSize := len(c.frame)
+
+	// two and a half frames arrive together with the end of the stream
+	pcm := make([]int16, frameSize*5/2)
+	for i := range pcm {
+		pcm[i] = int16(i%1000 + 1)
+	}
+	if err := c.Convert(pcm, 48000, 2); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	var got []int16
+	for {
+		frame, err := c.Flush()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+		if len(frame) != frameSize {
+			t.Fatalf("frame has %d samples, want %d", len(frame), frameSize)
+		}
+		got = append(got, frame...)
+	}
+
+	if len(got) != frameSize*3 {
+		t.Fatalf("flushed %d samples, want %d", len(got), frameSize*3)
+	}
+	for i, sample := range pcm {
+		if got[i] != sample {
+			t.Fatalf("sample %d is %d, want %d", i, got[i], sample)
+		}
+	}
+	for i := len(pcm); i < len(got); i++ {
+		if got[i] != 0 {
+			t.Fatalf("padding sample %d is %d, want 0", i, got[i])
+		}
+	}
+}
+
+func TestConverterNext(t *testing.T) {
+	c := NewConverter(48000, 2, nil)
+	frameSize := len(c.frame)
+
+	if c.Ready() {
+		t.Fatal("empty converter is ready")
+	}
+	if err := c.Convert(make([]int16, frameSize+2), 48000, 2); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if !c.Ready() {
+		t.Fatal("converter with a full frame is not ready")
+	}
+	if frame := c.Next(); len(frame) != frameSize {
+		t.Fatalf("frame has %d samples, want %d", len(frame), frameSize)
+	}
+	if c.Ready() {
+		t.Fatal("converter is ready after taking the only frame")
+	}
+	if pending := c.Pending(); pending != time.Second/48000 {
+		t.Fatalf("pending is %s, want %s", pending, time.Second/48000)
+	}
+}
+
+func TestConverterConvertsChannels(t *testing.T) {
+	c := NewConverter(48000, 2, nil)
+	frameSize := len(c.frame)
+
+	mono := make([]int16, frameSize/2)
+	for i := range mono {
+		mono[i] = int16(i)
+	}
+	if err := c.Convert(mono, 48000, 1); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if !c.Ready() {
+		t.Fatal("mono frame didn't fill a stereo frame")
+	}
+	frame := c.Next()
+	for i, sample := range mono {
+		if frame[i*2] != sample || frame[i*2+1] != sample {
+			t.Fatalf("frame %d is %d/%d, want %d", i, frame[i*2], frame[i*2+1], sample)
+		}
+	}
+}
+
+func TestConverterConvertBytes(t *testing.T) {
+	c := NewConverter(48000, 2, nil)
+	if err := c.ConvertBytes([]byte{0x01, 0x00, 0xff, 0xff}, 48000, 2); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	frame, err := c.Flush()
+	if err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if frame[0] != 1 || frame[1] != -1 {
+		t.Fatalf("got %d/%d, want 1/-1", frame[0], frame[1])
+	}
+	if _, err = c.Flush(); err != io.EOF {
+		t.Fatalf("got %v after the last frame, want io.EOF", err)
+	}
+}
+
+func TestConverterResetAfterFlush(t *testing.T) {
+	c := NewConverter(48000, 2, nil)
+	if _, err := c.Flush(); err != io.EOF {
+		t.Fatalf("got %v from empty converter, want io.EOF", err)
+	}
+	if err := c.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := c.Convert(make([]int16, 4), 48000, 2); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if _, err := c.Flush(); err != nil {
+		t.Fatalf("flush after reset: %v", err)
+	}
+}
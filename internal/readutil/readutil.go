@@ -0,0 +1,18 @@
+// Package readutil contains helpers for the read callbacks passed to the C decoders, which can't handle io.Reader(s) returning no data and no error.
+package readutil
+
+import "io"
+
+// MaxConsecutiveEmptyReads is the number of reads returning no data and no error after which ReadSome gives up with io.ErrNoProgress.
+const MaxConsecutiveEmptyReads = 100
+
+// ReadSome reads at least one byte into p unless r returns an error. Repeated empty reads return io.ErrNoProgress.
+func ReadSome(r io.Reader, p []byte) (int, error) {
+	for i := 0; i < MaxConsecutiveEmptyReads; i++ {
+		n, err := r.Read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+	return 0, io.ErrNoProgress
+}
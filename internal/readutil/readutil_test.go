@@ -0,0 +1,46 @@
+package readutil
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// emptyReader returns the given number of empty reads before reading data.
+type emptyReader struct {
+	empty int
+	reads int
+}
+
+func (r *emptyReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads <= r.empty {
+		return 0, nil
+	}
+	return copy(p, "data"), nil
+}
+
+func TestReadSomeSkipsEmptyReads(t *testing.T) {
+	r := &emptyReader{empty: 3}
+	n, err := ReadSome(r, make([]byte, 8))
+	if n != 4 || err != nil {
+		t.Fatalf("got %d, %v, want 4, nil", n, err)
+	}
+}
+
+func TestReadSomeNoProgress(t *testing.T) {
+	r := &emptyReader{empty: MaxConsecutiveEmptyReads * 2}
+	n, err := ReadSome(r, make([]byte, 8))
+	if n != 0 || !errors.Is(err, io.ErrNoProgress) {
+		t.Fatalf("got %d, %v, want 0, %v", n, err, io.ErrNoProgress)
+	}
+	if r.reads != MaxConsecutiveEmptyReads {
+		t.Fatalf("read %d times, want %d", r.reads, MaxConsecutiveEmptyReads)
+	}
+}
+
+func TestReadSomeEOF(t *testing.T) {
+	if _, err := ReadSome(&io.LimitedReader{}, make([]byte, 8)); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewExpvarMetrics creates a new Metrics which publishes all values in an expvar.Map with the given name.
+// Counters are published as expvar.Float and Histograms as a summary of count, sum, min and max, keyed by the name followed by the labels, e.g. `packets_lost{user_id="123"}`.
+// Calling NewExpvarMetrics twice with the same name reuses the existing expvar.Map. The returned Metrics implement Remover.
+func NewExpvarMetrics(name string) Metrics {
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		m = expvar.NewMap(name)
+	}
+	return &expvarMetrics{m: m}
+}
+
+type expvarMetrics struct {
+	m  *expvar.Map
+	mu sync.Mutex
+}
+
+func (e *expvarMetrics) Counter(name string, labels ...Label) Counter {
+	key := expvarKey(name, labels)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if counter, ok := e.m.Get(key).(*expvar.Float); ok {
+		return counter
+	}
+	counter := new(expvar.Float)
+	e.m.Set(key, counter)
+	return counter
+}
+
+func (e *expvarMetrics) Histogram(name string, labels ...Label) Histogram {
+	key := expvarKey(name, labels)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if histogram, ok := e.m.Get(key).(*expvarHistogram); ok {
+		return histogram
+	}
+	histogram := &expvarHistogram{min: math.Inf(1), max: math.Inf(-1)}
+	e.m.Set(key, histogram)
+	return histogram
+}
+
+// Remove removes all values whose labels contain the given Label from the expvar.Map.
+func (e *expvarMetrics) Remove(label Label) {
+	pair := expvarLabel(label)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var keys []string
+	e.m.Do(func(kv expvar.KeyValue) {
+		start := strings.IndexByte(kv.Key, '{')
+		if start == -1 {
+			return
+		}
+		for _, p := range strings.Split(kv.Key[start+1:len(kv.Key)-1], ",") {
+			if p == pair {
+				keys = append(keys, kv.Key)
+				return
+			}
+		}
+	})
+	// the expvar.Map is locked during Do
+	for _, key := range keys {
+		e.m.Delete(key)
+	}
+}
+
+func expvarKey(name string, labels []Label) string {
+	if len(labels) == 0 {
+		return name
+	}
+	labels = append([]Label(nil), labels...)
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(expvarLabel(label))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func expvarLabel(label Label) string {
+	return label.Name + `="` + label.Value + `"`
+}
+
+type expvarHistogram struct {
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+	mu    sync.Mutex
+}
+
+func (h *expvarHistogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += value
+	h.min = math.Min(h.min, value)
+	h.max = math.Max(h.max, value)
+}
+
+func (h *expvarHistogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	summary := struct {
+		Count uint64  `json:"count"`
+		Sum   float64 `json:"sum"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+	}{Count: h.count, Sum: h.sum}
+	if h.count > 0 {
+		summary.Min = h.min
+		summary.Max = h.max
+	}
+	data, _ := json.Marshal(summary)
+	return string(data)
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpvarCounter(t *testing.T) {
+	m := NewExpvarMetrics("test_expvar_counter")
+	m.Counter(PacketsLost, Label{Name: LabelUserID, Value: "1"}).Add(2)
+	m.Counter(PacketsLost, Label{Name: LabelUserID, Value: "1"}).Add(3)
+	m.Counter(PacketsLost, Label{Name: LabelUserID, Value: "2"}).Add(1)
+
+	published := expvar.Get("test_expvar_counter").(*expvar.Map)
+	if got := published.Get(`packets_lost{user_id="1"}`).String(); got != "5" {
+		t.Fatalf("got %s for user 1, want 5", got)
+	}
+	if got := published.Get(`packets_lost{user_id="2"}`).String(); got != "1" {
+		t.Fatalf("got %s for user 2, want 1", got)
+	}
+}
+
+func TestExpvarKeySortsLabels(t *testing.T) {
+	got := expvarKey("frames", []Label{{Name: "b", Value: "2"}, {Name: "a", Value: "1"}})
+	if want := `frames{a="1",b="2"}`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got = expvarKey("frames", nil); got != "frames" {
+		t.Fatalf("got %s without labels, want frames", got)
+	}
+}
+
+func TestExpvarHistogram(t *testing.T) {
+	m := NewExpvarMetrics("test_expvar_histogram")
+	histogram := m.Histogram(EncodeSeconds)
+
+	var summary struct {
+		Count uint64  `json:"count"`
+		Sum   float64 `json:"sum"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+	}
+	if err := json.Unmarshal([]byte(histogram.(expvar.Var).String()), &summary); err != nil {
+		t.Fatalf("empty histogram is not valid json: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, value := range []float64{0.5, 0.25, 1} {
+		wg.Add(1)
+		go func(value float64) {
+			defer wg.Done()
+			m.Histogram(EncodeSeconds).Observe(value)
+		}(value)
+	}
+	wg.Wait()
+
+	if err := json.Unmarshal([]byte(histogram.(expvar.Var).String()), &summary); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if summary.Count != 3 || summary.Sum != 1.75 || summary.Min != 0.25 || summary.Max != 1 {
+		t.Fatalf("got %+v, want count 3, sum 1.75, min 0.25 and max 1", summary)
+	}
+}
+
+func TestExpvarMetricsReusesMap(t *testing.T) {
+	NewExpvarMetrics("test_expvar_reuse").Counter(FramesEncoded).Add(1)
+	NewExpvarMetrics("test_expvar_reuse").Counter(FramesEncoded).Add(1)
+	if got := expvar.Get("test_expvar_reuse").(*expvar.Map).Get(FramesEncoded).String(); got != "2" {
+		t.Fatalf("got %s, want 2", got)
+	}
+}
+
+type recordingHistogram struct {
+	values []float64
+}
+
+func (h *recordingHistogram) Observe(value float64) {
+	h.values = append(h.values, value)
+}
+
+func TestObserveDuration(t *testing.T) {
+	histogram := &recordingHistogram{}
+	ObserveDuration(histogram, time.Now().Add(-time.Second))
+	if len(histogram.values) != 1 || histogram.values[0] < 1 {
+		t.Fatalf("got %v, want a single value of at least 1 second", histogram.values)
+	}
+}
+
+func TestOrNoop(t *testing.T) {
+	if OrNoop(nil) != Noop {
+		t.Fatal("nil Metrics not replaced by Noop")
+	}
+	m := NewExpvarMetrics("test_or_noop")
+	if OrNoop(m) != m {
+		t.Fatal("Metrics replaced by Noop")
+	}
+	// Noop accepts values without panicking
+	Noop.Counter(FramesEncoded).Add(1)
+	Noop.Histogram(EncodeSeconds).Observe(1)
+}
+
+func TestExpvarRemove(t *testing.T) {
+	m := NewExpvarMetrics("test_expvar_remove")
+	m.Counter(PacketsLost, Label{Name: LabelUserID, Value: "1"}).Add(1)
+	m.Counter(LatePackets, Label{Name: "ssrc", Value: "5"}, Label{Name: LabelUserID, Value: "1"}).Add(1)
+	m.Counter(PacketsLost, Label{Name: LabelUserID, Value: "10"}).Add(1)
+	m.Counter(FramesDecoded).Add(1)
+
+	Remove(m, Label{Name: LabelUserID, Value: "1"})
+
+	published := expvar.Get("test_expvar_remove").(*expvar.Map)
+	for key, want := range map[string]bool{
+		`packets_lost{user_id="1"}`:          false,
+		`late_packets{ssrc="5",user_id="1"}`: false,
+		`packets_lost{user_id="10"}`:         true,
+		FramesDecoded:                        true,
+	} {
+		if got := published.Get(key) != nil; got != want {
+			t.Fatalf("got published %t for %s, want %t", got, key, want)
+		}
+	}
+
+	// removing from Metrics without Remover does nothing
+	Remove(Noop, Label{Name: LabelUserID, Value: "1"})
+}
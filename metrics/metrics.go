@@ -0,0 +1,117 @@
+// Package metrics defines a small interface to record counters and histograms of the audio pipeline without depending on a specific metrics library.
+// Implement Metrics to forward the values to Prometheus, OpenTelemetry or similar, or use NewExpvarMetrics.
+package metrics
+
+import (
+	"time"
+)
+
+// Names of the metrics recorded by this module.
+const (
+	// FramesEncoded counts the Opus frames encoded.
+	FramesEncoded = "frames_encoded"
+	// EncodeSeconds observes the time it took to encode an Opus frame.
+	EncodeSeconds = "encode_seconds"
+	// EncodeErrors counts failed encodes.
+	EncodeErrors = "encode_errors"
+
+	// FramesDecoded counts the Opus frames decoded.
+	FramesDecoded = "frames_decoded"
+	// DecodeSeconds observes the time it took to decode an Opus frame.
+	DecodeSeconds = "decode_seconds"
+	// DecodeErrors counts invalid packets and failed decodes.
+	DecodeErrors = "decode_errors"
+
+	// PacketsReceived counts the packets received per user.
+	PacketsReceived = "packets_received"
+	// PacketsLost counts the packets missing in the sequence per user.
+	PacketsLost = "packets_lost"
+	// LatePackets counts the packets which arrived out of order per user.
+	LatePackets = "late_packets"
+	// DroppedPackets counts the packets dropped by the combiner because they were too old.
+	DroppedPackets = "dropped_packets"
+
+	// FramesCombined counts the frames mixed by the combiner.
+	FramesCombined = "frames_combined"
+	// CombineSeconds observes the time it took to mix a frame.
+	CombineSeconds = "combine_seconds"
+
+	// Underruns counts how often a Player ran out of buffered audio.
+	Underruns = "underruns"
+	// PlayerErrors counts the errors returned while providing Opus frames.
+	PlayerErrors = "player_errors"
+)
+
+// LabelUserID is the Label name of the user a metric belongs to.
+// The metrics of a user are removed from Metrics implementing Remover once the user is cleaned up.
+const LabelUserID = "user_id"
+
+// Label is a name value pair attached to a metric.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metrics creates the Counter(s) and Histogram(s) recorded by this module.
+// Implementations must be safe for concurrent use and should return the same Counter or Histogram for the same name and labels.
+type Metrics interface {
+	// Counter returns the Counter with the given name and labels.
+	Counter(name string, labels ...Label) Counter
+
+	// Histogram returns the Histogram with the given name and labels.
+	Histogram(name string, labels ...Label) Histogram
+}
+
+// Remover is a Metrics which can remove the Counter(s) and Histogram(s) of a Label, like the metrics of a user which disconnected.
+// Metrics with per user labels should implement it to keep the number of metrics from growing with every user.
+type Remover interface {
+	Metrics
+
+	// Remove removes all Counter(s) and Histogram(s) with the given Label.
+	Remove(label Label)
+}
+
+// Remove removes all Counter(s) and Histogram(s) with the given Label if the Metrics implement Remover.
+func Remove(metrics Metrics, label Label) {
+	if remover, ok := metrics.(Remover); ok {
+		remover.Remove(label)
+	}
+}
+
+// Counter is a value which only increases.
+type Counter interface {
+	// Add increases the Counter by the given delta.
+	Add(delta float64)
+}
+
+// Histogram records a distribution of values.
+type Histogram interface {
+	// Observe records the given value.
+	Observe(value float64)
+}
+
+// ObserveDuration records the time since start in seconds.
+func ObserveDuration(histogram Histogram, start time.Time) {
+	histogram.Observe(time.Since(start).Seconds())
+}
+
+// Noop is a Metrics which discards all values.
+var Noop Metrics = noopMetrics{}
+
+// OrNoop returns the given Metrics or Noop if it is nil.
+func OrNoop(metrics Metrics) Metrics {
+	if metrics == nil {
+		return Noop
+	}
+	return metrics
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) Counter(string, ...Label) Counter     { return noop{} }
+func (noopMetrics) Histogram(string, ...Label) Histogram { return noop{} }
+
+type noop struct{}
+
+func (noop) Add(float64)     {}
+func (noop) Observe(float64) {}
@@ -3,11 +3,26 @@ package mp3
 /*
 #cgo pkg-config: libmpg123
 #include <stdlib.h>
+#include <stdint.h>
 #include <mpg123.h>
+
+extern ssize_t goMP3Read(void *handle, void *buf, size_t size);
+extern off_t goMP3Seek(void *handle, off_t offset, int whence);
+
+static int mpg123_open_go_reader(mpg123_handle *mh, uintptr_t handle) {
+	int err = mpg123_replace_reader_handle(mh, goMP3Read, goMP3Seek, NULL);
+	if (err != MPG123_OK) {
+		return err;
+	}
+	return mpg123_open_handle(mh, (void *)handle);
+}
 */
 import "C"
 import (
 	"io"
+	"runtime/cgo"
+	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -27,6 +42,7 @@ func CreateDecoder() (*Decoder, error) {
 
 type Decoder struct {
 	handle *C.mpg123_handle
+	reader cgo.Handle
 }
 
 func (d *Decoder) FormatNone() {
@@ -71,6 +87,80 @@ func (d *Decoder) OpenFeed() error {
 	return nil
 }
 
+// OpenReader opens the given io.ReadSeeker using mpg123's reader replacement API.
+// Unlike OpenFeed this allows seeking and knowing the length of the stream. If the io.ReadSeeker can't seek, mpg123 treats it as a stream.
+func (d *Decoder) OpenReader(r io.ReadSeeker) error {
+	d.reader = cgo.NewHandle(r)
+	if err := C.mpg123_open_go_reader(d.handle, C.uintptr_t(d.reader)); err != C.MPG123_OK {
+		d.reader.Delete()
+		d.reader = 0
+		return Error(err)
+	}
+	return nil
+}
+
+// Seek seeks to the given sample offset per channel and returns the new offset. whence is one of io.SeekStart, io.SeekCurrent or io.SeekEnd.
+func (d *Decoder) Seek(sampleOffset int64, whence int) (int64, error) {
+	offset := C.mpg123_seek(d.handle, C.off_t(sampleOffset), C.int(whence))
+	if offset < 0 {
+		return 0, Error(offset)
+	}
+	return int64(offset), nil
+}
+
+// Tell returns the current sample offset per channel.
+func (d *Decoder) Tell() int64 {
+	return int64(C.mpg123_tell(d.handle))
+}
+
+// SeekFrame seeks to the given mpeg frame and returns the new frame offset.
+func (d *Decoder) SeekFrame(frameOffset int64, whence int) (int64, error) {
+	offset := C.mpg123_seek_frame(d.handle, C.off_t(frameOffset), C.int(whence))
+	if offset < 0 {
+		return 0, Error(offset)
+	}
+	return int64(offset), nil
+}
+
+// TellFrame returns the current mpeg frame offset.
+func (d *Decoder) TellFrame() int64 {
+	return int64(C.mpg123_tellframe(d.handle))
+}
+
+// TimeFrame returns the mpeg frame offset corresponding to the given time.
+func (d *Decoder) TimeFrame(duration time.Duration) (int64, error) {
+	frame := C.mpg123_timeframe(d.handle, C.double(duration.Seconds()))
+	if frame < 0 {
+		return 0, Error(frame)
+	}
+	return int64(frame), nil
+}
+
+// Index returns mpg123's frame index. offsets[i] is the byte offset of frame i*step.
+func (d *Decoder) Index() ([]int64, int64, error) {
+	var (
+		offsets *C.off_t
+		step    C.off_t
+		fill    C.size_t
+	)
+	if err := C.mpg123_index(d.handle, &offsets, &step, &fill); err != C.MPG123_OK {
+		return nil, 0, Error(err)
+	}
+	index := make([]int64, int(fill))
+	for i, offset := range unsafe.Slice(offsets, int(fill)) {
+		index[i] = int64(offset)
+	}
+	return index, int64(step), nil
+}
+
+// Scan scans the whole stream to build an accurate frame index and length. The stream has to be seekable.
+func (d *Decoder) Scan() error {
+	if err := C.mpg123_scan(d.handle); err != C.MPG123_OK {
+		return Error(err)
+	}
+	return nil
+}
+
 func (d *Decoder) Write(p []byte) (int, error) {
 	if err := C.mpg123_feed(d.handle, (*C.uchar)(unsafe.Pointer(&p[0])), C.size_t(len(p))); err != C.MPG123_OK {
 		return 0, Error(err)
@@ -78,26 +168,126 @@ func (d *Decoder) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+// Read reads decoded PCM data into p.
+// It returns NeedMore if a feed opened with OpenFeed needs more data and NewFormat if the output format changed, which can be read with GetFormat.
+// Both can be returned together with n > 0.
 func (d *Decoder) Read(p []byte) (int, error) {
 	var done C.size_t
 	err := C.mpg123_read(d.handle, (unsafe.Pointer)(&p[0]), C.size_t(cap(p)), &done)
 	if err == C.MPG123_DONE {
 		return int(done), io.EOF
 	}
-	if err == C.MPG123_NEW_FORMAT {
-		return d.Read(p)
-	}
-	if err == C.MPG123_NEED_MORE {
-		return 0, io.EOF
-	}
 	if err != C.MPG123_OK {
-		return 0, Error(err)
+		return int(done), Error(err)
 	}
 	return int(done), nil
 }
 
+// MetaCheck returns which new metadata is available. See MetaID3 and MetaNewID3.
+func (d *Decoder) MetaCheck() MetaFlags {
+	return MetaFlags(C.mpg123_meta_check(d.handle))
+}
+
+// Length returns the total length of the stream in samples per channel or an error if it is unknown.
+// mpg123 knows the length if the stream has a Xing/Info/VBRI header or the stream size is known.
+func (d *Decoder) Length() (int64, error) {
+	length := C.mpg123_length(d.handle)
+	if length < 0 {
+		return 0, Error(C.MPG123_ERR)
+	}
+	return int64(length), nil
+}
+
+// Metadata returns the ID3 tags parsed by mpg123 so far together with the duration.
+// To include embedded pictures ParamFlagPicture has to be added to the Flags before feeding the stream.
+func (d *Decoder) Metadata() (*Metadata, error) {
+	var (
+		v1 *C.mpg123_id3v1
+		v2 *C.mpg123_id3v2
+	)
+	if err := C.mpg123_id3(d.handle, &v1, &v2); err != C.MPG123_OK {
+		return nil, Error(err)
+	}
+
+	metadata := &Metadata{}
+	if v2 != nil {
+		metadata.Title = mpg123String(v2.title)
+		metadata.Artist = mpg123String(v2.artist)
+		metadata.Album = mpg123String(v2.album)
+		metadata.Year = mpg123String(v2.year)
+		metadata.Genre = parseID3Genre(mpg123String(v2.genre))
+		metadata.Comment = mpg123String(v2.comment)
+
+		for _, text := range unsafe.Slice(v2.text, v2.texts) {
+			switch C.GoStringN(&text.id[0], 4) {
+			case "TRCK":
+				metadata.Track, metadata.TrackTotal = parseTrack(mpg123String(&text.text))
+			case "TPE2":
+				metadata.AlbumArtist = mpg123String(&text.text)
+			}
+		}
+		for _, extra := range unsafe.Slice(v2.extra, v2.extras) {
+			parseReplayGain(&metadata.ReplayGain, mpg123String(&extra.description), mpg123String(&extra.text))
+		}
+		for _, picture := range unsafe.Slice(v2.picture, v2.pictures) {
+			metadata.Pictures = append(metadata.Pictures, Picture{
+				Type:        PictureType(picture._type),
+				MIMEType:    mpg123String(&picture.mime_type),
+				Description: mpg123String(&picture.description),
+				Data:        C.GoBytes(unsafe.Pointer(picture.data), C.int(picture.size)),
+			})
+		}
+	}
+	if v1 != nil {
+		id3v1, _ := ParseID3v1(C.GoBytes(unsafe.Pointer(v1), id3v1Size))
+		metadata.merge(id3v1)
+	}
+
+	if length, err := d.Length(); err == nil {
+		var info C.struct_mpg123_frameinfo
+		if C.mpg123_info(d.handle, &info) == C.MPG123_OK && info.rate > 0 {
+			metadata.Duration = time.Duration(length * int64(time.Second) / int64(info.rate))
+		}
+	}
+	return metadata, nil
+}
+
+// RawID3 returns the raw ID3v1 and ID3v2 tags. ParamFlagStoreRawID3 has to be added to the Flags before feeding the stream.
+// The returned tags can be parsed with ParseID3v1 and ParseID3v2.
+func (d *Decoder) RawID3() ([]byte, []byte, error) {
+	var (
+		v1     *C.uchar
+		v1Size C.size_t
+		v2     *C.uchar
+		v2Size C.size_t
+	)
+	if err := C.mpg123_id3_raw(d.handle, &v1, &v1Size, &v2, &v2Size); err != C.MPG123_OK {
+		return nil, nil, Error(err)
+	}
+	var id3v1, id3v2 []byte
+	if v1 != nil {
+		id3v1 = C.GoBytes(unsafe.Pointer(v1), C.int(v1Size))
+	}
+	if v2 != nil {
+		id3v2 = C.GoBytes(unsafe.Pointer(v2), C.int(v2Size))
+	}
+	return id3v1, id3v2, nil
+}
+
+func mpg123String(s *C.mpg123_string) string {
+	if s == nil || s.p == nil || s.fill == 0 {
+		return ""
+	}
+	return strings.TrimRight(C.GoStringN(s.p, C.int(s.fill)), "\x00")
+}
+
 func (d *Decoder) Close() error {
-	if err := C.mpg123_close(d.handle); err != C.MPG123_OK {
+	err := C.mpg123_close(d.handle)
+	if d.reader != 0 {
+		d.reader.Delete()
+		d.reader = 0
+	}
+	if err != C.MPG123_OK {
 		return Error(err)
 	}
 	return nil
@@ -0,0 +1,263 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	// ErrInvalidFrameHeader is returned when the data does not start with a valid mpeg audio frame header.
+	ErrInvalidFrameHeader = errors.New("invalid mpeg audio frame header")
+
+	// ErrNoInfoHeader is returned when a frame contains no Xing/Info/VBRI header.
+	ErrNoInfoHeader = errors.New("no xing/info/vbri header found")
+)
+
+// MPEGVersion is the version of an mpeg audio frame.
+type MPEGVersion int
+
+const (
+	MPEGVersion2_5 MPEGVersion = iota
+	mpegVersionReserved
+	MPEGVersion2
+	MPEGVersion1
+)
+
+var (
+	sampleRates = [4][3]int{
+		MPEGVersion2_5: {11025, 12000, 8000},
+		MPEGVersion2:   {22050, 24000, 16000},
+		MPEGVersion1:   {44100, 48000, 32000},
+	}
+
+	// bitrates in kbps indexed by [mpeg1][layer-1][index]
+	bitrates = [2][3][15]int{
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		},
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		},
+	}
+)
+
+// FrameHeader is the 4 byte header of an mpeg audio frame.
+type FrameHeader struct {
+	Version MPEGVersion
+	Layer   int
+	// Bitrate is the bitrate in kbps or 0 for free format streams.
+	Bitrate         int
+	SampleRate      int
+	Padding         bool
+	Channels        int
+	SamplesPerFrame int
+	// FrameSize is the size of the frame in bytes including the header or 0 for free format streams.
+	FrameSize int
+}
+
+// ParseFrameHeader parses the mpeg audio frame header at the start of data.
+func ParseFrameHeader(data []byte) (FrameHeader, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1]&0xe0 != 0xe0 {
+		return FrameHeader{}, ErrInvalidFrameHeader
+	}
+	version := MPEGVersion(data[1] >> 3 & 0x03)
+	layer := 4 - int(data[1]>>1&0x03)
+	bitrateIndex := int(data[2] >> 4)
+	sampleRateIndex := int(data[2] >> 2 & 0x03)
+	if version == mpegVersionReserved || layer == 4 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return FrameHeader{}, ErrInvalidFrameHeader
+	}
+
+	header := FrameHeader{
+		Version:    version,
+		Layer:      layer,
+		SampleRate: sampleRates[version][sampleRateIndex],
+		Padding:    data[2]&0x02 != 0,
+		Channels:   2,
+	}
+	if data[3]>>6 == 3 {
+		header.Channels = 1
+	}
+	mpeg1 := 0
+	if version == MPEGVersion1 {
+		mpeg1 = 1
+	}
+	header.Bitrate = bitrates[mpeg1][layer-1][bitrateIndex]
+
+	var padding int
+	if header.Padding {
+		padding = 1
+	}
+	switch {
+	case layer == 1:
+		header.SamplesPerFrame = 384
+		header.FrameSize = (12*header.Bitrate*1000/header.SampleRate + padding) * 4
+	case layer == 3 && version != MPEGVersion1:
+		header.SamplesPerFrame = 576
+		header.FrameSize = 72*header.Bitrate*1000/header.SampleRate + padding
+	default:
+		header.SamplesPerFrame = 1152
+		header.FrameSize = 144*header.Bitrate*1000/header.SampleRate + padding
+	}
+	return header, nil
+}
+
+// InfoHeader is the Xing/Info or VBRI header stored in the first frame of a mp3 file.
+// It optionally contains a LAME extension with the encoder delay, padding and ReplayGain.
+type InfoHeader struct {
+	// ID is either "Xing", "Info" or "VBRI".
+	ID     string
+	Header FrameHeader
+	// Frames is the number of audio frames or 0 if unknown.
+	Frames int
+	// Bytes is the size of the audio data in bytes or 0 if unknown.
+	Bytes int
+	// TOC is the seek table. For Xing headers it contains 100 entries scaled to 256.
+	TOC []int
+
+	// Encoder is the encoder version string of the LAME extension, e.g. "LAME3.100".
+	Encoder        string
+	EncoderDelay   int
+	EncoderPadding int
+	ReplayGain     ReplayGain
+}
+
+// Samples returns the number of decoded samples per channel without encoder delay and padding.
+func (h InfoHeader) Samples() int64 {
+	samples := int64(h.Frames)*int64(h.Header.SamplesPerFrame) - int64(h.EncoderDelay) - int64(h.EncoderPadding)
+	if samples < 0 {
+		return 0
+	}
+	return samples
+}
+
+// Duration returns the exact playback duration or 0 if the number of frames is unknown.
+func (h InfoHeader) Duration() time.Duration {
+	if h.Frames == 0 {
+		return 0
+	}
+	return time.Duration(h.Samples() * int64(time.Second) / int64(h.Header.SampleRate))
+}
+
+// ParseInfoHeader parses the Xing/Info or VBRI header of the frame at the start of data.
+func ParseInfoHeader(frame []byte) (*InfoHeader, error) {
+	header, err := ParseFrameHeader(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	// the Xing header is located after the side information
+	var sideInfo int
+	switch {
+	case header.Version == MPEGVersion1 && header.Channels == 1:
+		sideInfo = 17
+	case header.Version == MPEGVersion1:
+		sideInfo = 32
+	case header.Channels == 1:
+		sideInfo = 9
+	default:
+		sideInfo = 17
+	}
+	if offset := 4 + sideInfo; len(frame) >= offset+8 {
+		if id := string(frame[offset : offset+4]); id == "Xing" || id == "Info" {
+			return parseXingHeader(header, id, frame[offset+4:])
+		}
+	}
+	if len(frame) >= 36+26 && bytes.Equal(frame[36:40], []byte("VBRI")) {
+		return parseVBRIHeader(header, frame[40:])
+	}
+	return nil, ErrNoInfoHeader
+}
+
+func parseXingHeader(header FrameHeader, id string, data []byte) (*InfoHeader, error) {
+	info := &InfoHeader{
+		ID:     id,
+		Header: header,
+	}
+	flags := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if flags&0x1 != 0 && len(data) >= 4 {
+		info.Frames = int(binary.BigEndian.Uint32(data))
+		data = data[4:]
+	}
+	if flags&0x2 != 0 && len(data) >= 4 {
+		info.Bytes = int(binary.BigEndian.Uint32(data))
+		data = data[4:]
+	}
+	if flags&0x4 != 0 && len(data) >= 100 {
+		info.TOC = make([]int, 100)
+		for i := range info.TOC {
+			info.TOC[i] = int(data[i])
+		}
+		data = data[100:]
+	}
+	if flags&0x8 != 0 && len(data) >= 4 {
+		data = data[4:]
+	}
+
+	// LAME extension
+	if len(data) < 36 {
+		return info, nil
+	}
+	info.Encoder = string(bytes.TrimRight(data[:9], "\x00 "))
+	if !bytes.HasPrefix(data, []byte("LAME")) && !bytes.HasPrefix(data, []byte("Lavc")) && !bytes.HasPrefix(data, []byte("Lavf")) {
+		return info, nil
+	}
+	if peak := binary.BigEndian.Uint32(data[11:15]); peak != 0 {
+		info.ReplayGain.TrackPeak = float64(peak) / float64(1<<23)
+	}
+	for _, field := range [2]uint16{binary.BigEndian.Uint16(data[15:17]), binary.BigEndian.Uint16(data[17:19])} {
+		name := field >> 13
+		gain := float64(field&0x1ff) / 10
+		if field&0x200 != 0 {
+			gain = -gain
+		}
+		switch name {
+		case 1:
+			info.ReplayGain.TrackGain, info.ReplayGain.HasTrack = gain, true
+		case 2:
+			info.ReplayGain.AlbumGain, info.ReplayGain.HasAlbum = gain, true
+		}
+	}
+	info.EncoderDelay = int(data[21])<<4 | int(data[22])>>4
+	info.EncoderPadding = int(data[22]&0x0f)<<8 | int(data[23])
+	return info, nil
+}
+
+func parseVBRIHeader(header FrameHeader, data []byte) (*InfoHeader, error) {
+	info := &InfoHeader{
+		ID:           "VBRI",
+		Header:       header,
+		EncoderDelay: int(binary.BigEndian.Uint16(data[2:4])),
+		Bytes:        int(binary.BigEndian.Uint32(data[6:10])),
+		Frames:       int(binary.BigEndian.Uint32(data[10:14])),
+	}
+	entries := int(binary.BigEndian.Uint16(data[14:16]))
+	scale := int(binary.BigEndian.Uint16(data[16:18]))
+	entrySize := int(binary.BigEndian.Uint16(data[18:20]))
+	data = data[22:]
+	if entrySize < 1 || entrySize > 4 || len(data) < entries*entrySize {
+		return info, nil
+	}
+	info.TOC = make([]int, entries)
+	for i := range info.TOC {
+		var v int
+		for _, b := range data[i*entrySize : (i+1)*entrySize] {
+			v = v<<8 | int(b)
+		}
+		info.TOC[i] = v * scale
+	}
+	return info, nil
+}
+
+// GainFactor converts a ReplayGain value in dB into a linear volume factor.
+func GainFactor(gain float64) float32 {
+	return float32(math.Pow(10, gain/20))
+}
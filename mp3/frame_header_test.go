@@ -0,0 +1,127 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// testFrameHeader is a MPEG1 layer 3 frame header with 128 kbps, 44100 Hz and stereo.
+var testFrameHeader = []byte{0xff, 0xfb, 0x90, 0x00}
+
+// testXingFrame builds a frame with a Xing header of the given number of frames and a LAME extension.
+func testXingFrame(frames int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, testFrameHeader)
+	xing := frame[36:]
+	copy(xing, "Xing")
+	binary.BigEndian.PutUint32(xing[4:], 0x1|0x8)
+	binary.BigEndian.PutUint32(xing[8:], uint32(frames))
+
+	lame := xing[16:]
+	copy(lame, "LAME3.100")
+	binary.BigEndian.PutUint32(lame[11:], 1<<22)
+	// track gain of -6.5 dB
+	binary.BigEndian.PutUint16(lame[15:], 1<<13|0x200|65)
+	// 576 samples delay and 1000 samples padding
+	lame[21], lame[22], lame[23] = 576>>4, 1000>>8, 1000&0xff
+	return frame
+}
+
+func TestParseFrameHeader(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		data   []byte
+		header FrameHeader
+		err    error
+	}{
+		{
+			name:   "mpeg1 layer 3",
+			data:   testFrameHeader,
+			header: FrameHeader{Version: MPEGVersion1, Layer: 3, Bitrate: 128, SampleRate: 44100, Channels: 2, SamplesPerFrame: 1152, FrameSize: 417},
+		},
+		{
+			name:   "padded mono",
+			data:   []byte{0xff, 0xfb, 0x92, 0xc0},
+			header: FrameHeader{Version: MPEGVersion1, Layer: 3, Bitrate: 128, SampleRate: 44100, Padding: true, Channels: 1, SamplesPerFrame: 1152, FrameSize: 418},
+		},
+		{
+			name:   "mpeg2 layer 3",
+			data:   []byte{0xff, 0xf3, 0x84, 0x00},
+			header: FrameHeader{Version: MPEGVersion2, Layer: 3, Bitrate: 64, SampleRate: 24000, Channels: 2, SamplesPerFrame: 576, FrameSize: 192},
+		},
+		{name: "no sync", data: []byte{0xff, 0x1b, 0x90, 0x00}, err: ErrInvalidFrameHeader},
+		{name: "reserved version", data: []byte{0xff, 0xeb, 0x90, 0x00}, err: ErrInvalidFrameHeader},
+		{name: "invalid bitrate", data: []byte{0xff, 0xfb, 0xf0, 0x00}, err: ErrInvalidFrameHeader},
+		{name: "invalid sample rate", data: []byte{0xff, 0xfb, 0x9c, 0x00}, err: ErrInvalidFrameHeader},
+		{name: "truncated", data: []byte{0xff, 0xfb}, err: ErrInvalidFrameHeader},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := ParseFrameHeader(tt.data)
+			if err != tt.err {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if header != tt.header {
+				t.Fatalf("got %+v, want %+v", header, tt.header)
+			}
+		})
+	}
+}
+
+func TestParseInfoHeaderXing(t *testing.T) {
+	info, err := ParseInfoHeader(testXingFrame(100))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if info.ID != "Xing" || info.Frames != 100 || info.Encoder != "LAME3.100" {
+		t.Fatalf("got id %q, %d frames, encoder %q, want Xing, 100 frames, LAME3.100", info.ID, info.Frames, info.Encoder)
+	}
+	if info.EncoderDelay != 576 || info.EncoderPadding != 1000 {
+		t.Fatalf("got delay %d and padding %d, want 576 and 1000", info.EncoderDelay, info.EncoderPadding)
+	}
+	if gain := info.ReplayGain; !gain.HasTrack || gain.TrackGain != -6.5 || gain.TrackPeak != 0.5 || gain.HasAlbum {
+		t.Fatalf("got replay gain %+v, want a track gain of -6.5 dB with a peak of 0.5", gain)
+	}
+	if got, want := info.Samples(), int64(100*1152-576-1000); got != want {
+		t.Fatalf("got %d samples, want %d", got, want)
+	}
+	if got, want := info.Duration(), time.Duration((100*1152-576-1000)*int64(time.Second)/44100); got != want {
+		t.Fatalf("got duration %s, want %s", got, want)
+	}
+}
+
+func TestParseInfoHeaderVBRI(t *testing.T) {
+	frame := make([]byte, 417)
+	copy(frame, testFrameHeader)
+	vbri := frame[36:]
+	copy(vbri, "VBRI")
+	data := vbri[4:]
+	binary.BigEndian.PutUint16(data[2:], 576)
+	binary.BigEndian.PutUint32(data[6:], 41700)
+	binary.BigEndian.PutUint32(data[10:], 100)
+	// 2 entries of 2 bytes scaled by 10
+	binary.BigEndian.PutUint16(data[14:], 2)
+	binary.BigEndian.PutUint16(data[16:], 10)
+	binary.BigEndian.PutUint16(data[18:], 2)
+	binary.BigEndian.PutUint16(data[22:], 100)
+	binary.BigEndian.PutUint16(data[24:], 200)
+
+	info, err := ParseInfoHeader(frame)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if info.ID != "VBRI" || info.Frames != 100 || info.Bytes != 41700 || info.EncoderDelay != 576 {
+		t.Fatalf("got %+v, want a VBRI header with 100 frames, 41700 bytes and 576 samples delay", *info)
+	}
+	if len(info.TOC) != 2 || info.TOC[0] != 1000 || info.TOC[1] != 2000 {
+		t.Fatalf("got toc %v, want [1000 2000]", info.TOC)
+	}
+}
+
+func TestParseInfoHeaderMissing(t *testing.T) {
+	frame := make([]byte, 417)
+	copy(frame, testFrameHeader)
+	if _, err := ParseInfoHeader(frame); err != ErrNoInfoHeader {
+		t.Fatalf("got %v, want %v", err, ErrNoInfoHeader)
+	}
+}
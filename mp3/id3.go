@@ -0,0 +1,394 @@
+package mp3
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	id3v1Size       = 128
+	id3v2HeaderSize = 10
+)
+
+var (
+	// ErrInvalidID3v1 is returned when the data is not a valid ID3v1 tag.
+	ErrInvalidID3v1 = errors.New("invalid id3v1 tag")
+
+	// ErrInvalidID3v2 is returned when the data is not a valid ID3v2 tag.
+	ErrInvalidID3v2 = errors.New("invalid id3v2 tag")
+)
+
+// ParseID3v1 parses a 128 byte ID3v1 or ID3v1.1 tag.
+func ParseID3v1(tag []byte) (*Metadata, error) {
+	if len(tag) != id3v1Size || !bytes.HasPrefix(tag, []byte("TAG")) {
+		return nil, ErrInvalidID3v1
+	}
+	metadata := &Metadata{
+		Title:   id3v1String(tag[3:33]),
+		Artist:  id3v1String(tag[33:63]),
+		Album:   id3v1String(tag[63:93]),
+		Year:    id3v1String(tag[93:97]),
+		Comment: id3v1String(tag[97:127]),
+		Genre:   genreName(int(tag[127])),
+	}
+	// ID3v1.1 stores the track number in the last byte of the comment
+	if tag[125] == 0 && tag[126] != 0 {
+		metadata.Comment = id3v1String(tag[97:125])
+		metadata.Track = int(tag[126])
+	}
+	return metadata, nil
+}
+
+func id3v1String(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i != -1 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(decodeLatin1(b))
+}
+
+// ParseID3v2 parses a complete ID3v2.2, ID3v2.3 or ID3v2.4 tag including its 10 byte header.
+func ParseID3v2(tag []byte) (*Metadata, error) {
+	size, err := id3v2TagSize(tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(tag) < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	version := tag[3]
+	flags := tag[5]
+	data := tag[id3v2HeaderSize:size]
+	if flags&0x10 != 0 {
+		// strip footer
+		data = data[:len(data)-id3v2HeaderSize]
+	}
+	if version < 4 && flags&0x80 != 0 {
+		data = removeUnsynchronisation(data)
+	}
+	if flags&0x40 != 0 && version >= 3 {
+		// skip extended header
+		if len(data) < 4 {
+			return nil, ErrInvalidID3v2
+		}
+		extSize := int(binary.BigEndian.Uint32(data))
+		if version == 4 {
+			extSize = syncsafe(data)
+		} else {
+			extSize += 4
+		}
+		if extSize > len(data) {
+			return nil, ErrInvalidID3v2
+		}
+		data = data[extSize:]
+	}
+
+	metadata := &Metadata{}
+	for len(data) > 0 {
+		id, body, rest, ok := nextID3v2Frame(data, version)
+		if !ok {
+			break
+		}
+		data = rest
+		parseID3v2Frame(metadata, id, body)
+	}
+	return metadata, nil
+}
+
+func id3v2TagSize(header []byte) (int, error) {
+	if len(header) < id3v2HeaderSize || !bytes.HasPrefix(header, []byte("ID3")) || header[3] < 2 || header[3] > 4 {
+		return 0, ErrInvalidID3v2
+	}
+	size := id3v2HeaderSize + syncsafe(header[6:10])
+	if header[5]&0x10 != 0 {
+		size += id3v2HeaderSize
+	}
+	return size, nil
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+func removeUnsynchronisation(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// nextID3v2Frame splits off the next frame. v2.2 frames are mapped to their v2.3 ids.
+func nextID3v2Frame(data []byte, version byte) (string, []byte, []byte, bool) {
+	if version == 2 {
+		if len(data) < 6 || data[0] == 0 {
+			return "", nil, nil, false
+		}
+		size := int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		if 6+size > len(data) {
+			return "", nil, nil, false
+		}
+		return id3v22FrameIDs[string(data[:3])], data[6 : 6+size], data[6+size:], true
+	}
+
+	if len(data) < 10 || data[0] == 0 {
+		return "", nil, nil, false
+	}
+	id := string(data[:4])
+	size := int(binary.BigEndian.Uint32(data[4:8]))
+	if version == 4 {
+		size = syncsafe(data[4:8])
+	}
+	if 10+size > len(data) {
+		return "", nil, nil, false
+	}
+	flags := binary.BigEndian.Uint16(data[8:10])
+	body := data[10 : 10+size]
+	rest := data[10+size:]
+
+	var (
+		compressed   bool
+		encrypted    bool
+		unsync       bool
+		headerLength int
+	)
+	if version == 3 {
+		compressed = flags&0x0080 != 0
+		encrypted = flags&0x0040 != 0
+		if compressed {
+			headerLength += 4
+		}
+		if encrypted {
+			headerLength++
+		}
+		if flags&0x0020 != 0 {
+			headerLength++
+		}
+	} else {
+		compressed = flags&0x0008 != 0
+		encrypted = flags&0x0004 != 0
+		unsync = flags&0x0002 != 0
+		if flags&0x0040 != 0 {
+			headerLength++
+		}
+		if encrypted {
+			headerLength++
+		}
+		if flags&0x0001 != 0 {
+			headerLength += 4
+		}
+	}
+	if encrypted || headerLength > len(body) {
+		return id, nil, rest, true
+	}
+	body = body[headerLength:]
+	if unsync {
+		body = removeUnsynchronisation(body)
+	}
+	if compressed {
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return id, nil, rest, true
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			return id, nil, rest, true
+		}
+	}
+	return id, body, rest, true
+}
+
+var id3v22FrameIDs = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TP2": "TPE2",
+	"TAL": "TALB",
+	"TYE": "TYER",
+	"TCO": "TCON",
+	"TRK": "TRCK",
+	"TLE": "TLEN",
+	"COM": "COMM",
+	"TXX": "TXXX",
+	"PIC": "PIC",
+}
+
+func parseID3v2Frame(metadata *Metadata, id string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	switch id {
+	case "TIT2":
+		metadata.Title = decodeID3Text(body)
+	case "TPE1":
+		metadata.Artist = decodeID3Text(body)
+	case "TPE2":
+		metadata.AlbumArtist = decodeID3Text(body)
+	case "TALB":
+		metadata.Album = decodeID3Text(body)
+	case "TYER", "TDRC":
+		if metadata.Year == "" || id == "TDRC" {
+			metadata.Year = decodeID3Text(body)
+		}
+	case "TCON":
+		metadata.Genre = parseID3Genre(decodeID3Text(body))
+	case "TRCK":
+		metadata.Track, metadata.TrackTotal = parseTrack(decodeID3Text(body))
+	case "TLEN":
+		if ms, err := strconv.Atoi(decodeID3Text(body)); err == nil {
+			metadata.Duration = time.Duration(ms) * time.Millisecond
+		}
+	case "COMM":
+		if len(body) < 4 || metadata.Comment != "" {
+			return
+		}
+		_, text := splitID3String(body[0], body[4:])
+		metadata.Comment = decodeID3String(body[0], text)
+	case "TXXX":
+		desc, value := splitID3String(body[0], body[1:])
+		parseReplayGain(&metadata.ReplayGain, decodeID3String(body[0], desc), decodeID3String(body[0], value))
+	case "APIC":
+		mimeType, rest, ok := bytes.Cut(body[1:], []byte{0})
+		if !ok || len(rest) < 1 {
+			return
+		}
+		desc, data := splitID3String(body[0], rest[1:])
+		metadata.Pictures = append(metadata.Pictures, Picture{
+			Type:        PictureType(rest[0]),
+			MIMEType:    decodeLatin1(mimeType),
+			Description: decodeID3String(body[0], desc),
+			Data:        data,
+		})
+	case "PIC":
+		if len(body) < 5 {
+			return
+		}
+		mimeType := "image/" + strings.ToLower(string(body[1:4]))
+		if mimeType == "image/jpg" {
+			mimeType = "image/jpeg"
+		}
+		desc, data := splitID3String(body[0], body[5:])
+		metadata.Pictures = append(metadata.Pictures, Picture{
+			Type:        PictureType(body[4]),
+			MIMEType:    mimeType,
+			Description: decodeID3String(body[0], desc),
+			Data:        data,
+		})
+	}
+}
+
+func parseTrack(s string) (int, int) {
+	track, total, _ := strings.Cut(s, "/")
+	trackNumber, _ := strconv.Atoi(strings.TrimSpace(track))
+	totalNumber, _ := strconv.Atoi(strings.TrimSpace(total))
+	return trackNumber, totalNumber
+}
+
+// parseID3Genre resolves genres like "(17)", "17" or "(17)Rock" to their name.
+func parseID3Genre(s string) string {
+	if strings.HasPrefix(s, "(") {
+		if end := strings.IndexByte(s, ')'); end != -1 {
+			if rest := s[end+1:]; rest != "" {
+				return rest
+			}
+			s = s[1:end]
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return genreName(n)
+	}
+	return s
+}
+
+func parseReplayGain(gain *ReplayGain, key string, value string) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "dB"))
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch strings.ToUpper(key) {
+	case "REPLAYGAIN_TRACK_GAIN":
+		gain.TrackGain, gain.HasTrack = f, true
+	case "REPLAYGAIN_TRACK_PEAK":
+		gain.TrackPeak = f
+	case "REPLAYGAIN_ALBUM_GAIN":
+		gain.AlbumGain, gain.HasAlbum = f, true
+	case "REPLAYGAIN_ALBUM_PEAK":
+		gain.AlbumPeak = f
+	}
+}
+
+// decodeID3Text decodes the body of a text information frame. Only the first value of multi value frames is returned.
+func decodeID3Text(body []byte) string {
+	text, _ := splitID3String(body[0], body[1:])
+	return strings.TrimSpace(decodeID3String(body[0], text))
+}
+
+// splitID3String splits a null terminated string in the given encoding from the following data.
+func splitID3String(encoding byte, data []byte) ([]byte, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+		return data, nil
+	}
+	if i := bytes.IndexByte(data, 0); i != -1 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+func decodeID3String(encoding byte, data []byte) string {
+	switch encoding {
+	case 0:
+		return decodeLatin1(data)
+	case 1, 2:
+		order := binary.ByteOrder(binary.BigEndian)
+		if len(data) >= 2 && encoding == 1 {
+			if data[0] == 0xff && data[1] == 0xfe {
+				order = binary.LittleEndian
+			}
+			if (data[0] == 0xff && data[1] == 0xfe) || (data[0] == 0xfe && data[1] == 0xff) {
+				data = data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[i*2:])
+		}
+		return string(utf16.Decode(units))
+	default:
+		return string(data)
+	}
+}
+
+func decodeLatin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func genreName(n int) string {
+	if n < 0 || n >= len(id3Genres) {
+		return ""
+	}
+	return id3Genres[n]
+}
+
+var id3Genres = [...]string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychadelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+}
@@ -0,0 +1,185 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+)
+
+func syncsafeBytes(size int) []byte {
+	return []byte{byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+}
+
+// id3v2Tag builds an ID3v2 tag of the given version with the given frames.
+func id3v2Tag(version byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	tag := append([]byte{'I', 'D', '3', version, 0, 0}, syncsafeBytes(len(body))...)
+	return append(tag, body...)
+}
+
+// id3v2Frame builds an ID3v2.3 frame or an ID3v2.4 frame if syncsafe is set.
+func id3v2Frame(id string, syncsafe bool, body ...[]byte) []byte {
+	data := bytes.Join(body, nil)
+	frame := make([]byte, 10, 10+len(data))
+	copy(frame, id)
+	if syncsafe {
+		copy(frame[4:], syncsafeBytes(len(data)))
+	} else {
+		binary.BigEndian.PutUint32(frame[4:], uint32(len(data)))
+	}
+	return append(frame, data...)
+}
+
+func utf16Text(s string) []byte {
+	data := []byte{1, 0xff, 0xfe}
+	for _, r := range s {
+		data = append(data, byte(r), 0)
+	}
+	return data
+}
+
+func TestParseID3v1(t *testing.T) {
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	copy(tag[3:], "Title")
+	copy(tag[33:], "Artist  ")
+	copy(tag[63:], "Album")
+	copy(tag[93:], "2024")
+	copy(tag[97:], "Comment")
+	tag[126] = 7
+	tag[127] = 17
+
+	metadata, err := ParseID3v1(tag)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := Metadata{Title: "Title", Artist: "Artist", Album: "Album", Year: "2024", Comment: "Comment", Genre: "Rock", Track: 7}
+	if metadata.Title != want.Title || metadata.Artist != want.Artist || metadata.Album != want.Album || metadata.Year != want.Year ||
+		metadata.Comment != want.Comment || metadata.Genre != want.Genre || metadata.Track != want.Track {
+		t.Fatalf("got %+v, want %+v", *metadata, want)
+	}
+
+	if _, err = ParseID3v1(tag[:100]); err != ErrInvalidID3v1 {
+		t.Fatalf("got %v for a short tag, want %v", err, ErrInvalidID3v1)
+	}
+}
+
+func TestParseID3v2(t *testing.T) {
+	cover := bytes.Repeat([]byte{0xab}, 200)
+	for _, tt := range []struct {
+		name    string
+		version byte
+	}{
+		{name: "v2.3", version: 3},
+		{name: "v2.4", version: 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			syncsafe := tt.version == 4
+			tag := id3v2Tag(tt.version,
+				id3v2Frame("TIT2", syncsafe, []byte{0}, []byte("Title\x00")),
+				id3v2Frame("TPE1", syncsafe, utf16Text("Artíst")),
+				id3v2Frame("TALB", syncsafe, []byte{3}, []byte("Albüm")),
+				id3v2Frame("TCON", syncsafe, []byte{0}, []byte("(17)")),
+				id3v2Frame("TRCK", syncsafe, []byte{0}, []byte("3/12")),
+				id3v2Frame("TLEN", syncsafe, []byte{0}, []byte("61000")),
+				id3v2Frame("COMM", syncsafe, []byte{0}, []byte("eng"), []byte("desc\x00Comment")),
+				id3v2Frame("TXXX", syncsafe, []byte{0}, []byte("REPLAYGAIN_TRACK_GAIN\x00-3.20 dB")),
+				id3v2Frame("APIC", syncsafe, []byte{0}, []byte("image/png\x00"), []byte{byte(PictureTypeFrontCover)}, []byte("Cover\x00"), cover),
+			)
+			metadata, err := ParseID3v2(tag)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			if metadata.Title != "Title" || metadata.Artist != "Artíst" || metadata.Album != "Albüm" || metadata.Genre != "Rock" {
+				t.Fatalf("got title %q, artist %q, album %q, genre %q, want Title, Artíst, Albüm, Rock", metadata.Title, metadata.Artist, metadata.Album, metadata.Genre)
+			}
+			if metadata.Track != 3 || metadata.TrackTotal != 12 {
+				t.Fatalf("got track %d/%d, want 3/12", metadata.Track, metadata.TrackTotal)
+			}
+			if metadata.Duration != 61*time.Second || metadata.Comment != "Comment" {
+				t.Fatalf("got duration %s and comment %q, want 1m1s and Comment", metadata.Duration, metadata.Comment)
+			}
+			if gain := metadata.ReplayGain; !gain.HasTrack || gain.TrackGain != -3.2 {
+				t.Fatalf("got replay gain %+v, want a track gain of -3.2 dB", gain)
+			}
+			picture := metadata.CoverArt()
+			if picture == nil || picture.MIMEType != "image/png" || picture.Description != "Cover" || !bytes.Equal(picture.Data, cover) {
+				t.Fatalf("got cover art %+v, want the png cover", picture)
+			}
+		})
+	}
+}
+
+func TestParseID3v22(t *testing.T) {
+	frame := func(id string, body []byte) []byte {
+		return append([]byte{id[0], id[1], id[2], 0, 0, byte(len(body))}, body...)
+	}
+	tag := id3v2Tag(2,
+		frame("TT2", []byte("\x00Title")),
+		frame("PIC", []byte("\x00JPG\x03\x00\xff\xd8")),
+	)
+	metadata, err := ParseID3v2(tag)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if metadata.Title != "Title" {
+		t.Fatalf("got title %q, want %q", metadata.Title, "Title")
+	}
+	if len(metadata.Pictures) != 1 || metadata.Pictures[0].MIMEType != "image/jpeg" || !bytes.Equal(metadata.Pictures[0].Data, []byte{0xff, 0xd8}) {
+		t.Fatalf("got pictures %+v, want a single jpeg", metadata.Pictures)
+	}
+}
+
+func TestParseID3v2Unsynchronisation(t *testing.T) {
+	tag := id3v2Tag(3, id3v2Frame("TIT2", false, []byte{0}, []byte{'a', 0xff, 0x00, 'b'}))
+	// the frame size is the size after removing the unsynchronisation
+	binary.BigEndian.PutUint32(tag[id3v2HeaderSize+4:], 4)
+	tag[5] = 0x80
+
+	metadata, err := ParseID3v2(tag)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if metadata.Title != "aÿb" {
+		t.Fatalf("got title %q, want %q", metadata.Title, "aÿb")
+	}
+}
+
+func TestParseID3v2Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		tag  []byte
+		err  error
+	}{
+		{name: "no magic", tag: []byte("TAG\x03\x00\x00\x00\x00\x00\x00"), err: ErrInvalidID3v2},
+		{name: "unknown version", tag: []byte("ID3\x05\x00\x00\x00\x00\x00\x00"), err: ErrInvalidID3v2},
+		{name: "short header", tag: []byte("ID3\x03"), err: ErrInvalidID3v2},
+		{name: "truncated", tag: id3v2Tag(3, id3v2Frame("TIT2", false, []byte("\x00Title")))[:15], err: io.ErrUnexpectedEOF},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseID3v2(tt.tag); err != tt.err {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseID3Genre(t *testing.T) {
+	for _, tt := range []struct {
+		genre string
+		want  string
+	}{
+		{genre: "17", want: "Rock"},
+		{genre: "(17)", want: "Rock"},
+		{genre: "(17)Hard Rock", want: "Hard Rock"},
+		{genre: "Synthwave", want: "Synthwave"},
+		{genre: "(255)", want: ""},
+	} {
+		if got := parseID3Genre(tt.genre); got != tt.want {
+			t.Fatalf("got %q for %q, want %q", got, tt.genre, tt.want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package mp3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNoFrameFound is returned when no valid mpeg audio frame could be found.
+var ErrNoFrameFound = errors.New("no mpeg audio frame found")
+
+// Metadata holds the tags and the duration of a mp3 file.
+type Metadata struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Year        string
+	Genre       string
+	Comment     string
+	Track       int
+	TrackTotal  int
+
+	// Duration is the playback duration or 0 if it is unknown.
+	Duration time.Duration
+
+	Pictures   []Picture
+	ReplayGain ReplayGain
+}
+
+// CoverArt returns the front cover or the first picture if there is no front cover.
+func (m Metadata) CoverArt() *Picture {
+	for i := range m.Pictures {
+		if m.Pictures[i].Type == PictureTypeFrontCover {
+			return &m.Pictures[i]
+		}
+	}
+	if len(m.Pictures) > 0 {
+		return &m.Pictures[0]
+	}
+	return nil
+}
+
+// merge fills all empty fields of m with the values of other.
+func (m *Metadata) merge(other *Metadata) {
+	if other == nil {
+		return
+	}
+	mergeString(&m.Title, other.Title)
+	mergeString(&m.Artist, other.Artist)
+	mergeString(&m.Album, other.Album)
+	mergeString(&m.AlbumArtist, other.AlbumArtist)
+	mergeString(&m.Year, other.Year)
+	mergeString(&m.Genre, other.Genre)
+	mergeString(&m.Comment, other.Comment)
+	if m.Track == 0 {
+		m.Track, m.TrackTotal = other.Track, other.TrackTotal
+	}
+	if m.Duration == 0 {
+		m.Duration = other.Duration
+	}
+	if len(m.Pictures) == 0 {
+		m.Pictures = other.Pictures
+	}
+	m.ReplayGain.merge(other.ReplayGain)
+}
+
+func mergeString(s *string, other string) {
+	if *s == "" {
+		*s = other
+	}
+}
+
+// PictureType is the type of an embedded picture as defined by the APIC frame.
+type PictureType byte
+
+const (
+	PictureTypeOther PictureType = iota
+	PictureTypeFileIcon
+	PictureTypeOtherFileIcon
+	PictureTypeFrontCover
+	PictureTypeBackCover
+	PictureTypeLeafletPage
+	PictureTypeMedia
+	PictureTypeLeadArtist
+	PictureTypeArtist
+	PictureTypeConductor
+	PictureTypeBand
+	PictureTypeComposer
+	PictureTypeLyricist
+	PictureTypeRecordingLocation
+	PictureTypeDuringRecording
+	PictureTypeDuringPerformance
+	PictureTypeScreenCapture
+	PictureTypeBrightColouredFish
+	PictureTypeIllustration
+	PictureTypeBandLogotype
+	PictureTypePublisherLogotype
+)
+
+// Picture is an embedded picture like the cover art.
+type Picture struct {
+	Type        PictureType
+	MIMEType    string
+	Description string
+	Data        []byte
+}
+
+// ReplayGain holds the ReplayGain values in dB and the peak amplitudes where 1.0 is full scale.
+type ReplayGain struct {
+	TrackGain float64
+	TrackPeak float64
+	AlbumGain float64
+	AlbumPeak float64
+	HasTrack  bool
+	HasAlbum  bool
+}
+
+func (g *ReplayGain) merge(other ReplayGain) {
+	if !g.HasTrack && other.HasTrack {
+		g.TrackGain, g.TrackPeak, g.HasTrack = other.TrackGain, other.TrackPeak, true
+	}
+	if !g.HasAlbum && other.HasAlbum {
+		g.AlbumGain, g.AlbumPeak, g.HasAlbum = other.AlbumGain, other.AlbumPeak, true
+	}
+}
+
+// ParseMetadata parses the ID3v2 tag and the Xing/Info/VBRI header at the start of a mp3 stream.
+// It only reads as much as needed, so it can be used on the first bytes of a stream which is still being downloaded.
+// The duration is only known if the stream has a Xing/Info/VBRI header or a TLEN frame.
+func ParseMetadata(r io.Reader) (*Metadata, error) {
+	metadata, _, frame, err := parseHead(r)
+	if err != nil {
+		return nil, err
+	}
+	if frame.info != nil {
+		metadata.Duration = frame.info.Duration()
+		metadata.ReplayGain.merge(frame.info.ReplayGain)
+	}
+	return metadata, nil
+}
+
+// ReadMetadata reads the ID3v2 and ID3v1 tags and the Xing/Info/VBRI header of a mp3 file.
+// If the file has no Xing/Info/VBRI header the duration is estimated from the file size and the bitrate of the first frame.
+func ReadMetadata(rs io.ReadSeeker) (*Metadata, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var id3v1 *Metadata
+	if size >= id3v1Size {
+		if _, err = rs.Seek(-id3v1Size, io.SeekEnd); err != nil {
+			return nil, err
+		}
+		tag := make([]byte, id3v1Size)
+		if _, err = io.ReadFull(rs, tag); err != nil {
+			return nil, err
+		}
+		if id3v1, err = ParseID3v1(tag); err == nil {
+			size -= id3v1Size
+		}
+	}
+
+	if _, err = rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	metadata, frameOffset, frame, err := parseHead(rs)
+	if err != nil {
+		return nil, err
+	}
+	metadata.merge(id3v1)
+
+	if frame.info != nil {
+		if duration := frame.info.Duration(); duration > 0 {
+			metadata.Duration = duration
+		}
+		metadata.ReplayGain.merge(frame.info.ReplayGain)
+	} else if metadata.Duration == 0 && frame.header.Bitrate > 0 {
+		metadata.Duration = time.Duration((size - frameOffset) * 8 * int64(time.Second) / int64(frame.header.Bitrate*1000))
+	}
+	return metadata, nil
+}
+
+type headFrame struct {
+	header FrameHeader
+	info   *InfoHeader
+}
+
+// parseHead parses an optional ID3v2 tag followed by the first mpeg audio frame.
+func parseHead(r io.Reader) (*Metadata, int64, headFrame, error) {
+	var (
+		metadata = &Metadata{}
+		offset   int64
+		head     = make([]byte, id3v2HeaderSize)
+	)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, 0, headFrame{}, err
+	}
+
+	if bytes.HasPrefix(head, []byte("ID3")) {
+		tagSize, err := id3v2TagSize(head)
+		if err != nil {
+			return nil, 0, headFrame{}, err
+		}
+		tag := make([]byte, tagSize)
+		copy(tag, head)
+		if _, err = io.ReadFull(r, tag[len(head):]); err != nil {
+			return nil, 0, headFrame{}, err
+		}
+		if metadata, err = ParseID3v2(tag); err != nil {
+			return nil, 0, headFrame{}, err
+		}
+		offset = int64(tagSize)
+		head = head[:0]
+	}
+
+	// search the first frame sync within the next 64 KiB
+	buff := make([]byte, 0, 64*1024)
+	buff = append(buff, head...)
+	chunk := make([]byte, 4096)
+	searched := 0
+	for {
+		for ; searched+4 <= len(buff); searched++ {
+			header, err := ParseFrameHeader(buff[searched:])
+			if err != nil {
+				continue
+			}
+			// the info header is located in the first frame, make sure we have all of it
+			for len(buff) < searched+header.FrameSize && len(buff) < cap(buff) {
+				n, err := r.Read(chunk)
+				buff = append(buff, chunk[:n]...)
+				if err != nil {
+					break
+				}
+			}
+			frame := headFrame{header: header}
+			if info, err := ParseInfoHeader(buff[searched:]); err == nil {
+				frame.info = info
+			}
+			return metadata, offset + int64(searched), frame, nil
+		}
+		if len(buff) >= cap(buff)-len(chunk) {
+			return nil, 0, headFrame{}, ErrNoFrameFound
+		}
+		n, err := r.Read(chunk)
+		buff = append(buff, chunk[:n]...)
+		if err != nil && n == 0 {
+			if err == io.EOF {
+				return nil, 0, headFrame{}, ErrNoFrameFound
+			}
+			return nil, 0, headFrame{}, err
+		}
+	}
+}
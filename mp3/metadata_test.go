@@ -0,0 +1,78 @@
+package mp3
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// testMP3 builds a mp3 file with an ID3v2 tag, a Xing frame of 100 frames, 3 empty frames and an ID3v1 tag.
+func testMP3() []byte {
+	id3v1 := make([]byte, id3v1Size)
+	copy(id3v1, "TAG")
+	copy(id3v1[3:], "Other Title")
+	copy(id3v1[63:], "Album")
+	id3v1[127] = 17
+
+	frame := make([]byte, 417)
+	copy(frame, testFrameHeader)
+	return bytes.Join([][]byte{
+		id3v2Tag(3, id3v2Frame("TIT2", false, []byte("\x00Title"))),
+		testXingFrame(100),
+		frame, frame, frame,
+		id3v1,
+	}, nil)
+}
+
+func TestReadMetadata(t *testing.T) {
+	metadata, err := ReadMetadata(bytes.NewReader(testMP3()))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	// ID3v2 takes precedence, ID3v1 only fills the gaps
+	if metadata.Title != "Title" || metadata.Album != "Album" || metadata.Genre != "Rock" {
+		t.Fatalf("got title %q, album %q, genre %q, want Title, Album, Rock", metadata.Title, metadata.Album, metadata.Genre)
+	}
+	if want := time.Duration((100*1152 - 576 - 1000) * int64(time.Second) / 44100); metadata.Duration != want {
+		t.Fatalf("got duration %s, want %s of the xing header", metadata.Duration, want)
+	}
+	if gain := metadata.ReplayGain; !gain.HasTrack || gain.TrackGain != -6.5 {
+		t.Fatalf("got replay gain %+v, want the track gain of the lame extension", gain)
+	}
+}
+
+func TestReadMetadataEstimatesDuration(t *testing.T) {
+	frame := make([]byte, 417)
+	copy(frame, testFrameHeader)
+	// garbage before the first frame is skipped but counted as audio data
+	data := append(make([]byte, 100), bytes.Repeat(frame, 10)...)
+
+	metadata, err := ReadMetadata(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if want := time.Duration(10 * 417 * 8 * int64(time.Second) / 128000); metadata.Duration != want {
+		t.Fatalf("got duration %s, want %s estimated from the bitrate", metadata.Duration, want)
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	data := testMP3()
+	// only the head of the stream is available
+	metadata, err := ParseMetadata(io.LimitReader(bytes.NewReader(data), int64(len(data)-3*417-id3v1Size)))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if metadata.Title != "Title" || metadata.Album != "" {
+		t.Fatalf("got title %q and album %q, want only the ID3v2 title", metadata.Title, metadata.Album)
+	}
+	if metadata.Duration == 0 {
+		t.Fatal("got no duration, want the duration of the xing header")
+	}
+
+	if _, err = ParseMetadata(bytes.NewReader(make([]byte, 1000))); err != ErrNoFrameFound {
+		t.Fatalf("got %v without frames, want %v", err, ErrNoFrameFound)
+	}
+}
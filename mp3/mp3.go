@@ -61,3 +61,29 @@ const (
 	ParamFlagFloatFallback      ParamFlags = C.MPG123_FLOAT_FALLBACK
 	ParamFlagNoFrankenstein     ParamFlags = C.MPG123_NO_FRANKENSTEIN
 )
+
+type MetaFlags C.int
+
+const (
+	MetaID3    MetaFlags = C.MPG123_ID3
+	MetaNewID3 MetaFlags = C.MPG123_NEW_ID3
+	MetaICY    MetaFlags = C.MPG123_ICY
+	MetaNewICY MetaFlags = C.MPG123_NEW_ICY
+)
+
+type Encoding C.int
+
+const (
+	EncodingSigned16 Encoding = C.MPG123_ENC_SIGNED_16
+	EncodingFloat32  Encoding = C.MPG123_ENC_FLOAT_32
+)
+
+type Channels C.int
+
+const (
+	ChannelsMono   Channels = C.MPG123_MONO
+	ChannelsStereo Channels = C.MPG123_STEREO
+)
+
+// Rates are the sample rates supported by mpeg audio.
+var Rates = []int{8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000}
@@ -1,24 +1,49 @@
 package mp3
 
 import (
-	"bytes"
-	"encoding/binary"
+	"context"
 	"fmt"
 	"io"
+	"sync"
 
-	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/audio/pcm"
 )
 
 // NewPCMFrameProvider returns a FrameProvider that reads mp3 and converts it into pcm frames.
-// Write the Mp3 data to the returned writer.
-func NewPCMFrameProvider(decoder *Decoder) (pcm.FrameProvider, io.Writer, error) {
+// Write the Mp3 data to the returned writer. ProvidePCMFrame blocks until enough data is written to decode a frame.
+// Close the writer once all data is written so ProvidePCMFrame returns io.EOF after the remaining data is decoded, otherwise it blocks until the FrameProvider is closed.
+func NewPCMFrameProvider(decoder *Decoder) (pcm.FrameProvider, io.WriteCloser, error) {
 	return NewCustomPCMFrameProvider(decoder, 48000, 2)
 }
 
-// NewCustomPCMFrameProvider returns a FrameProvider that reads mp3 and converts it into pcm frames.
-// You can specify the rate and channels of the output PCM frames.
-func NewCustomPCMFrameProvider(decoder *Decoder, rate int, channels int) (pcm.FrameProvider, io.Writer, error) {
+// NewCustomPCMFrameProvider returns a FrameProvider like NewPCMFrameProvider.
+// You can specify the rate and channels of the output PCM frames. The mp3 is resampled and channel converted as needed, even if its format changes mid-stream.
+func NewCustomPCMFrameProvider(decoder *Decoder, rate int, channels int) (pcm.FrameProvider, io.WriteCloser, error) {
+	return NewCustomPCMFrameProviderContext(context.Background(), decoder, rate, channels)
+}
+
+// NewPCMFrameProviderContext returns a FrameProvider like NewPCMFrameProvider. Once ctx is done, ProvidePCMFrame stops blocking and returns ctx.Err().
+func NewPCMFrameProviderContext(ctx context.Context, decoder *Decoder) (pcm.FrameProvider, io.WriteCloser, error) {
+	return NewCustomPCMFrameProviderContext(ctx, decoder, 48000, 2)
+}
+
+// NewCustomPCMFrameProviderContext returns a FrameProvider like NewCustomPCMFrameProvider. Once ctx is done, ProvidePCMFrame stops blocking and returns ctx.Err().
+func NewCustomPCMFrameProviderContext(ctx context.Context, decoder *Decoder, rate int, channels int) (pcm.FrameProvider, io.WriteCloser, error) {
+	return newPCMFrameProvider(ctx, decoder, rate, channels, true)
+}
+
+// NewNonBlockingPCMFrameProvider returns a FrameProvider like NewPCMFrameProviderContext whose ProvidePCMFrame returns no frame instead of blocking while more data is needed.
+// This allows feeding the data from the goroutine calling ProvidePCMFrame.
+func NewNonBlockingPCMFrameProvider(ctx context.Context, decoder *Decoder) (pcm.FrameProvider, io.WriteCloser, error) {
+	return NewCustomNonBlockingPCMFrameProvider(ctx, decoder, 48000, 2)
+}
+
+// NewCustomNonBlockingPCMFrameProvider returns a FrameProvider like NewNonBlockingPCMFrameProvider with the given rate and channels of the output PCM frames.
+func NewCustomNonBlockingPCMFrameProvider(ctx context.Context, decoder *Decoder, rate int, channels int) (pcm.FrameProvider, io.WriteCloser, error) {
+	return newPCMFrameProvider(ctx, decoder, rate, channels, false)
+}
+
+func newPCMFrameProvider(ctx context.Context, decoder *Decoder, rate int, channels int, blocking bool) (pcm.FrameProvider, io.WriteCloser, error) {
 	if decoder == nil {
 		var err error
 		decoder, err = CreateDecoder()
@@ -27,49 +52,109 @@ func NewCustomPCMFrameProvider(decoder *Decoder, rate int, channels int) (pcm.Fr
 		}
 	}
 
-	if err := decoder.Param(ForceRate, rate, float64(rate)); err != nil {
-		return nil, nil, fmt.Errorf("failed to set param: %w", err)
-	}
-
 	if err := decoder.OpenFeed(); err != nil {
 		return nil, nil, fmt.Errorf("failed to open feed for mp3 decoder: %w", err)
 	}
 
-	writeFunc := writer(func(p []byte) (int, error) {
-		return decoder.Write(p)
-	})
-
-	return &pcmFrameProvider{
-		decoder:     decoder,
-		bytePCMBuff: make([]byte, opus.GetOutputBuffSize(rate, channels)*2),
-		pcmBuff:     make([]int16, opus.GetOutputBuffSize(rate, channels)),
-	}, writeFunc, nil
+	provider := &pcmFrameProvider{
+		ctx:          ctx,
+		decoder:      decoder,
+		frameDecoder: newPCMFrameDecoder(decoder, rate, channels),
+		blocking:     blocking,
+	}
+	provider.cond = sync.NewCond(&provider.mu)
+	provider.Lifecycle = pcm.NewLifecycle(ctx, provider.close)
+	if blocking && ctx.Done() != nil {
+		provider.Go(provider.wakeOnDone)
+	}
+	return provider, &feedWriter{provider: provider}, nil
 }
 
 type pcmFrameProvider struct {
-	decoder     *Decoder
-	bytePCMBuff []byte
-	pcmBuff     []int16
+	*pcm.Lifecycle
+	ctx          context.Context
+	decoder      *Decoder
+	frameDecoder *pcmFrameDecoder
+	blocking     bool
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	writerClosed bool
+	closed       bool
 }
 
 func (p *pcmFrameProvider) ProvidePCMFrame() ([]int16, error) {
-	_, err := p.decoder.Read(p.bytePCMBuff)
-	if err != nil {
-		return nil, err
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.closed {
+			return nil, io.EOF
+		}
+		if err := p.ctx.Err(); err != nil {
+			return nil, err
+		}
+		frame, err := p.frameDecoder.next()
+		if err != NeedMore {
+			return frame, err
+		}
+		if p.writerClosed {
+			return p.frameDecoder.flush()
+		}
+		if !p.blocking {
+			return nil, nil
+		}
+		p.cond.Wait()
 	}
+}
 
-	if err = binary.Read(bytes.NewReader(p.bytePCMBuff), binary.LittleEndian, p.pcmBuff); err != nil {
-		return nil, err
+func (p *pcmFrameProvider) write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || p.writerClosed {
+		return 0, io.ErrClosedPipe
 	}
-	return p.pcmBuff, nil
+	n, err := p.decoder.Write(data)
+	p.cond.Broadcast()
+	return n, err
 }
 
-func (p *pcmFrameProvider) Close() {
-	_ = p.decoder.Close()
+func (p *pcmFrameProvider) closeWriter() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.writerClosed = true
+	p.cond.Broadcast()
 }
 
-type writer func(p []byte) (int, error)
+// wakeOnDone wakes up a ProvidePCMFrame call waiting for data once ctx is done.
+func (p *pcmFrameProvider) wakeOnDone(ctx context.Context) {
+	<-ctx.Done()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+func (p *pcmFrameProvider) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.frameDecoder.close()
+	return p.decoder.Close()
+}
+
+// feedWriter feeds the written data to the pcmFrameProvider. Closing it signals the end of the stream.
+type feedWriter struct {
+	provider *pcmFrameProvider
+}
+
+func (w *feedWriter) Write(p []byte) (int, error) {
+	return w.provider.write(p)
+}
 
-func (w writer) Write(p []byte) (int, error) {
-	return w(p)
+func (w *feedWriter) Close() error {
+	w.provider.closeWriter()
+	return nil
 }
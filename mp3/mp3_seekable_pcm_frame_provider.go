@@ -0,0 +1,111 @@
+package mp3
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/audio/internal/pcmconv"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// NewSeekablePCMFrameProvider returns a SeekableFrameProvider that reads mp3 from the given io.ReadSeeker and converts it into pcm frames.
+func NewSeekablePCMFrameProvider(decoder *Decoder, r io.ReadSeeker) (pcm.SeekableFrameProvider, error) {
+	return NewCustomSeekablePCMFrameProvider(decoder, r, 48000, 2)
+}
+
+// NewCustomSeekablePCMFrameProvider returns a SeekableFrameProvider that reads mp3 from the given io.ReadSeeker and converts it into pcm frames.
+// You can specify the rate and channels of the output PCM frames. The mp3 is resampled and channel converted as needed, even if its format changes mid-stream.
+func NewCustomSeekablePCMFrameProvider(decoder *Decoder, r io.ReadSeeker, rate int, channels int) (pcm.SeekableFrameProvider, error) {
+	if decoder == nil {
+		var err error
+		decoder, err = CreateDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mp3 decoder: %w", err)
+		}
+	}
+
+	if err := decoder.OpenReader(r); err != nil {
+		return nil, fmt.Errorf("failed to open reader for mp3 decoder: %w", err)
+	}
+
+	provider := &seekablePCMFrameProvider{
+		decoder:      decoder,
+		frameDecoder: newPCMFrameDecoder(decoder, rate, channels),
+	}
+	provider.Lifecycle = pcm.NewLifecycle(nil, provider.close)
+	return provider, nil
+}
+
+type seekablePCMFrameProvider struct {
+	*pcm.Lifecycle
+	decoder      *Decoder
+	frameDecoder *pcmFrameDecoder
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (p *seekablePCMFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, io.EOF
+	}
+	frame, err := p.frameDecoder.next()
+	if err == io.EOF {
+		return p.frameDecoder.flush()
+	}
+	return frame, err
+}
+
+func (p *seekablePCMFrameProvider) Seek(position time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return io.ErrClosedPipe
+	}
+	if err := p.frameDecoder.ensureFormat(); err != nil {
+		return err
+	}
+	if _, err := p.decoder.Seek(int64(position.Seconds()*float64(p.frameDecoder.inputRate)), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek mp3 decoder: %w", err)
+	}
+	return p.frameDecoder.reset()
+}
+
+func (p *seekablePCMFrameProvider) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || p.frameDecoder.inputRate == 0 {
+		return 0
+	}
+	position := pcmconv.SamplesToDuration(p.decoder.Tell(), p.frameDecoder.inputRate)
+	// subtract what was decoded but not returned yet
+	return position - p.frameDecoder.pending()
+}
+
+func (p *seekablePCMFrameProvider) Duration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0
+	}
+	if err := p.frameDecoder.ensureFormat(); err != nil {
+		return 0
+	}
+	length, err := p.decoder.Length()
+	if err != nil {
+		return 0
+	}
+	return pcmconv.SamplesToDuration(length, p.frameDecoder.inputRate)
+}
+
+func (p *seekablePCMFrameProvider) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.frameDecoder.close()
+	return p.decoder.Close()
+}
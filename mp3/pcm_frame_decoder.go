@@ -0,0 +1,104 @@
+package mp3
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/disgoorg/audio/internal/pcmconv"
+	"github.com/disgoorg/audio/opus"
+)
+
+// newPCMFrameDecoder allows the Decoder to output every rate and channel count in signed 16 bit.
+func newPCMFrameDecoder(decoder *Decoder, rate int, channels int) *pcmFrameDecoder {
+	decoder.FormatNone()
+	for _, r := range Rates {
+		decoder.Format(int64(r), int(ChannelsMono|ChannelsStereo), int(EncodingSigned16))
+	}
+
+	return &pcmFrameDecoder{
+		decoder:     decoder,
+		converter:   pcmconv.NewConverter(rate, channels, nil),
+		bytePCMBuff: make([]byte, opus.GetOutputBuffSize(rate, channels)*2),
+	}
+}
+
+// pcmFrameDecoder reads PCM from a Decoder and converts it into frames of a fixed rate and channel count.
+// When the format of the stream changes it re-routes the PCM through a samplerate.Resampler and channelconverter.ChannelConverter as needed.
+type pcmFrameDecoder struct {
+	decoder   *Decoder
+	converter *pcmconv.Converter
+
+	inputRate     int
+	inputChannels int
+
+	bytePCMBuff []byte
+}
+
+// next returns the next frame. It returns NeedMore if the Decoder needs more data.
+func (d *pcmFrameDecoder) next() ([]int16, error) {
+	for !d.converter.Ready() {
+		n, err := d.decoder.Read(d.bytePCMBuff)
+		if n > 0 {
+			if convertErr := d.convert(d.bytePCMBuff[:n]); convertErr != nil {
+				return nil, convertErr
+			}
+		}
+		if err == NewFormat {
+			if err = d.updateFormat(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.converter.Next(), nil
+}
+
+// flush is called at the end of the stream, see pcmconv.Converter.Flush.
+func (d *pcmFrameDecoder) flush() ([]int16, error) {
+	return d.converter.Flush()
+}
+
+// reset drops all buffered PCM, use it after seeking.
+func (d *pcmFrameDecoder) reset() error {
+	return d.converter.Reset()
+}
+
+// pending returns the duration of the PCM which was decoded but not returned yet.
+func (d *pcmFrameDecoder) pending() time.Duration {
+	return d.converter.Pending()
+}
+
+// ensureFormat makes sure the input format is known, which requires parsing the first frame.
+func (d *pcmFrameDecoder) ensureFormat() error {
+	if d.inputRate != 0 {
+		return nil
+	}
+	return d.updateFormat()
+}
+
+func (d *pcmFrameDecoder) updateFormat() error {
+	rate, channels, encoding := d.decoder.GetFormat()
+	if Encoding(encoding) != EncodingSigned16 {
+		return fmt.Errorf("unsupported mp3 output encoding: %d", encoding)
+	}
+	if rate <= 0 || channels <= 0 {
+		return fmt.Errorf("invalid mp3 output format: %dhz %d channels", rate, channels)
+	}
+	d.inputRate = int(rate)
+	d.inputChannels = channels
+	return nil
+}
+
+func (d *pcmFrameDecoder) convert(data []byte) error {
+	if err := d.ensureFormat(); err != nil {
+		return err
+	}
+	return d.converter.ConvertBytes(data, d.inputRate, d.inputChannels)
+}
+
+func (d *pcmFrameDecoder) close() {
+	d.converter.Close()
+}
@@ -0,0 +1,46 @@
+package mp3
+
+/*
+#cgo pkg-config: libmpg123
+#include <stdlib.h>
+#include <mpg123.h>
+*/
+import "C"
+import (
+	"io"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/disgoorg/audio/internal/readutil"
+)
+
+// goMP3Read is the read callback passed to mpg123_replace_reader_handle. It returns 0 on EOF and -1 on error.
+//
+//export goMP3Read
+func goMP3Read(handle unsafe.Pointer, buf unsafe.Pointer, size C.size_t) C.ssize_t {
+	r := cgo.Handle(uintptr(handle)).Value().(io.ReadSeeker)
+	if size == 0 {
+		return 0
+	}
+	p := unsafe.Slice((*byte)(buf), int(size))
+	n, err := readutil.ReadSome(r, p)
+	if n > 0 {
+		return C.ssize_t(n)
+	}
+	if err == io.EOF {
+		return 0
+	}
+	return -1
+}
+
+// goMP3Seek is the lseek callback passed to mpg123_replace_reader_handle. It returns -1 if the reader can't seek.
+//
+//export goMP3Seek
+func goMP3Seek(handle unsafe.Pointer, offset C.off_t, whence C.int) C.off_t {
+	r := cgo.Handle(uintptr(handle)).Value().(io.ReadSeeker)
+	newOffset, err := r.Seek(int64(offset), int(whence))
+	if err != nil {
+		return -1
+	}
+	return C.off_t(newOffset)
+}
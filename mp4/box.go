@@ -0,0 +1,79 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrInvalidBox is returned when a box is truncated or its size is invalid.
+var ErrInvalidBox = errors.New("mp4: invalid box")
+
+// boxHeader is the header of an ISO base media file format box.
+type boxHeader struct {
+	Type string
+	// Size is the size of the box including the header or -1 if the box extends to the end of the file.
+	Size       int64
+	HeaderSize int64
+}
+
+func readBoxHeader(r io.Reader) (boxHeader, error) {
+	var buff [16]byte
+	if _, err := io.ReadFull(r, buff[:8]); err != nil {
+		return boxHeader{}, err
+	}
+	header := boxHeader{
+		Type:       string(buff[4:8]),
+		Size:       int64(binary.BigEndian.Uint32(buff[:4])),
+		HeaderSize: 8,
+	}
+	switch header.Size {
+	case 0:
+		header.Size = -1
+	case 1:
+		if _, err := io.ReadFull(r, buff[8:16]); err != nil {
+			return boxHeader{}, err
+		}
+		header.Size = int64(binary.BigEndian.Uint64(buff[8:16]))
+		header.HeaderSize = 16
+	}
+	if header.Size != -1 && header.Size < header.HeaderSize {
+		return boxHeader{}, ErrInvalidBox
+	}
+	return header, nil
+}
+
+// walkBoxes calls fn for each box in data. Boxes extending to the end are cut at the end of data.
+func walkBoxes(data []byte, fn func(boxType string, body []byte) error) error {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		boxType := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return ErrInvalidBox
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return ErrInvalidBox
+		}
+		if err := fn(boxType, data[headerSize:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// fullBox strips the version and flags of a full box.
+func fullBox(body []byte) (byte, []byte, error) {
+	if len(body) < 4 {
+		return 0, nil, ErrInvalidBox
+	}
+	return body[0], body[4:], nil
+}
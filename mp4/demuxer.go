@@ -0,0 +1,223 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNoMoov is returned when the file contains no moov box.
+	ErrNoMoov = errors.New("mp4: no moov box found")
+
+	// ErrFragmented is returned for fragmented mp4 files, which store their samples in moof boxes.
+	ErrFragmented = errors.New("mp4: fragmented mp4 is not supported")
+
+	// ErrNoAudioTrack is returned when the file contains no audio track.
+	ErrNoAudioTrack = errors.New("mp4: no audio track found")
+)
+
+// Open parses the moov box of the given mp4/m4a file and selects the first AAC or Opus audio track.
+// The moov box may be located before or after the mdat box.
+func Open(r io.ReadSeeker) (*Demuxer, error) {
+	moov, fragmented, err := findMoov(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Demuxer{
+		r:      r,
+		offset: -1,
+	}
+	var mvhdTimescale, mvhdDuration uint64
+	err = walkBoxes(moov, func(boxType string, body []byte) error {
+		switch boxType {
+		case "mvhd":
+			version, body, err := fullBox(body)
+			if err != nil {
+				return err
+			}
+			if version == 1 && len(body) >= 28 {
+				mvhdTimescale = uint64(binary.BigEndian.Uint32(body[16:20]))
+				mvhdDuration = binary.BigEndian.Uint64(body[20:28])
+			} else if len(body) >= 16 {
+				mvhdTimescale = uint64(binary.BigEndian.Uint32(body[8:12]))
+				mvhdDuration = uint64(binary.BigEndian.Uint32(body[12:16]))
+			}
+		case "trak":
+			track, err := parseTrak(body)
+			if err != nil {
+				return fmt.Errorf("failed to parse trak: %w", err)
+			}
+			d.tracks = append(d.tracks, track)
+		case "mvex":
+			fragmented = true
+		case "udta":
+			return walkBoxes(body, func(boxType string, body []byte) error {
+				if boxType == "meta" {
+					return parseMeta(body, &d.metadata)
+				}
+				return nil
+			})
+		case "meta":
+			return parseMeta(body, &d.metadata)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if mvhdTimescale > 0 {
+		d.metadata.Duration = time.Duration(mvhdDuration * uint64(time.Second) / mvhdTimescale)
+	}
+
+	for _, track := range d.tracks {
+		if track.IsAudio() && (track.IsAAC() || track.Codec == CodecOpus) {
+			d.track = track
+			break
+		}
+	}
+	if d.track == nil {
+		for _, track := range d.tracks {
+			if track.IsAudio() {
+				d.track = track
+				break
+			}
+		}
+	}
+	if d.track == nil {
+		return nil, ErrNoAudioTrack
+	}
+	if len(d.track.Samples) == 0 && fragmented {
+		return nil, ErrFragmented
+	}
+	if d.metadata.Duration == 0 {
+		d.metadata.Duration = d.track.Duration()
+	}
+	return d, nil
+}
+
+// findMoov reads the top level boxes until it finds the moov box and skips everything else.
+func findMoov(r io.ReadSeeker) ([]byte, bool, error) {
+	var (
+		offset     int64
+		fragmented bool
+	)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	for {
+		header, err := readBoxHeader(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, fragmented, ErrNoMoov
+		}
+		if err != nil {
+			return nil, fragmented, err
+		}
+		switch header.Type {
+		case "moov":
+			if header.Size == -1 {
+				moov, err := io.ReadAll(r)
+				return moov, fragmented, err
+			}
+			moov := make([]byte, header.Size-header.HeaderSize)
+			if _, err = io.ReadFull(r, moov); err != nil {
+				return nil, fragmented, fmt.Errorf("failed to read moov box: %w", err)
+			}
+			return moov, fragmented, nil
+		case "moof":
+			fragmented = true
+		}
+		if header.Size == -1 {
+			return nil, fragmented, ErrNoMoov
+		}
+		offset += header.Size
+		if _, err = r.Seek(offset, io.SeekStart); err != nil {
+			return nil, fragmented, err
+		}
+	}
+}
+
+// Demuxer reads the samples of a single track of a mp4/m4a file.
+type Demuxer struct {
+	r        io.ReadSeeker
+	tracks   []*Track
+	metadata Metadata
+	track    *Track
+	sample   int
+	// offset is the offset of the reader or -1 if unknown
+	offset int64
+	buff   []byte
+}
+
+// Tracks returns all tracks of the file.
+func (d *Demuxer) Tracks() []*Track {
+	return d.tracks
+}
+
+// Track returns the selected track.
+func (d *Demuxer) Track() *Track {
+	return d.track
+}
+
+// SelectTrack selects the track to read samples from and rewinds to its first sample.
+func (d *Demuxer) SelectTrack(track *Track) {
+	d.track = track
+	d.sample = 0
+}
+
+// Metadata returns the iTunes metadata of the file.
+func (d *Demuxer) Metadata() Metadata {
+	return d.metadata
+}
+
+// ReadSample reads the next sample of the selected track. It returns io.EOF after the last sample.
+// The returned slice is only valid until the next call to ReadSample.
+func (d *Demuxer) ReadSample() ([]byte, error) {
+	if d.sample >= len(d.track.Samples) {
+		return nil, io.EOF
+	}
+	sample := d.track.Samples[d.sample]
+	if cap(d.buff) < sample.Size {
+		d.buff = make([]byte, sample.Size)
+	}
+	d.buff = d.buff[:sample.Size]
+
+	// samples are usually stored back to back, only seek if they are not
+	if sample.Offset != d.offset {
+		if _, err := d.r.Seek(sample.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	d.offset = -1
+	if _, err := io.ReadFull(d.r, d.buff); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	d.offset = sample.Offset + int64(sample.Size)
+	d.sample++
+	return d.buff, nil
+}
+
+// Seek seeks to the sample playing at the given position.
+func (d *Demuxer) Seek(position time.Duration) error {
+	d.sample = d.track.SampleIndex(position)
+	return nil
+}
+
+// Position returns the position of the next sample.
+func (d *Demuxer) Position() time.Duration {
+	if d.sample >= len(d.track.Samples) {
+		return d.track.Duration()
+	}
+	return d.track.toDuration(d.track.Samples[d.sample].Time)
+}
+
+// Duration returns the duration of the selected track.
+func (d *Demuxer) Duration() time.Duration {
+	return d.track.Duration()
+}
@@ -0,0 +1,226 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+)
+
+func box(boxType string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	data := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(data, uint32(8+len(body)))
+	copy(data[4:], boxType)
+	return append(data, body...)
+}
+
+func fullBoxData(boxType string, children ...[]byte) []byte {
+	return box(boxType, append([][]byte{{0, 0, 0, 0}}, children...)...)
+}
+
+func uint32s(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.BigEndian.PutUint32(data[i*4:], value)
+	}
+	return data
+}
+
+var testSamples = [][]byte{{1, 2, 3}, {4, 5, 6, 7}, {8, 9, 10, 11, 12}}
+
+// testFile builds a m4a file with a single AAC track whose moov box follows the mdat box.
+// The first two samples are stored in the first chunk, the last one in a second chunk after a gap.
+func testFile() []byte {
+	ftyp := box("ftyp", []byte("M4A "), uint32s(0), []byte("M4A isom"))
+	gap := []byte{0xff, 0xff}
+	mdat := box("mdat", testSamples[0], testSamples[1], gap, testSamples[2])
+
+	firstChunk := uint32(len(ftyp) + 8)
+	secondChunk := firstChunk + uint32(len(testSamples[0])+len(testSamples[1])+len(gap))
+
+	audioSpecificConfig := []byte{0x12, 0x10}
+	esds := fullBoxData("esds",
+		[]byte{0x03, byte(3 + 2 + 13 + 2 + len(audioSpecificConfig)), 0, 1, 0},
+		[]byte{0x04, byte(13 + 2 + len(audioSpecificConfig)), ObjectTypeAAC}, make([]byte, 12),
+		[]byte{0x05, byte(len(audioSpecificConfig))}, audioSpecificConfig,
+	)
+	mp4a := make([]byte, 28)
+	binary.BigEndian.PutUint16(mp4a[16:], 2)
+	binary.BigEndian.PutUint32(mp4a[24:], 44100<<16)
+
+	stbl := box("stbl",
+		fullBoxData("stsd", uint32s(1), box("mp4a", mp4a, esds)),
+		fullBoxData("stts", uint32s(1, 3, 1024)),
+		fullBoxData("stsc", uint32s(2, 1, 2, 1, 2, 1, 1)),
+		fullBoxData("stsz", uint32s(0, 3, 3, 4, 5)),
+		fullBoxData("stco", uint32s(2, firstChunk, secondChunk)),
+	)
+	trak := box("trak",
+		fullBoxData("tkhd", uint32s(0, 0, 1)),
+		box("mdia",
+			fullBoxData("mdhd", uint32s(0, 0, 44100, 3072)),
+			fullBoxData("hdlr", uint32s(0), []byte("soun"), make([]byte, 12)),
+			box("minf", stbl),
+		),
+	)
+	ilst := box("ilst",
+		box("\xa9nam", box("data", uint32s(1, 0), []byte("Title"))),
+		box("trkn", box("data", uint32s(0, 0), []byte{0, 0, 0, 3, 0, 12, 0, 0})),
+		box("----",
+			fullBoxData("mean", []byte("com.apple.iTunes")),
+			fullBoxData("name", []byte("ISRC")),
+			box("data", uint32s(1, 0), []byte("XX0000000000")),
+		),
+	)
+	moov := box("moov",
+		fullBoxData("mvhd", uint32s(0, 0, 1000, 70)),
+		trak,
+		box("udta", fullBoxData("meta", fullBoxData("hdlr", uint32s(0), []byte("mdir"), make([]byte, 12)), ilst)),
+	)
+	return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+}
+
+func TestOpen(t *testing.T) {
+	d, err := Open(bytes.NewReader(testFile()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	track := d.Track()
+	if track == nil || len(d.Tracks()) != 1 {
+		t.Fatalf("got %d tracks, want 1 selected track", len(d.Tracks()))
+	}
+	if !track.IsAudio() || !track.IsAAC() {
+		t.Fatalf("got handler %q, codec %q, object type %#x, want an AAC audio track", track.Handler, track.Codec, track.ObjectType)
+	}
+	if track.ID != 1 || track.SampleRate != 44100 || track.Channels != 2 {
+		t.Fatalf("got id %d, %d Hz, %d channels, want id 1, 44100 Hz, 2 channels", track.ID, track.SampleRate, track.Channels)
+	}
+	if !bytes.Equal(track.DecoderConfig, []byte{0x12, 0x10}) {
+		t.Fatalf("got decoder config %x, want 1210", track.DecoderConfig)
+	}
+	if len(track.Samples) != len(testSamples) {
+		t.Fatalf("got %d samples, want %d", len(track.Samples), len(testSamples))
+	}
+	if sample := track.Samples[2]; sample.Time != 2048 || sample.Duration != 1024 || sample.Size != 5 {
+		t.Fatalf("got last sample %+v, want time 2048, duration 1024 and size 5", sample)
+	}
+	// the duration of the mvhd box takes precedence over the track duration
+	if got, want := d.Metadata().Duration, 70*time.Millisecond; got != want {
+		t.Fatalf("got metadata duration %s, want %s", got, want)
+	}
+	if got, want := d.Duration(), time.Duration(3072*uint64(time.Second)/44100); got != want {
+		t.Fatalf("got duration %s, want %s", got, want)
+	}
+}
+
+func TestDemuxerReadSample(t *testing.T) {
+	d, err := Open(bytes.NewReader(testFile()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	for i, want := range testSamples {
+		sample, err := d.ReadSample()
+		if err != nil {
+			t.Fatalf("sample %d: %v", i, err)
+		}
+		if !bytes.Equal(sample, want) {
+			t.Fatalf("got sample %d %v, want %v", i, sample, want)
+		}
+	}
+	if _, err = d.ReadSample(); err != io.EOF {
+		t.Fatalf("got %v after the last sample, want io.EOF", err)
+	}
+	if got := d.Position(); got != d.Duration() {
+		t.Fatalf("got position %s at the end, want %s", got, d.Duration())
+	}
+}
+
+func TestDemuxerSeek(t *testing.T) {
+	d, err := Open(bytes.NewReader(testFile()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	for _, tt := range []struct {
+		position time.Duration
+		sample   int
+	}{
+		{position: 0, sample: 0},
+		{position: 30 * time.Millisecond, sample: 1},
+		{position: 50 * time.Millisecond, sample: 2},
+		{position: time.Second, sample: 2},
+		{position: 10 * time.Millisecond, sample: 0},
+	} {
+		if err = d.Seek(tt.position); err != nil {
+			t.Fatalf("seek to %s: %v", tt.position, err)
+		}
+		if got, want := d.Position(), time.Duration(uint64(tt.sample)*1024*uint64(time.Second)/44100); got != want {
+			t.Fatalf("got position %s after seeking to %s, want %s", got, tt.position, want)
+		}
+		sample, err := d.ReadSample()
+		if err != nil {
+			t.Fatalf("read after seeking to %s: %v", tt.position, err)
+		}
+		if !bytes.Equal(sample, testSamples[tt.sample]) {
+			t.Fatalf("got sample %v after seeking to %s, want %v", sample, tt.position, testSamples[tt.sample])
+		}
+	}
+}
+
+func TestDemuxerMetadata(t *testing.T) {
+	d, err := Open(bytes.NewReader(testFile()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	metadata := d.Metadata()
+	if metadata.Title != "Title" {
+		t.Fatalf("got title %q, want %q", metadata.Title, "Title")
+	}
+	if metadata.Track != 3 || metadata.TrackTotal != 12 {
+		t.Fatalf("got track %d/%d, want 3/12", metadata.Track, metadata.TrackTotal)
+	}
+	if got := metadata.Fields["©nam"]; got != "Title" {
+		t.Fatalf("got field ©nam %q, want %q", got, "Title")
+	}
+	if got := metadata.Fields["ISRC"]; got != "XX0000000000" {
+		t.Fatalf("got freeform field ISRC %q, want %q", got, "XX0000000000")
+	}
+	if metadata.CoverArt() != nil {
+		t.Fatal("got cover art, want none")
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "empty", data: nil, err: ErrNoMoov},
+		{name: "no moov", data: box("ftyp", []byte("M4A ")), err: ErrNoMoov},
+		{name: "invalid box size", data: []byte{0, 0, 0, 4, 'm', 'o', 'o', 'v'}, err: ErrInvalidBox},
+		{name: "no audio track", data: box("moov", fullBoxData("mvhd", uint32s(0, 0, 1000, 0))), err: ErrNoAudioTrack},
+		{name: "fragmented", data: box("moov", box("mvex"), box("trak", box("mdia", fullBoxData("hdlr", uint32s(0), []byte("soun"))))), err: ErrFragmented},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Open(bytes.NewReader(tt.data)); err != tt.err {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWalkBoxesTruncated(t *testing.T) {
+	data := box("free", []byte{1, 2, 3})
+	err := walkBoxes(data[:len(data)-1], func(string, []byte) error {
+		return nil
+	})
+	if err != ErrInvalidBox {
+		t.Fatalf("got %v, want %v", err, ErrInvalidBox)
+	}
+}
@@ -0,0 +1,160 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// Picture is a cover art image of the iTunes metadata.
+type Picture struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Metadata is the iTunes metadata stored in moov/udta/meta/ilst.
+type Metadata struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Composer    string
+	Date        string
+	Genre       string
+	Comment     string
+	Track       int
+	TrackTotal  int
+	Disc        int
+	DiscTotal   int
+	Duration    time.Duration
+	Pictures    []Picture
+	// Fields holds all text items keyed by their box type, e.g. "©nam", or by their name for freeform "----" items.
+	Fields map[string]string
+}
+
+// CoverArt returns the first picture or nil if there is none.
+func (m Metadata) CoverArt() *Picture {
+	if len(m.Pictures) == 0 {
+		return nil
+	}
+	return &m.Pictures[0]
+}
+
+// data types of the well-known types table
+const (
+	dataTypeUTF8 = 1
+	dataTypeJPEG = 13
+	dataTypePNG  = 14
+	dataTypeBMP  = 27
+)
+
+func parseMeta(body []byte, metadata *Metadata) error {
+	// QuickTime meta boxes have no version and flags, ISO ones do
+	if len(body) < 8 || string(body[4:8]) != "hdlr" {
+		var err error
+		if _, body, err = fullBox(body); err != nil {
+			return err
+		}
+	}
+	return walkBoxes(body, func(boxType string, body []byte) error {
+		if boxType != "ilst" {
+			return nil
+		}
+		return walkBoxes(body, func(item string, body []byte) error {
+			parseItem(item, body, metadata)
+			return nil
+		})
+	})
+}
+
+func parseItem(item string, body []byte, metadata *Metadata) {
+	var name string
+	_ = walkBoxes(body, func(boxType string, body []byte) error {
+		switch boxType {
+		case "name":
+			if len(body) >= 4 {
+				name = string(body[4:])
+			}
+		case "data":
+			if len(body) < 8 {
+				return nil
+			}
+			dataType := binary.BigEndian.Uint32(body[:4]) & 0xffffff
+			value := body[8:]
+			if item == "----" {
+				if name != "" && dataType == dataTypeUTF8 {
+					metadata.setField(name, string(value))
+				}
+				return nil
+			}
+			parseItemData(item, dataType, value, metadata)
+		}
+		return nil
+	})
+}
+
+func parseItemData(item string, dataType uint32, value []byte, metadata *Metadata) {
+	switch item {
+	case "trkn":
+		metadata.Track, metadata.TrackTotal = parseIndex(value)
+		return
+	case "disk":
+		metadata.Disc, metadata.DiscTotal = parseIndex(value)
+		return
+	case "covr":
+		picture := Picture{Data: append([]byte(nil), value...)}
+		switch dataType {
+		case dataTypeJPEG:
+			picture.MIMEType = "image/jpeg"
+		case dataTypePNG:
+			picture.MIMEType = "image/png"
+		case dataTypeBMP:
+			picture.MIMEType = "image/bmp"
+		}
+		metadata.Pictures = append(metadata.Pictures, picture)
+		return
+	}
+	if dataType != dataTypeUTF8 {
+		return
+	}
+
+	text := string(value)
+	metadata.setField(item, text)
+	switch item {
+	case "\xa9nam":
+		metadata.Title = text
+	case "\xa9ART":
+		metadata.Artist = text
+	case "\xa9alb":
+		metadata.Album = text
+	case "aART":
+		metadata.AlbumArtist = text
+	case "\xa9wrt":
+		metadata.Composer = text
+	case "\xa9day":
+		metadata.Date = text
+	case "\xa9gen":
+		metadata.Genre = text
+	case "\xa9cmt":
+		metadata.Comment = text
+	}
+}
+
+func (m *Metadata) setField(name string, value string) {
+	if m.Fields == nil {
+		m.Fields = map[string]string{}
+	}
+	// box types are latin1, make "\xa9nam" readable as "©nam"
+	if strings.HasPrefix(name, "\xa9") {
+		name = "©" + name[1:]
+	}
+	m.Fields[name] = value
+}
+
+// parseIndex parses the index and total of trkn and disk items.
+func parseIndex(value []byte) (int, int) {
+	if len(value) < 6 {
+		return 0, 0
+	}
+	return int(binary.BigEndian.Uint16(value[2:4])), int(binary.BigEndian.Uint16(value[4:6]))
+}
@@ -0,0 +1,388 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// Codec is the four character code of a sample entry.
+type Codec string
+
+const (
+	CodecAAC  Codec = "mp4a"
+	CodecOpus Codec = "Opus"
+	CodecFLAC Codec = "fLaC"
+	CodecALAC Codec = "alac"
+)
+
+// object type indications of the esds box
+const (
+	ObjectTypeAAC        = 0x40
+	ObjectTypeMPEG2AAC   = 0x67
+	ObjectTypeMP3        = 0x6b
+	ObjectTypeMPEG2Audio = 0x69
+)
+
+// Track is a track of the moov box with its sample table.
+type Track struct {
+	ID int
+	// Handler is the handler type, "soun" for audio tracks.
+	Handler string
+	Codec   Codec
+	// ObjectType is the object type indication of mp4a tracks.
+	ObjectType int
+	SampleRate int
+	Channels   int
+	// DecoderConfig is the AudioSpecificConfig of AAC tracks or the dOps box of Opus tracks.
+	DecoderConfig []byte
+	Timescale     uint32
+	// Length is the duration in Timescale units.
+	Length  uint64
+	Samples []Sample
+}
+
+// Sample is a single access unit of a Track.
+type Sample struct {
+	Offset int64
+	Size   int
+	// Time is the decode time in Timescale units.
+	Time     uint64
+	Duration uint32
+}
+
+// IsAudio returns whether the Track is an audio track.
+func (t *Track) IsAudio() bool {
+	return t.Handler == "soun"
+}
+
+// IsAAC returns whether the Track contains AAC.
+func (t *Track) IsAAC() bool {
+	return t.Codec == CodecAAC && (t.ObjectType == ObjectTypeAAC || t.ObjectType == ObjectTypeMPEG2AAC || t.ObjectType == 0)
+}
+
+// Duration returns the duration of the Track.
+func (t *Track) Duration() time.Duration {
+	return t.toDuration(t.Length)
+}
+
+// SampleIndex returns the index of the sample playing at the given position.
+func (t *Track) SampleIndex(position time.Duration) int {
+	if t.Timescale == 0 || len(t.Samples) == 0 {
+		return 0
+	}
+	target := uint64(position.Seconds() * float64(t.Timescale))
+	i := sort.Search(len(t.Samples), func(i int) bool {
+		return t.Samples[i].Time > target
+	})
+	if i > 0 {
+		i--
+	}
+	return i
+}
+
+func (t *Track) toDuration(units uint64) time.Duration {
+	if t.Timescale == 0 {
+		return 0
+	}
+	return time.Duration(units * uint64(time.Second) / uint64(t.Timescale))
+}
+
+func parseTrak(body []byte) (*Track, error) {
+	track := &Track{}
+	var table sampleTable
+	err := walkBoxes(body, func(boxType string, body []byte) error {
+		switch boxType {
+		case "tkhd":
+			version, body, err := fullBox(body)
+			if err != nil {
+				return err
+			}
+			offset := 8
+			if version == 1 {
+				offset = 16
+			}
+			if len(body) < offset+4 {
+				return ErrInvalidBox
+			}
+			track.ID = int(binary.BigEndian.Uint32(body[offset:]))
+		case "mdia":
+			return walkBoxes(body, func(boxType string, body []byte) error {
+				switch boxType {
+				case "mdhd":
+					return parseMdhd(body, track)
+				case "hdlr":
+					if len(body) < 12 {
+						return ErrInvalidBox
+					}
+					track.Handler = string(body[8:12])
+				case "minf":
+					return walkBoxes(body, func(boxType string, body []byte) error {
+						if boxType != "stbl" {
+							return nil
+						}
+						return table.parse(body, track)
+					})
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	track.Samples = table.build()
+	return track, nil
+}
+
+func parseMdhd(body []byte, track *Track) error {
+	version, body, err := fullBox(body)
+	if err != nil {
+		return err
+	}
+	if version == 1 {
+		if len(body) < 24 {
+			return ErrInvalidBox
+		}
+		track.Timescale = binary.BigEndian.Uint32(body[16:20])
+		track.Length = binary.BigEndian.Uint64(body[20:28])
+		return nil
+	}
+	if len(body) < 16 {
+		return ErrInvalidBox
+	}
+	track.Timescale = binary.BigEndian.Uint32(body[8:12])
+	track.Length = uint64(binary.BigEndian.Uint32(body[12:16]))
+	return nil
+}
+
+func parseStsd(body []byte, track *Track) error {
+	_, body, err := fullBox(body)
+	if err != nil {
+		return err
+	}
+	if len(body) < 4 {
+		return ErrInvalidBox
+	}
+	// only the first sample entry is used
+	first := true
+	return walkBoxes(body[4:], func(boxType string, entry []byte) error {
+		if !first {
+			return nil
+		}
+		first = false
+		track.Codec = Codec(boxType)
+		if track.Handler != "" && !track.IsAudio() {
+			return nil
+		}
+		if len(entry) < 28 {
+			return ErrInvalidBox
+		}
+		track.Channels = int(binary.BigEndian.Uint16(entry[16:18]))
+		track.SampleRate = int(binary.BigEndian.Uint32(entry[24:28]) >> 16)
+
+		// QuickTime sound sample description versions 1 and 2 have additional fields
+		children := entry[28:]
+		switch binary.BigEndian.Uint16(entry[8:10]) {
+		case 1:
+			if len(children) < 16 {
+				return ErrInvalidBox
+			}
+			children = children[16:]
+		case 2:
+			if len(children) < 36 {
+				return ErrInvalidBox
+			}
+			children = children[36:]
+		}
+		return walkBoxes(children, func(boxType string, body []byte) error {
+			switch boxType {
+			case "esds":
+				_, body, err := fullBox(body)
+				if err != nil {
+					return err
+				}
+				parseDescriptors(body, track)
+			case "dOps":
+				track.DecoderConfig = append([]byte(nil), body...)
+			}
+			return nil
+		})
+	})
+}
+
+// parseDescriptors parses the ES_Descriptor of an esds box for the object type and AudioSpecificConfig.
+func parseDescriptors(data []byte, track *Track) {
+	for len(data) >= 2 {
+		tag := data[0]
+		data = data[1:]
+		var size int
+		for i := 0; i < 4 && len(data) > 0; i++ {
+			b := data[0]
+			data = data[1:]
+			size = size<<7 | int(b&0x7f)
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		if size > len(data) {
+			size = len(data)
+		}
+		payload := data[:size]
+		data = data[size:]
+
+		switch tag {
+		case 0x03: // ES_Descriptor
+			if len(payload) < 3 {
+				return
+			}
+			flags := payload[2]
+			payload = payload[3:]
+			if flags&0x80 != 0 && len(payload) >= 2 {
+				payload = payload[2:]
+			}
+			if flags&0x40 != 0 && len(payload) >= 1 {
+				urlLength := int(payload[0])
+				if len(payload) < urlLength+1 {
+					return
+				}
+				payload = payload[urlLength+1:]
+			}
+			if flags&0x20 != 0 && len(payload) >= 2 {
+				payload = payload[2:]
+			}
+			parseDescriptors(payload, track)
+		case 0x04: // DecoderConfigDescriptor
+			if len(payload) < 13 {
+				return
+			}
+			track.ObjectType = int(payload[0])
+			parseDescriptors(payload[13:], track)
+		case 0x05: // DecoderSpecificInfo
+			track.DecoderConfig = append([]byte(nil), payload...)
+		}
+	}
+}
+
+// sampleTable holds the boxes of a stbl box needed to locate the samples.
+type sampleTable struct {
+	timeToSample  []uint32
+	sampleToChunk []uint32
+	sampleSize    uint32
+	sampleSizes   []uint32
+	sampleCount   int
+	chunkOffsets  []int64
+}
+
+func (t *sampleTable) parse(body []byte, track *Track) error {
+	return walkBoxes(body, func(boxType string, body []byte) error {
+		if boxType == "stsd" {
+			return parseStsd(body, track)
+		}
+
+		var entries []byte
+		switch boxType {
+		case "stts", "stsc", "stsz", "stco", "co64":
+			_, body, err := fullBox(body)
+			if err != nil {
+				return err
+			}
+			entries = body
+		default:
+			return nil
+		}
+		if len(entries) < 4 {
+			return ErrInvalidBox
+		}
+
+		switch boxType {
+		case "stts":
+			t.timeToSample = readUint32s(entries[4:], int(binary.BigEndian.Uint32(entries))*2)
+		case "stsc":
+			t.sampleToChunk = readUint32s(entries[4:], int(binary.BigEndian.Uint32(entries))*3)
+		case "stsz":
+			if len(entries) < 8 {
+				return ErrInvalidBox
+			}
+			t.sampleSize = binary.BigEndian.Uint32(entries)
+			t.sampleCount = int(binary.BigEndian.Uint32(entries[4:]))
+			if t.sampleSize == 0 {
+				t.sampleSizes = readUint32s(entries[8:], t.sampleCount)
+				t.sampleCount = len(t.sampleSizes)
+			}
+		case "stco":
+			offsets := readUint32s(entries[4:], int(binary.BigEndian.Uint32(entries)))
+			t.chunkOffsets = make([]int64, len(offsets))
+			for i, offset := range offsets {
+				t.chunkOffsets[i] = int64(offset)
+			}
+		case "co64":
+			count := int(binary.BigEndian.Uint32(entries))
+			entries = entries[4:]
+			if count > len(entries)/8 {
+				count = len(entries) / 8
+			}
+			t.chunkOffsets = make([]int64, count)
+			for i := range t.chunkOffsets {
+				t.chunkOffsets[i] = int64(binary.BigEndian.Uint64(entries[i*8:]))
+			}
+		}
+		return nil
+	})
+}
+
+// build resolves the offset, size and time of every sample.
+func (t *sampleTable) build() []Sample {
+	samples := make([]Sample, 0, t.sampleCount)
+
+	for chunk := range t.chunkOffsets {
+		samplesPerChunk := 0
+		// stsc entries are (first chunk, samples per chunk, sample description index) with 1 based chunk numbers
+		for i := 0; i+2 < len(t.sampleToChunk); i += 3 {
+			if int(t.sampleToChunk[i]) > chunk+1 {
+				break
+			}
+			samplesPerChunk = int(t.sampleToChunk[i+1])
+		}
+
+		offset := t.chunkOffsets[chunk]
+		for i := 0; i < samplesPerChunk && len(samples) < t.sampleCount; i++ {
+			size := int(t.sampleSize)
+			if t.sampleSize == 0 {
+				size = int(t.sampleSizes[len(samples)])
+			}
+			samples = append(samples, Sample{
+				Offset: offset,
+				Size:   size,
+			})
+			offset += int64(size)
+		}
+	}
+
+	var (
+		sample int
+		time   uint64
+	)
+	for i := 0; i+1 < len(t.timeToSample) && sample < len(samples); i += 2 {
+		count, delta := int(t.timeToSample[i]), t.timeToSample[i+1]
+		for j := 0; j < count && sample < len(samples); j++ {
+			samples[sample].Time = time
+			samples[sample].Duration = delta
+			time += uint64(delta)
+			sample++
+		}
+	}
+	return samples
+}
+
+func readUint32s(data []byte, count int) []uint32 {
+	if count > len(data)/4 {
+		count = len(data) / 4
+	}
+	values := make([]uint32, count)
+	for i := range values {
+		values[i] = binary.BigEndian.Uint32(data[i*4:])
+	}
+	return values
+}
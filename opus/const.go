@@ -130,64 +130,437 @@ int opus_get_phase_inversion_disabled(OpusEncoder *st, opus_int32 *phase_inversi
    return opus_encoder_ctl(st, OPUS_GET_PHASE_INVERSION_DISABLED(phase_inversion));
 }
 
-int opus_set_gain(OpusEncoder *st, opus_int32 gain) {
-	return opus_encoder_ctl(st, OPUS_SET_GAIN(gain));
+int opus_get_in_dtx(OpusEncoder *st, opus_int32 *in_dtx) {
+   return opus_encoder_ctl(st, OPUS_GET_IN_DTX(in_dtx));
 }
-int opus_get_gain(OpusEncoder *st, opus_int32 *gain) {
-   return opus_encoder_ctl(st, OPUS_GET_GAIN(gain));
+
+int opus_get_final_range(OpusEncoder *st, opus_uint32 *final_range) {
+   return opus_encoder_ctl(st, OPUS_GET_FINAL_RANGE(final_range));
 }
 
-int opus_get_last_packet_duration(OpusEncoder *st, opus_int32 *duration) {
-   return opus_encoder_ctl(st, OPUS_GET_LAST_PACKET_DURATION(duration));
+int opus_reset_state(OpusEncoder *st) {
+	return opus_encoder_ctl(st, OPUS_RESET_STATE);
 }
 
-int opus_get_pitch(OpusEncoder *st, opus_int32 *pitch) {
-   return opus_encoder_ctl(st, OPUS_GET_PITCH(pitch));
+int opus_decoder_set_gain(OpusDecoder *st, opus_int32 gain) {
+	return opus_decoder_ctl(st, OPUS_SET_GAIN(gain));
+}
+int opus_decoder_get_gain(OpusDecoder *st, opus_int32 *gain) {
+   return opus_decoder_ctl(st, OPUS_GET_GAIN(gain));
 }
 
-int opus_get_in_dtx(OpusEncoder *st, opus_int32 *in_dtx) {
-   return opus_encoder_ctl(st, OPUS_GET_IN_DTX(in_dtx));
+int opus_decoder_get_last_packet_duration(OpusDecoder *st, opus_int32 *duration) {
+   return opus_decoder_ctl(st, OPUS_GET_LAST_PACKET_DURATION(duration));
 }
 
-int opus_get_final_range(OpusEncoder *st, opus_uint32 *final_range) {
-   return opus_encoder_ctl(st, OPUS_GET_FINAL_RANGE(final_range));
+int opus_decoder_get_pitch(OpusDecoder *st, opus_int32 *pitch) {
+   return opus_decoder_ctl(st, OPUS_GET_PITCH(pitch));
 }
 
-int opus_reset_state(OpusEncoder *st) {
-	return opus_encoder_ctl(st, OPUS_RESET_STATE);
+int opus_decoder_get_bandwidth(OpusDecoder *st, opus_int32 *bandwidth) {
+   return opus_decoder_ctl(st, OPUS_GET_BANDWIDTH(bandwidth));
+}
+
+int opus_decoder_set_phase_inversion_disabled(OpusDecoder *st, opus_int32 phase_inversion) {
+	return opus_decoder_ctl(st, OPUS_SET_PHASE_INVERSION_DISABLED(phase_inversion));
+}
+int opus_decoder_get_phase_inversion_disabled(OpusDecoder *st, opus_int32 *phase_inversion) {
+   return opus_decoder_ctl(st, OPUS_GET_PHASE_INVERSION_DISABLED(phase_inversion));
+}
+
+int opus_decoder_get_final_range(OpusDecoder *st, opus_uint32 *final_range) {
+   return opus_decoder_ctl(st, OPUS_GET_FINAL_RANGE(final_range));
+}
+
+int opus_decoder_reset_state(OpusDecoder *st) {
+	return opus_decoder_ctl(st, OPUS_RESET_STATE);
 }
 */
 import "C"
-import "unsafe"
 
 type Macro[T any] func(t *T) C.int
 
+func boolToCInt(b bool) C.opus_int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func SetBitrate(bitrate int) Macro[Encoder] {
 	return func(e *Encoder) C.int {
-		return C.opus_set_bitrate(e.encoder, C.int(bitrate))
+		return C.opus_set_bitrate(e.encoder, C.opus_int32(bitrate))
+	}
+}
+
+func GetBitrate(bitrate *int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_bitrate(e.encoder, &v)
+		*bitrate = int(v)
+		return err
 	}
 }
 
 func GetEncoderSampleRate(sampleRate *int) Macro[Encoder] {
 	return func(e *Encoder) C.int {
-		return C.opus_encoder_get_sample_rate(e.encoder, (*C.int)(unsafe.Pointer(sampleRate)))
+		var v C.opus_int32
+		err := C.opus_encoder_get_sample_rate(e.encoder, &v)
+		*sampleRate = int(v)
+		return err
 	}
 }
 
-func GetDecoderSamplerRte(sampleRate *int) Macro[Decoder] {
-	return func(e *Decoder) C.int {
-		return C.opus_decoder_get_sample_rate(e.decoder, (*C.int)(unsafe.Pointer(sampleRate)))
+func GetDecoderSampleRate(sampleRate *int) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_int32
+		err := C.opus_decoder_get_sample_rate(d.decoder, &v)
+		*sampleRate = int(v)
+		return err
 	}
 }
 
+// Deprecated: use GetDecoderSampleRate instead.
+func GetDecoderSamplerRte(sampleRate *int) Macro[Decoder] {
+	return GetDecoderSampleRate(sampleRate)
+}
+
 func SetComplexity(complexity int) Macro[Encoder] {
 	return func(e *Encoder) C.int {
-		return C.opus_set_complexity(e.encoder, C.int(complexity))
+		return C.opus_set_complexity(e.encoder, C.opus_int32(complexity))
+	}
+}
+
+func GetComplexity(complexity *int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_complexity(e.encoder, &v)
+		*complexity = int(v)
+		return err
 	}
 }
 
+// SetForceChannels forces mono or stereo encoding, use ChannelsAuto to let the encoder decide.
 func SetForceChannels(channels int) Macro[Encoder] {
 	return func(e *Encoder) C.int {
 		return C.opus_set_force_channels(e.encoder, C.opus_int32(channels))
 	}
 }
+
+func GetForceChannels(channels *int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_force_channels(e.encoder, &v)
+		*channels = int(v)
+		return err
+	}
+}
+
+func SetVBR(vbr bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_vbr(e.encoder, boolToCInt(vbr))
+	}
+}
+
+func GetVBR(vbr *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_vbr(e.encoder, &v)
+		*vbr = v != 0
+		return err
+	}
+}
+
+func SetVBRConstraint(constrained bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_vbr_constraint(e.encoder, boolToCInt(constrained))
+	}
+}
+
+func GetVBRConstraint(constrained *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_vbr_constraint(e.encoder, &v)
+		*constrained = v != 0
+		return err
+	}
+}
+
+func SetMaxBandwidth(bandwidth Bandwidth) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_max_bandwidth(e.encoder, C.opus_int32(bandwidth))
+	}
+}
+
+func GetMaxBandwidth(bandwidth *Bandwidth) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_max_bandwidth(e.encoder, &v)
+		*bandwidth = Bandwidth(v)
+		return err
+	}
+}
+
+func SetBandwidth(bandwidth Bandwidth) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_bandwidth(e.encoder, C.opus_int32(bandwidth))
+	}
+}
+
+func GetBandwidth(bandwidth *Bandwidth) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_bandwidth(e.encoder, &v)
+		*bandwidth = Bandwidth(v)
+		return err
+	}
+}
+
+func SetSignal(signal Signal) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_signal(e.encoder, C.opus_int32(signal))
+	}
+}
+
+func GetSignal(signal *Signal) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_signal(e.encoder, &v)
+		*signal = Signal(v)
+		return err
+	}
+}
+
+func SetApplication(application Application) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_application(e.encoder, C.opus_int32(application))
+	}
+}
+
+func GetApplication(application *Application) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_application(e.encoder, &v)
+		*application = Application(v)
+		return err
+	}
+}
+
+// GetLookahead returns the number of samples the encoder adds as delay at the start of the stream.
+func GetLookahead(lookahead *int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_LOOKAHEAD(e.encoder, &v)
+		*lookahead = int(v)
+		return err
+	}
+}
+
+func SetInbandFEC(fec bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_inband_fec(e.encoder, boolToCInt(fec))
+	}
+}
+
+func GetInbandFEC(fec *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_inband_fec(e.encoder, &v)
+		*fec = v != 0
+		return err
+	}
+}
+
+// SetPacketLossPerc sets the expected packet loss in percent (0-100).
+func SetPacketLossPerc(perc int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_packet_loss_perc(e.encoder, C.opus_int32(perc))
+	}
+}
+
+func GetPacketLossPerc(perc *int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_packet_loss_perc(e.encoder, &v)
+		*perc = int(v)
+		return err
+	}
+}
+
+func SetDTX(dtx bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_dtx(e.encoder, boolToCInt(dtx))
+	}
+}
+
+func GetDTX(dtx *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_dtx(e.encoder, &v)
+		*dtx = v != 0
+		return err
+	}
+}
+
+// GetInDTX reports whether the last encoded frame was a DTX frame.
+func GetInDTX(inDTX *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_in_dtx(e.encoder, &v)
+		*inDTX = v != 0
+		return err
+	}
+}
+
+// SetLSBDepth sets the bit depth of the input signal (8-24).
+func SetLSBDepth(depth int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_lsb_depth(e.encoder, C.opus_int32(depth))
+	}
+}
+
+func GetLSBDepth(depth *int) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_lsb_depth(e.encoder, &v)
+		*depth = int(v)
+		return err
+	}
+}
+
+func SetExpertFrameDuration(duration FrameDuration) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_expert_frame_duration(e.encoder, C.opus_int32(duration))
+	}
+}
+
+func GetExpertFrameDuration(duration *FrameDuration) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_expert_frame_duration(e.encoder, &v)
+		*duration = FrameDuration(v)
+		return err
+	}
+}
+
+func SetPredictionDisabled(disabled bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_prediction_disabled(e.encoder, boolToCInt(disabled))
+	}
+}
+
+func GetPredictionDisabled(disabled *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_prediction_disabled(e.encoder, &v)
+		*disabled = v != 0
+		return err
+	}
+}
+
+func SetPhaseInversionDisabled(disabled bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_set_phase_inversion_disabled(e.encoder, boolToCInt(disabled))
+	}
+}
+
+func GetPhaseInversionDisabled(disabled *bool) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_int32
+		err := C.opus_get_phase_inversion_disabled(e.encoder, &v)
+		*disabled = v != 0
+		return err
+	}
+}
+
+// GetFinalRange returns the final state of the range coder after the last encoded packet.
+func GetFinalRange(finalRange *uint32) Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		var v C.opus_uint32
+		err := C.opus_get_final_range(e.encoder, &v)
+		*finalRange = uint32(v)
+		return err
+	}
+}
+
+// ResetState resets the encoder to the state of a freshly initialized one.
+func ResetState() Macro[Encoder] {
+	return func(e *Encoder) C.int {
+		return C.opus_reset_state(e.encoder)
+	}
+}
+
+// SetGain sets the decoder output gain in Q8 dB units (-32768 to 32767).
+func SetGain(gain int) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		return C.opus_decoder_set_gain(d.decoder, C.opus_int32(gain))
+	}
+}
+
+func GetGain(gain *int) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_int32
+		err := C.opus_decoder_get_gain(d.decoder, &v)
+		*gain = int(v)
+		return err
+	}
+}
+
+// GetLastPacketDuration returns the duration in samples per channel of the last decoded packet.
+func GetLastPacketDuration(duration *int) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_int32
+		err := C.opus_decoder_get_last_packet_duration(d.decoder, &v)
+		*duration = int(v)
+		return err
+	}
+}
+
+// GetPitch returns the pitch period in samples of the last decoded frame, or 0 if not available.
+func GetPitch(pitch *int) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_int32
+		err := C.opus_decoder_get_pitch(d.decoder, &v)
+		*pitch = int(v)
+		return err
+	}
+}
+
+func GetDecoderBandwidth(bandwidth *Bandwidth) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_int32
+		err := C.opus_decoder_get_bandwidth(d.decoder, &v)
+		*bandwidth = Bandwidth(v)
+		return err
+	}
+}
+
+func SetDecoderPhaseInversionDisabled(disabled bool) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		return C.opus_decoder_set_phase_inversion_disabled(d.decoder, boolToCInt(disabled))
+	}
+}
+
+func GetDecoderPhaseInversionDisabled(disabled *bool) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_int32
+		err := C.opus_decoder_get_phase_inversion_disabled(d.decoder, &v)
+		*disabled = v != 0
+		return err
+	}
+}
+
+func GetDecoderFinalRange(finalRange *uint32) Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		var v C.opus_uint32
+		err := C.opus_decoder_get_final_range(d.decoder, &v)
+		*finalRange = uint32(v)
+		return err
+	}
+}
+
+func ResetDecoderState() Macro[Decoder] {
+	return func(d *Decoder) C.int {
+		return C.opus_decoder_reset_state(d.decoder)
+	}
+}
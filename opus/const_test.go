@@ -0,0 +1,205 @@
+package opus
+
+import (
+	"errors"
+	"testing"
+)
+
+// encoderCtl returns a func reading a value of the Encoder with the given getter.
+func encoderCtl[V any](get func(*V) Macro[Encoder]) func(e *Encoder) (any, error) {
+	return func(e *Encoder) (any, error) {
+		var v V
+		err := e.Ctl(get(&v))
+		return v, err
+	}
+}
+
+// decoderCtl returns a func reading a value of the Decoder with the given getter.
+func decoderCtl[V any](get func(*V) Macro[Decoder]) func(d *Decoder) (any, error) {
+	return func(d *Decoder) (any, error) {
+		var v V
+		err := d.Ctl(get(&v))
+		return v, err
+	}
+}
+
+func TestEncoderCtlRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  Macro[Encoder]
+		get  func(e *Encoder) (any, error)
+		want any
+	}{
+		{"bitrate", SetBitrate(32000), encoderCtl(GetBitrate), 32000},
+		{"complexity", SetComplexity(5), encoderCtl(GetComplexity), 5},
+		{"force channels", SetForceChannels(1), encoderCtl(GetForceChannels), 1},
+		{"vbr", SetVBR(false), encoderCtl(GetVBR), false},
+		{"vbr constraint", SetVBRConstraint(false), encoderCtl(GetVBRConstraint), false},
+		{"max bandwidth", SetMaxBandwidth(BandwidthWideband), encoderCtl(GetMaxBandwidth), BandwidthWideband},
+		{"signal", SetSignal(SignalVoice), encoderCtl(GetSignal), SignalVoice},
+		{"application", SetApplication(ApplicationVoip), encoderCtl(GetApplication), ApplicationVoip},
+		{"inband fec", SetInbandFEC(true), encoderCtl(GetInbandFEC), true},
+		{"packet loss", SetPacketLossPerc(10), encoderCtl(GetPacketLossPerc), 10},
+		{"dtx", SetDTX(true), encoderCtl(GetDTX), true},
+		{"lsb depth", SetLSBDepth(16), encoderCtl(GetLSBDepth), 16},
+		{"frame duration", SetExpertFrameDuration(FrameDuration10ms), encoderCtl(GetExpertFrameDuration), FrameDuration10ms},
+		{"prediction disabled", SetPredictionDisabled(true), encoderCtl(GetPredictionDisabled), true},
+		{"phase inversion disabled", SetPhaseInversionDisabled(true), encoderCtl(GetPhaseInversionDisabled), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder, err := NewEncoder(48000, 2, ApplicationAudio)
+			if err != nil {
+				t.Fatalf("create encoder: %v", err)
+			}
+			defer encoder.Destroy()
+
+			if err = encoder.Ctl(tt.set); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			got, err := tt.get(encoder)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderCtlGetters(t *testing.T) {
+	encoder, err := NewEncoder(48000, 2, ApplicationAudio)
+	if err != nil {
+		t.Fatalf("create encoder: %v", err)
+	}
+	defer encoder.Destroy()
+
+	if err = encoder.Ctl(SetBandwidth(BandwidthNarrowband)); err != nil {
+		t.Fatalf("set bandwidth: %v", err)
+	}
+	if _, err = encoder.Encode(make([]int16, 960*2), make([]byte, 1275)); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	var bandwidth Bandwidth
+	if err = encoder.Ctl(GetBandwidth(&bandwidth)); err != nil || bandwidth != BandwidthNarrowband {
+		t.Fatalf("got bandwidth %s, %v, want %s", bandwidth, err, BandwidthNarrowband)
+	}
+	var lookahead int
+	if err = encoder.Ctl(GetLookahead(&lookahead)); err != nil || lookahead <= 0 {
+		t.Fatalf("got lookahead %d, %v, want a positive lookahead", lookahead, err)
+	}
+	var inDTX bool
+	if err = encoder.Ctl(GetInDTX(&inDTX)); err != nil {
+		t.Fatalf("get in dtx: %v", err)
+	}
+	var finalRange uint32
+	if err = encoder.Ctl(GetFinalRange(&finalRange)); err != nil {
+		t.Fatalf("get final range: %v", err)
+	}
+	if sampleRate, err := encoder.SampleRate(); err != nil || sampleRate != 48000 {
+		t.Fatalf("got sample rate %d, %v, want 48000", sampleRate, err)
+	}
+
+	if err = encoder.Ctl(SetBitrate(16000)); err != nil {
+		t.Fatalf("set bitrate: %v", err)
+	}
+	if err = encoder.Ctl(ResetState()); err != nil {
+		t.Fatalf("reset state: %v", err)
+	}
+	var bitrate int
+	if err = encoder.Ctl(GetBitrate(&bitrate)); err != nil || bitrate != 16000 {
+		t.Fatalf("got bitrate %d, %v after reset, want the configured 16000", bitrate, err)
+	}
+}
+
+func TestDecoderCtlRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  Macro[Decoder]
+		get  func(d *Decoder) (any, error)
+		want any
+	}{
+		{"gain", SetGain(256), decoderCtl(GetGain), 256},
+		{"phase inversion disabled", SetDecoderPhaseInversionDisabled(true), decoderCtl(GetDecoderPhaseInversionDisabled), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, err := NewDecoder(48000, 2)
+			if err != nil {
+				t.Fatalf("create decoder: %v", err)
+			}
+			defer decoder.Destroy()
+
+			if err = decoder.Ctl(tt.set); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			got, err := tt.get(decoder)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoderCtlGetters(t *testing.T) {
+	encoder, err := NewEncoder(48000, 2, ApplicationAudio)
+	if err != nil {
+		t.Fatalf("create encoder: %v", err)
+	}
+	defer encoder.Destroy()
+	decoder, err := NewDecoder(48000, 2)
+	if err != nil {
+		t.Fatalf("create decoder: %v", err)
+	}
+	defer decoder.Destroy()
+
+	packet := make([]byte, 1275)
+	n, err := encoder.Encode(make([]int16, 960*2), packet)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if _, err = decoder.Decode(packet[:n], make([]int16, 960*2), false); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	var duration int
+	if err = decoder.Ctl(GetLastPacketDuration(&duration)); err != nil || duration != 960 {
+		t.Fatalf("got last packet duration %d, %v, want 960", duration, err)
+	}
+	var pitch int
+	if err = decoder.Ctl(GetPitch(&pitch)); err != nil {
+		t.Fatalf("get pitch: %v", err)
+	}
+	var bandwidth Bandwidth
+	if err = decoder.Ctl(GetDecoderBandwidth(&bandwidth)); err != nil || bandwidth == BandwidthAuto {
+		t.Fatalf("got bandwidth %s, %v, want the bandwidth of the packet", bandwidth, err)
+	}
+	var encoderRange, decoderRange uint32
+	if err = encoder.Ctl(GetFinalRange(&encoderRange)); err != nil {
+		t.Fatalf("get encoder final range: %v", err)
+	}
+	if err = decoder.Ctl(GetDecoderFinalRange(&decoderRange)); err != nil || decoderRange != encoderRange {
+		t.Fatalf("got decoder final range %d, %v, want the encoder final range %d", decoderRange, err, encoderRange)
+	}
+	var sampleRate int
+	if err = decoder.Ctl(GetDecoderSampleRate(&sampleRate)); err != nil || sampleRate != 48000 {
+		t.Fatalf("got sample rate %d, %v, want 48000", sampleRate, err)
+	}
+	if err = decoder.Ctl(ResetDecoderState()); err != nil {
+		t.Fatalf("reset state: %v", err)
+	}
+}
+
+func TestCtlNotInitialized(t *testing.T) {
+	var bitrate int
+	if err := (&Encoder{}).Ctl(GetBitrate(&bitrate)); !errors.Is(err, ErrEncoderNotInitialized) {
+		t.Fatalf("got %v, want %v", err, ErrEncoderNotInitialized)
+	}
+	var gain int
+	if err := (&Decoder{}).Ctl(GetGain(&gain)); !errors.Is(err, ErrDecoderNotInitialized) {
+		t.Fatalf("got %v, want %v", err, ErrDecoderNotInitialized)
+	}
+}
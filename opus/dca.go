@@ -0,0 +1,254 @@
+package opus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/disgoorg/disgo/voice"
+)
+
+// DCAMagic is the magic number of DCA1 files.
+const DCAMagic = "DCA1"
+
+// ErrInvalidDCAFrame is returned when a DCA frame has a negative length.
+var ErrInvalidDCAFrame = errors.New("invalid dca frame")
+
+// dcaFrameInterval is the time DCAWriter.WriteFrames waits after a nil frame before asking the provider again.
+const dcaFrameInterval = 20 * time.Millisecond
+
+// DCAMetadata is the JSON metadata header of a DCA1 file.
+type DCAMetadata struct {
+	DCA    DCAInfo        `json:"dca"`
+	Opus   DCAOpusInfo    `json:"opus"`
+	Info   *DCASongInfo   `json:"info,omitempty"`
+	Origin *DCAOrigin     `json:"origin,omitempty"`
+	Extra  map[string]any `json:"extra"`
+}
+
+// DCAInfo describes the DCA version and the tool which created the file.
+type DCAInfo struct {
+	Version int     `json:"version"`
+	Tool    DCATool `json:"tool"`
+}
+
+// DCATool is the tool which created a DCA file.
+type DCATool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url,omitempty"`
+	Author  string `json:"author,omitempty"`
+}
+
+// DCAOpusInfo describes the encoder settings of the Opus frames.
+type DCAOpusInfo struct {
+	// Mode is either "voip", "audio" or "lowdelay".
+	Mode       string `json:"mode"`
+	SampleRate int    `json:"sample_rate"`
+	// FrameSize is the amount of samples per channel in each frame.
+	FrameSize int  `json:"frame_size"`
+	Bitrate   int  `json:"abr"`
+	VBR       bool `json:"vbr"`
+	Channels  int  `json:"channels"`
+}
+
+// DCASongInfo holds information about the encoded song.
+type DCASongInfo struct {
+	Title    string `json:"title,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	Album    string `json:"album,omitempty"`
+	Genre    string `json:"genre,omitempty"`
+	Comments string `json:"comments,omitempty"`
+	// Cover is the base64 encoded cover art.
+	Cover string `json:"cover,omitempty"`
+}
+
+// DCAOrigin describes the source the DCA file was encoded from.
+type DCAOrigin struct {
+	Source   string `json:"source,omitempty"`
+	Bitrate  int    `json:"abr,omitempty"`
+	Channels int    `json:"channels,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// DefaultDCAMetadata returns the DCAMetadata of frames created by the default encoder of pcm.NewOpusProvider.
+func DefaultDCAMetadata() *DCAMetadata {
+	return &DCAMetadata{
+		DCA: DCAInfo{
+			Version: 1,
+			Tool: DCATool{
+				Name: "disgoorg/audio",
+				URL:  "https://github.com/disgoorg/audio",
+			},
+		},
+		Opus: DCAOpusInfo{
+			Mode:       "audio",
+			SampleRate: 48000,
+			FrameSize:  960,
+			Bitrate:    64000,
+			VBR:        true,
+			Channels:   2,
+		},
+		Extra: map[string]any{},
+	}
+}
+
+// DCAFrameProvider is a voice.OpusFrameProvider which reads frames from a DCA file.
+type DCAFrameProvider interface {
+	voice.OpusFrameProvider
+
+	// Metadata returns the metadata of a DCA1 file or nil for DCA0 files.
+	Metadata() *DCAMetadata
+}
+
+// NewDCAFrameProvider returns a DCAFrameProvider which reads the frames of the given DCA0 or DCA1 file.
+// DCA1 files start with DCAMagic followed by the JSON metadata, DCA0 files only contain frames.
+// ProvideOpusFrame returns io.EOF after the last frame.
+func NewDCAFrameProvider(r io.Reader) (DCAFrameProvider, error) {
+	br := bufio.NewReader(r)
+	p := &dcaFrameProvider{
+		reader: br,
+	}
+
+	magic, err := br.Peek(len(DCAMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(magic, []byte(DCAMagic)) {
+		return p, nil
+	}
+	if _, err = br.Discard(len(DCAMagic)); err != nil {
+		return nil, err
+	}
+
+	var length int32
+	if err = binary.Read(br, binary.LittleEndian, &length); err != nil {
+		return nil, fmt.Errorf("failed to read dca metadata length: %w", err)
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("invalid dca metadata length: %d", length)
+	}
+	lr := &io.LimitedReader{R: br, N: int64(length)}
+	if err = json.NewDecoder(lr).Decode(&p.metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode dca metadata: %w", err)
+	}
+	// skip whatever follows the JSON value, like trailing whitespace, so the frames start at the right offset
+	if _, err = io.Copy(io.Discard, lr); err != nil {
+		return nil, fmt.Errorf("failed to read dca metadata: %w", err)
+	}
+	if lr.N > 0 {
+		return nil, fmt.Errorf("failed to read dca metadata: %w", io.ErrUnexpectedEOF)
+	}
+	return p, nil
+}
+
+type dcaFrameProvider struct {
+	reader   *bufio.Reader
+	metadata *DCAMetadata
+	frame    []byte
+}
+
+func (p *dcaFrameProvider) ProvideOpusFrame() ([]byte, error) {
+	var length int16
+	if err := binary.Read(p.reader, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, ErrInvalidDCAFrame
+	}
+	if cap(p.frame) < int(length) {
+		p.frame = make([]byte, length)
+	}
+	p.frame = p.frame[:length]
+	if _, err := io.ReadFull(p.reader, p.frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return p.frame, nil
+}
+
+func (p *dcaFrameProvider) Metadata() *DCAMetadata {
+	return p.metadata
+}
+
+func (p *dcaFrameProvider) Close() {}
+
+// NewDCAWriter writes the DCA1 header with the given metadata to w and returns a DCAWriter to write the frames.
+// If metadata is nil, a DCA0 file without header is written.
+func NewDCAWriter(w io.Writer, metadata *DCAMetadata) (*DCAWriter, error) {
+	if metadata != nil {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode dca metadata: %w", err)
+		}
+		header := make([]byte, len(DCAMagic)+4, len(DCAMagic)+4+len(data))
+		copy(header, DCAMagic)
+		binary.LittleEndian.PutUint32(header[len(DCAMagic):], uint32(len(data)))
+		if _, err = w.Write(append(header, data...)); err != nil {
+			return nil, err
+		}
+	}
+	return &DCAWriter{
+		writer: w,
+	}, nil
+}
+
+// DCAWriter writes length prefixed Opus frames.
+type DCAWriter struct {
+	writer io.Writer
+	buff   []byte
+}
+
+// WriteFrame writes a single Opus frame.
+func (w *DCAWriter) WriteFrame(frame []byte) error {
+	if len(frame) > math.MaxInt16 {
+		return ErrInvalidDCAFrame
+	}
+	w.buff = append(w.buff[:0], 0, 0)
+	binary.LittleEndian.PutUint16(w.buff, uint16(len(frame)))
+	w.buff = append(w.buff, frame...)
+	_, err := w.writer.Write(w.buff)
+	return err
+}
+
+// WriteFrames writes all frames of the given voice.OpusFrameProvider until it returns io.EOF and returns the amount of written frames.
+// Nil frames, which the provider returns while it is paused or buffering, are skipped after waiting for one frame interval.
+func (w *DCAWriter) WriteFrames(provider voice.OpusFrameProvider) (int, error) {
+	var frames int
+	for {
+		frame, err := provider.ProvideOpusFrame()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+		if frame == nil {
+			time.Sleep(dcaFrameInterval)
+			continue
+		}
+		if err = w.WriteFrame(frame); err != nil {
+			return frames, err
+		}
+		frames++
+	}
+}
+
+// WriteDCA writes all frames of the given voice.OpusFrameProvider to w as DCA1 file with the given metadata or as DCA0 file if metadata is nil.
+func WriteDCA(w io.Writer, provider voice.OpusFrameProvider, metadata *DCAMetadata) error {
+	writer, err := NewDCAWriter(w, metadata)
+	if err != nil {
+		return err
+	}
+	_, err = writer.WriteFrames(provider)
+	return err
+}
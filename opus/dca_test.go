@@ -0,0 +1,140 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// frameProvider provides the given frames in order and io.EOF afterwards.
+type frameProvider struct {
+	frames [][]byte
+}
+
+func (p *frameProvider) ProvideOpusFrame() ([]byte, error) {
+	if len(p.frames) == 0 {
+		return nil, io.EOF
+	}
+	frame := p.frames[0]
+	p.frames = p.frames[1:]
+	return frame, nil
+}
+
+func (p *frameProvider) Close() {}
+
+func readDCAFrames(t *testing.T, provider DCAFrameProvider) [][]byte {
+	t.Helper()
+	var frames [][]byte
+	for {
+		frame, err := provider.ProvideOpusFrame()
+		if err == io.EOF {
+			return frames
+		}
+		if err != nil {
+			t.Fatalf("read frame %d: %v", len(frames), err)
+		}
+		frames = append(frames, append([]byte(nil), frame...))
+	}
+}
+
+func TestDCARoundTrip(t *testing.T) {
+	frames := [][]byte{{0xF8, 0xFF, 0xFE}, {1, 2, 3, 4}, {}}
+	for _, metadata := range []*DCAMetadata{nil, DefaultDCAMetadata()} {
+		buf := &bytes.Buffer{}
+		if err := WriteDCA(buf, &frameProvider{frames: frames}, metadata); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+
+		provider, err := NewDCAFrameProvider(buf)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if (provider.Metadata() == nil) != (metadata == nil) {
+			t.Fatalf("got metadata %v, want %v", provider.Metadata(), metadata)
+		}
+		if metadata != nil && provider.Metadata().Opus != metadata.Opus {
+			t.Fatalf("got opus info %+v, want %+v", provider.Metadata().Opus, metadata.Opus)
+		}
+		got := readDCAFrames(t, provider)
+		if len(got) != len(frames) {
+			t.Fatalf("read %d frames, want %d", len(got), len(frames))
+		}
+		for i := range frames {
+			if !bytes.Equal(got[i], frames[i]) {
+				t.Fatalf("frame %d is %v, want %v", i, got[i], frames[i])
+			}
+		}
+	}
+}
+
+func TestDCAMetadataTrailingWhitespace(t *testing.T) {
+	header := []byte(`{"dca":{"version":1,"tool":{"name":"test","version":"1"}}}` + "\n\n")
+	buf := &bytes.Buffer{}
+	buf.WriteString(DCAMagic)
+	_ = binary.Write(buf, binary.LittleEndian, int32(len(header)))
+	buf.Write(header)
+	_ = binary.Write(buf, binary.LittleEndian, int16(2))
+	buf.Write([]byte{7, 8})
+
+	provider, err := NewDCAFrameProvider(buf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if provider.Metadata().DCA.Tool.Name != "test" {
+		t.Fatalf("got tool %q, want test", provider.Metadata().DCA.Tool.Name)
+	}
+	frames := readDCAFrames(t, provider)
+	if len(frames) != 1 || !bytes.Equal(frames[0], []byte{7, 8}) {
+		t.Fatalf("got frames %v, want [[7 8]]", frames)
+	}
+}
+
+func TestDCAMetadataTruncated(t *testing.T) {
+	header := []byte(`{"dca":{"version":1}}`)
+	buf := &bytes.Buffer{}
+	buf.WriteString(DCAMagic)
+	_ = binary.Write(buf, binary.LittleEndian, int32(len(header)+10))
+	buf.Write(header)
+
+	if _, err := NewDCAFrameProvider(buf); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+// pausedProvider returns nil frames until the given number of calls was made.
+type pausedProvider struct {
+	nilFrames int
+	calls     int
+}
+
+func (p *pausedProvider) ProvideOpusFrame() ([]byte, error) {
+	p.calls++
+	if p.calls <= p.nilFrames {
+		return nil, nil
+	}
+	if p.calls == p.nilFrames+1 {
+		return []byte{1}, nil
+	}
+	return nil, io.EOF
+}
+
+func (p *pausedProvider) Close() {}
+
+func TestDCAWriterWaitsOnNilFrames(t *testing.T) {
+	writer, err := NewDCAWriter(io.Discard, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	provider := &pausedProvider{nilFrames: 3}
+	start := time.Now()
+	frames, err := writer.WriteFrames(provider)
+	if err != nil || frames != 1 {
+		t.Fatalf("got %d, %v, want 1, nil", frames, err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*dcaFrameInterval {
+		t.Fatalf("skipped 3 nil frames in %s, want at least %s", elapsed, 3*dcaFrameInterval)
+	}
+}
@@ -0,0 +1,290 @@
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Bitrates available to guilds depending on their boost level.
+const (
+	BitrateDefault     = 64000
+	BitrateMusic       = 96000
+	BitrateBoostLevel1 = 128000
+	BitrateBoostLevel2 = 256000
+	BitrateBoostLevel3 = 384000
+)
+
+// DefaultEncoderConfig returns the EncoderConfig used when no *Encoder is passed to pcm.NewOpusProvider (48000hz sample rate, 2 channels, ApplicationAudio & 64kbps bitrate).
+func DefaultEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		SampleRate:     48000,
+		Channels:       2,
+		Application:    ApplicationAudio,
+		Bitrate:        BitrateDefault,
+		Complexity:     10,
+		Signal:         SignalAuto,
+		MaxBandwidth:   BandwidthFullband,
+		VBR:            true,
+		VBRConstraint:  true,
+		InbandFEC:      false,
+		PacketLossPerc: 0,
+		DTX:            false,
+	}
+}
+
+// VoiceEncoderConfig returns an EncoderConfig tuned for speech with inband FEC enabled.
+func VoiceEncoderConfig() EncoderConfig {
+	config := DefaultEncoderConfig()
+	config.Application = ApplicationVoip
+	config.Signal = SignalVoice
+	config.InbandFEC = true
+	config.PacketLossPerc = 5
+	return config
+}
+
+// MusicEncoderConfig returns an EncoderConfig tuned for music at the maximum bitrate of guilds without boosts.
+func MusicEncoderConfig() EncoderConfig {
+	config := DefaultEncoderConfig()
+	config.Bitrate = BitrateMusic
+	config.Signal = SignalMusic
+	return config
+}
+
+// HighQualityMusicEncoderConfig returns an EncoderConfig tuned for music at the given bitrate.
+// Use BitrateBoostLevel1, BitrateBoostLevel2 or BitrateBoostLevel3 depending on the boost level of the guild.
+func HighQualityMusicEncoderConfig(bitrate int) EncoderConfig {
+	config := MusicEncoderConfig()
+	config.Bitrate = bitrate
+	config.VBRConstraint = false
+	return config
+}
+
+// EncoderConfig describes all settings of an Encoder.
+type EncoderConfig struct {
+	SampleRate  int
+	Channels    int
+	Application Application
+
+	// Bitrate in bits per second, Auto or BitrateMax.
+	Bitrate int
+	// Complexity from 0 to 10.
+	Complexity    int
+	Signal        Signal
+	MaxBandwidth  Bandwidth
+	VBR           bool
+	VBRConstraint bool
+
+	InbandFEC bool
+	// PacketLossPerc is the expected packet loss in percent (0-100).
+	PacketLossPerc int
+	DTX            bool
+
+	// Adaptive enables adjusting the bitrate, FEC and packet loss percentage at runtime. It is ignored by NewEncoder.
+	Adaptive *AdaptiveConfig
+}
+
+// NewEncoder creates a new *Encoder and applies the EncoderConfig to it.
+func (c EncoderConfig) NewEncoder() (*Encoder, error) {
+	encoder, err := NewEncoder(c.SampleRate, c.Channels, c.Application)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create opus encoder: %w", err)
+	}
+	if err = c.Apply(encoder); err != nil {
+		encoder.Destroy()
+		return nil, err
+	}
+	return encoder, nil
+}
+
+// Apply sets all settings of the EncoderConfig except the sample rate and channels on the given *Encoder.
+func (c EncoderConfig) Apply(encoder *Encoder) error {
+	ctls := []struct {
+		name  string
+		macro Macro[Encoder]
+	}{
+		{"application", SetApplication(c.Application)},
+		{"bitrate", SetBitrate(c.Bitrate)},
+		{"complexity", SetComplexity(c.Complexity)},
+		{"signal", SetSignal(c.Signal)},
+		{"max bandwidth", SetMaxBandwidth(c.MaxBandwidth)},
+		{"vbr", SetVBR(c.VBR)},
+		{"vbr constraint", SetVBRConstraint(c.VBRConstraint)},
+		{"inband fec", SetInbandFEC(c.InbandFEC)},
+		{"packet loss percentage", SetPacketLossPerc(c.PacketLossPerc)},
+		{"dtx", SetDTX(c.DTX)},
+	}
+	for _, ctl := range ctls {
+		if err := encoder.Ctl(ctl.macro); err != nil {
+			return fmt.Errorf("failed to set opus %s: %w", ctl.name, err)
+		}
+	}
+	return nil
+}
+
+// NetworkStats is the connection quality reported to an AdaptiveController.
+type NetworkStats struct {
+	// PacketLoss is the fraction of lost packets from 0 to 1.
+	PacketLoss float64
+	RTT        time.Duration
+}
+
+// NetworkStatsFunc returns the current NetworkStats of the voice connection.
+type NetworkStatsFunc func() NetworkStats
+
+// DefaultAdaptiveConfig returns an AdaptiveConfig which adapts the bitrate between 16kbps and the given maximum bitrate.
+// Auto and BitrateMax fall back to BitrateMusic.
+func DefaultAdaptiveConfig(statsFunc NetworkStatsFunc, maxBitrate int) *AdaptiveConfig {
+	if maxBitrate <= 0 {
+		maxBitrate = BitrateMusic
+	}
+	return &AdaptiveConfig{
+		StatsFunc:     statsFunc,
+		Interval:      time.Second,
+		MinBitrate:    16000,
+		MaxBitrate:    maxBitrate,
+		BitrateStep:   8000,
+		BackoffFactor: 0.75,
+		LossThreshold: 0.05,
+		RTTThreshold:  400 * time.Millisecond,
+		FECThreshold:  0.01,
+		MaxLossPerc:   30,
+		LossSmoothing: 0.3,
+	}
+}
+
+// AdaptiveConfig configures an AdaptiveController.
+type AdaptiveConfig struct {
+	// StatsFunc is called every Interval to get the current NetworkStats.
+	StatsFunc NetworkStatsFunc
+	Interval  time.Duration
+
+	MinBitrate int
+	MaxBitrate int
+	// BitrateStep is added to the bitrate while the connection is good.
+	BitrateStep int
+	// BackoffFactor is multiplied with the bitrate when the packet loss or rtt exceeds their threshold.
+	BackoffFactor float64
+
+	LossThreshold float64
+	RTTThreshold  time.Duration
+	// FECThreshold is the smoothed packet loss above which inband FEC is enabled.
+	FECThreshold float64
+	// MaxLossPerc caps the packet loss percentage reported to the encoder.
+	MaxLossPerc int
+	// LossSmoothing is the weight of the newest packet loss sample from 0 to 1.
+	LossSmoothing float64
+}
+
+// ErrNoNetworkStatsFunc is returned by NewAdaptiveController if the AdaptiveConfig has no StatsFunc.
+var ErrNoNetworkStatsFunc = errors.New("adaptive config has no network stats func")
+
+// NewAdaptiveController creates a new *AdaptiveController starting at the given bitrate.
+// The StatsFunc of the AdaptiveConfig is required, an unset Interval, MinBitrate, MaxBitrate and BackoffFactor fall back to the values of DefaultAdaptiveConfig.
+func NewAdaptiveController(config AdaptiveConfig, bitrate int) (*AdaptiveController, error) {
+	if config.StatsFunc == nil {
+		return nil, ErrNoNetworkStatsFunc
+	}
+	defaultConfig := DefaultAdaptiveConfig(config.StatsFunc, config.MaxBitrate)
+	if config.Interval <= 0 {
+		config.Interval = defaultConfig.Interval
+	}
+	if config.MaxBitrate <= 0 {
+		config.MaxBitrate = defaultConfig.MaxBitrate
+	}
+	if config.MinBitrate <= 0 {
+		config.MinBitrate = defaultConfig.MinBitrate
+	}
+	if config.MinBitrate > config.MaxBitrate {
+		config.MinBitrate = config.MaxBitrate
+	}
+	// a factor of 1 or more would never back off
+	if config.BackoffFactor <= 0 || config.BackoffFactor >= 1 {
+		config.BackoffFactor = defaultConfig.BackoffFactor
+	}
+
+	if bitrate < config.MinBitrate {
+		bitrate = config.MinBitrate
+	} else if bitrate > config.MaxBitrate {
+		bitrate = config.MaxBitrate
+	}
+	return &AdaptiveController{
+		config:   config,
+		bitrate:  bitrate,
+		lossPerc: -1,
+	}, nil
+}
+
+// AdaptiveController adjusts the bitrate, inband FEC and packet loss percentage of an Encoder based on the NetworkStats.
+// The bitrate is increased additively while the connection is good and decreased multiplicatively when it is not.
+type AdaptiveController struct {
+	config     AdaptiveConfig
+	lastUpdate time.Time
+	loss       float64
+	bitrate    int
+	fec        bool
+	lossPerc   int
+}
+
+// Bitrate returns the current target bitrate.
+func (c *AdaptiveController) Bitrate() int {
+	return c.bitrate
+}
+
+// Adapt polls the NetworkStats if the Interval has passed and applies the new settings to the given *Encoder.
+// It should be called before each Encoder.Encode.
+func (c *AdaptiveController) Adapt(encoder *Encoder) error {
+	now := time.Now()
+	if !c.lastUpdate.IsZero() && now.Sub(c.lastUpdate) < c.config.Interval {
+		return nil
+	}
+	first := c.lastUpdate.IsZero()
+	c.lastUpdate = now
+
+	stats := c.config.StatsFunc()
+	loss := math.Min(math.Max(stats.PacketLoss, 0), 1)
+	if first {
+		c.loss = loss
+	} else {
+		c.loss += c.config.LossSmoothing * (loss - c.loss)
+	}
+
+	bitrate := c.bitrate
+	if c.loss > c.config.LossThreshold || (c.config.RTTThreshold > 0 && stats.RTT > c.config.RTTThreshold) {
+		bitrate = int(float64(bitrate) * c.config.BackoffFactor)
+	} else {
+		bitrate += c.config.BitrateStep
+	}
+	if bitrate < c.config.MinBitrate {
+		bitrate = c.config.MinBitrate
+	} else if bitrate > c.config.MaxBitrate {
+		bitrate = c.config.MaxBitrate
+	}
+
+	lossPerc := int(math.Round(c.loss * 100))
+	if lossPerc > c.config.MaxLossPerc {
+		lossPerc = c.config.MaxLossPerc
+	}
+	fec := c.loss > c.config.FECThreshold
+
+	if first || bitrate != c.bitrate {
+		if err := encoder.Ctl(SetBitrate(bitrate)); err != nil {
+			return fmt.Errorf("failed to set opus bitrate: %w", err)
+		}
+		c.bitrate = bitrate
+	}
+	if first || fec != c.fec {
+		if err := encoder.Ctl(SetInbandFEC(fec)); err != nil {
+			return fmt.Errorf("failed to set opus inband fec: %w", err)
+		}
+		c.fec = fec
+	}
+	if lossPerc != c.lossPerc {
+		if err := encoder.Ctl(SetPacketLossPerc(lossPerc)); err != nil {
+			return fmt.Errorf("failed to set opus packet loss percentage: %w", err)
+		}
+		c.lossPerc = lossPerc
+	}
+	return nil
+}
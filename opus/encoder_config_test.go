@@ -0,0 +1,62 @@
+package opus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAdaptiveControllerRequiresStatsFunc(t *testing.T) {
+	if _, err := NewAdaptiveController(AdaptiveConfig{MaxBitrate: BitrateMusic}, BitrateDefault); err != ErrNoNetworkStatsFunc {
+		t.Fatalf("got %v, want %v", err, ErrNoNetworkStatsFunc)
+	}
+}
+
+func TestNewAdaptiveControllerDefaults(t *testing.T) {
+	statsFunc := func() NetworkStats {
+		return NetworkStats{}
+	}
+	defaultConfig := DefaultAdaptiveConfig(statsFunc, 0)
+
+	for _, tt := range []struct {
+		name    string
+		config  AdaptiveConfig
+		bitrate int
+		want    AdaptiveConfig
+	}{
+		{
+			name:    "zero values",
+			config:  AdaptiveConfig{StatsFunc: statsFunc},
+			bitrate: BitrateDefault,
+			want:    AdaptiveConfig{Interval: defaultConfig.Interval, MinBitrate: defaultConfig.MinBitrate, MaxBitrate: BitrateMusic, BackoffFactor: defaultConfig.BackoffFactor},
+		},
+		{
+			name:    "min above max",
+			config:  AdaptiveConfig{StatsFunc: statsFunc, Interval: time.Minute, MinBitrate: 64000, MaxBitrate: 32000, BackoffFactor: 1.5},
+			bitrate: BitrateMusic,
+			want:    AdaptiveConfig{Interval: time.Minute, MinBitrate: 32000, MaxBitrate: 32000, BackoffFactor: defaultConfig.BackoffFactor},
+		},
+		{
+			name:    "kept",
+			config:  AdaptiveConfig{StatsFunc: statsFunc, Interval: time.Minute, MinBitrate: 8000, MaxBitrate: 48000, BackoffFactor: 0.5},
+			bitrate: 6000,
+			want:    AdaptiveConfig{Interval: time.Minute, MinBitrate: 8000, MaxBitrate: 48000, BackoffFactor: 0.5},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, err := NewAdaptiveController(tt.config, tt.bitrate)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			config := controller.config
+			if config.Interval != tt.want.Interval || config.MinBitrate != tt.want.MinBitrate || config.MaxBitrate != tt.want.MaxBitrate || config.BackoffFactor != tt.want.BackoffFactor {
+				t.Fatalf("got interval %s, bitrates %d-%d and backoff factor %g, want %s, %d-%d and %g",
+					config.Interval, config.MinBitrate, config.MaxBitrate, config.BackoffFactor,
+					tt.want.Interval, tt.want.MinBitrate, tt.want.MaxBitrate, tt.want.BackoffFactor,
+				)
+			}
+			if bitrate := controller.Bitrate(); bitrate < config.MinBitrate || bitrate > config.MaxBitrate {
+				t.Fatalf("got starting bitrate %d, want it between %d and %d", bitrate, config.MinBitrate, config.MaxBitrate)
+			}
+		})
+	}
+}
@@ -0,0 +1,494 @@
+package opus
+
+/*
+#cgo pkg-config: opus
+#include <stdlib.h>
+#include <opus/opus.h>
+#include <opus/opus_multistream.h>
+
+int opus_multistream_set_bitrate(OpusMSEncoder *st, opus_int32 bitrate) {
+	return opus_multistream_encoder_ctl(st, OPUS_SET_BITRATE(bitrate));
+}
+int opus_multistream_get_bitrate(OpusMSEncoder *st, opus_int32 *bitrate) {
+	return opus_multistream_encoder_ctl(st, OPUS_GET_BITRATE(bitrate));
+}
+
+int opus_multistream_set_complexity(OpusMSEncoder *st, opus_int32 complexity) {
+	return opus_multistream_encoder_ctl(st, OPUS_SET_COMPLEXITY(complexity));
+}
+
+int opus_multistream_set_vbr(OpusMSEncoder *st, opus_int32 vbr) {
+	return opus_multistream_encoder_ctl(st, OPUS_SET_VBR(vbr));
+}
+
+int opus_multistream_set_signal(OpusMSEncoder *st, opus_int32 signal) {
+	return opus_multistream_encoder_ctl(st, OPUS_SET_SIGNAL(signal));
+}
+
+int opus_multistream_set_inband_fec(OpusMSEncoder *st, opus_int32 fec) {
+	return opus_multistream_encoder_ctl(st, OPUS_SET_INBAND_FEC(fec));
+}
+
+int opus_multistream_set_packet_loss_perc(OpusMSEncoder *st, opus_int32 perc) {
+	return opus_multistream_encoder_ctl(st, OPUS_SET_PACKET_LOSS_PERC(perc));
+}
+
+int opus_multistream_encoder_get_sample_rate(OpusMSEncoder *st, opus_int32 *sample_rate) {
+	return opus_multistream_encoder_ctl(st, OPUS_GET_SAMPLE_RATE(sample_rate));
+}
+
+int opus_multistream_encoder_reset_state(OpusMSEncoder *st) {
+	return opus_multistream_encoder_ctl(st, OPUS_RESET_STATE);
+}
+
+int opus_multistream_decoder_set_gain(OpusMSDecoder *st, opus_int32 gain) {
+	return opus_multistream_decoder_ctl(st, OPUS_SET_GAIN(gain));
+}
+
+int opus_multistream_decoder_get_sample_rate(OpusMSDecoder *st, opus_int32 *sample_rate) {
+	return opus_multistream_decoder_ctl(st, OPUS_GET_SAMPLE_RATE(sample_rate));
+}
+
+int opus_multistream_decoder_reset_state(OpusMSDecoder *st) {
+	return opus_multistream_decoder_ctl(st, OPUS_RESET_STATE);
+}
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrInvalidOpusHead is returned by ParseOpusHead if the data isn't a valid Opus identification header.
+var ErrInvalidOpusHead = errors.New("invalid opus head")
+
+// MappingFamily defines the channel order and how channels are mapped to streams as described in RFC 7845 section 5.1.1.
+type MappingFamily int
+
+const (
+	// MappingFamilyRTP supports mono and stereo in a single stream.
+	MappingFamilyRTP MappingFamily = 0
+	// MappingFamilyVorbis supports 1 to 8 channels in the Vorbis channel order.
+	//
+	//	1: mono
+	//	2: left, right
+	//	3: left, center, right
+	//	4: front left, front right, rear left, rear right
+	//	5: front left, center, front right, rear left, rear right
+	//	6: front left, center, front right, rear left, rear right, LFE
+	//	7: front left, center, front right, side left, side right, rear center, LFE
+	//	8: front left, center, front right, side left, side right, rear left, rear right, LFE
+	MappingFamilyVorbis MappingFamily = 1
+	// MappingFamilyAmbisonics supports ambisonics in ACN channel order with SN3D normalization and an optional non-diegetic stereo stream.
+	MappingFamilyAmbisonics MappingFamily = 2
+	// MappingFamilyDiscrete supports up to 255 channels without a defined meaning.
+	MappingFamilyDiscrete MappingFamily = 255
+)
+
+// NewMultistreamEncoder creates a new *MultistreamEncoder encoding the given channels into streams, of which coupledStreams are stereo.
+// mapping maps each input channel to a stream channel and must contain one entry per channel.
+func NewMultistreamEncoder(sampleRate int, channels int, streams int, coupledStreams int, mapping []byte, application Application) (*MultistreamEncoder, error) {
+	if channels <= 0 || len(mapping) != channels {
+		return nil, ErrBadArg
+	}
+	var err C.int
+	encoder := C.opus_multistream_encoder_create(C.opus_int32(sampleRate), C.int(channels), C.int(streams), C.int(coupledStreams), (*C.uchar)(&mapping[0]), C.int(application), &err)
+	if err != C.OPUS_OK {
+		return nil, Error(err)
+	}
+	return &MultistreamEncoder{
+		encoder:        encoder,
+		channels:       channels,
+		streams:        streams,
+		coupledStreams: coupledStreams,
+		mapping:        append([]byte(nil), mapping...),
+	}, nil
+}
+
+// NewSurroundEncoder creates a new *MultistreamEncoder for the given channels using the standard stream layout of the MappingFamily.
+// The resulting streams, coupled streams and mapping are needed to create the matching MultistreamDecoder.
+func NewSurroundEncoder(sampleRate int, channels int, mappingFamily MappingFamily, application Application) (*MultistreamEncoder, error) {
+	if channels <= 0 || channels > 255 {
+		return nil, ErrBadArg
+	}
+	var (
+		err            C.int
+		streams        C.int
+		coupledStreams C.int
+	)
+	mapping := make([]byte, channels)
+	encoder := C.opus_multistream_surround_encoder_create(C.opus_int32(sampleRate), C.int(channels), C.int(mappingFamily), &streams, &coupledStreams, (*C.uchar)(&mapping[0]), C.int(application), &err)
+	if err != C.OPUS_OK {
+		return nil, Error(err)
+	}
+	return &MultistreamEncoder{
+		encoder:        encoder,
+		channels:       channels,
+		streams:        int(streams),
+		coupledStreams: int(coupledStreams),
+		mapping:        mapping,
+	}, nil
+}
+
+// MultistreamEncoder wraps libopus's OpusMSEncoder.
+type MultistreamEncoder struct {
+	encoder        *C.OpusMSEncoder
+	channels       int
+	streams        int
+	coupledStreams int
+	mapping        []byte
+}
+
+func (e *MultistreamEncoder) Encode(pcm []int16, data []byte) (int, error) {
+	if e.encoder == nil {
+		return 0, ErrEncoderNotInitialized
+	}
+	if len(pcm) < e.channels || len(data) == 0 {
+		return 0, ErrBadArg
+	}
+	n := C.opus_multistream_encode(e.encoder, (*C.opus_int16)(&pcm[0]), C.int(len(pcm)/e.channels), (*C.uchar)(&data[0]), C.opus_int32(cap(data)))
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return int(n), nil
+}
+
+func (e *MultistreamEncoder) EncodeFloat(pcm []float32, data []byte) (int, error) {
+	if e.encoder == nil {
+		return 0, ErrEncoderNotInitialized
+	}
+	if len(pcm) < e.channels || len(data) == 0 {
+		return 0, ErrBadArg
+	}
+	n := C.opus_multistream_encode_float(e.encoder, (*C.float)(&pcm[0]), C.int(len(pcm)/e.channels), (*C.uchar)(&data[0]), C.opus_int32(cap(data)))
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return int(n), nil
+}
+
+func (e *MultistreamEncoder) Ctl(macro Macro[MultistreamEncoder]) error {
+	if e.encoder == nil {
+		return ErrEncoderNotInitialized
+	}
+	if err := macro(e); err != C.OPUS_OK {
+		return Error(err)
+	}
+	return nil
+}
+
+func (e *MultistreamEncoder) Channels() int {
+	return e.channels
+}
+
+func (e *MultistreamEncoder) Streams() int {
+	return e.streams
+}
+
+func (e *MultistreamEncoder) CoupledStreams() int {
+	return e.coupledStreams
+}
+
+// Mapping returns the stream channel of each input channel.
+func (e *MultistreamEncoder) Mapping() []byte {
+	return e.mapping
+}
+
+func (e *MultistreamEncoder) SampleRate() (int, error) {
+	var sampleRate int
+	if err := e.Ctl(GetMultistreamEncoderSampleRate(&sampleRate)); err != nil {
+		return 0, err
+	}
+	return sampleRate, nil
+}
+
+func (e *MultistreamEncoder) Destroy() {
+	if e.encoder == nil {
+		return
+	}
+	C.opus_multistream_encoder_destroy(e.encoder)
+	e.encoder = nil
+}
+
+// NewMultistreamDecoder creates a new *MultistreamDecoder decoding the given streams, of which coupledStreams are stereo, into channels.
+// mapping maps each output channel to a stream channel and must contain one entry per channel.
+func NewMultistreamDecoder(sampleRate int, channels int, streams int, coupledStreams int, mapping []byte) (*MultistreamDecoder, error) {
+	if channels <= 0 || len(mapping) != channels {
+		return nil, ErrBadArg
+	}
+	var err C.int
+	decoder := C.opus_multistream_decoder_create(C.opus_int32(sampleRate), C.int(channels), C.int(streams), C.int(coupledStreams), (*C.uchar)(&mapping[0]), &err)
+	if err != C.OPUS_OK {
+		return nil, Error(err)
+	}
+	return &MultistreamDecoder{
+		decoder:  decoder,
+		channels: channels,
+	}, nil
+}
+
+// MultistreamDecoder wraps libopus's OpusMSDecoder.
+type MultistreamDecoder struct {
+	decoder  *C.OpusMSDecoder
+	channels int
+	// preSkip is the number of samples per channel which are still discarded from the start of the decoded audio.
+	preSkip int
+}
+
+// Decode decodes the given packet into pcm and returns the number of samples per channel.
+// Passing an empty packet runs the packet loss concealment for the duration of pcm.
+func (d *MultistreamDecoder) Decode(data []byte, pcm []int16, decodeFec bool) (int, error) {
+	if d.decoder == nil {
+		return 0, ErrDecoderNotInitialized
+	}
+	if cap(pcm) < d.channels {
+		return 0, ErrBadArg
+	}
+	pcm = pcm[:cap(pcm)]
+	var decodeFecCInt C.int
+	if decodeFec {
+		decodeFecCInt = C.int(1)
+	}
+	n := C.opus_multistream_decode(d.decoder, packetPtr(data), C.opus_int32(len(data)), (*C.opus_int16)(&pcm[0]), C.int(cap(pcm)/d.channels), decodeFecCInt)
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return discardPreSkip(d, pcm, int(n)), nil
+}
+
+// DecodeFloat decodes the given packet into pcm and returns the number of samples per channel.
+// Passing an empty packet runs the packet loss concealment for the duration of pcm.
+func (d *MultistreamDecoder) DecodeFloat(data []byte, pcm []float32, decodeFec bool) (int, error) {
+	if d.decoder == nil {
+		return 0, ErrDecoderNotInitialized
+	}
+	if cap(pcm) < d.channels {
+		return 0, ErrBadArg
+	}
+	pcm = pcm[:cap(pcm)]
+	var decodeFecCInt C.int
+	if decodeFec {
+		decodeFecCInt = C.int(1)
+	}
+	n := C.opus_multistream_decode_float(d.decoder, packetPtr(data), C.opus_int32(len(data)), (*C.float)(&pcm[0]), C.int(cap(pcm)/d.channels), decodeFecCInt)
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return discardPreSkip(d, pcm, int(n)), nil
+}
+
+// discardPreSkip discards the remaining pre-skip samples from the n decoded samples per channel in pcm and returns the number of samples left.
+func discardPreSkip[T int16 | float32](d *MultistreamDecoder, pcm []T, n int) int {
+	skip := d.preSkip
+	if skip == 0 {
+		return n
+	}
+	if skip > n {
+		skip = n
+	}
+	d.preSkip -= skip
+	copy(pcm, pcm[skip*d.channels:n*d.channels])
+	return n - skip
+}
+
+// SetPreSkip sets the number of samples per channel to discard from the start of the decoded audio.
+// Use it after resetting the decoder state when seeking, see OpusHead.PreSkip.
+func (d *MultistreamDecoder) SetPreSkip(samples int) {
+	d.preSkip = samples
+}
+
+func (d *MultistreamDecoder) Ctl(macro Macro[MultistreamDecoder]) error {
+	if d.decoder == nil {
+		return ErrDecoderNotInitialized
+	}
+	if err := macro(d); err != C.OPUS_OK {
+		return Error(err)
+	}
+	return nil
+}
+
+func (d *MultistreamDecoder) Channels() int {
+	return d.channels
+}
+
+func (d *MultistreamDecoder) SampleRate() (int, error) {
+	var sampleRate int
+	if err := d.Ctl(GetMultistreamDecoderSampleRate(&sampleRate)); err != nil {
+		return 0, err
+	}
+	return sampleRate, nil
+}
+
+func (d *MultistreamDecoder) Destroy() {
+	if d.decoder == nil {
+		return
+	}
+	C.opus_multistream_decoder_destroy(d.decoder)
+	d.decoder = nil
+}
+
+func packetPtr(data []byte) *C.uchar {
+	if len(data) == 0 {
+		return nil
+	}
+	return (*C.uchar)(unsafe.Pointer(&data[0]))
+}
+
+func SetMultistreamBitrate(bitrate int) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_set_bitrate(e.encoder, C.opus_int32(bitrate))
+	}
+}
+
+func GetMultistreamBitrate(bitrate *int) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		var v C.opus_int32
+		err := C.opus_multistream_get_bitrate(e.encoder, &v)
+		*bitrate = int(v)
+		return err
+	}
+}
+
+func SetMultistreamComplexity(complexity int) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_set_complexity(e.encoder, C.opus_int32(complexity))
+	}
+}
+
+func SetMultistreamVBR(vbr bool) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_set_vbr(e.encoder, boolToCInt(vbr))
+	}
+}
+
+func SetMultistreamSignal(signal Signal) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_set_signal(e.encoder, C.opus_int32(signal))
+	}
+}
+
+func SetMultistreamInbandFEC(fec bool) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_set_inband_fec(e.encoder, boolToCInt(fec))
+	}
+}
+
+func SetMultistreamPacketLossPerc(perc int) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_set_packet_loss_perc(e.encoder, C.opus_int32(perc))
+	}
+}
+
+func GetMultistreamEncoderSampleRate(sampleRate *int) Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		var v C.opus_int32
+		err := C.opus_multistream_encoder_get_sample_rate(e.encoder, &v)
+		*sampleRate = int(v)
+		return err
+	}
+}
+
+func ResetMultistreamEncoderState() Macro[MultistreamEncoder] {
+	return func(e *MultistreamEncoder) C.int {
+		return C.opus_multistream_encoder_reset_state(e.encoder)
+	}
+}
+
+// SetMultistreamGain sets the decoder output gain in Q8 dB units (-32768 to 32767).
+func SetMultistreamGain(gain int) Macro[MultistreamDecoder] {
+	return func(d *MultistreamDecoder) C.int {
+		return C.opus_multistream_decoder_set_gain(d.decoder, C.opus_int32(gain))
+	}
+}
+
+func GetMultistreamDecoderSampleRate(sampleRate *int) Macro[MultistreamDecoder] {
+	return func(d *MultistreamDecoder) C.int {
+		var v C.opus_int32
+		err := C.opus_multistream_decoder_get_sample_rate(d.decoder, &v)
+		*sampleRate = int(v)
+		return err
+	}
+}
+
+func ResetMultistreamDecoderState() Macro[MultistreamDecoder] {
+	return func(d *MultistreamDecoder) C.int {
+		return C.opus_multistream_decoder_reset_state(d.decoder)
+	}
+}
+
+// OpusHead is the Opus identification header found in Ogg streams and the CodecPrivate of WebM/Matroska tracks as described in RFC 7845 section 5.1.
+type OpusHead struct {
+	Version  uint8
+	Channels int
+	// PreSkip is the number of samples at 48kHz to discard from the start of the decoded audio.
+	PreSkip         int
+	InputSampleRate int
+	// OutputGain in Q7.8 dB units which should be applied with SetMultistreamGain.
+	OutputGain     int
+	MappingFamily  MappingFamily
+	Streams        int
+	CoupledStreams int
+	Mapping        []byte
+}
+
+// ParseOpusHead parses an Opus identification header.
+func ParseOpusHead(data []byte) (OpusHead, error) {
+	if len(data) < 19 || string(data[:8]) != "OpusHead" {
+		return OpusHead{}, ErrInvalidOpusHead
+	}
+	head := OpusHead{
+		Version:         data[8],
+		Channels:        int(data[9]),
+		PreSkip:         int(uint16(data[10]) | uint16(data[11])<<8),
+		InputSampleRate: int(uint32(data[12]) | uint32(data[13])<<8 | uint32(data[14])<<16 | uint32(data[15])<<24),
+		OutputGain:      int(int16(uint16(data[16]) | uint16(data[17])<<8)),
+		MappingFamily:   MappingFamily(data[18]),
+	}
+	if head.Version>>4 != 0 || head.Channels == 0 {
+		return OpusHead{}, ErrInvalidOpusHead
+	}
+
+	if head.MappingFamily == MappingFamilyRTP {
+		if head.Channels > 2 {
+			return OpusHead{}, fmt.Errorf("%w: %d channels with mapping family 0", ErrInvalidOpusHead, head.Channels)
+		}
+		head.Streams = 1
+		head.CoupledStreams = head.Channels - 1
+		head.Mapping = []byte{0, 1}[:head.Channels]
+		return head, nil
+	}
+
+	if len(data) < 21+head.Channels {
+		return OpusHead{}, fmt.Errorf("%w: truncated channel mapping table", ErrInvalidOpusHead)
+	}
+	head.Streams = int(data[19])
+	head.CoupledStreams = int(data[20])
+	head.Mapping = append([]byte(nil), data[21:21+head.Channels]...)
+	if head.Streams == 0 || head.CoupledStreams > head.Streams {
+		return OpusHead{}, fmt.Errorf("%w: invalid stream count", ErrInvalidOpusHead)
+	}
+	return head, nil
+}
+
+// PreSkipSamples returns the PreSkip converted to samples per channel at the given sample rate.
+func (h OpusHead) PreSkipSamples(sampleRate int) int {
+	return h.PreSkip * sampleRate / 48000
+}
+
+// NewDecoder creates a new *MultistreamDecoder at the given sample rate for the stream described by the OpusHead.
+// It applies the OutputGain and discards the PreSkip samples from the start of the decoded audio.
+func (h OpusHead) NewDecoder(sampleRate int) (*MultistreamDecoder, error) {
+	decoder, err := NewMultistreamDecoder(sampleRate, h.Channels, h.Streams, h.CoupledStreams, h.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	decoder.SetPreSkip(h.PreSkipSamples(sampleRate))
+	if h.OutputGain != 0 {
+		if err = decoder.Ctl(SetMultistreamGain(h.OutputGain)); err != nil {
+			decoder.Destroy()
+			return nil, err
+		}
+	}
+	return decoder, nil
+}
@@ -0,0 +1,123 @@
+package opus
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// opusHead builds an identification header with the given fields followed by the channel mapping table.
+func opusHead(version byte, channels byte, preSkip uint16, sampleRate uint32, gain int16, family byte, table ...byte) []byte {
+	head := []byte("OpusHead")
+	head = append(head, version, channels, byte(preSkip), byte(preSkip>>8),
+		byte(sampleRate), byte(sampleRate>>8), byte(sampleRate>>16), byte(sampleRate>>24),
+		byte(gain), byte(uint16(gain)>>8), family,
+	)
+	return append(head, table...)
+}
+
+func TestParseOpusHead(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want OpusHead
+	}{
+		{
+			name: "mono",
+			data: opusHead(1, 1, 312, 44100, 0, 0),
+			want: OpusHead{Version: 1, Channels: 1, PreSkip: 312, InputSampleRate: 44100, Streams: 1, Mapping: []byte{0}},
+		},
+		{
+			name: "stereo with negative gain",
+			data: opusHead(1, 2, 3840, 48000, -256, 0),
+			want: OpusHead{Version: 1, Channels: 2, PreSkip: 3840, InputSampleRate: 48000, OutputGain: -256, Streams: 1, CoupledStreams: 1, Mapping: []byte{0, 1}},
+		},
+		{
+			name: "5.1 surround",
+			data: opusHead(1, 6, 312, 48000, 0, 1, 4, 2, 0, 4, 1, 2, 3, 5),
+			want: OpusHead{Version: 1, Channels: 6, PreSkip: 312, InputSampleRate: 48000, MappingFamily: MappingFamilyVorbis, Streams: 4, CoupledStreams: 2, Mapping: []byte{0, 4, 1, 2, 3, 5}},
+		},
+		{
+			name: "compatible minor version",
+			data: opusHead(0x0f, 1, 0, 0, 0, 0),
+			want: OpusHead{Version: 0x0f, Channels: 1, Streams: 1, Mapping: []byte{0}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			head, err := ParseOpusHead(tt.data)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(head, tt.want) {
+				t.Fatalf("got %+v, want %+v", head, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOpusHeadInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "truncated", data: opusHead(1, 2, 0, 48000, 0, 0)[:18]},
+		{name: "wrong magic", data: append([]byte("OpusTags"), opusHead(1, 2, 0, 48000, 0, 0)[8:]...)},
+		{name: "incompatible version", data: opusHead(0x10, 2, 0, 48000, 0, 0)},
+		{name: "no channels", data: opusHead(1, 0, 0, 48000, 0, 0)},
+		{name: "mapping family 0 with 3 channels", data: opusHead(1, 3, 0, 48000, 0, 0)},
+		{name: "truncated mapping table", data: opusHead(1, 3, 0, 48000, 0, 1, 2, 1, 0, 1)},
+		{name: "no streams", data: opusHead(1, 1, 0, 48000, 0, 1, 0, 0, 0)},
+		{name: "more coupled streams than streams", data: opusHead(1, 2, 0, 48000, 0, 1, 1, 2, 0, 1)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseOpusHead(tt.data); !errors.Is(err, ErrInvalidOpusHead) {
+				t.Fatalf("got %v, want %v", err, ErrInvalidOpusHead)
+			}
+		})
+	}
+}
+
+func TestOpusHeadPreSkipSamples(t *testing.T) {
+	head := OpusHead{PreSkip: 3840}
+	if samples := head.PreSkipSamples(48000); samples != 3840 {
+		t.Fatalf("got %d samples at 48kHz, want 3840", samples)
+	}
+	if samples := head.PreSkipSamples(24000); samples != 1920 {
+		t.Fatalf("got %d samples at 24kHz, want 1920", samples)
+	}
+}
+
+func TestDiscardPreSkip(t *testing.T) {
+	d := &MultistreamDecoder{channels: 2}
+	d.SetPreSkip(3)
+
+	// the pre-skip spans multiple packets
+	pcm := []int16{1, 1, 2, 2}
+	if n := discardPreSkip(d, pcm, 2); n != 0 {
+		t.Fatalf("got %d samples, want the whole packet to be skipped", n)
+	}
+	pcm = []int16{3, 3, 4, 4, 5, 5}
+	if n := discardPreSkip(d, pcm, 3); n != 2 || pcm[0] != 4 || pcm[2] != 5 {
+		t.Fatalf("got %d samples %v, want 2 samples starting at 4", n, pcm[:n*2])
+	}
+	floats := []float32{6, 6}
+	if n := discardPreSkip(d, floats, 1); n != 1 || floats[0] != 6 {
+		t.Fatalf("got %d samples %v after the pre-skip, want the samples untouched", n, floats)
+	}
+}
+
+func TestMultistreamBadArg(t *testing.T) {
+	if _, err := NewMultistreamEncoder(48000, 0, 1, 0, nil, ApplicationAudio); err != ErrBadArg {
+		t.Fatalf("got %v creating an encoder without channels, want %v", err, ErrBadArg)
+	}
+	if _, err := NewMultistreamEncoder(48000, 2, 1, 1, []byte{0}, ApplicationAudio); err != ErrBadArg {
+		t.Fatalf("got %v creating an encoder with a short mapping, want %v", err, ErrBadArg)
+	}
+	if _, err := NewMultistreamDecoder(48000, 0, 1, 0, nil); err != ErrBadArg {
+		t.Fatalf("got %v creating a decoder without channels, want %v", err, ErrBadArg)
+	}
+	if _, err := NewSurroundEncoder(48000, 0, MappingFamilyVorbis, ApplicationAudio); err != ErrBadArg {
+		t.Fatalf("got %v creating a surround encoder without channels, want %v", err, ErrBadArg)
+	}
+}
@@ -7,6 +7,8 @@ package opus
 import "C"
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 const FrameSize = 20
@@ -27,6 +29,118 @@ const (
 	ApplicationRestrictedLowdelay Application = C.OPUS_APPLICATION_RESTRICTED_LOWDELAY
 )
 
+const (
+	// Auto lets the encoder choose the value, it is accepted by most encoder CTLs.
+	Auto         = C.OPUS_AUTO
+	BitrateMax   = C.OPUS_BITRATE_MAX
+	ChannelsAuto = C.OPUS_AUTO
+)
+
+type Bandwidth int
+
+const (
+	BandwidthAuto          Bandwidth = C.OPUS_AUTO
+	BandwidthNarrowband    Bandwidth = C.OPUS_BANDWIDTH_NARROWBAND
+	BandwidthMediumband    Bandwidth = C.OPUS_BANDWIDTH_MEDIUMBAND
+	BandwidthWideband      Bandwidth = C.OPUS_BANDWIDTH_WIDEBAND
+	BandwidthSuperwideband Bandwidth = C.OPUS_BANDWIDTH_SUPERWIDEBAND
+	BandwidthFullband      Bandwidth = C.OPUS_BANDWIDTH_FULLBAND
+)
+
+func (b Bandwidth) String() string {
+	switch b {
+	case BandwidthAuto:
+		return "auto"
+	case BandwidthNarrowband:
+		return "narrowband"
+	case BandwidthMediumband:
+		return "mediumband"
+	case BandwidthWideband:
+		return "wideband"
+	case BandwidthSuperwideband:
+		return "superwideband"
+	case BandwidthFullband:
+		return "fullband"
+	}
+	return fmt.Sprintf("Bandwidth(%d)", int(b))
+}
+
+// SampleRate returns the audio sample rate needed to cover the bandwidth.
+func (b Bandwidth) SampleRate() int {
+	switch b {
+	case BandwidthNarrowband:
+		return 8000
+	case BandwidthMediumband:
+		return 12000
+	case BandwidthWideband:
+		return 16000
+	case BandwidthSuperwideband:
+		return 24000
+	}
+	return 48000
+}
+
+type Signal int
+
+const (
+	SignalAuto  Signal = C.OPUS_AUTO
+	SignalVoice Signal = C.OPUS_SIGNAL_VOICE
+	SignalMusic Signal = C.OPUS_SIGNAL_MUSIC
+)
+
+func (s Signal) String() string {
+	switch s {
+	case SignalAuto:
+		return "auto"
+	case SignalVoice:
+		return "voice"
+	case SignalMusic:
+		return "music"
+	}
+	return fmt.Sprintf("Signal(%d)", int(s))
+}
+
+type FrameDuration int
+
+const (
+	// FrameDurationArg uses the frame size passed to Encode.
+	FrameDurationArg   FrameDuration = C.OPUS_FRAMESIZE_ARG
+	FrameDuration2_5ms FrameDuration = C.OPUS_FRAMESIZE_2_5_MS
+	FrameDuration5ms   FrameDuration = C.OPUS_FRAMESIZE_5_MS
+	FrameDuration10ms  FrameDuration = C.OPUS_FRAMESIZE_10_MS
+	FrameDuration20ms  FrameDuration = C.OPUS_FRAMESIZE_20_MS
+	FrameDuration40ms  FrameDuration = C.OPUS_FRAMESIZE_40_MS
+	FrameDuration60ms  FrameDuration = C.OPUS_FRAMESIZE_60_MS
+	FrameDuration80ms  FrameDuration = C.OPUS_FRAMESIZE_80_MS
+	FrameDuration100ms FrameDuration = C.OPUS_FRAMESIZE_100_MS
+	FrameDuration120ms FrameDuration = C.OPUS_FRAMESIZE_120_MS
+)
+
+// Duration returns the frame duration or 0 for FrameDurationArg.
+func (d FrameDuration) Duration() time.Duration {
+	switch d {
+	case FrameDuration2_5ms:
+		return 2500 * time.Microsecond
+	case FrameDuration5ms:
+		return 5 * time.Millisecond
+	case FrameDuration10ms:
+		return 10 * time.Millisecond
+	case FrameDuration20ms:
+		return 20 * time.Millisecond
+	case FrameDuration40ms:
+		return 40 * time.Millisecond
+	case FrameDuration60ms:
+		return 60 * time.Millisecond
+	case FrameDuration80ms:
+		return 80 * time.Millisecond
+	case FrameDuration100ms:
+		return 100 * time.Millisecond
+	case FrameDuration120ms:
+		return 120 * time.Millisecond
+	}
+	return 0
+}
+
 func Version() string {
 	return C.GoString(C.opus_get_version_string())
 }
@@ -0,0 +1,317 @@
+package opus
+
+/*
+#cgo pkg-config: opus
+#include <opus/opus.h>
+*/
+import "C"
+import (
+	"fmt"
+	"time"
+)
+
+// MaxFrameLength is the maximum length of a single compressed frame in bytes.
+const MaxFrameLength = 1275
+
+// MaxPacketDuration is the maximum duration of all frames in a packet.
+const MaxPacketDuration = 120 * time.Millisecond
+
+// Mode is the coding mode of an Opus packet.
+type Mode int
+
+const (
+	ModeSILK Mode = iota
+	ModeHybrid
+	ModeCELT
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeSILK:
+		return "SILK"
+	case ModeHybrid:
+		return "Hybrid"
+	case ModeCELT:
+		return "CELT"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// TOC is the table-of-contents byte at the start of every Opus packet as described in RFC 6716 section 3.1.
+type TOC byte
+
+// Config returns the configuration number from 0 to 31.
+func (t TOC) Config() int {
+	return int(t >> 3)
+}
+
+func (t TOC) Mode() Mode {
+	switch config := t.Config(); {
+	case config < 12:
+		return ModeSILK
+	case config < 16:
+		return ModeHybrid
+	default:
+		return ModeCELT
+	}
+}
+
+func (t TOC) Bandwidth() Bandwidth {
+	switch config := t.Config(); {
+	case config < 4:
+		return BandwidthNarrowband
+	case config < 8:
+		return BandwidthMediumband
+	case config < 12:
+		return BandwidthWideband
+	case config < 14:
+		return BandwidthSuperwideband
+	case config < 16:
+		return BandwidthFullband
+	case config < 20:
+		return BandwidthNarrowband
+	case config < 24:
+		return BandwidthWideband
+	case config < 28:
+		return BandwidthSuperwideband
+	default:
+		return BandwidthFullband
+	}
+}
+
+// FrameDuration returns the duration of each frame in the packet.
+func (t TOC) FrameDuration() time.Duration {
+	config := t.Config()
+	switch t.Mode() {
+	case ModeSILK:
+		return [4]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond}[config%4]
+	case ModeHybrid:
+		return [2]time.Duration{10 * time.Millisecond, 20 * time.Millisecond}[config%2]
+	default:
+		return [4]time.Duration{2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}[config%4]
+	}
+}
+
+// FrameSize returns the number of samples per channel of each frame at the given sample rate.
+func (t TOC) FrameSize(sampleRate int) int {
+	return int(int64(sampleRate) * int64(t.FrameDuration()) / int64(time.Second))
+}
+
+func (t TOC) Stereo() bool {
+	return t&0x04 != 0
+}
+
+// Code returns the frame count code from 0 to 3.
+//
+//	0: 1 frame
+//	1: 2 frames of equal size
+//	2: 2 frames of different size
+//	3: an arbitrary number of frames
+func (t TOC) Code() int {
+	return int(t & 0x03)
+}
+
+// PacketInfo describes the structure of an Opus packet.
+type PacketInfo struct {
+	TOC TOC
+	// Frames are the compressed frames of the packet, they reference the parsed packet.
+	Frames [][]byte
+	// VBR is true if the frames of a code 3 packet have different sizes.
+	VBR bool
+	// Padding is the number of padding bytes of a code 3 packet.
+	Padding int
+}
+
+func (i PacketInfo) Mode() Mode {
+	return i.TOC.Mode()
+}
+
+func (i PacketInfo) Bandwidth() Bandwidth {
+	return i.TOC.Bandwidth()
+}
+
+func (i PacketInfo) Stereo() bool {
+	return i.TOC.Stereo()
+}
+
+func (i PacketInfo) FrameCount() int {
+	return len(i.Frames)
+}
+
+// Duration returns the duration of all frames in the packet.
+func (i PacketInfo) Duration() time.Duration {
+	return time.Duration(len(i.Frames)) * i.TOC.FrameDuration()
+}
+
+// Samples returns the number of samples per channel of all frames at the given sample rate.
+func (i PacketInfo) Samples(sampleRate int) int {
+	return len(i.Frames) * i.TOC.FrameSize(sampleRate)
+}
+
+func (i PacketInfo) String() string {
+	return fmt.Sprintf("opus packet: mode=%s bandwidth=%s stereo=%t code=%d frames=%d frame_duration=%s vbr=%t padding=%d", i.Mode(), i.Bandwidth(), i.Stereo(), i.TOC.Code(), len(i.Frames), i.TOC.FrameDuration(), i.VBR, i.Padding)
+}
+
+// ParsePacket parses the structure of an Opus packet as described in RFC 6716 section 3.2 without decoding it.
+// It returns an error wrapping ErrInvalidPacket if the packet violates RFC 6716 section 3.4.
+func ParsePacket(packet []byte) (PacketInfo, error) {
+	if len(packet) == 0 {
+		return PacketInfo{}, fmt.Errorf("%w: empty packet", ErrInvalidPacket)
+	}
+	info := PacketInfo{TOC: TOC(packet[0])}
+	data := packet[1:]
+
+	switch info.TOC.Code() {
+	case 0:
+		info.Frames = [][]byte{data}
+	case 1:
+		if len(data)%2 != 0 {
+			return PacketInfo{}, fmt.Errorf("%w: code 1 packet with odd length", ErrInvalidPacket)
+		}
+		info.Frames = [][]byte{data[:len(data)/2], data[len(data)/2:]}
+	case 2:
+		size, n := parseFrameLength(data)
+		if n == 0 || size > len(data)-n {
+			return PacketInfo{}, fmt.Errorf("%w: code 2 frame length exceeds packet", ErrInvalidPacket)
+		}
+		info.Frames = [][]byte{data[n : n+size], data[n+size:]}
+	case 3:
+		frames, vbr, padding, err := parseCode3(data)
+		if err != nil {
+			return PacketInfo{}, err
+		}
+		info.Frames = frames
+		info.VBR = vbr
+		info.Padding = padding
+	}
+
+	for _, frame := range info.Frames {
+		if len(frame) > MaxFrameLength {
+			return PacketInfo{}, fmt.Errorf("%w: frame length %d exceeds %d bytes", ErrInvalidPacket, len(frame), MaxFrameLength)
+		}
+	}
+	if info.Duration() > MaxPacketDuration {
+		return PacketInfo{}, fmt.Errorf("%w: packet duration %s exceeds %s", ErrInvalidPacket, info.Duration(), MaxPacketDuration)
+	}
+	return info, nil
+}
+
+func parseCode3(data []byte) ([][]byte, bool, int, error) {
+	if len(data) == 0 {
+		return nil, false, 0, fmt.Errorf("%w: missing code 3 frame count", ErrInvalidPacket)
+	}
+	vbr := data[0]&0x80 != 0
+	padded := data[0]&0x40 != 0
+	count := int(data[0] & 0x3f)
+	data = data[1:]
+	if count == 0 {
+		return nil, false, 0, fmt.Errorf("%w: code 3 packet without frames", ErrInvalidPacket)
+	}
+
+	var padding int
+	if padded {
+		for {
+			if len(data) == 0 {
+				return nil, false, 0, fmt.Errorf("%w: truncated padding length", ErrInvalidPacket)
+			}
+			b := int(data[0])
+			data = data[1:]
+			if b == 255 {
+				padding += 254
+				continue
+			}
+			padding += b
+			break
+		}
+		if padding > len(data) {
+			return nil, false, 0, fmt.Errorf("%w: padding exceeds packet", ErrInvalidPacket)
+		}
+		data = data[:len(data)-padding]
+	}
+
+	frames := make([][]byte, 0, count)
+	if !vbr {
+		if len(data)%count != 0 {
+			return nil, false, 0, fmt.Errorf("%w: code 3 cbr length not a multiple of the frame count", ErrInvalidPacket)
+		}
+		size := len(data) / count
+		for i := 0; i < count; i++ {
+			frames = append(frames, data[i*size:(i+1)*size])
+		}
+		return frames, false, padding, nil
+	}
+
+	sizes := make([]int, count-1)
+	for i := range sizes {
+		size, n := parseFrameLength(data)
+		if n == 0 {
+			return nil, false, 0, fmt.Errorf("%w: truncated frame length", ErrInvalidPacket)
+		}
+		sizes[i] = size
+		data = data[n:]
+	}
+	for _, size := range sizes {
+		if size > len(data) {
+			return nil, false, 0, fmt.Errorf("%w: frame length exceeds packet", ErrInvalidPacket)
+		}
+		frames = append(frames, data[:size])
+		data = data[size:]
+	}
+	return append(frames, data), true, padding, nil
+}
+
+// parseFrameLength parses a one or two byte frame length and returns the length and the number of bytes read.
+func parseFrameLength(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	if data[0] < 252 {
+		return int(data[0]), 1
+	}
+	if len(data) < 2 {
+		return 0, 0
+	}
+	return int(data[0]) + 4*int(data[1]), 2
+}
+
+// ValidatePacket checks whether the given Opus packet is well-formed, for example voice.Packet.Opus before passing it to Decoder.Decode.
+func ValidatePacket(packet []byte) error {
+	_, err := ParsePacket(packet)
+	return err
+}
+
+// PacketNbSamples returns the number of samples per channel of the given Opus packet at the given sample rate using libopus.
+func PacketNbSamples(packet []byte, sampleRate int) (int, error) {
+	if len(packet) == 0 {
+		return 0, ErrBadArg
+	}
+	n := C.opus_packet_get_nb_samples((*C.uchar)(&packet[0]), C.opus_int32(len(packet)), C.opus_int32(sampleRate))
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return int(n), nil
+}
+
+// PacketNbFrames returns the number of frames of the given Opus packet using libopus.
+func PacketNbFrames(packet []byte) (int, error) {
+	if len(packet) == 0 {
+		return 0, ErrBadArg
+	}
+	n := C.opus_packet_get_nb_frames((*C.uchar)(&packet[0]), C.opus_int32(len(packet)))
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return int(n), nil
+}
+
+// PacketBandwidth returns the Bandwidth of the given Opus packet using libopus.
+func PacketBandwidth(packet []byte) (Bandwidth, error) {
+	if len(packet) == 0 {
+		return 0, ErrBadArg
+	}
+	bandwidth := C.opus_packet_get_bandwidth((*C.uchar)(&packet[0]))
+	if bandwidth < 0 {
+		return 0, Error(bandwidth)
+	}
+	return Bandwidth(bandwidth), nil
+}
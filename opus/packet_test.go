@@ -0,0 +1,130 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// packet joins the given parts into a packet.
+func packet(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestTOC(t *testing.T) {
+	for _, tt := range []struct {
+		toc       TOC
+		mode      Mode
+		bandwidth Bandwidth
+		duration  time.Duration
+		stereo    bool
+		code      int
+	}{
+		{toc: 0<<3 | 0x00, mode: ModeSILK, bandwidth: BandwidthNarrowband, duration: 10 * time.Millisecond, code: 0},
+		{toc: 7<<3 | 0x04, mode: ModeSILK, bandwidth: BandwidthMediumband, duration: 60 * time.Millisecond, stereo: true, code: 0},
+		{toc: 9<<3 | 0x01, mode: ModeSILK, bandwidth: BandwidthWideband, duration: 20 * time.Millisecond, code: 1},
+		{toc: 12<<3 | 0x02, mode: ModeHybrid, bandwidth: BandwidthSuperwideband, duration: 10 * time.Millisecond, code: 2},
+		{toc: 15<<3 | 0x07, mode: ModeHybrid, bandwidth: BandwidthFullband, duration: 20 * time.Millisecond, stereo: true, code: 3},
+		{toc: 16 << 3, mode: ModeCELT, bandwidth: BandwidthNarrowband, duration: 2500 * time.Microsecond, code: 0},
+		{toc: 22 << 3, mode: ModeCELT, bandwidth: BandwidthWideband, duration: 10 * time.Millisecond, code: 0},
+		{toc: 25 << 3, mode: ModeCELT, bandwidth: BandwidthSuperwideband, duration: 5 * time.Millisecond, code: 0},
+		{toc: 31<<3 | 0x04, mode: ModeCELT, bandwidth: BandwidthFullband, duration: 20 * time.Millisecond, stereo: true, code: 0},
+	} {
+		if tt.toc.Mode() != tt.mode || tt.toc.Bandwidth() != tt.bandwidth || tt.toc.FrameDuration() != tt.duration || tt.toc.Stereo() != tt.stereo || tt.toc.Code() != tt.code {
+			t.Fatalf("got mode %s, bandwidth %s, duration %s, stereo %t and code %d for config %d, want %s, %s, %s, %t and %d",
+				tt.toc.Mode(), tt.toc.Bandwidth(), tt.toc.FrameDuration(), tt.toc.Stereo(), tt.toc.Code(), tt.toc.Config(),
+				tt.mode, tt.bandwidth, tt.duration, tt.stereo, tt.code,
+			)
+		}
+	}
+	if size := TOC(31 << 3).FrameSize(48000); size != 960 {
+		t.Fatalf("got frame size %d, want 960", size)
+	}
+}
+
+func TestParsePacket(t *testing.T) {
+	frame256 := bytes.Repeat([]byte{1}, 256)
+	padding255 := make([]byte, 255)
+	for _, tt := range []struct {
+		name    string
+		packet  []byte
+		frames  [][]byte
+		vbr     bool
+		padding int
+	}{
+		{name: "code 0", packet: []byte{0xf8, 1, 2, 3}, frames: [][]byte{{1, 2, 3}}},
+		{name: "code 0 empty frame", packet: []byte{0xf8}, frames: [][]byte{{}}},
+		{name: "code 0 max frame length", packet: packet([]byte{0xf8}, make([]byte, MaxFrameLength)), frames: [][]byte{make([]byte, MaxFrameLength)}},
+		{name: "code 1", packet: []byte{0xf9, 1, 2, 3, 4}, frames: [][]byte{{1, 2}, {3, 4}}},
+		{name: "code 2", packet: []byte{0xfa, 1, 9, 8, 8}, frames: [][]byte{{9}, {8, 8}}},
+		{name: "code 2 two byte length", packet: packet([]byte{0xfa, 252, 1}, frame256, []byte{2, 2, 2}), frames: [][]byte{frame256, {2, 2, 2}}},
+		{name: "code 3 cbr", packet: []byte{0xfb, 0x03, 1, 2, 3}, frames: [][]byte{{1}, {2}, {3}}},
+		{name: "code 3 vbr", packet: []byte{0xfb, 0x82, 1, 7, 8, 8}, frames: [][]byte{{7}, {8, 8}}, vbr: true},
+		{name: "code 3 vbr padded", packet: []byte{0xfb, 0xc2, 2, 1, 7, 8, 8, 0, 0}, frames: [][]byte{{7}, {8, 8}}, vbr: true, padding: 2},
+		{name: "code 3 padding continuation", packet: packet([]byte{0xfb, 0x41, 255, 1, 5}, padding255), frames: [][]byte{{5}}, padding: 255},
+		{name: "code 3 120ms", packet: []byte{0x1b, 0x02, 1, 2}, frames: [][]byte{{1}, {2}}},
+		{name: "code 3 48 celt frames", packet: packet([]byte{16<<3 | 0x03, 48}, make([]byte, 48)), frames: bytes.SplitAfter(make([]byte, 48), []byte{0})[:48]},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParsePacket(tt.packet)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if len(info.Frames) != len(tt.frames) {
+				t.Fatalf("got %d frames, want %d", len(info.Frames), len(tt.frames))
+			}
+			for i := range info.Frames {
+				if !bytes.Equal(info.Frames[i], tt.frames[i]) {
+					t.Fatalf("got frame %d %v, want %v", i, info.Frames[i], tt.frames[i])
+				}
+			}
+			if info.VBR != tt.vbr || info.Padding != tt.padding {
+				t.Fatalf("got vbr %t and padding %d, want %t and %d", info.VBR, info.Padding, tt.vbr, tt.padding)
+			}
+			if err = ValidatePacket(tt.packet); err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePacketInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		packet []byte
+	}{
+		{name: "empty", packet: nil},
+		{name: "code 0 frame too long", packet: packet([]byte{0xf8}, make([]byte, MaxFrameLength+1))},
+		{name: "code 1 odd length", packet: []byte{0xf9, 1, 2, 3}},
+		{name: "code 1 frames too long", packet: packet([]byte{0xf9}, make([]byte, 2*(MaxFrameLength+1)))},
+		{name: "code 2 missing length", packet: []byte{0xfa}},
+		{name: "code 2 truncated two byte length", packet: []byte{0xfa, 252}},
+		{name: "code 2 length exceeds packet", packet: []byte{0xfa, 5, 1}},
+		{name: "code 3 missing count", packet: []byte{0xfb}},
+		{name: "code 3 no frames", packet: []byte{0xfb, 0x00}},
+		{name: "code 3 cbr uneven", packet: []byte{0xfb, 0x02, 1, 2, 3}},
+		{name: "code 3 vbr truncated length", packet: []byte{0xfb, 0x82}},
+		{name: "code 3 vbr length exceeds packet", packet: []byte{0xfb, 0x82, 5, 1}},
+		{name: "code 3 truncated padding", packet: []byte{0xfb, 0x41, 255}},
+		{name: "code 3 padding exceeds packet", packet: []byte{0xfb, 0x41, 10, 1}},
+		{name: "code 3 above 120ms", packet: []byte{0x1b, 0x03, 1, 2, 3}},
+		{name: "code 3 49 celt frames", packet: packet([]byte{16<<3 | 0x03, 49}, make([]byte, 49))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePacket(tt.packet); !errors.Is(err, ErrInvalidPacket) {
+				t.Fatalf("got %v, want %v", err, ErrInvalidPacket)
+			}
+		})
+	}
+}
+
+func TestPacketInfoDuration(t *testing.T) {
+	info, err := ParsePacket([]byte{0xfb, 0x03, 1, 2, 3})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if info.FrameCount() != 3 || info.Duration() != 60*time.Millisecond || info.Samples(48000) != 3*960 {
+		t.Fatalf("got %d frames of %s and %d samples, want 3 frames of 60ms and 2880 samples", info.FrameCount(), info.Duration(), info.Samples(48000))
+	}
+}
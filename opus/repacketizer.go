@@ -0,0 +1,114 @@
+package opus
+
+/*
+#cgo pkg-config: opus
+#include <stdlib.h>
+#include <opus/opus.h>
+*/
+import "C"
+import "unsafe"
+
+// NewRepacketizer creates a new *Repacketizer which merges and splits Opus packets without decoding them.
+func NewRepacketizer() (*Repacketizer, error) {
+	rp := C.opus_repacketizer_create()
+	if rp == nil {
+		return nil, ErrAllocFail
+	}
+	return &Repacketizer{
+		rp: rp,
+	}, nil
+}
+
+// Repacketizer wraps libopus's OpusRepacketizer.
+// All packets added with Cat must have the same TOC configuration and stereo flag and may not exceed 120ms in total.
+type Repacketizer struct {
+	rp *C.OpusRepacketizer
+	// packets are copies of the packets added with Cat, the repacketizer references them until Reset is called.
+	packets []unsafe.Pointer
+}
+
+// Reset removes all packets from the Repacketizer.
+func (r *Repacketizer) Reset() {
+	C.opus_repacketizer_init(r.rp)
+	r.freePackets()
+}
+
+// Cat adds the frames of the given packet to the Repacketizer.
+// It returns ErrInvalidPacket if the packet is malformed, has a different TOC configuration than the previous packets or exceeds 120ms in total.
+func (r *Repacketizer) Cat(packet []byte) error {
+	if len(packet) == 0 {
+		return ErrInvalidPacket
+	}
+	data := C.CBytes(packet)
+	if err := C.opus_repacketizer_cat(r.rp, (*C.uchar)(data), C.opus_int32(len(packet))); err != C.OPUS_OK {
+		C.free(data)
+		return Error(err)
+	}
+	r.packets = append(r.packets, data)
+	return nil
+}
+
+// NbFrames returns the number of frames added with Cat since the last Reset.
+func (r *Repacketizer) NbFrames() int {
+	return int(C.opus_repacketizer_get_nb_frames(r.rp))
+}
+
+// OutRange writes a packet containing the frames in the range [begin, end) into data and returns the length of the packet.
+func (r *Repacketizer) OutRange(begin int, end int, data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, ErrBufferTooSmall
+	}
+	n := C.opus_repacketizer_out_range(r.rp, C.int(begin), C.int(end), (*C.uchar)(&data[0]), C.opus_int32(len(data)))
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return int(n), nil
+}
+
+// Out writes a packet containing all frames into data and returns the length of the packet.
+func (r *Repacketizer) Out(data []byte) (int, error) {
+	return r.OutRange(0, r.NbFrames(), data)
+}
+
+// Destroy frees the Repacketizer.
+func (r *Repacketizer) Destroy() {
+	if r.rp == nil {
+		return
+	}
+	C.opus_repacketizer_destroy(r.rp)
+	r.rp = nil
+	r.freePackets()
+}
+
+func (r *Repacketizer) freePackets() {
+	for _, packet := range r.packets {
+		C.free(packet)
+	}
+	r.packets = r.packets[:0]
+}
+
+// PacketPad returns a copy of the given packet padded to the given length.
+// The padded packet decodes to the same audio as the original one.
+func PacketPad(packet []byte, length int) ([]byte, error) {
+	if len(packet) == 0 || length < len(packet) {
+		return nil, ErrBadArg
+	}
+	padded := make([]byte, length)
+	copy(padded, packet)
+	if err := C.opus_packet_pad((*C.uchar)(&padded[0]), C.opus_int32(len(packet)), C.opus_int32(length)); err != C.OPUS_OK {
+		return nil, Error(err)
+	}
+	return padded, nil
+}
+
+// PacketUnpad removes all padding from the given packet in place and returns the shortened packet.
+func PacketUnpad(packet []byte) ([]byte, error) {
+	if len(packet) == 0 {
+		return nil, ErrBadArg
+	}
+	n := C.opus_packet_unpad((*C.uchar)(&packet[0]), C.opus_int32(len(packet)))
+	if n < 0 {
+		return nil, Error(n)
+	}
+	return packet[:n], nil
+}
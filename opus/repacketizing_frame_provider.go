@@ -0,0 +1,136 @@
+package opus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/disgoorg/disgo/voice"
+)
+
+// repacketizedBufferSize fits 20ms of the shortest (2.5ms) frames including their length headers.
+const repacketizedBufferSize = 8*(MaxFrameLength+2) + 2
+
+// NewRepacketizingFrameProvider creates a new voice.OpusFrameProvider which normalizes the Opus packets of the given voice.OpusFrameProvider to 20ms packets without decoding them.
+// Packets containing multiple 20ms frames are split and packets with frames shorter than 20ms are merged.
+// Frames longer than 20ms (40ms and 60ms SILK frames) can't be split without decoding and are passed through unchanged.
+func NewRepacketizingFrameProvider(provider voice.OpusFrameProvider) (voice.OpusFrameProvider, error) {
+	rp, err := NewRepacketizer()
+	if err != nil {
+		return nil, err
+	}
+	return &repacketizingFrameProvider{
+		provider:  provider,
+		rp:        rp,
+		buff:      make([]byte, repacketizedBufferSize),
+		carryBuff: make([]byte, repacketizedBufferSize),
+	}, nil
+}
+
+type repacketizingFrameProvider struct {
+	provider voice.OpusFrameProvider
+	rp       *Repacketizer
+
+	// toc is the TOC of the frames in rp, pos the next frame to provide and frames the number of frames in rp.
+	toc    TOC
+	pos    int
+	frames int
+
+	// next is a packet which didn't fit into rp and gets added once the pending frames are provided.
+	next []byte
+	// err is returned once all pending frames are provided.
+	err error
+
+	buff      []byte
+	carryBuff []byte
+}
+
+func (p *repacketizingFrameProvider) ProvideOpusFrame() ([]byte, error) {
+	for {
+		if pending := p.frames - p.pos; pending > 0 {
+			if perPacket := framesPer20ms(p.toc); pending >= perPacket {
+				return p.out(p.pos + perPacket)
+			}
+			if p.err != nil || p.next != nil {
+				return p.out(p.frames)
+			}
+		} else if p.err != nil {
+			err := p.err
+			p.err = nil
+			return nil, err
+		}
+
+		packet := p.next
+		p.next = nil
+		if packet == nil {
+			var err error
+			packet, err = p.provider.ProvideOpusFrame()
+			if err != nil {
+				p.err = err
+				continue
+			}
+			if packet == nil {
+				return nil, nil
+			}
+		}
+
+		info, err := ParsePacket(packet)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.add(packet, info); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// add adds the packet to rp. If the packet can't be merged with the pending frames it is stored in next.
+func (p *repacketizingFrameProvider) add(packet []byte, info PacketInfo) error {
+	pending := p.frames - p.pos
+	if pending > 0 {
+		if info.TOC&^0x03 != p.toc&^0x03 || time.Duration(pending+info.FrameCount())*p.toc.FrameDuration() > MaxPacketDuration {
+			p.next = packet
+			return nil
+		}
+		// move the pending frames to the front so the repacketizer only holds what's still needed
+		n, err := p.rp.OutRange(p.pos, p.frames, p.carryBuff)
+		if err != nil {
+			return fmt.Errorf("failed to repacketize opus frames: %w", err)
+		}
+		p.rp.Reset()
+		if err = p.rp.Cat(p.carryBuff[:n]); err != nil {
+			return fmt.Errorf("failed to repacketize opus frames: %w", err)
+		}
+	} else {
+		p.rp.Reset()
+	}
+
+	if err := p.rp.Cat(packet); err != nil {
+		return fmt.Errorf("failed to repacketize opus packet: %w", err)
+	}
+	p.toc = info.TOC
+	p.pos = 0
+	p.frames = p.rp.NbFrames()
+	return nil
+}
+
+func (p *repacketizingFrameProvider) out(end int) ([]byte, error) {
+	n, err := p.rp.OutRange(p.pos, end, p.buff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to repacketize opus frames: %w", err)
+	}
+	p.pos = end
+	return p.buff[:n], nil
+}
+
+func (p *repacketizingFrameProvider) Close() {
+	p.rp.Destroy()
+	p.provider.Close()
+}
+
+// framesPer20ms returns the number of frames needed for a 20ms packet or 1 if the frames are 20ms or longer.
+func framesPer20ms(toc TOC) int {
+	if n := int(FrameSize * time.Millisecond / toc.FrameDuration()); n > 1 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,162 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// packetProvider provides the given packets followed by err.
+type packetProvider struct {
+	packets [][]byte
+	err     error
+	closed  bool
+}
+
+func (p *packetProvider) ProvideOpusFrame() ([]byte, error) {
+	if len(p.packets) == 0 {
+		return nil, p.err
+	}
+	packet := p.packets[0]
+	p.packets = p.packets[1:]
+	return packet, nil
+}
+
+func (p *packetProvider) Close() {
+	p.closed = true
+}
+
+func TestFramesPer20ms(t *testing.T) {
+	for _, tt := range []struct {
+		toc    TOC
+		frames int
+	}{
+		{toc: 16 << 3, frames: 8},
+		{toc: 17 << 3, frames: 4},
+		{toc: 18 << 3, frames: 2},
+		{toc: 19 << 3, frames: 1},
+		{toc: 12 << 3, frames: 2},
+		{toc: 13 << 3, frames: 1},
+		{toc: 0 << 3, frames: 2},
+		{toc: 2 << 3, frames: 1},
+		{toc: 3 << 3, frames: 1},
+	} {
+		if frames := framesPer20ms(tt.toc); frames != tt.frames {
+			t.Fatalf("got %d frames for %s, want %d", frames, tt.toc.FrameDuration(), tt.frames)
+		}
+	}
+}
+
+func TestRepacketizingFrameProvider(t *testing.T) {
+	const (
+		// CELT fullband with 10ms and 20ms frames
+		toc10ms = 0xf0
+		toc20ms = 0xf8
+		// SILK narrowband with 40ms frames
+		toc40ms = 0x10
+	)
+	for _, tt := range []struct {
+		name    string
+		packets [][]byte
+		want    [][][]byte
+	}{
+		{
+			name:    "20ms passed through",
+			packets: [][]byte{{toc20ms, 1}, {toc20ms, 2}},
+			want:    [][][]byte{{{1}}, {{2}}},
+		},
+		{
+			name:    "split",
+			packets: [][]byte{{toc20ms | 0x03, 0x03, 1, 2, 3}},
+			want:    [][][]byte{{{1}}, {{2}}, {{3}}},
+		},
+		{
+			name:    "merge",
+			packets: [][]byte{{toc10ms, 1}, {toc10ms, 2}, {toc10ms, 3}, {toc10ms, 4}},
+			want:    [][][]byte{{{1}, {2}}, {{3}, {4}}},
+		},
+		{
+			name:    "carry",
+			packets: [][]byte{{toc10ms | 0x03, 0x83, 1, 1, 1, 2, 3, 3}, {toc10ms | 0x01, 4, 5}},
+			want:    [][][]byte{{{1}, {2}}, {{3, 3}, {4}}, {{5}}},
+		},
+		{
+			name:    "toc change flushes the pending frames",
+			packets: [][]byte{{toc10ms, 1}, {toc20ms, 2}, {toc10ms, 3}, {toc10ms, 4}},
+			want:    [][][]byte{{{1}}, {{2}}, {{3}, {4}}},
+		},
+		{
+			name: "120ms limit flushes the pending frames",
+			packets: [][]byte{
+				{toc10ms | 0x03, 0x03, 1, 2, 3},
+				append([]byte{toc10ms | 0x03, 12}, bytes.Repeat([]byte{4}, 12)...),
+			},
+			want: [][][]byte{{{1}, {2}}, {{3}}, {{4}, {4}}, {{4}, {4}}, {{4}, {4}}, {{4}, {4}}, {{4}, {4}}, {{4}, {4}}},
+		},
+		{
+			name:    "40ms passed through",
+			packets: [][]byte{{toc40ms, 1}, {toc40ms | 0x01, 2, 3}},
+			want:    [][][]byte{{{1}}, {{2}}, {{3}}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &packetProvider{packets: tt.packets, err: io.EOF}
+			provider, err := NewRepacketizingFrameProvider(source)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			for i, want := range tt.want {
+				packet, err := provider.ProvideOpusFrame()
+				if err != nil {
+					t.Fatalf("packet %d: %v", i, err)
+				}
+				info, err := ParsePacket(packet)
+				if err != nil {
+					t.Fatalf("parse packet %d: %v", i, err)
+				}
+				if len(info.Frames) != len(want) {
+					t.Fatalf("got %d frames in packet %d, want %d", len(info.Frames), i, len(want))
+				}
+				for j := range want {
+					if !bytes.Equal(info.Frames[j], want[j]) {
+						t.Fatalf("got frame %d of packet %d %v, want %v", j, i, info.Frames[j], want[j])
+					}
+				}
+			}
+			if _, err = provider.ProvideOpusFrame(); err != io.EOF {
+				t.Fatalf("got %v after the last packet, want io.EOF", err)
+			}
+			provider.Close()
+			if !source.closed {
+				t.Fatal("source not closed")
+			}
+		})
+	}
+}
+
+func TestRepacketizingFrameProviderErrors(t *testing.T) {
+	sourceErr := errors.New("source error")
+	source := &packetProvider{packets: [][]byte{nil, {0xf0, 1}}, err: sourceErr}
+	provider, err := NewRepacketizingFrameProvider(source)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer provider.Close()
+
+	if packet, err := provider.ProvideOpusFrame(); packet != nil || err != nil {
+		t.Fatalf("got %v, %v for a missing packet, want no packet", packet, err)
+	}
+	// the pending frame is provided before the error
+	if packet, err := provider.ProvideOpusFrame(); !bytes.Equal(packet, []byte{0xf0, 1}) || err != nil {
+		t.Fatalf("got %v, %v, want the pending frame", packet, err)
+	}
+	if _, err = provider.ProvideOpusFrame(); err != sourceErr {
+		t.Fatalf("got %v, want %v", err, sourceErr)
+	}
+
+	source.packets = [][]byte{{0xfb}}
+	if _, err = provider.ProvideOpusFrame(); !errors.Is(err, ErrInvalidPacket) {
+		t.Fatalf("got %v for an invalid packet, want %v", err, ErrInvalidPacket)
+	}
+}
@@ -0,0 +1,277 @@
+package pcm
+
+import (
+	"math"
+	"math/cmplx"
+	"sync/atomic"
+	"time"
+)
+
+// MinDB is the lowest level reported by an Analyzer.
+const MinDB = -120
+
+// DefaultAnalyzerConfig returns an AnalyzerConfig for 48000hz stereo PCM with 32 bands published every 50ms.
+func DefaultAnalyzerConfig() AnalyzerConfig {
+	return AnalyzerConfig{
+		SampleRate:     48000,
+		Channels:       2,
+		FFTSize:        2048,
+		Bands:          32,
+		MinFrequency:   20,
+		MaxFrequency:   20000,
+		WaveformPoints: 64,
+		Interval:       50 * time.Millisecond,
+		BufferSize:     8,
+	}
+}
+
+// AnalyzerConfig configures an Analyzer.
+type AnalyzerConfig struct {
+	SampleRate int
+	Channels   int
+
+	// FFTSize is the number of samples per FFT and must be a power of two.
+	FFTSize int
+	// Bands is the number of log-spaced frequency bands between MinFrequency and MaxFrequency.
+	Bands        int
+	MinFrequency float64
+	MaxFrequency float64
+
+	// WaveformPoints is the number of waveform points per Analysis.
+	WaveformPoints int
+
+	// Interval is how often an Analysis is published.
+	Interval time.Duration
+	// BufferSize is the capacity of the channel returned by Analyzer.Analyses. Analyses are dropped if it is full.
+	BufferSize int
+}
+
+// Analysis contains the analyzed audio of one Interval.
+type Analysis struct {
+	Time time.Time
+	// Bands are the magnitudes of the frequency bands in dBFS from MinDB to 0.
+	Bands []float64
+	// Frequencies are the center frequencies of the Bands in Hz.
+	Frequencies []float64
+	// Peak is the highest absolute sample per channel from 0 to 1.
+	Peak []float64
+	// RMS is the root mean square per channel from 0 to 1.
+	RMS []float64
+	// Waveform contains the peak of each waveform point of all channels from 0 to 1.
+	Waveform []float64
+}
+
+// ToDB converts a linear level from 0 to 1 into dBFS, clamped to MinDB.
+func ToDB(level float64) float64 {
+	if level <= 0 {
+		return MinDB
+	}
+	return math.Max(20*math.Log10(level), MinDB)
+}
+
+// NewAnalyzer creates a new *Analyzer with the given AnalyzerConfig.
+// Invalid values of the AnalyzerConfig are replaced with the values of DefaultAnalyzerConfig.
+// The Analyzer is a Filter which doesn't modify the PCM frames, use NewFilterFrameProvider or NewFilterReceiver to insert it into a chain.
+func NewAnalyzer(config AnalyzerConfig) *Analyzer {
+	defaults := DefaultAnalyzerConfig()
+	if config.SampleRate <= 0 {
+		config.SampleRate = defaults.SampleRate
+	}
+	if config.Channels <= 0 {
+		config.Channels = defaults.Channels
+	}
+	if config.FFTSize < 2 || config.FFTSize&(config.FFTSize-1) != 0 {
+		config.FFTSize = defaults.FFTSize
+	}
+	if config.Bands < 0 {
+		config.Bands = defaults.Bands
+	}
+	if config.BufferSize < 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	nyquist := float64(config.SampleRate) / 2
+	if config.MaxFrequency <= 0 || config.MaxFrequency > nyquist {
+		config.MaxFrequency = nyquist
+	}
+	if config.MinFrequency <= 0 || config.MinFrequency >= config.MaxFrequency {
+		config.MinFrequency = config.MaxFrequency / 1000
+	}
+
+	a := &Analyzer{
+		config:         config,
+		intervalFrames: int(config.Interval.Seconds() * float64(config.SampleRate)),
+		window:         make([]float64, config.FFTSize),
+		mono:           make([]float64, config.FFTSize),
+		fft:            make([]complex128, config.FFTSize),
+		peak:           make([]float64, config.Channels),
+		sumSquares:     make([]float64, config.Channels),
+		analyses:       make(chan Analysis, config.BufferSize),
+	}
+	if a.intervalFrames <= 0 {
+		a.intervalFrames = config.SampleRate / 20
+	}
+	for i := range a.window {
+		// hann window
+		a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(config.FFTSize-1))
+	}
+
+	a.bandEdges = make([]int, config.Bands+1)
+	a.frequencies = make([]float64, config.Bands)
+	ratio := config.MaxFrequency / config.MinFrequency
+	binWidth := float64(config.SampleRate) / float64(config.FFTSize)
+	for i := range a.bandEdges {
+		frequency := config.MinFrequency * math.Pow(ratio, float64(i)/float64(config.Bands))
+		a.bandEdges[i] = int(math.Round(frequency / binWidth))
+	}
+	for i := range a.frequencies {
+		a.frequencies[i] = config.MinFrequency * math.Pow(ratio, (float64(i)+0.5)/float64(config.Bands))
+	}
+	return a
+}
+
+// Analyzer publishes frequency bands, levels and waveform points of the PCM frames it processes.
+// Process never blocks: the latest Analysis is stored atomically and Analyses drops Analysis values nobody reads.
+type Analyzer struct {
+	config         AnalyzerConfig
+	intervalFrames int
+	window         []float64
+	bandEdges      []int
+	frequencies    []float64
+
+	// mono is a ring buffer of the last FFTSize downmixed samples.
+	mono    []float64
+	monoPos int
+	fft     []complex128
+
+	frames     int
+	peak       []float64
+	sumSquares []float64
+	waveform   []float64
+
+	latest   atomic.Value
+	analyses chan Analysis
+}
+
+// Snapshot returns the latest Analysis or false if none was published yet.
+func (a *Analyzer) Snapshot() (Analysis, bool) {
+	analysis, ok := a.latest.Load().(Analysis)
+	return analysis, ok
+}
+
+// Analyses returns a channel which receives every published Analysis. Analysis values are dropped while the channel is full.
+func (a *Analyzer) Analyses() <-chan Analysis {
+	return a.analyses
+}
+
+func (a *Analyzer) Process(pcm []int16) {
+	channels := a.config.Channels
+	for i := 0; i+channels <= len(pcm); i += channels {
+		var mono float64
+		for ch := 0; ch < channels; ch++ {
+			sample := float64(pcm[i+ch]) / 32768
+			mono += sample
+			if abs := math.Abs(sample); abs > a.peak[ch] {
+				a.peak[ch] = abs
+			}
+			a.sumSquares[ch] += sample * sample
+		}
+		mono /= float64(channels)
+		a.mono[a.monoPos] = mono
+		if a.monoPos++; a.monoPos == len(a.mono) {
+			a.monoPos = 0
+		}
+		a.waveform = append(a.waveform, math.Abs(mono))
+
+		if a.frames++; a.frames >= a.intervalFrames {
+			a.publish()
+		}
+	}
+}
+
+func (a *Analyzer) publish() {
+	analysis := Analysis{
+		Time:        time.Now(),
+		Bands:       a.bands(),
+		Frequencies: a.frequencies,
+		Peak:        make([]float64, len(a.peak)),
+		RMS:         make([]float64, len(a.peak)),
+		Waveform:    a.downsampleWaveform(),
+	}
+	for ch := range a.peak {
+		analysis.Peak[ch] = a.peak[ch]
+		analysis.RMS[ch] = math.Sqrt(a.sumSquares[ch] / float64(a.frames))
+		a.peak[ch] = 0
+		a.sumSquares[ch] = 0
+	}
+	a.frames = 0
+	a.waveform = a.waveform[:0]
+
+	a.latest.Store(analysis)
+	select {
+	case a.analyses <- analysis:
+	default:
+	}
+}
+
+func (a *Analyzer) bands() []float64 {
+	size := len(a.mono)
+	for i := range a.fft {
+		a.fft[i] = complex(a.mono[(a.monoPos+i)%size]*a.window[i], 0)
+	}
+	fft(a.fft)
+
+	bands := make([]float64, a.config.Bands)
+	for b := range bands {
+		start, end := a.bandEdges[b], a.bandEdges[b+1]
+		if end <= start {
+			end = start + 1
+		}
+		var magnitude float64
+		for bin := start; bin < end && bin < size/2; bin++ {
+			magnitude = math.Max(magnitude, cmplx.Abs(a.fft[bin]))
+		}
+		// a full scale sine results in 0dBFS with the coherent gain of the hann window (0.5)
+		bands[b] = ToDB(magnitude * 4 / float64(size))
+	}
+	return bands
+}
+
+func (a *Analyzer) downsampleWaveform() []float64 {
+	points := a.config.WaveformPoints
+	if points <= 0 || len(a.waveform) == 0 {
+		return nil
+	}
+	waveform := make([]float64, points)
+	for i, sample := range a.waveform {
+		point := i * points / len(a.waveform)
+		waveform[point] = math.Max(waveform[point], sample)
+	}
+	return waveform
+}
+
+// fft is an in-place iterative radix-2 fast fourier transform. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package pcm
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+)
+
+// sineFrame returns frames of a sine with the given frequency and amplitude from 0 to 1 on the given channel.
+func sineFrame(sampleRate int, channels int, channel int, frequency float64, amplitude float64, frames int) []int16 {
+	pcm := make([]int16, frames*channels)
+	for i := 0; i < frames; i++ {
+		pcm[i*channels+channel] = int16(amplitude * 32767 * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+	}
+	return pcm
+}
+
+func TestFFT(t *testing.T) {
+	// an impulse has a flat spectrum
+	x := []complex128{1, 0, 0, 0, 0, 0, 0, 0}
+	fft(x)
+	for i, v := range x {
+		if cmplx.Abs(v-1) > 1e-9 {
+			t.Fatalf("got bin %d %v for an impulse, want 1", i, v)
+		}
+	}
+
+	// a cosine with 4 periods puts half of its energy into bin 4 and its mirror
+	x = make([]complex128, 64)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*4*float64(i)/64), 0)
+	}
+	fft(x)
+	for i, v := range x {
+		want := 0.0
+		if i == 4 || i == 60 {
+			want = 32
+		}
+		if math.Abs(cmplx.Abs(v)-want) > 1e-9 {
+			t.Fatalf("got magnitude %f in bin %d, want %f", cmplx.Abs(v), i, want)
+		}
+	}
+}
+
+func TestAnalyzerBands(t *testing.T) {
+	config := DefaultAnalyzerConfig()
+	analyzer := NewAnalyzer(config)
+
+	// a full scale sine in the center of a FFT bin at about 1kHz
+	frequency := 43 * float64(config.SampleRate) / float64(config.FFTSize)
+	analyzer.Process(sineFrame(config.SampleRate, config.Channels, 0, frequency, 1, config.SampleRate/20))
+	analysis, ok := analyzer.Snapshot()
+	if !ok {
+		t.Fatal("no analysis published after an interval")
+	}
+
+	loudest := 0
+	for b := range analysis.Bands {
+		if analysis.Bands[b] > analysis.Bands[loudest] {
+			loudest = b
+		}
+	}
+	if analysis.Bands[loudest] < -7.5 || analysis.Bands[loudest] > -5 {
+		t.Fatalf("got %fdBFS for the band of the sine, want about -6dBFS for a sine on one of two channels", analysis.Bands[loudest])
+	}
+	if f := analysis.Frequencies[loudest]; f < frequency/1.5 || f > frequency*1.5 {
+		t.Fatalf("got the loudest band at %fHz, want it around %fHz", f, frequency)
+	}
+	if analysis.Bands[0] > -60 || analysis.Bands[len(analysis.Bands)-1] > -60 {
+		t.Fatalf("got %fdBFS and %fdBFS for the outer bands, want silence", analysis.Bands[0], analysis.Bands[len(analysis.Bands)-1])
+	}
+}
+
+func TestAnalyzerLevels(t *testing.T) {
+	config := DefaultAnalyzerConfig()
+	analyzer := NewAnalyzer(config)
+	analyzer.Process(sineFrame(config.SampleRate, config.Channels, 0, 1000, 0.5, config.SampleRate/20))
+
+	analysis, ok := analyzer.Snapshot()
+	if !ok {
+		t.Fatal("no analysis published after an interval")
+	}
+	if math.Abs(analysis.Peak[0]-0.5) > 0.01 || math.Abs(analysis.RMS[0]-0.5/math.Sqrt2) > 0.01 {
+		t.Fatalf("got peak %f and rms %f, want 0.5 and %f", analysis.Peak[0], analysis.RMS[0], 0.5/math.Sqrt2)
+	}
+	if analysis.Peak[1] != 0 || analysis.RMS[1] != 0 {
+		t.Fatalf("got peak %f and rms %f for the silent channel, want 0", analysis.Peak[1], analysis.RMS[1])
+	}
+	if math.Abs(ToDB(analysis.Peak[0])+6.02) > 0.2 || ToDB(0) != MinDB {
+		t.Fatalf("got %fdBFS for the peak and %fdBFS for silence, want -6dBFS and %d", ToDB(analysis.Peak[0]), ToDB(0), MinDB)
+	}
+}
+
+func TestAnalyzerDownsampleWaveform(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		points   int
+		waveform []float64
+		want     []float64
+	}{
+		{name: "peak per point", points: 4, waveform: []float64{0.1, 0.2, 0.4, 0.3, 0, 0, 0.9, 0.5}, want: []float64{0.2, 0.4, 0, 0.9}},
+		{name: "fewer samples than points", points: 4, waveform: []float64{0.5, 0.7}, want: []float64{0.5, 0, 0.7, 0}},
+		{name: "disabled", points: 0, waveform: []float64{0.5}},
+		{name: "no samples", points: 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Analyzer{config: AnalyzerConfig{WaveformPoints: tt.points}, waveform: tt.waveform}
+			got := a.downsampleWaveform()
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzerDropsAnalysesWhileFull(t *testing.T) {
+	config := DefaultAnalyzerConfig()
+	config.Interval = 10 * time.Millisecond
+	config.BufferSize = 1
+	analyzer := NewAnalyzer(config)
+
+	// three intervals without reading the channel, the first is 0.25 and the last 0.75
+	for _, amplitude := range []float64{0.25, 0.5, 0.75} {
+		analyzer.Process(sineFrame(config.SampleRate, config.Channels, 0, 1000, amplitude, config.SampleRate/100))
+	}
+	if len(analyzer.Analyses()) != 1 {
+		t.Fatalf("got %d buffered analyses, want 1", len(analyzer.Analyses()))
+	}
+	if analysis := <-analyzer.Analyses(); math.Abs(analysis.Peak[0]-0.25) > 0.01 {
+		t.Fatalf("got peak %f for the buffered analysis, want the first interval", analysis.Peak[0])
+	}
+	if analysis, _ := analyzer.Snapshot(); math.Abs(analysis.Peak[0]-0.75) > 0.01 {
+		t.Fatalf("got peak %f for the latest analysis, want the last interval", analysis.Peak[0])
+	}
+}
+
+func TestAnalyzerInvalidConfig(t *testing.T) {
+	analyzer := NewAnalyzer(AnalyzerConfig{Bands: 8})
+	defaults := DefaultAnalyzerConfig()
+	if analyzer.config.SampleRate != defaults.SampleRate || analyzer.config.Channels != defaults.Channels || analyzer.config.FFTSize != defaults.FFTSize {
+		t.Fatalf("got %+v, want the defaults for the missing values", analyzer.config)
+	}
+	for _, frequency := range analyzer.frequencies {
+		if math.IsNaN(frequency) || frequency <= 0 {
+			t.Fatalf("got band frequencies %v, want positive frequencies", analyzer.frequencies)
+		}
+	}
+
+	analyzer.Process(make([]int16, defaults.SampleRate/20*defaults.Channels))
+	if _, ok := analyzer.Snapshot(); !ok {
+		t.Fatal("no analysis published after an interval")
+	}
+}
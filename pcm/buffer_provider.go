@@ -4,58 +4,126 @@ import (
 	"context"
 	"errors"
 	"io"
-	"sync/atomic"
+	"sync"
+	"time"
 )
 
+const (
+	// bufferFrames is the number of PCM frames buffered by a FrameProvider created with NewBufferPCMProvider.
+	bufferFrames = 10
+	// bufferMaxSkips is the number of consecutive transient errors skipped before the error is returned.
+	bufferMaxSkips = 50
+	// bufferSkipBackoff is the time waited after skipping a transient error, so a persistent error doesn't spin.
+	bufferSkipBackoff = 10 * time.Millisecond
+)
+
+// NewBufferPCMProvider creates a new FrameProvider which reads PCM frames from the given FrameProvider in a background goroutine and buffers up to 10 of them.
+// ProvidePCMFrame never blocks and returns nil if the buffer is empty.
+// Errors are returned in order with the buffered frames. Up to 50 consecutive transient errors, see ErrorClassTransient, are skipped.
+// The buffer keeps reading after an error unless it is a ClassifiedError of ErrorClassFatal or ErrorClassExhausted.
+// Wrap the FrameProvider with NewErrorPolicyFrameProvider to handle errors differently.
 func NewBufferPCMProvider(provider FrameProvider) FrameProvider {
-	ctx, cancel := context.WithCancel(context.Background())
+	return NewBufferPCMProviderContext(context.Background(), provider)
+}
+
+// NewBufferPCMProviderContext creates a new FrameProvider like NewBufferPCMProvider. The background goroutine stops once ctx is done.
+// Close and Shutdown close the given FrameProvider first, so a ProvidePCMFrame call of the background goroutine waiting for data returns, and then await the background goroutine.
+func NewBufferPCMProviderContext(ctx context.Context, provider FrameProvider) FrameProvider {
 	buffer := &bufferFrameProvider{
 		provider: provider,
-		cancel:   cancel,
+		frames:   make(chan bufferedFrame, bufferFrames),
 	}
-
-	go buffer.process(ctx)
+	buffer.Lifecycle = NewLifecycle(ctx, buffer.closeProvider)
+	buffer.SetInterruptFunc(func() {
+		_ = buffer.closeProvider()
+	})
+	buffer.Go(buffer.process)
 	return buffer
 }
 
+// bufferedFrame is a frame or an error of the buffered FrameProvider.
+type bufferedFrame struct {
+	frame []int16
+	err   error
+}
+
 type bufferFrameProvider struct {
-	provider   FrameProvider
-	buff       [][]int16
-	processing atomic.Bool
-	cancel     context.CancelFunc
+	*Lifecycle
+	provider FrameProvider
+	frames   chan bufferedFrame
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 func (p *bufferFrameProvider) process(ctx context.Context) {
-	defer p.Close()
+	defer close(p.frames)
+	var skips int
 	for {
-		select {
-		case <-ctx.Done():
+		frame, err := p.provider.ProvidePCMFrame()
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, io.EOF) {
 			return
-		default:
-			if len(p.buff) >= 10 {
+		}
+		if err != nil {
+			class := ClassifyError(err)
+			if class == ErrorClassTransient && skips < bufferMaxSkips {
+				skips++
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(bufferSkipBackoff):
+				}
 				continue
 			}
-			frame, err := p.provider.ProvidePCMFrame()
-			if errors.Is(err, io.EOF) {
+			if !p.send(ctx, bufferedFrame{err: err}) {
 				return
 			}
-			if err != nil {
-				continue
+			var classifiedErr *ClassifiedError
+			if errors.As(err, &classifiedErr) && class != ErrorClassTransient {
+				return
 			}
-			p.buff = append(p.buff, frame)
+			continue
+		}
+		skips = 0
+
+		// the provider may reuse its buffer for the next frame
+		buffered := make([]int16, len(frame))
+		copy(buffered, frame)
+		if !p.send(ctx, bufferedFrame{frame: buffered}) {
+			return
 		}
 	}
 }
 
-func (p *bufferFrameProvider) ProvidePCMFrame() (frame []int16, err error) {
-	if len(p.buff) == 0 {
-		return
+// send waits until the bufferedFrame is buffered and returns false if ctx is done first.
+func (p *bufferFrameProvider) send(ctx context.Context, frame bufferedFrame) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case p.frames <- frame:
+		return true
 	}
-	frame, p.buff = p.buff[0], p.buff[1:]
-	return
 }
 
-func (p *bufferFrameProvider) Close() {
-	p.cancel()
-	p.provider.Close()
+// closeProvider closes the wrapped FrameProvider once and returns the error of the first call.
+func (p *bufferFrameProvider) closeProvider() error {
+	p.closeOnce.Do(func() {
+		p.closeErr = Shutdown(context.Background(), p.provider)
+	})
+	return p.closeErr
+}
+
+func (p *bufferFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	select {
+	case buffered, ok := <-p.frames:
+		if !ok {
+			return nil, io.EOF
+		}
+		return buffered.frame, buffered.err
+	default:
+		return nil, nil
+	}
 }
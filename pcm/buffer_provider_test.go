@@ -0,0 +1,253 @@
+package pcm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// feedProvider provides the frames written to it and blocks while it is empty, like the blocking mp3 feed provider.
+type feedProvider struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	frames [][]int16
+	eof    bool
+	closed bool
+}
+
+func newFeedProvider() *feedProvider {
+	p := &feedProvider{}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+func (p *feedProvider) write(frame []int16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frames = append(p.frames, frame)
+	p.cond.Broadcast()
+}
+
+func (p *feedProvider) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.eof = true
+	p.cond.Broadcast()
+}
+
+func (p *feedProvider) ProvidePCMFrame() ([]int16, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.frames) == 0 {
+		if p.closed || p.eof {
+			return nil, io.EOF
+		}
+		p.cond.Wait()
+	}
+	frame := p.frames[0]
+	p.frames = p.frames[1:]
+	return frame, nil
+}
+
+func (p *feedProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.cond.Broadcast()
+}
+
+// provideFrame polls the FrameProvider until it provides a frame or an error.
+func provideFrame(t *testing.T, p FrameProvider) ([]int16, error) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		frame, err := p.ProvidePCMFrame()
+		if frame != nil || err != nil {
+			return frame, err
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no frame provided")
+	return nil, nil
+}
+
+func TestBufferProviderShutdownUnblocksSource(t *testing.T) {
+	checkGoroutines(t)
+
+	source := newFeedProvider()
+	buffer := NewBufferPCMProvider(source)
+
+	// the background goroutine is now waiting for data
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- buffer.(Shutdowner).Shutdown(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shutdown blocked on the waiting source")
+	}
+	if !source.closed {
+		t.Fatal("source was not closed")
+	}
+}
+
+func TestBufferProviderProvidesCopies(t *testing.T) {
+	checkGoroutines(t)
+
+	source := newFeedProvider()
+	buffer := NewBufferPCMProvider(source)
+	defer buffer.Close()
+
+	frame := []int16{1, 2, 3, 4}
+	source.write(frame)
+	got, err := provideFrame(t, buffer)
+	if err != nil {
+		t.Fatalf("provide: %v", err)
+	}
+	frame[0] = 42
+	if got[0] != 1 {
+		t.Fatal("buffered frame shares memory with the source frame")
+	}
+}
+
+func TestBufferProviderEOF(t *testing.T) {
+	checkGoroutines(t)
+
+	source := newFeedProvider()
+	buffer := NewBufferPCMProvider(source)
+	defer buffer.Close()
+
+	source.write([]int16{1, 2})
+	source.write([]int16{3, 4})
+	source.end()
+
+	for i := 0; i < 2; i++ {
+		if _, err := provideFrame(t, buffer); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+	if _, err := provideFrame(t, buffer); err != io.EOF {
+		t.Fatalf("got %v after the last frame, want io.EOF", err)
+	}
+}
+
+func TestBufferProviderContextCancel(t *testing.T) {
+	checkGoroutines(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	source := newFeedProvider()
+	buffer := NewBufferPCMProviderContext(ctx, source)
+
+	cancel()
+	// the canceled goroutine stops as soon as the source returns
+	source.write([]int16{1, 2})
+	if _, err := provideFrame(t, buffer); err != io.EOF {
+		t.Fatalf("got %v after cancel, want io.EOF", err)
+	}
+	buffer.Close()
+}
+
+// scriptedProvider returns the scripted results in order and io.EOF afterwards.
+type scriptedProvider struct {
+	mu      sync.Mutex
+	results []bufferedFrame
+}
+
+func (p *scriptedProvider) ProvidePCMFrame() ([]int16, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.results) == 0 {
+		return nil, io.EOF
+	}
+	result := p.results[0]
+	p.results = p.results[1:]
+	return result.frame, result.err
+}
+
+func (p *scriptedProvider) Close() {}
+
+func TestBufferProviderPropagatesErrors(t *testing.T) {
+	checkGoroutines(t)
+
+	sourceErr := errors.New("source failed")
+	source := &scriptedProvider{results: []bufferedFrame{
+		{frame: []int16{1, 2}},
+		{err: sourceErr},
+		{frame: []int16{3, 4}},
+	}}
+	buffer := NewBufferPCMProvider(source)
+	defer buffer.Close()
+
+	if frame, err := provideFrame(t, buffer); err != nil || frame[0] != 1 {
+		t.Fatalf("got %v, %v, want the first frame", frame, err)
+	}
+	if _, err := provideFrame(t, buffer); err != sourceErr {
+		t.Fatalf("got %v, want the unchanged source error", err)
+	}
+	if frame, err := provideFrame(t, buffer); err != nil || frame[0] != 3 {
+		t.Fatalf("got %v, %v, want the frame after the error", frame, err)
+	}
+	if _, err := provideFrame(t, buffer); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+}
+
+func TestBufferProviderStopsOnFatalError(t *testing.T) {
+	checkGoroutines(t)
+
+	fatalErr := NewFatalError(errors.New("codec broken"))
+	source := &scriptedProvider{results: []bufferedFrame{
+		{err: fatalErr},
+		{frame: []int16{1, 2}},
+	}}
+	buffer := NewBufferPCMProvider(source)
+	defer buffer.Close()
+
+	if _, err := provideFrame(t, buffer); err != fatalErr {
+		t.Fatalf("got %v, want %v", err, fatalErr)
+	}
+	if _, err := provideFrame(t, buffer); err != io.EOF {
+		t.Fatalf("got %v after a fatal error, want io.EOF", err)
+	}
+}
+
+func TestBufferProviderLimitsSkips(t *testing.T) {
+	checkGoroutines(t)
+
+	transientErr := NewTransientError(errors.New("corrupt frame"))
+	results := []bufferedFrame{{err: transientErr}, {frame: []int16{1, 2}}}
+	for i := 0; i < bufferMaxSkips+1; i++ {
+		results = append(results, bufferedFrame{err: transientErr})
+	}
+	source := &scriptedProvider{results: results}
+	buffer := NewBufferPCMProvider(source)
+	defer buffer.Close()
+
+	// a single transient error is skipped
+	if frame, err := provideFrame(t, buffer); err != nil || frame[0] != 1 {
+		t.Fatalf("got %v, %v, want the frame after the skipped error", frame, err)
+	}
+
+	start := time.Now()
+	var err error
+	for deadline := start.Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if _, err = buffer.ProvidePCMFrame(); err != nil {
+			break
+		}
+	}
+	if err != transientErr {
+		t.Fatalf("got %v, want the transient error after %d skips", err, bufferMaxSkips)
+	}
+	if elapsed := time.Since(start); elapsed < bufferMaxSkips*bufferSkipBackoff/2 {
+		t.Fatalf("skipped %d errors in %s without backing off", bufferMaxSkips, elapsed)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/disgoorg/audio/metrics"
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/log"
 	"github.com/disgoorg/snowflake/v2"
@@ -14,23 +15,38 @@ import (
 
 // NewPCMCombinerReceiver creates a new FrameReceiver which combines multiple Packet(s) into a single CombinedPacket.
 // You can process the CombinedPacket by passing a CombinedFrameReceiver.
-func NewPCMCombinerReceiver(logger log.Logger, pcmCombinedFrameReceiver CombinedFrameReceiver) FrameReceiver {
-	if logger == nil {
-		logger = log.Default()
+// The packets are combined every 20ms in a background goroutine which is awaited by Close and Shutdown before the CombinedFrameReceiver is closed.
+func NewPCMCombinerReceiver(logger log.Logger, pcmCombinedFrameReceiver CombinedFrameReceiver, opts ...CombinerConfigOpt) FrameReceiver {
+	config := DefaultCombinerConfig()
+	if logger != nil {
+		config.Logger = logger
 	}
+	config.Apply(opts)
+
 	receiver := &pcmCombinerReceiver{
-		logger:                   logger,
+		logger:                   config.Logger,
+		meter:                    config.Meter,
+		framesCombined:           config.Metrics.Counter(metrics.FramesCombined),
+		combineSeconds:           config.Metrics.Histogram(metrics.CombineSeconds),
+		droppedPackets:           config.Metrics.Counter(metrics.DroppedPackets),
 		pcmCombinedFrameReceiver: pcmCombinedFrameReceiver,
 		queue:                    map[snowflake.ID]*[]audioData{},
 	}
-	go receiver.startCombinePackets()
+	receiver.Lifecycle = NewLifecycle(config.Context, func() error {
+		return Shutdown(context.Background(), pcmCombinedFrameReceiver)
+	})
+	receiver.Go(receiver.startCombinePackets)
 	return receiver
 }
 
 type pcmCombinerReceiver struct {
+	*Lifecycle
 	logger                   log.Logger
+	meter                    *Meter
+	framesCombined           metrics.Counter
+	combineSeconds           metrics.Histogram
+	droppedPackets           metrics.Counter
 	pcmCombinedFrameReceiver CombinedFrameReceiver
-	cancelFunc               context.CancelFunc
 	queue                    map[snowflake.ID]*[]audioData
 	queueMu                  sync.Mutex
 }
@@ -61,16 +77,12 @@ func (r *pcmCombinerReceiver) ReceivePCMFrame(userID snowflake.ID, packet *Packe
 	return nil
 }
 
-func (r *pcmCombinerReceiver) startCombinePackets() {
+func (r *pcmCombinerReceiver) startCombinePackets(ctx context.Context) {
 	lastFrameSent := time.Now().UnixMilli()
-	ctx, cancel := context.WithCancel(context.Background())
-	r.cancelFunc = cancel
-	defer cancel()
-loop:
 	for {
 		select {
 		case <-ctx.Done():
-			break loop
+			return
 
 		default:
 			if err := r.combinePackets(); err != nil {
@@ -78,7 +90,11 @@ loop:
 			}
 			sleepTime := time.Duration(opus.FrameSize - (time.Now().UnixMilli() - lastFrameSent))
 			if sleepTime > 0 {
-				time.Sleep(sleepTime * time.Millisecond)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(sleepTime * time.Millisecond):
+				}
 			}
 			if time.Now().UnixMilli() < lastFrameSent+opus.FrameSize*2 {
 				lastFrameSent += opus.FrameSize
@@ -92,7 +108,8 @@ loop:
 func (r *pcmCombinerReceiver) combinePackets() error {
 	r.queueMu.Lock()
 	defer r.queueMu.Unlock()
-	now := time.Now().UnixMilli()
+	start := time.Now()
+	now := start.UnixMilli()
 	var audioParts []audioData
 	var audioLen int
 	for _, packets := range r.queue {
@@ -104,6 +121,7 @@ func (r *pcmCombinerReceiver) combinePackets() error {
 		*data, *packets = (*packets)[0], (*packets)[1:]
 		for len(*packets) > 0 && now-data.time > 100 {
 			*data, *packets = (*packets)[0], (*packets)[1:]
+			r.droppedPackets.Add(1)
 		}
 		if data == nil {
 			continue
@@ -140,6 +158,11 @@ func (r *pcmCombinerReceiver) combinePackets() error {
 		}
 		i++
 	}
+	if r.meter != nil {
+		r.meter.Process(combinedPacket.PCM)
+	}
+	metrics.ObserveDuration(r.combineSeconds, start)
+	r.framesCombined.Add(1)
 	return r.pcmCombinedFrameReceiver.ReceiveCombinedPCMFrame(userIds, combinedPacket)
 }
 
@@ -149,11 +172,6 @@ func (r *pcmCombinerReceiver) CleanupUser(userID snowflake.ID) {
 	delete(r.queue, userID)
 }
 
-func (r *pcmCombinerReceiver) Close() {
-	r.cancelFunc()
-	r.pcmCombinedFrameReceiver.Close()
-}
-
 type audioData struct {
 	time   int64
 	userID snowflake.ID
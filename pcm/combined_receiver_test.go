@@ -0,0 +1,69 @@
+package pcm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+type combinedRecorder struct {
+	mu      sync.Mutex
+	packets []*CombinedPacket
+	closed  int
+}
+
+func (r *combinedRecorder) ReceiveCombinedPCMFrame(_ []snowflake.ID, packet *CombinedPacket) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packets = append(r.packets, packet)
+	return nil
+}
+
+func (r *combinedRecorder) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed++
+}
+
+func TestCombinerReceiverShutdown(t *testing.T) {
+	checkGoroutines(t)
+
+	recorder := &combinedRecorder{}
+	receiver := NewPCMCombinerReceiver(nil, recorder)
+	if err := receiver.ReceivePCMFrame(1, &Packet{PCM: []int16{100, 100}}); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if err := receiver.(Shutdowner).Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	receiver.Close()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.packets) != 1 {
+		t.Fatalf("combined %d packets, want 1", len(recorder.packets))
+	}
+	if recorder.closed != 1 {
+		t.Fatalf("combined receiver closed %d times, want 1", recorder.closed)
+	}
+}
+
+func TestCombinerReceiverContextCancel(t *testing.T) {
+	checkGoroutines(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	receiver := NewPCMCombinerReceiver(nil, &combinedRecorder{}, WithCombinerContext(ctx))
+	cancel()
+	// the combining goroutine exits on its own, Close only closes the CombinedFrameReceiver
+	select {
+	case <-receiver.(*pcmCombinerReceiver).Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("receiver context not done after cancel")
+	}
+	receiver.Close()
+}
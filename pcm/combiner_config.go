@@ -0,0 +1,62 @@
+package pcm
+
+import (
+	"context"
+
+	"github.com/disgoorg/audio/metrics"
+	"github.com/disgoorg/log"
+)
+
+// DefaultCombinerConfig returns a CombinerConfig with sensible defaults.
+func DefaultCombinerConfig() *CombinerConfig {
+	return &CombinerConfig{
+		Context: context.Background(),
+		Logger:  log.Default(),
+		Metrics: metrics.Noop,
+	}
+}
+
+// CombinerConfig is used to configure the FrameReceiver created by NewPCMCombinerReceiver.
+type CombinerConfig struct {
+	// Context stops the combining goroutine once it is done.
+	Context context.Context
+	Logger  log.Logger
+
+	// Meter measures the combined PCM frames and counts the samples clipped by mixing.
+	Meter *Meter
+
+	// Metrics records metrics.FramesCombined, metrics.CombineSeconds and metrics.DroppedPackets.
+	Metrics metrics.Metrics
+}
+
+// CombinerConfigOpt is used to functionally configure a CombinerConfig.
+type CombinerConfigOpt func(config *CombinerConfig)
+
+// Apply applies the CombinerConfigOpt(s) to the CombinerConfig.
+func (c *CombinerConfig) Apply(opts []CombinerConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.Metrics = metrics.OrNoop(c.Metrics)
+}
+
+// WithCombinerMeter sets the Meter which measures the combined PCM frames.
+func WithCombinerMeter(meter *Meter) CombinerConfigOpt {
+	return func(config *CombinerConfig) {
+		config.Meter = meter
+	}
+}
+
+// WithCombinerMetrics sets the metrics.Metrics of the CombinerConfig.
+func WithCombinerMetrics(m metrics.Metrics) CombinerConfigOpt {
+	return func(config *CombinerConfig) {
+		config.Metrics = m
+	}
+}
+
+// WithCombinerContext sets the context.Context which stops the combining goroutine once it is done.
+func WithCombinerContext(ctx context.Context) CombinerConfigOpt {
+	return func(config *CombinerConfig) {
+		config.Context = ctx
+	}
+}
@@ -0,0 +1,102 @@
+package pcm
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// silenceThreshold is the gain at which a decaying tail is considered silent (-60dB).
+const silenceThreshold = 0.001
+
+// NoteDuration returns the duration of a note at the given tempo in beats per minute.
+// note is the fraction of a whole note, for example 0.25 for a quarter note or 0.375 for a dotted quarter note.
+// This can be used to sync the DelayFilter to the tempo of a song.
+func NoteDuration(bpm float64, note float64) time.Duration {
+	return time.Duration(60 / bpm * 4 * note * float64(time.Second))
+}
+
+// feedbackTail returns how long a signal repeated every delay with the given feedback needs to decay below the silenceThreshold.
+func feedbackTail(delay time.Duration, feedback float64) time.Duration {
+	feedback = math.Abs(feedback)
+	if feedback < silenceThreshold {
+		return delay
+	}
+	if feedback >= 1 {
+		feedback = 0.999
+	}
+	repeats := math.Ceil(math.Log(silenceThreshold) / math.Log(feedback))
+	return time.Duration(repeats+1) * delay
+}
+
+// NewDelayFilter creates a new *DelayFilter for interleaved PCM frames with the given sample rate and channels.
+// feedback from 0 to <1 controls how much of each echo is repeated and wet how loud the echoes are mixed into the signal.
+func NewDelayFilter(sampleRate int, channels int, delay time.Duration, feedback float64, wet float64) *DelayFilter {
+	f := &DelayFilter{
+		sampleRate: sampleRate,
+		channels:   filterChannels(channels),
+	}
+	f.SetDelay(delay)
+	f.SetFeedback(feedback)
+	f.SetWet(wet)
+	return f
+}
+
+// DelayFilter is a feedback delay which produces repeating echoes.
+type DelayFilter struct {
+	sampleRate int
+	channels   int
+	delay      time.Duration
+	feedback   float64
+	wet        float64
+	buff       []float64
+	pos        int
+	mu         sync.Mutex
+}
+
+// SetDelay sets the time between echoes. Changing the delay clears the pending echoes.
+func (f *DelayFilter) SetDelay(delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	samples := int(delay.Seconds() * float64(f.sampleRate))
+	if samples < 1 {
+		samples = 1
+	}
+	f.delay = delay
+	f.buff = make([]float64, samples*f.channels)
+	f.pos = 0
+}
+
+// SetFeedback sets how much of each echo is repeated from 0 to 0.99.
+func (f *DelayFilter) SetFeedback(feedback float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.feedback = clampFloat(feedback, 0, 0.99)
+}
+
+// SetWet sets the level of the echoes from 0 to 1.
+func (f *DelayFilter) SetWet(wet float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.wet = clampFloat(wet, 0, 1)
+}
+
+func (f *DelayFilter) Tail() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return feedbackTail(f.delay, f.feedback)
+}
+
+func (f *DelayFilter) Process(pcm []int16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range pcm {
+		in := float64(pcm[i])
+		delayed := f.buff[f.pos]
+		f.buff[f.pos] = in + delayed*f.feedback
+		pcm[i] = clampSample(in + delayed*f.wet)
+		if f.pos++; f.pos == len(f.buff) {
+			f.pos = 0
+		}
+	}
+}
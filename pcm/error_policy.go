@@ -0,0 +1,206 @@
+package pcm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/disgoorg/audio/opus"
+)
+
+// ErrorClass classifies an error of a FrameProvider, FrameReceiver or codec.
+type ErrorClass int
+
+const (
+	// ErrorClassTransient is an error affecting a single frame, like a corrupt packet. The following frames are likely fine.
+	ErrorClassTransient ErrorClass = iota
+	// ErrorClassExhausted means the source has no more frames, like io.EOF.
+	ErrorClassExhausted
+	// ErrorClassFatal is an error after which no further frames can be produced, like a broken codec or source.
+	ErrorClassFatal
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassTransient:
+		return "transient"
+	case ErrorClassExhausted:
+		return "exhausted"
+	case ErrorClassFatal:
+		return "fatal"
+	default:
+		return fmt.Sprintf("ErrorClass(%d)", int(c))
+	}
+}
+
+// ClassifiedError is an error with an ErrorClass.
+type ClassifiedError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return fmt.Sprintf("%s error: %s", e.Class, e.Err)
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// NewTransientError wraps the given error as ErrorClassTransient.
+func NewTransientError(err error) error {
+	return &ClassifiedError{Class: ErrorClassTransient, Err: err}
+}
+
+// NewFatalError wraps the given error as ErrorClassFatal.
+func NewFatalError(err error) error {
+	return &ClassifiedError{Class: ErrorClassFatal, Err: err}
+}
+
+// ClassifyError returns the ErrorClass of the given error.
+// A ClassifiedError keeps its ErrorClass, io.EOF and io.ErrUnexpectedEOF are ErrorClassExhausted, invalid Opus packets are ErrorClassTransient and everything else is ErrorClassFatal.
+func ClassifyError(err error) ErrorClass {
+	var classifiedErr *ClassifiedError
+	switch {
+	case errors.As(err, &classifiedErr):
+		return classifiedErr.Class
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorClassExhausted
+	case errors.Is(err, opus.ErrInvalidPacket), errors.Is(err, opus.ErrBufferTooSmall):
+		return ErrorClassTransient
+	default:
+		return ErrorClassFatal
+	}
+}
+
+// ErrorAction is what a stage does when an error occurs.
+type ErrorAction int
+
+const (
+	// ErrorActionAbort returns the error.
+	ErrorActionAbort ErrorAction = iota
+	// ErrorActionSkip drops the frame. FrameProvider(s) return no frame, FrameReceiver(s) don't pass the frame on.
+	ErrorActionSkip
+	// ErrorActionSilence substitutes the frame with silence.
+	ErrorActionSilence
+	// ErrorActionRetry retries up to ErrorPolicy.Retries times and aborts afterwards.
+	ErrorActionRetry
+	// ErrorActionEnd ends the stream like the source was exhausted. FrameProvider(s) return io.EOF, other stages abort.
+	ErrorActionEnd
+)
+
+func (a ErrorAction) String() string {
+	switch a {
+	case ErrorActionAbort:
+		return "abort"
+	case ErrorActionSkip:
+		return "skip"
+	case ErrorActionSilence:
+		return "silence"
+	case ErrorActionRetry:
+		return "retry"
+	case ErrorActionEnd:
+		return "end"
+	default:
+		return fmt.Sprintf("ErrorAction(%d)", int(a))
+	}
+}
+
+// DefaultErrorPolicy returns an ErrorPolicy which substitutes transient errors with silence and aborts on fatal errors.
+func DefaultErrorPolicy() ErrorPolicy {
+	return ErrorPolicy{
+		Transient: ErrorActionSilence,
+		Fatal:     ErrorActionAbort,
+		Retries:   3,
+	}
+}
+
+// ErrorPolicy configures how a stage handles errors per ErrorClass. ErrorClassExhausted errors always end the stream.
+// The zero value aborts on every error.
+type ErrorPolicy struct {
+	Transient ErrorAction
+	Fatal     ErrorAction
+	// Retries is the number of consecutive retries of ErrorActionRetry.
+	Retries int
+
+	// OnError is called with every handled error and the ErrorAction taken. It can be nil.
+	OnError func(err error, class ErrorClass, action ErrorAction)
+}
+
+// Action returns the ErrorAction for the given error. retries is the number of consecutive retries so far, once it reaches Retries ErrorActionRetry turns into ErrorActionAbort.
+func (p ErrorPolicy) Action(err error, retries int) ErrorAction {
+	class := ClassifyError(err)
+	var action ErrorAction
+	switch class {
+	case ErrorClassTransient:
+		action = p.Transient
+	case ErrorClassExhausted:
+		action = ErrorActionEnd
+	default:
+		action = p.Fatal
+	}
+	if action == ErrorActionRetry && retries >= p.Retries {
+		action = ErrorActionAbort
+	}
+	if p.OnError != nil {
+		p.OnError(err, class, action)
+	}
+	return action
+}
+
+// classify wraps the error into a ClassifiedError unless it already is one or signals the end of the stream.
+func classify(err error) error {
+	var classifiedErr *ClassifiedError
+	if errors.As(err, &classifiedErr) || errors.Is(err, io.EOF) {
+		return err
+	}
+	return &ClassifiedError{Class: ClassifyError(err), Err: err}
+}
+
+// NewErrorPolicyFrameProvider creates a new FrameProvider which handles the errors of the given FrameProvider with the given ErrorPolicy.
+// Aborted errors are returned as ClassifiedError. ErrorActionSilence returns a silent frame of the length of the last frame or no frame if there was none yet.
+func NewErrorPolicyFrameProvider(pcmFrameProvider FrameProvider, policy ErrorPolicy) FrameProvider {
+	return &errorPolicyFrameProvider{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), pcmFrameProvider)
+		}),
+		pcmFrameProvider: pcmFrameProvider,
+		policy:           policy,
+	}
+}
+
+type errorPolicyFrameProvider struct {
+	*Lifecycle
+	pcmFrameProvider FrameProvider
+	policy           ErrorPolicy
+	silence          []int16
+}
+
+func (p *errorPolicyFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	for retries := 0; ; retries++ {
+		frame, err := p.pcmFrameProvider.ProvidePCMFrame()
+		if err == nil {
+			if len(frame) > 0 && len(frame) != len(p.silence) {
+				p.silence = make([]int16, len(frame))
+			}
+			return frame, nil
+		}
+
+		switch p.policy.Action(err, retries) {
+		case ErrorActionRetry:
+			continue
+		case ErrorActionSkip:
+			return nil, nil
+		case ErrorActionSilence:
+			for i := range p.silence {
+				p.silence[i] = 0
+			}
+			return p.silence, nil
+		case ErrorActionEnd:
+			return nil, io.EOF
+		default:
+			return nil, classify(err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package pcm
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Filter is an effect which processes interleaved PCM frames in place.
+type Filter interface {
+	// Process is called with every PCM frame.
+	Process(pcm []int16)
+}
+
+// TailFilter is a Filter which keeps producing sound after its input went silent, like a delay or reverb.
+type TailFilter interface {
+	Filter
+
+	// Tail returns how long the Filter keeps producing sound after its input went silent.
+	Tail() time.Duration
+}
+
+// FilterFunc is a function which implements Filter.
+type FilterFunc func(pcm []int16)
+
+func (f FilterFunc) Process(pcm []int16) {
+	f(pcm)
+}
+
+// FilterChain is a Filter which applies the contained Filter(s) in order.
+type FilterChain []Filter
+
+func (c FilterChain) Process(pcm []int16) {
+	for _, filter := range c {
+		filter.Process(pcm)
+	}
+}
+
+// Tail returns the sum of the tails of all TailFilter(s) in the FilterChain.
+func (c FilterChain) Tail() time.Duration {
+	var tail time.Duration
+	for _, filter := range c {
+		if tailFilter, ok := filter.(TailFilter); ok {
+			tail += tailFilter.Tail()
+		}
+	}
+	return tail
+}
+
+// filterChannels returns the given channels or 1 if there are less, the filters process invalid channel counts as mono.
+func filterChannels(channels int) int {
+	if channels < 1 {
+		return 1
+	}
+	return channels
+}
+
+// NewFilterFrameProvider creates a new FrameProvider which applies the given Filter(s) to the PCM frames of the given FrameProvider.
+// When the FrameProvider returns io.EOF, silent frames are passed through the Filter(s) until the tails of all TailFilter(s) decayed before io.EOF is returned.
+func NewFilterFrameProvider(pcmFrameProvider FrameProvider, filters ...Filter) FrameProvider {
+	return &filterFrameProvider{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), pcmFrameProvider)
+		}),
+		pcmFrameProvider: pcmFrameProvider,
+		filter:           FilterChain(filters),
+	}
+}
+
+type filterFrameProvider struct {
+	*Lifecycle
+	pcmFrameProvider FrameProvider
+	filter           FilterChain
+	frameLen         int
+	tailFrames       int
+	tailBuff         []int16
+}
+
+func (p *filterFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	if p.tailFrames > 0 {
+		return p.provideTailFrame()
+	}
+
+	frame, err := p.pcmFrameProvider.ProvidePCMFrame()
+	if err == io.EOF && p.frameLen > 0 {
+		if tail := p.filter.Tail(); tail > 0 {
+			// one extra call to return io.EOF
+			p.tailFrames = int((tail+opus.FrameSize*time.Millisecond-1)/(opus.FrameSize*time.Millisecond)) + 1
+			return p.provideTailFrame()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(frame) > 0 {
+		p.frameLen = len(frame)
+	}
+	p.filter.Process(frame)
+	return frame, nil
+}
+
+// provideTailFrame passes a silent frame through the filters and returns io.EOF once the tail is over.
+func (p *filterFrameProvider) provideTailFrame() ([]int16, error) {
+	if p.tailFrames--; p.tailFrames == 0 {
+		p.frameLen = 0
+		return nil, io.EOF
+	}
+	if cap(p.tailBuff) < p.frameLen {
+		p.tailBuff = make([]int16, p.frameLen)
+	}
+	frame := p.tailBuff[:p.frameLen]
+	for i := range frame {
+		frame[i] = 0
+	}
+	p.filter.Process(frame)
+	return frame, nil
+}
+
+// NewFilterReceiver creates a new FrameReceiver which applies a Filter per user to the received PCM frames before passing them to the given FrameReceiver.
+// filterFunc is called once per user and can return nil to leave the user untouched. Placed in front of NewPCMCombinerReceiver this applies the effects before the users are mixed.
+func NewFilterReceiver(receiver FrameReceiver, filterFunc func(userID snowflake.ID) Filter) FrameReceiver {
+	return &filterReceiver{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), receiver)
+		}),
+		receiver:   receiver,
+		filterFunc: filterFunc,
+		filters:    map[snowflake.ID]Filter{},
+	}
+}
+
+type filterReceiver struct {
+	*Lifecycle
+	receiver   FrameReceiver
+	filterFunc func(userID snowflake.ID) Filter
+	filters    map[snowflake.ID]Filter
+	filtersMu  sync.Mutex
+}
+
+func (r *filterReceiver) ReceivePCMFrame(userID snowflake.ID, packet *Packet) error {
+	r.filtersMu.Lock()
+	filter, ok := r.filters[userID]
+	if !ok {
+		filter = r.filterFunc(userID)
+		r.filters[userID] = filter
+	}
+	r.filtersMu.Unlock()
+
+	if filter != nil {
+		filter.Process(packet.PCM)
+	}
+	return r.receiver.ReceivePCMFrame(userID, packet)
+}
+
+func (r *filterReceiver) CleanupUser(userID snowflake.ID) {
+	r.filtersMu.Lock()
+	delete(r.filters, userID)
+	r.filtersMu.Unlock()
+	r.receiver.CleanupUser(userID)
+}
@@ -0,0 +1,232 @@
+package pcm
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func TestFilterFrameProvider(t *testing.T) {
+	source := &scriptedProvider{results: []bufferedFrame{
+		{frame: []int16{1, 2}},
+		{frame: []int16{3, 4}},
+	}}
+	provider := NewFilterFrameProvider(source,
+		FilterFunc(func(pcm []int16) {
+			for i := range pcm {
+				pcm[i] *= 10
+			}
+		}),
+		FilterFunc(func(pcm []int16) {
+			for i := range pcm {
+				pcm[i]++
+			}
+		}),
+	)
+	defer provider.Close()
+
+	for _, want := range [][]int16{{11, 21}, {31, 41}} {
+		frame, err := provider.ProvidePCMFrame()
+		if err != nil {
+			t.Fatalf("provide: %v", err)
+		}
+		if !equalFrames(frame, want) {
+			t.Fatalf("got %v, want the filters applied in order %v", frame, want)
+		}
+	}
+	// without a TailFilter io.EOF is returned right away
+	if _, err := provider.ProvidePCMFrame(); err != io.EOF {
+		t.Fatalf("got %v after the last frame, want io.EOF", err)
+	}
+}
+
+// tailFilter records the processed frames and reports the given tail.
+type tailFilter struct {
+	tail   time.Duration
+	frames [][]int16
+}
+
+func (f *tailFilter) Process(pcm []int16) {
+	f.frames = append(f.frames, append([]int16(nil), pcm...))
+}
+
+func (f *tailFilter) Tail() time.Duration {
+	return f.tail
+}
+
+func TestFilterFrameProviderTail(t *testing.T) {
+	filter := &tailFilter{tail: 50 * time.Millisecond}
+	source := &scriptedProvider{results: []bufferedFrame{{frame: []int16{1, 2, 3, 4}}}}
+	provider := NewFilterFrameProvider(source, filter)
+	defer provider.Close()
+
+	if _, err := provider.ProvidePCMFrame(); err != nil {
+		t.Fatalf("provide: %v", err)
+	}
+	// 50ms of tail are rounded up to 3 silent frames of the last frame's length
+	for i := 0; i < 3; i++ {
+		frame, err := provider.ProvidePCMFrame()
+		if err != nil {
+			t.Fatalf("tail frame %d: %v", i, err)
+		}
+		if !equalFrames(frame, []int16{0, 0, 0, 0}) {
+			t.Fatalf("got tail frame %d %v, want 4 silent samples", i, frame)
+		}
+	}
+	if _, err := provider.ProvidePCMFrame(); err != io.EOF {
+		t.Fatalf("got %v after the tail, want io.EOF", err)
+	}
+	if len(filter.frames) != 4 {
+		t.Fatalf("filtered %d frames, want the frame and 3 tail frames", len(filter.frames))
+	}
+
+	// the tail is only played once
+	if _, err := provider.ProvidePCMFrame(); err != io.EOF {
+		t.Fatalf("got %v after the end, want io.EOF", err)
+	}
+	if len(filter.frames) != 4 {
+		t.Fatalf("filtered %d frames after the end, want no second tail", len(filter.frames))
+	}
+}
+
+func TestFilterFrameProviderNoTailWithoutFrames(t *testing.T) {
+	filter := &tailFilter{tail: time.Second}
+	provider := NewFilterFrameProvider(&scriptedProvider{}, filter)
+	defer provider.Close()
+
+	if _, err := provider.ProvidePCMFrame(); err != io.EOF {
+		t.Fatalf("got %v for an empty source, want io.EOF", err)
+	}
+	if len(filter.frames) != 0 {
+		t.Fatalf("filtered %d frames, want no tail without frames", len(filter.frames))
+	}
+}
+
+func TestFilterFrameProviderDelayTail(t *testing.T) {
+	frame := make([]int16, 960)
+	frame[0] = 10000
+	// a 20ms mono delay repeats the impulse at the start of each following frame
+	provider := NewFilterFrameProvider(&scriptedProvider{results: []bufferedFrame{{frame: frame}}}, NewDelayFilter(48000, 1, 20*time.Millisecond, 0.5, 1))
+	defer provider.Close()
+
+	if _, err := provider.ProvidePCMFrame(); err != nil {
+		t.Fatalf("provide: %v", err)
+	}
+	tail, err := provider.ProvidePCMFrame()
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if tail[0] != 10000 {
+		t.Fatalf("got %d at the start of the tail, want the echo 10000", tail[0])
+	}
+	if tail, _ = provider.ProvidePCMFrame(); tail[0] != 5000 {
+		t.Fatalf("got %d at the start of the second tail frame, want the echo 5000", tail[0])
+	}
+}
+
+func TestFiltersWithoutChannels(t *testing.T) {
+	for name, filter := range map[string]TailFilter{
+		"reverb":          NewReverbFilter(48000, 0, 0.5, 0.5, 0.3, 1),
+		"delay":           NewDelayFilter(48000, 0, 100*time.Millisecond, 0.5, 0.5),
+		"modulated delay": NewChorusFilter(48000, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			filter.Process([]int16{1000, 1000})
+			if filter.Tail() <= 0 {
+				t.Fatalf("got tail %s, want a positive tail", filter.Tail())
+			}
+		})
+	}
+	NewPhaserFilter(48000, 0, DefaultPhaserConfig()).Process([]int16{1000, 1000})
+}
+
+// filterRecorder records the received frames and cleaned up users.
+type filterRecorder struct {
+	mu       sync.Mutex
+	frames   map[snowflake.ID][][]int16
+	cleanups []snowflake.ID
+}
+
+func (r *filterRecorder) ReceivePCMFrame(userID snowflake.ID, packet *Packet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames[userID] = append(r.frames[userID], append([]int16(nil), packet.PCM...))
+	return nil
+}
+
+func (r *filterRecorder) CleanupUser(userID snowflake.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cleanups = append(r.cleanups, userID)
+}
+
+func (r *filterRecorder) Close() {}
+
+func TestFilterReceiverPerUser(t *testing.T) {
+	recorder := &filterRecorder{frames: map[snowflake.ID][][]int16{}}
+	created := map[snowflake.ID]int{}
+	receiver := NewFilterReceiver(recorder, func(userID snowflake.ID) Filter {
+		created[userID]++
+		if userID == 2 {
+			return nil
+		}
+		// each user gets its own filter state
+		var calls int16
+		return FilterFunc(func(pcm []int16) {
+			calls++
+			pcm[0] = calls
+		})
+	})
+	defer receiver.Close()
+
+	receive := func(userID snowflake.ID) {
+		if err := receiver.ReceivePCMFrame(userID, &Packet{PCM: []int16{100}}); err != nil {
+			t.Fatalf("receive: %v", err)
+		}
+	}
+	receive(1)
+	receive(1)
+	receive(2)
+	receive(3)
+
+	if created[1] != 1 || created[2] != 1 || created[3] != 1 {
+		t.Fatalf("created filters %v, want one per user", created)
+	}
+	if frames := recorder.frames[1]; frames[0][0] != 1 || frames[1][0] != 2 {
+		t.Fatalf("got frames %v for user 1, want the state of a single filter", frames)
+	}
+	if frames := recorder.frames[2]; frames[0][0] != 100 {
+		t.Fatalf("got frames %v for user 2, want them untouched", frames)
+	}
+	if frames := recorder.frames[3]; frames[0][0] != 1 {
+		t.Fatalf("got frames %v for user 3, want a new filter", frames)
+	}
+
+	// cleaning up a user drops its filter and is passed on
+	receiver.CleanupUser(1)
+	if len(recorder.cleanups) != 1 || recorder.cleanups[0] != 1 {
+		t.Fatalf("got cleanups %v, want [1]", recorder.cleanups)
+	}
+	receive(1)
+	if created[1] != 2 {
+		t.Fatalf("created %d filters for user 1 after the cleanup, want 2", created[1])
+	}
+	if frames := recorder.frames[1]; frames[2][0] != 1 {
+		t.Fatalf("got frame %v for user 1 after the cleanup, want a new filter", frames[2])
+	}
+}
+
+func equalFrames(a []int16, b []int16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
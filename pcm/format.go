@@ -0,0 +1,71 @@
+package pcm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/disgoorg/audio/opus"
+)
+
+// ErrInvalidFormat is returned when a Format is invalid or not supported by a stage.
+var ErrInvalidFormat = errors.New("invalid pcm format")
+
+// SampleType is the type of the samples of PCM frames.
+type SampleType int
+
+const (
+	// SampleTypeInt16 is a signed 16-bit sample, used by FrameProvider and FrameReceiver.
+	SampleTypeInt16 SampleType = iota
+	// SampleTypeFloat32 is a 32-bit float sample from -1 to 1.
+	SampleTypeFloat32
+)
+
+func (t SampleType) String() string {
+	switch t {
+	case SampleTypeInt16:
+		return "int16"
+	case SampleTypeFloat32:
+		return "float32"
+	default:
+		return fmt.Sprintf("SampleType(%d)", int(t))
+	}
+}
+
+// DefaultFormat returns the Format used by Discord, 48000hz stereo int16.
+func DefaultFormat() Format {
+	return Format{
+		SampleRate: 48000,
+		Channels:   2,
+		SampleType: SampleTypeInt16,
+	}
+}
+
+// Format describes interleaved PCM frames.
+type Format struct {
+	SampleRate int
+	Channels   int
+	SampleType SampleType
+}
+
+// Validate returns an error wrapping ErrInvalidFormat if the Format has no sample rate, no channels or an unknown SampleType.
+func (f Format) Validate() error {
+	if f.SampleRate <= 0 {
+		return fmt.Errorf("%w: sample rate %d", ErrInvalidFormat, f.SampleRate)
+	}
+	if f.Channels <= 0 {
+		return fmt.Errorf("%w: %d channels", ErrInvalidFormat, f.Channels)
+	}
+	if f.SampleType != SampleTypeInt16 && f.SampleType != SampleTypeFloat32 {
+		return fmt.Errorf("%w: sample type %s", ErrInvalidFormat, f.SampleType)
+	}
+	return nil
+}
+
+// FrameSize returns the number of samples of all channels in a 20ms frame.
+func (f Format) FrameSize() int {
+	return opus.GetOutputBuffSize(f.SampleRate, f.Channels)
+}
+
+func (f Format) String() string {
+	return fmt.Sprintf("%dhz %dch %s", f.SampleRate, f.Channels, f.SampleType)
+}
@@ -1,31 +1,41 @@
 package pcm
 
 import (
+	"context"
+
 	"github.com/disgoorg/audio/channelconverter"
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/snowflake/v2"
 )
 
 func NewFrameChannelConverterCombinedReceiver(receiver CombinedFrameReceiver, rate int, inputChannels int, outputChannels int) CombinedFrameReceiver {
+	return NewCustomFrameChannelConverterCombinedReceiver(receiver, rate, channelconverter.CreateChannelConverter(inputChannels, outputChannels))
+}
+
+// NewCustomFrameChannelConverterCombinedReceiver creates a new CombinedFrameReceiver which converts the channels of the combined PCM frames with the given *channelconverter.ChannelConverter.
+func NewCustomFrameChannelConverterCombinedReceiver(receiver CombinedFrameReceiver, rate int, channelConverter *channelconverter.ChannelConverter) CombinedFrameReceiver {
 	return &frameChannelConverterCombinedReceiver{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), receiver)
+		}),
 		r:                receiver,
-		channelConverter: channelconverter.CreateChannelConverter(inputChannels, outputChannels),
-		newPCM:           make([]int16, opus.GetOutputBuffSize(rate, outputChannels)),
+		channelConverter: channelConverter,
+		newPCM:           make([]int16, opus.GetOutputBuffSize(rate, channelConverter.OutputChannels())),
 	}
 }
 
 type frameChannelConverterCombinedReceiver struct {
+	*Lifecycle
 	r                CombinedFrameReceiver
 	channelConverter *channelconverter.ChannelConverter
 	newPCM           []int16
 }
 
 func (p *frameChannelConverterCombinedReceiver) ReceiveCombinedPCMFrame(userIDs []snowflake.ID, packet *CombinedPacket) error {
-	if err := p.channelConverter.Convert(packet.PCM, p.newPCM); err != nil {
+	newPCM, err := convertChannels(p.channelConverter, packet.PCM, &p.newPCM)
+	if err != nil {
 		return err
 	}
-	packet.PCM = p.newPCM
+	packet.PCM = newPCM
 	return p.r.ReceiveCombinedPCMFrame(userIDs, packet)
 }
-
-func (*frameChannelConverterCombinedReceiver) Close() {}
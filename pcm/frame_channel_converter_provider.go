@@ -1,19 +1,31 @@
 package pcm
 
 import (
+	"context"
+
 	"github.com/disgoorg/audio/channelconverter"
 	"github.com/disgoorg/audio/opus"
 )
 
 func NewPCMFrameChannelConverterProvider(Provider FrameProvider, rate int, inputChannels int, outputChannels int) FrameProvider {
+	return NewCustomPCMFrameChannelConverterProvider(Provider, rate, channelconverter.CreateChannelConverter(inputChannels, outputChannels))
+}
+
+// NewCustomPCMFrameChannelConverterProvider creates a new FrameProvider which converts the channels of the PCM frames with the given *channelconverter.ChannelConverter.
+// This can be used with channelconverter.CreateMatrixChannelConverter, channelconverter.CreateSwapChannelConverter or channelconverter.CreateExtractChannelConverter.
+func NewCustomPCMFrameChannelConverterProvider(Provider FrameProvider, rate int, channelConverter *channelconverter.ChannelConverter) FrameProvider {
 	return &pcmFrameChannelConverterProvider{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), Provider)
+		}),
 		pcmFrameProvider: Provider,
-		channelConverter: channelconverter.CreateChannelConverter(inputChannels, outputChannels),
-		newPCM:           make([]int16, opus.GetOutputBuffSize(rate, outputChannels)),
+		channelConverter: channelConverter,
+		newPCM:           make([]int16, opus.GetOutputBuffSize(rate, channelConverter.OutputChannels())),
 	}
 }
 
 type pcmFrameChannelConverterProvider struct {
+	*Lifecycle
 	pcmFrameProvider FrameProvider
 	channelConverter *channelconverter.ChannelConverter
 	newPCM           []int16
@@ -25,10 +37,21 @@ func (p *pcmFrameChannelConverterProvider) ProvidePCMFrame() ([]int16, error) {
 		return nil, err
 	}
 
-	if err = p.channelConverter.Convert(frame, p.newPCM); err != nil {
+	return convertChannels(p.channelConverter, frame, &p.newPCM)
+}
+
+// convertChannels converts the channels of pcm into buff and grows buff if the frame is larger than expected.
+func convertChannels(channelConverter *channelconverter.ChannelConverter, pcm []int16, buff *[]int16) ([]int16, error) {
+	if channelConverter.InputChannels() == 0 {
+		return nil, channelconverter.ErrInvalidChannelCount
+	}
+	size := len(pcm) / channelConverter.InputChannels() * channelConverter.OutputChannels()
+	if cap(*buff) < size {
+		*buff = make([]int16, size)
+	}
+	newPCM := (*buff)[:size]
+	if err := channelConverter.Convert(pcm, newPCM); err != nil {
 		return nil, err
 	}
-	return p.newPCM, nil
+	return newPCM, nil
 }
-
-func (*pcmFrameChannelConverterProvider) Close() {}
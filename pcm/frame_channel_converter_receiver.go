@@ -1,33 +1,45 @@
 package pcm
 
 import (
+	"context"
+
 	"github.com/disgoorg/audio/channelconverter"
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/snowflake/v2"
 )
 
 func NewPCMFrameChannelConverterReceiver(receiver FrameReceiver, rate int, inputChannels int, outputChannels int) FrameReceiver {
+	return NewCustomPCMFrameChannelConverterReceiver(receiver, rate, channelconverter.CreateChannelConverter(inputChannels, outputChannels))
+}
+
+// NewCustomPCMFrameChannelConverterReceiver creates a new FrameReceiver which converts the channels of the received PCM frames with the given *channelconverter.ChannelConverter.
+func NewCustomPCMFrameChannelConverterReceiver(receiver FrameReceiver, rate int, channelConverter *channelconverter.ChannelConverter) FrameReceiver {
 	return &pcmFrameChannelConverterReceiver{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), receiver)
+		}),
 		r:                receiver,
-		channelConverter: channelconverter.CreateChannelConverter(inputChannels, outputChannels),
-		newPCM:           make([]int16, opus.GetOutputBuffSize(rate, outputChannels)),
+		channelConverter: channelConverter,
+		newPCM:           make([]int16, opus.GetOutputBuffSize(rate, channelConverter.OutputChannels())),
 	}
 }
 
 type pcmFrameChannelConverterReceiver struct {
+	*Lifecycle
 	r                FrameReceiver
 	channelConverter *channelconverter.ChannelConverter
 	newPCM           []int16
 }
 
 func (p *pcmFrameChannelConverterReceiver) ReceivePCMFrame(userID snowflake.ID, packet *Packet) error {
-	if err := p.channelConverter.Convert(packet.PCM, p.newPCM); err != nil {
+	newPCM, err := convertChannels(p.channelConverter, packet.PCM, &p.newPCM)
+	if err != nil {
 		return err
 	}
-	packet.PCM = p.newPCM
+	packet.PCM = newPCM
 	return p.r.ReceivePCMFrame(userID, packet)
 }
 
-func (p *pcmFrameChannelConverterReceiver) CleanupUser(_ snowflake.ID) {}
-
-func (*pcmFrameChannelConverterReceiver) Close() {}
+func (p *pcmFrameChannelConverterReceiver) CleanupUser(userID snowflake.ID) {
+	p.r.CleanupUser(userID)
+}
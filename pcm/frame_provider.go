@@ -3,7 +3,9 @@ package pcm
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
+	"time"
 
 	"github.com/disgoorg/audio/opus"
 )
@@ -17,6 +19,39 @@ type FrameProvider interface {
 	Close()
 }
 
+// ErrNotSeekable is returned by SeekableFrameProvider.Seek if the underlying source can't seek.
+var ErrNotSeekable = errors.New("frame provider is not seekable")
+
+// SeekableFrameProvider is a FrameProvider which supports seeking.
+type SeekableFrameProvider interface {
+	FrameProvider
+
+	// Seek seeks to the given position. The next frame starts at this position.
+	Seek(position time.Duration) error
+
+	// Position returns the position of the next frame.
+	Position() time.Duration
+
+	// Duration returns the total duration or 0 if it is unknown.
+	Duration() time.Duration
+}
+
+// BufferingFrameProvider is a FrameProvider which reads from a source that can run dry, like a network stream.
+type BufferingFrameProvider interface {
+	FrameProvider
+
+	// Buffering returns whether the provider is currently waiting for more data.
+	Buffering() bool
+}
+
+// TitleFrameProvider is a FrameProvider which knows the title of the audio it currently provides, like an internet radio stream.
+type TitleFrameProvider interface {
+	FrameProvider
+
+	// Title returns the title of the currently playing audio or an empty string if it is unknown.
+	Title() string
+}
+
 // NewReader creates a new FrameProvider which reads PCM frames from the given io.Reader.
 func NewReader(r io.Reader) FrameProvider {
 	return NewCustomReader(r, 48000, 2)
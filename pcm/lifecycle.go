@@ -0,0 +1,109 @@
+package pcm
+
+import (
+	"context"
+	"sync"
+)
+
+// Shutdowner is implemented by FrameProvider(s) and FrameReceiver(s) which run background goroutines or own resources which can fail to close.
+// Close of such types calls Shutdown with context.Background and discards the error.
+type Shutdowner interface {
+	// Shutdown stops all background goroutines, waits for them to exit and closes all resources.
+	// It returns the first error which occurred while closing or ctx.Err() if ctx is done before everything is closed.
+	// Shutdown is safe to call concurrently and multiple times, every call returns the result of the first.
+	Shutdown(ctx context.Context) error
+}
+
+// Shutdown shuts down the given FrameProvider, FrameReceiver or CombinedFrameReceiver.
+// It calls Shutdowner.Shutdown if it is implemented and Close otherwise.
+func Shutdown(ctx context.Context, closer interface{ Close() }) error {
+	if shutdowner, ok := closer.(Shutdowner); ok {
+		return shutdowner.Shutdown(ctx)
+	}
+	closer.Close()
+	return nil
+}
+
+// NewLifecycle creates a new *Lifecycle derived from the given context.Context. closeFunc is called once after all goroutines started with Lifecycle.Go exited.
+// If ctx is nil, context.Background is used.
+func NewLifecycle(ctx context.Context, closeFunc func() error) *Lifecycle {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Lifecycle{
+		ctx:       ctx,
+		cancel:    cancel,
+		closeFunc: closeFunc,
+		done:      make(chan struct{}),
+	}
+}
+
+// Lifecycle tracks the background goroutines of a FrameProvider or FrameReceiver and implements idempotent and concurrency safe Close and Shutdown methods.
+// Goroutines started with Go must not call Close or Shutdown themselves as they would wait for their own exit.
+type Lifecycle struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	interruptFunc func()
+	closeFunc     func() error
+
+	wg   sync.WaitGroup
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// Context returns the context.Context which is done once the parent context is done or Shutdown was called.
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// SetInterruptFunc sets a function which Shutdown calls after canceling the Context and before waiting for the goroutines started with Go.
+// Use it to unblock calls of these goroutines which don't observe the Context, like ProvidePCMFrame of a wrapped FrameProvider waiting for data.
+// It must be set before Shutdown is called.
+func (l *Lifecycle) SetInterruptFunc(interruptFunc func()) {
+	l.interruptFunc = interruptFunc
+}
+
+// Go starts the given function in a goroutine which is awaited by Shutdown. f should return once ctx is done.
+func (l *Lifecycle) Go(f func(ctx context.Context)) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		f(l.ctx)
+	}()
+}
+
+// Done returns a channel which is closed once Shutdown completed.
+func (l *Lifecycle) Done() <-chan struct{} {
+	return l.done
+}
+
+// Close calls Shutdown with context.Background and discards the error.
+func (l *Lifecycle) Close() {
+	_ = l.Shutdown(context.Background())
+}
+
+// Shutdown cancels the Context, calls the interrupt func, waits for all goroutines started with Go and calls the close func.
+// See Shutdowner.Shutdown.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.once.Do(func() {
+		l.cancel()
+		go func() {
+			if l.interruptFunc != nil {
+				l.interruptFunc()
+			}
+			l.wg.Wait()
+			if l.closeFunc != nil {
+				l.err = l.closeFunc()
+			}
+			close(l.done)
+		}()
+	})
+	select {
+	case <-l.done:
+		return l.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
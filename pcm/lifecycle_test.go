@@ -0,0 +1,180 @@
+package pcm
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkGoroutines fails the test if more goroutines are running at the end of the test than at the start.
+func checkGoroutines(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				buf := make([]byte, 1<<16)
+				buf = buf[:runtime.Stack(buf, true)]
+				t.Errorf("leaked %d goroutine(s):\n%s", runtime.NumGoroutine()-before, buf)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestLifecycleShutdownAwaitsGoroutines(t *testing.T) {
+	checkGoroutines(t)
+
+	var exited int32
+	l := NewLifecycle(nil, func() error {
+		if atomic.LoadInt32(&exited) != 1 {
+			t.Error("close func called before the goroutine exited")
+		}
+		return nil
+	})
+	l.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&exited, 1)
+	})
+
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if atomic.LoadInt32(&exited) != 1 {
+		t.Fatal("shutdown returned before the goroutine exited")
+	}
+	select {
+	case <-l.Done():
+	default:
+		t.Fatal("done is not closed after shutdown")
+	}
+}
+
+func TestLifecycleShutdownIdempotent(t *testing.T) {
+	checkGoroutines(t)
+
+	closeErr := errors.New("close failed")
+	var calls int32
+	l := NewLifecycle(nil, func() error {
+		atomic.AddInt32(&calls, 1)
+		return closeErr
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Shutdown(context.Background()); err != closeErr {
+				t.Errorf("got %v, want %v", err, closeErr)
+			}
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Fatalf("close func called %d times, want 1", calls)
+	}
+}
+
+func TestLifecycleShutdownContextDone(t *testing.T) {
+	checkGoroutines(t)
+
+	release := make(chan struct{})
+	l := NewLifecycle(nil, nil)
+	l.Go(func(ctx context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown after release: %v", err)
+	}
+}
+
+func TestLifecycleInterruptUnblocksGoroutine(t *testing.T) {
+	checkGoroutines(t)
+
+	blocked := make(chan struct{})
+	var order []string
+	var mu sync.Mutex
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, s)
+	}
+
+	l := NewLifecycle(nil, func() error {
+		record("close")
+		return nil
+	})
+	l.SetInterruptFunc(func() {
+		record("interrupt")
+		close(blocked)
+	})
+	l.Go(func(ctx context.Context) {
+		// ignores ctx like a blocking read
+		<-blocked
+		record("exit")
+	})
+
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "interrupt,exit,close" {
+		t.Fatalf("got order %s, want interrupt,exit,close", got)
+	}
+}
+
+func TestLifecycleParentContext(t *testing.T) {
+	checkGoroutines(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := NewLifecycle(ctx, nil)
+	exited := make(chan struct{})
+	l.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(exited)
+	})
+
+	cancel()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("goroutine didn't observe the canceled parent context")
+	}
+	l.Close()
+}
+
+func TestShutdownFallsBackToClose(t *testing.T) {
+	closer := &closeCounter{}
+	if err := Shutdown(context.Background(), closer); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if closer.calls != 1 {
+		t.Fatalf("close called %d times, want 1", closer.calls)
+	}
+}
+
+type closeCounter struct {
+	calls int
+}
+
+func (c *closeCounter) Close() {
+	c.calls++
+}
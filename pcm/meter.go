@@ -0,0 +1,382 @@
+package pcm
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+const (
+	// meterBlocks is the number of 100ms blocks kept for the short-term loudness (3s).
+	meterBlocks = 30
+	// momentaryBlocks is the number of 100ms blocks of the momentary loudness (400ms).
+	momentaryBlocks = 4
+
+	absoluteGate = -70
+	relativeGate = -10
+
+	// gatingBins is the number of 0.1 LU bins between the absolute gate and +5 LUFS used for the integrated loudness.
+	gatingBins = 750
+)
+
+// MeterSnapshot contains the levels measured by a Meter.
+type MeterSnapshot struct {
+	// Peak is the highest absolute sample of the last 400ms from 0 to 1.
+	Peak float64
+	// MaxPeak is the highest absolute sample since the last reset from 0 to 1.
+	MaxPeak float64
+	// RMS is the root mean square of the last 400ms from 0 to 1.
+	RMS float64
+
+	// MomentaryLUFS is the loudness of the last 400ms as defined by ITU-R BS.1770.
+	MomentaryLUFS float64
+	// ShortTermLUFS is the loudness of the last 3s.
+	ShortTermLUFS float64
+	// IntegratedLUFS is the gated loudness since the last reset.
+	IntegratedLUFS float64
+
+	// Clipped is the number of samples at full scale since the last reset.
+	Clipped uint64
+	// Samples is the number of samples measured since the last reset.
+	Samples uint64
+}
+
+// ClipRatio returns the fraction of clipped samples.
+func (s MeterSnapshot) ClipRatio() float64 {
+	if s.Samples == 0 {
+		return 0
+	}
+	return float64(s.Clipped) / float64(s.Samples)
+}
+
+// NewMeter creates a new *Meter for interleaved PCM frames with the given sample rate and channels.
+// The Meter is a Filter which doesn't modify the PCM frames, use NewFilterFrameProvider or NewMeterReceiver to insert it into a chain.
+func NewMeter(sampleRate int, channels int) *Meter {
+	channels = filterChannels(channels)
+	m := &Meter{
+		channels:     channels,
+		blockSamples: sampleRate / 10,
+		weighting:    make([]kWeighting, channels),
+	}
+	for ch := range m.weighting {
+		m.weighting[ch] = newKWeighting(float64(sampleRate))
+	}
+	return m
+}
+
+// Meter measures peak, RMS and loudness of PCM frames and counts clipped samples.
+// Samples at full scale are counted as clipped, as clamping to the int16 range is the only way a sample gets there.
+type Meter struct {
+	channels     int
+	blockSamples int
+	weighting    []kWeighting
+
+	// current 100ms block
+	current meterBlock
+
+	blocks     [meterBlocks]meterBlock
+	blockPos   int
+	blockCount int
+
+	// gating is a histogram of the 400ms gating blocks above the absolute gate, which keeps the memory of the integrated loudness constant.
+	gating [gatingBins]gatingBin
+
+	maxPeak float64
+	clipped uint64
+	samples uint64
+
+	mu sync.Mutex
+}
+
+type gatingBin struct {
+	count  int
+	energy float64
+}
+
+type meterBlock struct {
+	peak       float64
+	sumSquares float64
+	energy     float64
+	frames     int
+}
+
+func (m *Meter) Process(pcm []int16) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 0; i+m.channels <= len(pcm); i += m.channels {
+		for ch := 0; ch < m.channels; ch++ {
+			s := pcm[i+ch]
+			if s >= 32767 || s <= -32768 {
+				m.clipped++
+			}
+			sample := float64(s) / 32768
+			if abs := math.Abs(sample); abs > m.current.peak {
+				m.current.peak = abs
+			}
+			m.current.sumSquares += sample * sample
+			weighted := m.weighting[ch].process(sample)
+			m.current.energy += weighted * weighted
+		}
+		m.samples += uint64(m.channels)
+		if m.current.frames++; m.current.frames == m.blockSamples {
+			m.finishBlock()
+		}
+	}
+}
+
+func (m *Meter) finishBlock() {
+	if m.current.peak > m.maxPeak {
+		m.maxPeak = m.current.peak
+	}
+	m.blocks[m.blockPos] = m.current
+	m.blockPos = (m.blockPos + 1) % meterBlocks
+	if m.blockCount < meterBlocks {
+		m.blockCount++
+	}
+	m.current = meterBlock{}
+
+	// a new 400ms gating block with 75% overlap is complete every 100ms
+	if m.blockCount >= momentaryBlocks {
+		m.addGatingBlock(m.energy(momentaryBlocks))
+	}
+}
+
+func (m *Meter) addGatingBlock(energy float64) {
+	l := loudness(energy)
+	if l <= absoluteGate {
+		return
+	}
+	bin := int((l - absoluteGate) * 10)
+	if bin >= gatingBins {
+		bin = gatingBins - 1
+	}
+	m.gating[bin].count++
+	m.gating[bin].energy += energy
+}
+
+// energy returns the mean K-weighted energy of the last n blocks summed over all channels.
+func (m *Meter) energy(n int) float64 {
+	if n > m.blockCount {
+		n = m.blockCount
+	}
+	var energy float64
+	var frames int
+	for i := 1; i <= n; i++ {
+		block := m.blocks[(m.blockPos-i+meterBlocks)%meterBlocks]
+		energy += block.energy
+		frames += block.frames
+	}
+	if frames == 0 {
+		return 0
+	}
+	return energy / float64(frames)
+}
+
+// Snapshot returns the current MeterSnapshot.
+func (m *Meter) Snapshot() MeterSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MeterSnapshot{
+		MaxPeak:        m.maxPeak,
+		MomentaryLUFS:  loudness(m.energy(momentaryBlocks)),
+		ShortTermLUFS:  loudness(m.energy(meterBlocks)),
+		IntegratedLUFS: m.integrated(),
+		Clipped:        m.clipped,
+		Samples:        m.samples,
+	}
+	var sumSquares float64
+	var frames int
+	for i := 1; i <= momentaryBlocks && i <= m.blockCount; i++ {
+		block := m.blocks[(m.blockPos-i+meterBlocks)%meterBlocks]
+		snapshot.Peak = math.Max(snapshot.Peak, block.peak)
+		sumSquares += block.sumSquares
+		frames += block.frames
+	}
+	if frames > 0 {
+		snapshot.RMS = math.Sqrt(sumSquares / float64(frames*m.channels))
+	}
+	return snapshot
+}
+
+// integrated returns the gated loudness of all gating blocks as defined by ITU-R BS.1770.
+// The relative gate is applied with the 0.1 LU resolution of the histogram.
+func (m *Meter) integrated() float64 {
+	var sum float64
+	var count int
+	for _, bin := range m.gating {
+		sum += bin.energy
+		count += bin.count
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	threshold := loudness(sum/float64(count)) + relativeGate
+
+	sum, count = 0, 0
+	for i, bin := range m.gating {
+		if float64(i+1)/10+absoluteGate <= threshold {
+			continue
+		}
+		sum += bin.energy
+		count += bin.count
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	return loudness(sum / float64(count))
+}
+
+// Reset clears all measurements.
+func (m *Meter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = meterBlock{}
+	m.blocks = [meterBlocks]meterBlock{}
+	m.blockPos = 0
+	m.blockCount = 0
+	m.gating = [gatingBins]gatingBin{}
+	m.maxPeak = 0
+	m.clipped = 0
+	m.samples = 0
+}
+
+// loudness converts the mean K-weighted energy into LUFS.
+func loudness(energy float64) float64 {
+	if energy <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(energy)
+}
+
+// kWeighting is the two stage K-weighting filter of ITU-R BS.1770, a high shelf followed by a high pass.
+type kWeighting struct {
+	shelf    biquad
+	highPass biquad
+}
+
+func newKWeighting(sampleRate float64) kWeighting {
+	// coefficients for any sample rate as derived by libebur128
+	k := math.Tan(math.Pi * 1681.974450955533 / sampleRate)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / sampleRate)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return kWeighting{shelf: shelf, highPass: highPass}
+}
+
+func (k *kWeighting) process(sample float64) float64 {
+	return k.highPass.process(k.shelf.process(sample))
+}
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (b *biquad) process(x float64) float64 {
+	y := b.b0*x + b.z1
+	b.z1 = b.b1*x - b.a1*y + b.z2
+	b.z2 = b.b2*x - b.a2*y
+	return y
+}
+
+// NewMeters creates a new *Meters which creates a Meter per user with the given sample rate and channels.
+func NewMeters(sampleRate int, channels int) *Meters {
+	return &Meters{
+		sampleRate: sampleRate,
+		channels:   channels,
+		meters:     map[snowflake.ID]*Meter{},
+	}
+}
+
+// Meters holds a Meter per user.
+type Meters struct {
+	sampleRate int
+	channels   int
+	meters     map[snowflake.ID]*Meter
+	mu         sync.Mutex
+}
+
+// Meter returns the Meter of the given user and creates it if needed.
+func (m *Meters) Meter(userID snowflake.ID) *Meter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	meter, ok := m.meters[userID]
+	if !ok {
+		meter = NewMeter(m.sampleRate, m.channels)
+		m.meters[userID] = meter
+	}
+	return meter
+}
+
+// Remove removes the Meter of the given user.
+func (m *Meters) Remove(userID snowflake.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.meters, userID)
+}
+
+// Snapshot returns the MeterSnapshot of every user.
+func (m *Meters) Snapshot() map[snowflake.ID]MeterSnapshot {
+	m.mu.Lock()
+	meters := make(map[snowflake.ID]*Meter, len(m.meters))
+	for userID, meter := range m.meters {
+		meters[userID] = meter
+	}
+	m.mu.Unlock()
+
+	snapshots := make(map[snowflake.ID]MeterSnapshot, len(meters))
+	for userID, meter := range meters {
+		snapshots[userID] = meter.Snapshot()
+	}
+	return snapshots
+}
+
+// NewMeterReceiver creates a new FrameReceiver which measures the PCM frames of each user with the given *Meters before passing them to the given FrameReceiver.
+// The Meter of a user is removed when the user is cleaned up.
+func NewMeterReceiver(receiver FrameReceiver, meters *Meters) FrameReceiver {
+	return &meterReceiver{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), receiver)
+		}),
+		receiver: receiver,
+		meters:   meters,
+	}
+}
+
+type meterReceiver struct {
+	*Lifecycle
+	receiver FrameReceiver
+	meters   *Meters
+}
+
+func (r *meterReceiver) ReceivePCMFrame(userID snowflake.ID, packet *Packet) error {
+	r.meters.Meter(userID).Process(packet.PCM)
+	return r.receiver.ReceivePCMFrame(userID, packet)
+}
+
+func (r *meterReceiver) CleanupUser(userID snowflake.ID) {
+	r.meters.Remove(userID)
+	r.receiver.CleanupUser(userID)
+}
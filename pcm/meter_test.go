@@ -0,0 +1,96 @@
+package pcm
+
+import (
+	"math"
+	"testing"
+)
+
+// meterSine processes the given seconds of a 997Hz sine with the given amplitude from 0 to 1 on all channels in 20ms frames.
+func meterSine(m *Meter, channels int, amplitude float64, seconds int) {
+	for i := 0; i < seconds*50; i++ {
+		frame := make([]int16, 960*channels)
+		for j := 0; j < 960; j++ {
+			sample := int16(amplitude * 32767 * math.Sin(2*math.Pi*997*float64(i*960+j)/48000))
+			for ch := 0; ch < channels; ch++ {
+				frame[j*channels+ch] = sample
+			}
+		}
+		m.Process(frame)
+	}
+}
+
+func TestMeterLoudness(t *testing.T) {
+	m := NewMeter(48000, 2)
+	// a -20dBFS sine on both channels is -20 LUFS
+	meterSine(m, 2, 0.1, 4)
+
+	snapshot := m.Snapshot()
+	for name, lufs := range map[string]float64{
+		"momentary":  snapshot.MomentaryLUFS,
+		"short-term": snapshot.ShortTermLUFS,
+		"integrated": snapshot.IntegratedLUFS,
+	} {
+		if math.Abs(lufs+20) > 0.2 {
+			t.Fatalf("got %s loudness %f, want -20 LUFS", name, lufs)
+		}
+	}
+	if math.Abs(snapshot.Peak-0.1) > 0.001 || math.Abs(snapshot.RMS-0.1/math.Sqrt2) > 0.001 {
+		t.Fatalf("got peak %f and rms %f, want 0.1 and %f", snapshot.Peak, snapshot.RMS, 0.1/math.Sqrt2)
+	}
+
+	m.Reset()
+	if snapshot = m.Snapshot(); !math.IsInf(snapshot.IntegratedLUFS, -1) || snapshot.MaxPeak != 0 || snapshot.Samples != 0 {
+		t.Fatalf("got %+v after the reset, want no measurements", snapshot)
+	}
+}
+
+func TestMeterGating(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		amplitude float64
+	}{
+		// silence is below the absolute gate
+		{name: "absolute gate", amplitude: 0},
+		// -40 LUFS is more than 10 LU below the -20 LUFS of the loud part
+		{name: "relative gate", amplitude: 0.01},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMeter(48000, 2)
+			meterSine(m, 2, 0.1, 3)
+			meterSine(m, 2, tt.amplitude, 3)
+			// the overlapping gating blocks at the transition are above both gates
+			if integrated := m.Snapshot().IntegratedLUFS; math.Abs(integrated+20) > 0.5 {
+				t.Fatalf("got integrated loudness %f, want -20 LUFS of the loud part only", integrated)
+			}
+		})
+	}
+
+	// without the gates the quiet part would lower the loudness
+	m := NewMeter(48000, 2)
+	meterSine(m, 2, 0.1, 3)
+	meterSine(m, 2, 0.05, 3)
+	if integrated := m.Snapshot().IntegratedLUFS; integrated > -21 || integrated < -24 {
+		t.Fatalf("got integrated loudness %f, want the -26 LUFS part included", integrated)
+	}
+}
+
+func TestMeterClipping(t *testing.T) {
+	m := NewMeter(48000, 1)
+	m.Process([]int16{32767, -32768, 32766, 0})
+
+	snapshot := m.Snapshot()
+	if snapshot.Clipped != 2 || snapshot.Samples != 4 || snapshot.ClipRatio() != 0.5 {
+		t.Fatalf("got %d clipped of %d samples, want 2 of 4", snapshot.Clipped, snapshot.Samples)
+	}
+	if (MeterSnapshot{}).ClipRatio() != 0 {
+		t.Fatal("got a clip ratio without samples")
+	}
+}
+
+func TestMeterWithoutChannels(t *testing.T) {
+	m := NewMeter(48000, 0)
+	m.Process([]int16{32767, 0})
+	if snapshot := m.Snapshot(); snapshot.Samples != 2 || snapshot.Clipped != 1 {
+		t.Fatalf("got %+v, want the samples measured as mono", snapshot)
+	}
+}
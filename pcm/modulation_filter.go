@@ -0,0 +1,242 @@
+package pcm
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// ModulatedDelayConfig configures a ModulatedDelayFilter.
+type ModulatedDelayConfig struct {
+	// Delay is the center delay of the modulated delay line.
+	Delay time.Duration
+	// Depth is how far the delay is modulated around Delay.
+	Depth time.Duration
+	// Rate is the LFO rate in Hz.
+	Rate float64
+	// Feedback from -0.95 to 0.95 feeds the delayed signal back into the delay line.
+	Feedback float64
+	// Wet is the level of the delayed signal from 0 to 1.
+	Wet float64
+}
+
+// ChorusConfig returns a ModulatedDelayConfig for a chorus effect.
+func ChorusConfig() ModulatedDelayConfig {
+	return ModulatedDelayConfig{
+		Delay: 20 * time.Millisecond,
+		Depth: 5 * time.Millisecond,
+		Rate:  0.8,
+		Wet:   0.5,
+	}
+}
+
+// FlangerConfig returns a ModulatedDelayConfig for a flanger effect.
+func FlangerConfig() ModulatedDelayConfig {
+	return ModulatedDelayConfig{
+		Delay:    3 * time.Millisecond,
+		Depth:    2 * time.Millisecond,
+		Rate:     0.25,
+		Feedback: 0.6,
+		Wet:      0.5,
+	}
+}
+
+// NewChorusFilter creates a new *ModulatedDelayFilter with the ChorusConfig.
+func NewChorusFilter(sampleRate int, channels int) *ModulatedDelayFilter {
+	return NewModulatedDelayFilter(sampleRate, channels, ChorusConfig())
+}
+
+// NewFlangerFilter creates a new *ModulatedDelayFilter with the FlangerConfig.
+func NewFlangerFilter(sampleRate int, channels int) *ModulatedDelayFilter {
+	return NewModulatedDelayFilter(sampleRate, channels, FlangerConfig())
+}
+
+// NewModulatedDelayFilter creates a new *ModulatedDelayFilter for interleaved PCM frames with the given sample rate and channels.
+func NewModulatedDelayFilter(sampleRate int, channels int, config ModulatedDelayConfig) *ModulatedDelayFilter {
+	f := &ModulatedDelayFilter{
+		sampleRate: float64(sampleRate),
+		channels:   filterChannels(channels),
+	}
+	f.SetConfig(config)
+	return f
+}
+
+// ModulatedDelayFilter mixes the signal with a copy delayed by a sine LFO, which results in a chorus or, with short delays and feedback, a flanger.
+// The LFO of each channel is shifted to widen the stereo image.
+type ModulatedDelayFilter struct {
+	sampleRate float64
+	channels   int
+	config     ModulatedDelayConfig
+	buff       []float64
+	pos        int
+	phase      float64
+	mu         sync.Mutex
+}
+
+// SetConfig sets the ModulatedDelayConfig. Changing the maximum delay clears the delay line.
+func (f *ModulatedDelayFilter) SetConfig(config ModulatedDelayConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	config.Feedback = clampFloat(config.Feedback, -0.95, 0.95)
+	config.Wet = clampFloat(config.Wet, 0, 1)
+	if config.Depth > config.Delay {
+		config.Depth = config.Delay
+	}
+	f.config = config
+
+	// one extra sample for the interpolation
+	samples := int((config.Delay+config.Depth).Seconds()*f.sampleRate) + 2
+	if len(f.buff) != samples*f.channels {
+		f.buff = make([]float64, samples*f.channels)
+		f.pos = 0
+	}
+}
+
+func (f *ModulatedDelayFilter) Tail() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return feedbackTail(f.config.Delay+f.config.Depth, f.config.Feedback)
+}
+
+func (f *ModulatedDelayFilter) Process(pcm []int16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	frames := len(f.buff) / f.channels
+	delay := f.config.Delay.Seconds() * f.sampleRate
+	depth := f.config.Depth.Seconds() * f.sampleRate
+	step := 2 * math.Pi * f.config.Rate / f.sampleRate
+
+	for i := 0; i+f.channels <= len(pcm); i += f.channels {
+		for ch := 0; ch < f.channels; ch++ {
+			lfo := math.Sin(f.phase + float64(ch)*math.Pi/2)
+			delayed := f.read(frames, ch, delay+depth*lfo)
+			in := float64(pcm[i+ch])
+			f.buff[f.pos*f.channels+ch] = in + delayed*f.config.Feedback
+			pcm[i+ch] = clampSample(in*(1-f.config.Wet/2) + delayed*f.config.Wet)
+		}
+		if f.pos++; f.pos == frames {
+			f.pos = 0
+		}
+		f.phase += step
+	}
+	f.phase = math.Mod(f.phase, 2*math.Pi)
+}
+
+// read returns the linear interpolated sample of the channel the given number of frames ago.
+func (f *ModulatedDelayFilter) read(frames int, channel int, delay float64) float64 {
+	if delay < 1 {
+		delay = 1
+	}
+	whole := int(delay)
+	frac := delay - float64(whole)
+	a := f.pos - whole
+	for a < 0 {
+		a += frames
+	}
+	b := a - 1
+	if b < 0 {
+		b += frames
+	}
+	return f.buff[a*f.channels+channel]*(1-frac) + f.buff[b*f.channels+channel]*frac
+}
+
+// PhaserConfig configures a PhaserFilter.
+type PhaserConfig struct {
+	// Stages is the number of allpass stages, each pair of stages creates one notch.
+	Stages int
+	// MinFrequency and MaxFrequency are the range the notches sweep through in Hz.
+	MinFrequency float64
+	MaxFrequency float64
+	// Rate is the LFO rate in Hz.
+	Rate float64
+	// Feedback from -0.95 to 0.95 deepens the notches.
+	Feedback float64
+	// Wet is the level of the phased signal from 0 to 1.
+	Wet float64
+}
+
+// DefaultPhaserConfig returns a PhaserConfig with 6 stages sweeping from 200 to 2000 Hz.
+func DefaultPhaserConfig() PhaserConfig {
+	return PhaserConfig{
+		Stages:       6,
+		MinFrequency: 200,
+		MaxFrequency: 2000,
+		Rate:         0.5,
+		Feedback:     0.5,
+		Wet:          0.5,
+	}
+}
+
+// NewPhaserFilter creates a new *PhaserFilter for interleaved PCM frames with the given sample rate and channels.
+func NewPhaserFilter(sampleRate int, channels int, config PhaserConfig) *PhaserFilter {
+	channels = filterChannels(channels)
+	f := &PhaserFilter{
+		sampleRate: float64(sampleRate),
+		channels:   channels,
+		last:       make([]float64, channels),
+	}
+	f.SetConfig(config)
+	return f
+}
+
+// PhaserFilter sweeps notches through the spectrum with a chain of first order allpass filters modulated by a sine LFO.
+type PhaserFilter struct {
+	sampleRate float64
+	channels   int
+	config     PhaserConfig
+	// state holds the allpass state per channel and stage.
+	state [][]float64
+	last  []float64
+	phase float64
+	mu    sync.Mutex
+}
+
+// SetConfig sets the PhaserConfig.
+func (f *PhaserFilter) SetConfig(config PhaserConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if config.Stages < 1 {
+		config.Stages = 1
+	}
+	config.Feedback = clampFloat(config.Feedback, -0.95, 0.95)
+	config.Wet = clampFloat(config.Wet, 0, 1)
+	f.config = config
+	if len(f.state) == 0 || len(f.state[0]) != config.Stages {
+		f.state = make([][]float64, f.channels)
+		for ch := range f.state {
+			f.state[ch] = make([]float64, config.Stages)
+		}
+	}
+}
+
+// Tail returns 0 as the allpass chain decays within a few samples.
+func (f *PhaserFilter) Tail() time.Duration {
+	return 0
+}
+
+func (f *PhaserFilter) Process(pcm []int16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	step := 2 * math.Pi * f.config.Rate / f.sampleRate
+	minFrequency := f.config.MinFrequency
+	frequencyRange := f.config.MaxFrequency - f.config.MinFrequency
+
+	for i := 0; i+f.channels <= len(pcm); i += f.channels {
+		frequency := minFrequency + frequencyRange*(math.Sin(f.phase)+1)/2
+		t := math.Tan(math.Pi * frequency / f.sampleRate)
+		coefficient := (t - 1) / (t + 1)
+		for ch := 0; ch < f.channels; ch++ {
+			in := float64(pcm[i+ch])
+			sample := in + f.last[ch]*f.config.Feedback
+			for s := range f.state[ch] {
+				out := coefficient*sample + f.state[ch][s]
+				f.state[ch][s] = sample - coefficient*out
+				sample = out
+			}
+			f.last[ch] = sample
+			pcm[i+ch] = clampSample(in*(1-f.config.Wet/2) + sample*f.config.Wet)
+		}
+		f.phase += step
+	}
+	f.phase = math.Mod(f.phase, 2*math.Pi)
+}
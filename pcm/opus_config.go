@@ -0,0 +1,85 @@
+package pcm
+
+import "github.com/disgoorg/audio/metrics"
+
+// DefaultOpusProviderConfig returns an OpusProviderConfig with sensible defaults.
+func DefaultOpusProviderConfig() *OpusProviderConfig {
+	return &OpusProviderConfig{
+		Metrics: metrics.Noop,
+	}
+}
+
+// OpusProviderConfig is used to configure the voice.OpusFrameProvider created by NewOpusProvider and NewConfiguredOpusProvider.
+type OpusProviderConfig struct {
+	// Metrics records metrics.FramesEncoded, metrics.EncodeSeconds and metrics.EncodeErrors.
+	Metrics metrics.Metrics
+
+	// ErrorPolicy handles encode errors. The zero value returns them.
+	ErrorPolicy ErrorPolicy
+}
+
+// OpusProviderConfigOpt is used to functionally configure an OpusProviderConfig.
+type OpusProviderConfigOpt func(config *OpusProviderConfig)
+
+// Apply applies the OpusProviderConfigOpt(s) to the OpusProviderConfig.
+func (c *OpusProviderConfig) Apply(opts []OpusProviderConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.Metrics = metrics.OrNoop(c.Metrics)
+}
+
+// WithOpusProviderMetrics sets the metrics.Metrics of the OpusProviderConfig.
+func WithOpusProviderMetrics(m metrics.Metrics) OpusProviderConfigOpt {
+	return func(config *OpusProviderConfig) {
+		config.Metrics = m
+	}
+}
+
+// WithOpusProviderErrorPolicy sets the ErrorPolicy which handles encode errors.
+func WithOpusProviderErrorPolicy(policy ErrorPolicy) OpusProviderConfigOpt {
+	return func(config *OpusProviderConfig) {
+		config.ErrorPolicy = policy
+	}
+}
+
+// DefaultOpusReceiverConfig returns an OpusReceiverConfig with sensible defaults.
+func DefaultOpusReceiverConfig() *OpusReceiverConfig {
+	return &OpusReceiverConfig{
+		Metrics: metrics.Noop,
+	}
+}
+
+// OpusReceiverConfig is used to configure the voice.OpusFrameReceiver created by NewPCMOpusReceiver.
+type OpusReceiverConfig struct {
+	// Metrics records metrics.FramesDecoded, metrics.DecodeSeconds, metrics.DecodeErrors and per user metrics.PacketsReceived, metrics.PacketsLost and metrics.LatePackets.
+	Metrics metrics.Metrics
+
+	// ErrorPolicy handles invalid packets and decode errors. The zero value returns them.
+	ErrorPolicy ErrorPolicy
+}
+
+// OpusReceiverConfigOpt is used to functionally configure an OpusReceiverConfig.
+type OpusReceiverConfigOpt func(config *OpusReceiverConfig)
+
+// Apply applies the OpusReceiverConfigOpt(s) to the OpusReceiverConfig.
+func (c *OpusReceiverConfig) Apply(opts []OpusReceiverConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.Metrics = metrics.OrNoop(c.Metrics)
+}
+
+// WithOpusReceiverMetrics sets the metrics.Metrics of the OpusReceiverConfig.
+func WithOpusReceiverMetrics(m metrics.Metrics) OpusReceiverConfigOpt {
+	return func(config *OpusReceiverConfig) {
+		config.Metrics = m
+	}
+}
+
+// WithOpusReceiverErrorPolicy sets the ErrorPolicy which handles invalid packets and decode errors.
+func WithOpusReceiverErrorPolicy(policy ErrorPolicy) OpusReceiverConfigOpt {
+	return func(config *OpusReceiverConfig) {
+		config.ErrorPolicy = policy
+	}
+}
@@ -0,0 +1,107 @@
+package pcm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/disgoorg/audio/channelconverter"
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/disgo/voice"
+)
+
+// NewMultistreamPCMFrameProvider creates a new FrameProvider which decodes the Opus packets of the given voice.OpusFrameProvider with the given *opus.MultistreamDecoder.
+// The PCM frames have as many channels as the decoder.
+func NewMultistreamPCMFrameProvider(decoder *opus.MultistreamDecoder, opusProvider voice.OpusFrameProvider) (FrameProvider, error) {
+	return newMultistreamPCMFrameProvider(decoder, opusProvider, decoder.Channels())
+}
+
+// NewStereoDownmixPCMFrameProvider creates a new FrameProvider like NewMultistreamPCMFrameProvider which downmixes the decoded surround PCM frames to stereo using the channelconverter.
+// The decoder must use the channel order of opus.MappingFamilyVorbis.
+func NewStereoDownmixPCMFrameProvider(decoder *opus.MultistreamDecoder, opusProvider voice.OpusFrameProvider) (FrameProvider, error) {
+	return newMultistreamPCMFrameProvider(decoder, opusProvider, 2)
+}
+
+func newMultistreamPCMFrameProvider(decoder *opus.MultistreamDecoder, opusProvider voice.OpusFrameProvider, outputChannels int) (FrameProvider, error) {
+	rate, err := decoder.SampleRate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sample rate: %w", err)
+	}
+	provider := &multistreamPCMFrameProvider{
+		decoder:        decoder,
+		opusProvider:   opusProvider,
+		rate:           rate,
+		outputChannels: outputChannels,
+		// fits the longest possible opus packet of 120ms
+		pcmBuff: make([]int16, opus.GetOutputBuffSize(rate, decoder.Channels())*6),
+	}
+	if outputChannels != decoder.Channels() {
+		provider.channelConverter = channelconverter.CreateChannelConverter(decoder.Channels(), outputChannels)
+		provider.convertedBuff = make([]int16, opus.GetOutputBuffSize(rate, outputChannels)*6)
+	}
+	provider.Lifecycle = NewLifecycle(nil, provider.close)
+	return provider, nil
+}
+
+type multistreamPCMFrameProvider struct {
+	*Lifecycle
+	decoder          *opus.MultistreamDecoder
+	opusProvider     voice.OpusFrameProvider
+	rate             int
+	outputChannels   int
+	pcmBuff          []int16
+	channelConverter *channelconverter.ChannelConverter
+	convertedBuff    []int16
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (p *multistreamPCMFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	packet, err := p.opusProvider.ProvideOpusFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, io.EOF
+	}
+
+	channels := p.decoder.Channels()
+	var pcm []int16
+	if packet == nil {
+		// no packet available, provide a frame of silence
+		pcm = p.pcmBuff[:opus.GetOutputBuffSize(p.rate, channels)]
+		for i := range pcm {
+			pcm[i] = 0
+		}
+	} else {
+		n, err := p.decoder.Decode(packet, p.pcmBuff, false)
+		if err != nil {
+			return nil, err
+		}
+		pcm = p.pcmBuff[:n*channels]
+	}
+
+	if p.channelConverter == nil {
+		return pcm, nil
+	}
+	converted := p.convertedBuff[:len(pcm)/channels*p.outputChannels]
+	if err = p.channelConverter.Convert(pcm, converted); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// close closes the voice.OpusFrameProvider first to release a ProvidePCMFrame call waiting for it before the decoder is destroyed.
+func (p *multistreamPCMFrameProvider) close() error {
+	err := Shutdown(context.Background(), p.opusProvider)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.decoder.Destroy()
+	return err
+}
@@ -1,56 +1,130 @@
 package pcm
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
+	"github.com/disgoorg/audio/metrics"
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/disgo/voice"
 )
 
 // NewOpusProvider creates a new voice.OpusFrameProvider which gets PCM frames from the given FrameProvider and encodes the PCM frames into Opus frames.
 // You can pass your own *opus.Encoder or nil to use the default Opus encoder(48000hz sample rate, 2 channels, opus.ApplicationAudio & 64kbps bitrate).
-func NewOpusProvider(encoder *opus.Encoder, pcmProvider FrameProvider) (voice.OpusFrameProvider, error) {
+// An empty PCM frame results in no Opus frame, errors of the FrameProvider are returned as ClassifiedError and encode errors are handled by the OpusProviderConfig.ErrorPolicy.
+func NewOpusProvider(encoder *opus.Encoder, pcmProvider FrameProvider, opts ...OpusProviderConfigOpt) (voice.OpusFrameProvider, error) {
 	if encoder == nil {
+		return NewConfiguredOpusProvider(opus.DefaultEncoderConfig(), pcmProvider, opts...)
+	}
+	return newOpusProvider(encoder, pcmProvider, nil, opts), nil
+}
+
+// NewConfiguredOpusProvider creates a new voice.OpusFrameProvider like NewOpusProvider with an *opus.Encoder created from the given opus.EncoderConfig.
+// If opus.EncoderConfig.Adaptive is set, the encoder settings are adapted to the reported opus.NetworkStats before each frame is encoded.
+func NewConfiguredOpusProvider(config opus.EncoderConfig, pcmProvider FrameProvider, opts ...OpusProviderConfigOpt) (voice.OpusFrameProvider, error) {
+	var controller *opus.AdaptiveController
+	if config.Adaptive != nil {
 		var err error
-		if encoder, err = opus.NewEncoder(48000, 2, opus.ApplicationAudio); err != nil {
-			return nil, fmt.Errorf("failed to create opus encoder: %w", err)
-		}
-		if err = encoder.Ctl(opus.SetBitrate(64000)); err != nil {
-			return nil, fmt.Errorf("failed to set opus bitrate: %w", err)
+		if controller, err = opus.NewAdaptiveController(*config.Adaptive, config.Bitrate); err != nil {
+			return nil, fmt.Errorf("failed to create adaptive controller: %w", err)
 		}
+	}
+	encoder, err := config.NewEncoder()
+	if err != nil {
+		return nil, err
+	}
+	return newOpusProvider(encoder, pcmProvider, controller, opts), nil
+}
 
+func newOpusProvider(encoder *opus.Encoder, pcmProvider FrameProvider, controller *opus.AdaptiveController, opts []OpusProviderConfigOpt) *opusProvider {
+	config := DefaultOpusProviderConfig()
+	config.Apply(opts)
+
+	provider := &opusProvider{
+		encoder:       encoder,
+		pcmProvider:   pcmProvider,
+		opusBuff:      make([]byte, 2048),
+		controller:    controller,
+		framesEncoded: config.Metrics.Counter(metrics.FramesEncoded),
+		encodeSeconds: config.Metrics.Histogram(metrics.EncodeSeconds),
+		encodeErrors:  config.Metrics.Counter(metrics.EncodeErrors),
+		errorPolicy:   config.ErrorPolicy,
 	}
-	return &opusProvider{
-		encoder:     encoder,
-		pcmProvider: pcmProvider,
-		opusBuff:    make([]byte, 2048),
-	}, nil
+	provider.Lifecycle = NewLifecycle(nil, provider.close)
+	return provider
 }
 
 type opusProvider struct {
+	*Lifecycle
 	encoder     *opus.Encoder
 	pcmProvider FrameProvider
 	opusBuff    []byte
+	controller  *opus.AdaptiveController
+
+	framesEncoded metrics.Counter
+	encodeSeconds metrics.Histogram
+	encodeErrors  metrics.Counter
+	errorPolicy   ErrorPolicy
+
+	mu     sync.Mutex
+	closed bool
 }
 
 func (p *opusProvider) ProvideOpusFrame() ([]byte, error) {
 	pcm, err := p.pcmProvider.ProvidePCMFrame()
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	if len(pcm) == 0 {
+		return nil, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
 		return nil, io.EOF
 	}
 
-	n, err := p.encoder.Encode(pcm, p.opusBuff)
-	if err != nil {
-		return nil, err
+	if p.controller != nil {
+		if err = p.controller.Adapt(p.encoder); err != nil {
+			return nil, err
+		}
+	}
+
+	for retries := 0; ; retries++ {
+		start := time.Now()
+		n, err := p.encoder.Encode(pcm, p.opusBuff)
+		if err == nil {
+			metrics.ObserveDuration(p.encodeSeconds, start)
+			p.framesEncoded.Add(1)
+			return p.opusBuff[:n], nil
+		}
+		p.encodeErrors.Add(1)
+
+		switch p.errorPolicy.Action(err, retries) {
+		case ErrorActionRetry:
+			continue
+		case ErrorActionSkip:
+			return nil, nil
+		case ErrorActionSilence:
+			return voice.SilenceAudioFrame, nil
+		case ErrorActionEnd:
+			return nil, io.EOF
+		default:
+			return nil, classify(err)
+		}
 	}
-	return p.opusBuff[:n], nil
 }
 
-func (p *opusProvider) Close() {
+// close closes the FrameProvider first to release a ProvideOpusFrame call waiting for it before the encoder is destroyed.
+func (p *opusProvider) close() error {
+	err := Shutdown(context.Background(), p.pcmProvider)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
 	p.encoder.Destroy()
-	p.pcmProvider.Close()
+	return err
 }
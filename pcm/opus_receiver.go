@@ -1,9 +1,13 @@
 package pcm
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
+	"github.com/disgoorg/audio/metrics"
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/disgo/voice"
 	"github.com/disgoorg/snowflake/v2"
@@ -12,7 +16,11 @@ import (
 // NewPCMOpusReceiver creates a new voice.OpusFrameReceiver which receives Opus frames and decodes them into PCM frames. A new decoder is created for each user.
 // You can pass your own *opus.Decoder by passing a decoderCreateFunc or nil to use the default Opus decoder(48000hz sample rate, 2 channels).
 // You can filter users by passing a voice.ShouldReceiveUserFunc or nil to receive all users.
-func NewPCMOpusReceiver(decoderCreateFunc func() (*opus.Decoder, error), pcmFrameReceiver FrameReceiver, userFilter voice.UserFilterFunc) voice.OpusFrameReceiver {
+// Invalid packets and decode errors are handled by the OpusReceiverConfig.ErrorPolicy, ErrorActionSilence passes a silent frame on.
+func NewPCMOpusReceiver(decoderCreateFunc func() (*opus.Decoder, error), pcmFrameReceiver FrameReceiver, userFilter voice.UserFilterFunc, opts ...OpusReceiverConfigOpt) voice.OpusFrameReceiver {
+	config := DefaultOpusReceiverConfig()
+	config.Apply(opts)
+
 	if decoderCreateFunc == nil {
 		decoderCreateFunc = func() (*opus.Decoder, error) {
 			decoder, err := opus.NewDecoder(48000, 2)
@@ -22,25 +30,76 @@ func NewPCMOpusReceiver(decoderCreateFunc func() (*opus.Decoder, error), pcmFram
 			return decoder, nil
 		}
 	}
-	return &pcmOpusReceiver{
+	receiver := &pcmOpusReceiver{
 		userFilter:        userFilter,
 		decoderCreateFunc: decoderCreateFunc,
 		decoderStates:     map[snowflake.ID]*decoderState{},
 		pcmFrameReceiver:  pcmFrameReceiver,
+		metrics:           config.Metrics,
+		framesDecoded:     config.Metrics.Counter(metrics.FramesDecoded),
+		decodeSeconds:     config.Metrics.Histogram(metrics.DecodeSeconds),
+		decodeErrors:      config.Metrics.Counter(metrics.DecodeErrors),
+		errorPolicy:       config.ErrorPolicy,
 	}
+	receiver.Lifecycle = NewLifecycle(nil, receiver.close)
+	return receiver
 }
 
 type decoderState struct {
-	decoder *opus.Decoder
-	pcmBuff []int16
+	decoder   *opus.Decoder
+	pcmBuff   []int16
+	mu        sync.Mutex
+	destroyed bool
+
+	hasSequence     bool
+	lastSequence    uint16
+	packetsReceived metrics.Counter
+	packetsLost     metrics.Counter
+	latePackets     metrics.Counter
+}
+
+// trackSequence counts lost and late packets based on the gaps in the sequence numbers.
+func (s *decoderState) trackSequence(sequence uint16) {
+	s.packetsReceived.Add(1)
+	if !s.hasSequence {
+		s.hasSequence = true
+		s.lastSequence = sequence
+		return
+	}
+	// the difference wraps around, so packets older than the last one result in a large gap
+	gap := sequence - s.lastSequence
+	if gap == 0 || gap >= 1<<15 {
+		s.latePackets.Add(1)
+		return
+	}
+	if gap > 1 {
+		s.packetsLost.Add(float64(gap - 1))
+	}
+	s.lastSequence = sequence
+}
+
+// destroy destroys the decoder once no frame is decoded anymore.
+func (s *decoderState) destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.destroyed = true
+	s.decoder.Destroy()
 }
 
 type pcmOpusReceiver struct {
+	*Lifecycle
 	userFilter        voice.UserFilterFunc
 	decoderCreateFunc func() (*opus.Decoder, error)
 	decoderStates     map[snowflake.ID]*decoderState
 	decodersMu        sync.Mutex
+	closed            bool
 	pcmFrameReceiver  FrameReceiver
+
+	metrics       metrics.Metrics
+	framesDecoded metrics.Counter
+	decodeSeconds metrics.Histogram
+	decodeErrors  metrics.Counter
+	errorPolicy   ErrorPolicy
 }
 
 func (r *pcmOpusReceiver) ReceiveOpusFrame(userID snowflake.ID, packet *voice.Packet) error {
@@ -48,6 +107,10 @@ func (r *pcmOpusReceiver) ReceiveOpusFrame(userID snowflake.ID, packet *voice.Pa
 		return nil
 	}
 	r.decodersMu.Lock()
+	if r.closed {
+		r.decodersMu.Unlock()
+		return io.ErrClosedPipe
+	}
 	state, ok := r.decoderStates[userID]
 	if !ok {
 		decoder, err := r.decoderCreateFunc()
@@ -59,20 +122,50 @@ func (r *pcmOpusReceiver) ReceiveOpusFrame(userID snowflake.ID, packet *voice.Pa
 		sampleRate, err := decoder.SampleRate()
 		if err != nil {
 			r.decodersMu.Unlock()
+			decoder.Destroy()
 			return fmt.Errorf("failed to get sample rate: %w", err)
 		}
 
+		label := userLabel(userID)
 		state = &decoderState{
-			decoder: decoder,
-			pcmBuff: make([]int16, opus.GetOutputBuffSize(sampleRate, decoder.Channels())),
+			decoder:         decoder,
+			pcmBuff:         make([]int16, opus.GetOutputBuffSize(sampleRate, decoder.Channels())),
+			packetsReceived: r.metrics.Counter(metrics.PacketsReceived, label),
+			packetsLost:     r.metrics.Counter(metrics.PacketsLost, label),
+			latePackets:     r.metrics.Counter(metrics.LatePackets, label),
 		}
 		r.decoderStates[userID] = state
 	}
 	r.decodersMu.Unlock()
 
-	_, err := state.decoder.Decode(packet.Opus, state.pcmBuff, false)
-	if err != nil {
-		return err
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.destroyed {
+		return io.ErrClosedPipe
+	}
+	state.trackSequence(packet.Sequence)
+
+	for retries := 0; ; retries++ {
+		err := r.decode(state, packet.Opus)
+		if err == nil {
+			break
+		}
+		r.decodeErrors.Add(1)
+
+		action := r.errorPolicy.Action(err, retries)
+		if action == ErrorActionRetry {
+			continue
+		}
+		if action == ErrorActionSkip {
+			return nil
+		}
+		if action != ErrorActionSilence {
+			return classify(err)
+		}
+		for i := range state.pcmBuff {
+			state.pcmBuff[i] = 0
+		}
+		break
 	}
 
 	return r.pcmFrameReceiver.ReceivePCMFrame(userID, &Packet{
@@ -83,22 +176,43 @@ func (r *pcmOpusReceiver) ReceiveOpusFrame(userID snowflake.ID, packet *voice.Pa
 	})
 }
 
+func (r *pcmOpusReceiver) decode(state *decoderState, packet []byte) error {
+	if err := opus.ValidatePacket(packet); err != nil {
+		return err
+	}
+	start := time.Now()
+	if _, err := state.decoder.Decode(packet, state.pcmBuff, false); err != nil {
+		return err
+	}
+	metrics.ObserveDuration(r.decodeSeconds, start)
+	r.framesDecoded.Add(1)
+	return nil
+}
+
 func (r *pcmOpusReceiver) CleanupUser(userID snowflake.ID) {
 	r.decodersMu.Lock()
 	defer r.decodersMu.Unlock()
 	state, ok := r.decoderStates[userID]
 	if ok {
-		state.decoder.Destroy()
+		state.destroy()
 		delete(r.decoderStates, userID)
+		metrics.Remove(r.metrics, userLabel(userID))
 	}
 	r.pcmFrameReceiver.CleanupUser(userID)
 }
 
-func (r *pcmOpusReceiver) Close() {
+func (r *pcmOpusReceiver) close() error {
 	r.decodersMu.Lock()
-	defer r.decodersMu.Unlock()
-	for _, state := range r.decoderStates {
-		state.decoder.Destroy()
+	r.closed = true
+	for userID, state := range r.decoderStates {
+		state.destroy()
+		delete(r.decoderStates, userID)
+		metrics.Remove(r.metrics, userLabel(userID))
 	}
-	r.pcmFrameReceiver.Close()
+	r.decodersMu.Unlock()
+	return Shutdown(context.Background(), r.pcmFrameReceiver)
+}
+
+func userLabel(userID snowflake.ID) metrics.Label {
+	return metrics.Label{Name: metrics.LabelUserID, Value: userID.String()}
 }
@@ -1,13 +1,19 @@
 package pcm
 
+import "context"
+
 func NewPauseablePCMFrameProvider(provider FrameProvider, pauseProvider func() bool) FrameProvider {
 	return &pauseablePCMFrameProvider{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), provider)
+		}),
 		provider:      provider,
 		pauseProvider: pauseProvider,
 	}
 }
 
 type pauseablePCMFrameProvider struct {
+	*Lifecycle
 	provider      FrameProvider
 	pauseProvider func() bool
 }
@@ -18,7 +24,3 @@ func (p *pauseablePCMFrameProvider) ProvidePCMFrame() ([]int16, error) {
 	}
 	return p.provider.ProvidePCMFrame()
 }
-
-func (p *pauseablePCMFrameProvider) Close() {
-	p.provider.Close()
-}
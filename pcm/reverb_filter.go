@@ -0,0 +1,186 @@
+package pcm
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Freeverb tunings at 44100hz.
+var (
+	reverbCombTunings    = [...]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpassTunings = [...]int{556, 441, 341, 225}
+)
+
+const (
+	reverbStereoSpread = 23
+	reverbFixedGain    = 0.015
+	reverbScaleRoom    = 0.28
+	reverbOffsetRoom   = 0.7
+	reverbScaleDamping = 0.4
+	reverbScaleWet     = 3
+	reverbAllpassGain  = 0.5
+)
+
+// NewReverbFilter creates a new *ReverbFilter for interleaved PCM frames with the given sample rate and channels.
+// roomSize and damping range from 0 to 1, wet and dry are the levels of the reverberated and original signal from 0 to 1.
+func NewReverbFilter(sampleRate int, channels int, roomSize float64, damping float64, wet float64, dry float64) *ReverbFilter {
+	channels = filterChannels(channels)
+	f := &ReverbFilter{
+		sampleRate: sampleRate,
+		channels:   channels,
+		width:      1,
+		combs:      make([][]reverbComb, channels),
+		allpasses:  make([][]reverbAllpass, channels),
+	}
+	scale := float64(sampleRate) / 44100
+	for ch := 0; ch < channels; ch++ {
+		spread := ch * reverbStereoSpread
+		for _, tuning := range reverbCombTunings {
+			f.combs[ch] = append(f.combs[ch], reverbComb{buff: make([]float64, int(float64(tuning+spread)*scale))})
+		}
+		for _, tuning := range reverbAllpassTunings {
+			f.allpasses[ch] = append(f.allpasses[ch], reverbAllpass{buff: make([]float64, int(float64(tuning+spread)*scale))})
+		}
+	}
+	f.SetRoomSize(roomSize)
+	f.SetDamping(damping)
+	f.SetWet(wet)
+	f.SetDry(dry)
+	return f
+}
+
+// ReverbFilter is a Freeverb style reverb built from parallel lowpass-feedback comb filters followed by series allpass filters (Schroeder-Moorer).
+type ReverbFilter struct {
+	sampleRate int
+	channels   int
+	roomSize   float64
+	damping    float64
+	wet        float64
+	dry        float64
+	width      float64
+	combs      [][]reverbComb
+	allpasses  [][]reverbAllpass
+	mu         sync.Mutex
+}
+
+// SetRoomSize sets the size of the room from 0 to 1, larger rooms reverberate longer.
+func (f *ReverbFilter) SetRoomSize(roomSize float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.roomSize = clampFloat(roomSize, 0, 1)
+}
+
+// SetDamping sets how fast high frequencies decay from 0 to 1.
+func (f *ReverbFilter) SetDamping(damping float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.damping = clampFloat(damping, 0, 1)
+}
+
+// SetWet sets the level of the reverberated signal from 0 to 1.
+func (f *ReverbFilter) SetWet(wet float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.wet = clampFloat(wet, 0, 1)
+}
+
+// SetDry sets the level of the original signal from 0 to 1.
+func (f *ReverbFilter) SetDry(dry float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dry = clampFloat(dry, 0, 1)
+}
+
+// SetWidth sets the stereo width of the reverberated signal from 0 to 1.
+func (f *ReverbFilter) SetWidth(width float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.width = clampFloat(width, 0, 1)
+}
+
+// Tail returns the time the longest comb filter needs to decay below -60dB.
+func (f *ReverbFilter) Tail() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	longest := f.combs[0][len(f.combs[0])-1]
+	delay := time.Duration(float64(len(longest.buff)) / float64(f.sampleRate) * float64(time.Second))
+	return feedbackTail(delay, f.feedback())
+}
+
+func (f *ReverbFilter) feedback() float64 {
+	return f.roomSize*reverbScaleRoom + reverbOffsetRoom
+}
+
+func (f *ReverbFilter) Process(pcm []int16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	feedback := f.feedback()
+	damping := f.damping * reverbScaleDamping
+	wet := f.wet * reverbScaleWet
+	wet1 := wet * (f.width/2 + 0.5)
+	wet2 := wet * ((1 - f.width) / 2)
+
+	var out [2]float64
+	for i := 0; i+f.channels <= len(pcm); i += f.channels {
+		var input float64
+		for ch := 0; ch < f.channels; ch++ {
+			input += float64(pcm[i+ch])
+		}
+		input *= reverbFixedGain
+
+		for ch := 0; ch < f.channels; ch++ {
+			var sample float64
+			for c := range f.combs[ch] {
+				sample += f.combs[ch][c].process(input, feedback, damping)
+			}
+			for a := range f.allpasses[ch] {
+				sample = f.allpasses[ch][a].process(sample)
+			}
+			if ch < 2 {
+				out[ch] = sample
+			}
+			if f.channels != 2 {
+				pcm[i+ch] = clampSample(sample*wet + float64(pcm[i+ch])*f.dry)
+			}
+		}
+		if f.channels == 2 {
+			pcm[i] = clampSample(out[0]*wet1 + out[1]*wet2 + float64(pcm[i])*f.dry)
+			pcm[i+1] = clampSample(out[1]*wet1 + out[0]*wet2 + float64(pcm[i+1])*f.dry)
+		}
+	}
+}
+
+type reverbComb struct {
+	buff        []float64
+	pos         int
+	filterStore float64
+}
+
+func (c *reverbComb) process(input float64, feedback float64, damping float64) float64 {
+	output := c.buff[c.pos]
+	c.filterStore = output*(1-damping) + c.filterStore*damping
+	if math.Abs(c.filterStore) < 1e-20 {
+		// flush denormals which slow down the feedback loop once the tail decayed
+		c.filterStore = 0
+	}
+	c.buff[c.pos] = input + c.filterStore*feedback
+	if c.pos++; c.pos == len(c.buff) {
+		c.pos = 0
+	}
+	return output
+}
+
+type reverbAllpass struct {
+	buff []float64
+	pos  int
+}
+
+func (a *reverbAllpass) process(input float64) float64 {
+	buffered := a.buff[a.pos]
+	a.buff[a.pos] = input + buffered*reverbAllpassGain
+	if a.pos++; a.pos == len(a.buff) {
+		a.pos = 0
+	}
+	return buffered - input
+}
@@ -0,0 +1,197 @@
+package pcm
+
+import (
+	"math"
+	"sync"
+)
+
+// The stereo filters expect interleaved stereo PCM frames.
+
+// NewPanFilter creates a new *PanFilter with the given pan from -1 (left) to 1 (right).
+func NewPanFilter(pan float64) *PanFilter {
+	f := &PanFilter{}
+	f.SetPan(pan)
+	return f
+}
+
+// PanFilter moves the stereo image with a constant-power pan law. Panning to one side mixes the other channel into it, so no signal is lost.
+type PanFilter struct {
+	pan float64
+	mu  sync.Mutex
+}
+
+func (f *PanFilter) Pan() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pan
+}
+
+// SetPan sets the pan from -1 (left) to 1 (right).
+func (f *PanFilter) SetPan(pan float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pan = clampFloat(pan, -1, 1)
+}
+
+func (f *PanFilter) Process(pcm []int16) {
+	pan := f.Pan()
+	if pan == 0 {
+		return
+	}
+	for i := 0; i+1 < len(pcm); i += 2 {
+		pcm[i], pcm[i+1] = panSample(float64(pcm[i]), float64(pcm[i+1]), pan)
+	}
+}
+
+// panSample pans a stereo sample like the Web Audio StereoPannerNode.
+func panSample(left float64, right float64, pan float64) (int16, int16) {
+	if pan <= 0 {
+		x := (pan + 1) * math.Pi / 2
+		return clampSample(left + right*math.Cos(x)), clampSample(right * math.Sin(x))
+	}
+	x := pan * math.Pi / 2
+	return clampSample(left * math.Cos(x)), clampSample(right + left*math.Sin(x))
+}
+
+// NewBalanceFilter creates a new *BalanceFilter with the given balance from -1 (left) to 1 (right).
+func NewBalanceFilter(balance float64) *BalanceFilter {
+	f := &BalanceFilter{}
+	f.SetBalance(balance)
+	return f
+}
+
+// BalanceFilter attenuates the channel opposite to the balance without mixing the channels.
+type BalanceFilter struct {
+	balance float64
+	mu      sync.Mutex
+}
+
+func (f *BalanceFilter) Balance() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.balance
+}
+
+// SetBalance sets the balance from -1 (left) to 1 (right).
+func (f *BalanceFilter) SetBalance(balance float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balance = clampFloat(balance, -1, 1)
+}
+
+func (f *BalanceFilter) Process(pcm []int16) {
+	balance := f.Balance()
+	if balance == 0 {
+		return
+	}
+	offset, gain := 1, 1+balance
+	if balance > 0 {
+		offset, gain = 0, 1-balance
+	}
+	for i := offset; i < len(pcm); i += 2 {
+		pcm[i] = int16(float64(pcm[i]) * gain)
+	}
+}
+
+// NewStereoWidthFilter creates a new *StereoWidthFilter with the given width.
+func NewStereoWidthFilter(width float64) *StereoWidthFilter {
+	f := &StereoWidthFilter{}
+	f.SetWidth(width)
+	return f
+}
+
+// NewMonoFilter creates a new *StereoWidthFilter which collapses the stereo image to mono while keeping both channels, which is useful to check mono compatibility.
+func NewMonoFilter() *StereoWidthFilter {
+	return NewStereoWidthFilter(0)
+}
+
+// StereoWidthFilter scales the side signal of the mid/side representation.
+// A width of 0 results in mono, 1 leaves the signal unchanged and values above 1 widen the stereo image.
+type StereoWidthFilter struct {
+	width float64
+	mu    sync.Mutex
+}
+
+func (f *StereoWidthFilter) Width() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.width
+}
+
+// SetWidth sets the width from 0 (mono) to 4.
+func (f *StereoWidthFilter) SetWidth(width float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.width = clampFloat(width, 0, 4)
+}
+
+func (f *StereoWidthFilter) Process(pcm []int16) {
+	width := f.Width()
+	if width == 1 {
+		return
+	}
+	for i := 0; i+1 < len(pcm); i += 2 {
+		mid := (float64(pcm[i]) + float64(pcm[i+1])) / 2
+		side := (float64(pcm[i]) - float64(pcm[i+1])) / 2 * width
+		pcm[i] = clampSample(mid + side)
+		pcm[i+1] = clampSample(mid - side)
+	}
+}
+
+// NewAutoPanFilter creates a new *AutoPanFilter for the given sample rate which moves the sound between left and right at the given rate in Hz.
+// depth from 0 to 1 controls how far the sound moves to each side. This is also known as "8D audio".
+func NewAutoPanFilter(sampleRate int, rate float64, depth float64) *AutoPanFilter {
+	f := &AutoPanFilter{
+		sampleRate: float64(sampleRate),
+	}
+	f.SetRate(rate)
+	f.SetDepth(depth)
+	return f
+}
+
+// AutoPanFilter pans the signal with a sine LFO.
+type AutoPanFilter struct {
+	sampleRate float64
+	rate       float64
+	depth      float64
+	phase      float64
+	mu         sync.Mutex
+}
+
+// SetRate sets the LFO rate in Hz.
+func (f *AutoPanFilter) SetRate(rate float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rate = math.Max(rate, 0)
+}
+
+// SetDepth sets how far the sound moves to each side from 0 to 1.
+func (f *AutoPanFilter) SetDepth(depth float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.depth = clampFloat(depth, 0, 1)
+}
+
+func (f *AutoPanFilter) Process(pcm []int16) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	step := 2 * math.Pi * f.rate / f.sampleRate
+	for i := 0; i+1 < len(pcm); i += 2 {
+		pcm[i], pcm[i+1] = panSample(float64(pcm[i]), float64(pcm[i+1]), f.depth*math.Sin(f.phase))
+		f.phase += step
+	}
+	f.phase = math.Mod(f.phase, 2*math.Pi)
+}
+
+func clampFloat(v float64, min float64, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
+
+func clampSample(sample float64) int16 {
+	if sample > 32767 {
+		return 32767
+	} else if sample < -32768 {
+		return -32768
+	}
+	return int16(sample)
+}
@@ -0,0 +1,121 @@
+package pcm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPanFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		pan   float64
+		input []int16
+		want  []int16
+	}{
+		{name: "center", pan: 0, input: []int16{1000, -2000}, want: []int16{1000, -2000}},
+		{name: "hard left", pan: -1, input: []int16{1000, 2000}, want: []int16{3000, 0}},
+		{name: "hard right", pan: 1, input: []int16{1000, 2000}, want: []int16{0, 3000}},
+		{name: "half right", pan: 0.5, input: []int16{10000, 0}, want: []int16{7071, 7071}},
+		{name: "half left", pan: -0.5, input: []int16{0, 10000}, want: []int16{7071, 7071}},
+		{name: "clipping", pan: -1, input: []int16{30000, 30000}, want: []int16{32767, 0}},
+		{name: "out of range", pan: -5, input: []int16{1000, 2000}, want: []int16{3000, 0}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := append([]int16(nil), tt.input...)
+			NewPanFilter(tt.pan).Process(pcm)
+			if !equalFrames(pcm, tt.want) {
+				t.Fatalf("got %v, want %v", pcm, tt.want)
+			}
+		})
+	}
+}
+
+func TestPanFilterConstantPower(t *testing.T) {
+	// a signal on one side keeps its power while moving to the other side
+	for pan := -1.0; pan <= 0; pan += 0.25 {
+		pcm := []int16{10000, 0}
+		NewPanFilter(pan).Process(pcm)
+		if power := math.Hypot(float64(pcm[0]), float64(pcm[1])); math.Abs(power-10000) > 2 {
+			t.Fatalf("got power %f at pan %f, want 10000", power, pan)
+		}
+	}
+}
+
+func TestBalanceFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		balance float64
+		want    []int16
+	}{
+		{name: "center", balance: 0, want: []int16{1000, 1000, 1000, 1000}},
+		{name: "right", balance: 0.5, want: []int16{500, 1000, 500, 1000}},
+		{name: "left", balance: -0.75, want: []int16{1000, 250, 1000, 250}},
+		{name: "hard right", balance: 2, want: []int16{0, 1000, 0, 1000}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := []int16{1000, 1000, 1000, 1000}
+			NewBalanceFilter(tt.balance).Process(pcm)
+			if !equalFrames(pcm, tt.want) {
+				t.Fatalf("got %v, want %v", pcm, tt.want)
+			}
+		})
+	}
+}
+
+func TestStereoWidthFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		filter *StereoWidthFilter
+		want   []int16
+	}{
+		{name: "unchanged", filter: NewStereoWidthFilter(1), want: []int16{3000, 1000}},
+		{name: "mono", filter: NewMonoFilter(), want: []int16{2000, 2000}},
+		{name: "narrow", filter: NewStereoWidthFilter(0.5), want: []int16{2500, 1500}},
+		{name: "wide", filter: NewStereoWidthFilter(2), want: []int16{4000, 0}},
+		{name: "limited to 4", filter: NewStereoWidthFilter(100), want: []int16{6000, -2000}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := []int16{3000, 1000}
+			tt.filter.Process(pcm)
+			if !equalFrames(pcm, tt.want) {
+				t.Fatalf("got %v, want %v", pcm, tt.want)
+			}
+		})
+	}
+
+	pcm := []int16{30000, -30000}
+	NewStereoWidthFilter(2).Process(pcm)
+	if !equalFrames(pcm, []int16{32767, -32768}) {
+		t.Fatalf("got %v, want the samples clipped", pcm)
+	}
+}
+
+func TestAutoPanFilterPhaseContinuity(t *testing.T) {
+	input := make([]int16, 2*9600)
+	for i := range input {
+		input[i] = 10000
+	}
+
+	whole := append([]int16(nil), input...)
+	NewAutoPanFilter(48000, 2, 1).Process(whole)
+
+	// processing the same signal in 20ms frames moves the pan identically
+	split := append([]int16(nil), input...)
+	filter := NewAutoPanFilter(48000, 2, 1)
+	for i := 0; i < len(split); i += 2 * 960 {
+		filter.Process(split[i : i+2*960])
+	}
+	for i := range whole {
+		if diff := int(whole[i]) - int(split[i]); diff < -1 || diff > 1 {
+			t.Fatalf("got sample %d %d processing 20ms frames, want %d", i, split[i], whole[i])
+		}
+	}
+
+	// a quarter of the 2Hz period pans the sound to the right
+	if i := 2 * 48000 / 8; whole[i] > 1 || whole[i+1] < 19999 {
+		t.Fatalf("got %v at the peak of the LFO, want the sound on the right", whole[i:i+2])
+	}
+	if filter.phase < 0 || filter.phase >= 2*math.Pi {
+		t.Fatalf("got phase %f, want it wrapped to [0, 2π)", filter.phase)
+	}
+}
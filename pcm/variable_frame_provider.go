@@ -1,12 +1,21 @@
 package pcm
 
+import "context"
+
 func NewVariablePCMFrameProvider(providerFunc func() FrameProvider) FrameProvider {
 	return &variablePCMFrameProvider{
+		Lifecycle: NewLifecycle(nil, func() error {
+			if provider := providerFunc(); provider != nil {
+				return Shutdown(context.Background(), provider)
+			}
+			return nil
+		}),
 		providerFunc: providerFunc,
 	}
 }
 
 type variablePCMFrameProvider struct {
+	*Lifecycle
 	providerFunc func() FrameProvider
 }
 
@@ -16,9 +25,3 @@ func (v *variablePCMFrameProvider) ProvidePCMFrame() ([]int16, error) {
 	}
 	return nil, nil
 }
-
-func (v *variablePCMFrameProvider) Close() {
-	if provider := v.providerFunc(); provider != nil {
-		provider.Close()
-	}
-}
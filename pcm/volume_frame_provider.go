@@ -1,13 +1,19 @@
 package pcm
 
+import "context"
+
 func NewPCMVolumeFrameProvider(pcmFrameProvider FrameProvider, volumeProvider func() float32) FrameProvider {
 	return &pcmVolumeFrameProvider{
+		Lifecycle: NewLifecycle(nil, func() error {
+			return Shutdown(context.Background(), pcmFrameProvider)
+		}),
 		pcmFrameProvider: pcmFrameProvider,
 		volumeProvider:   volumeProvider,
 	}
 }
 
 type pcmVolumeFrameProvider struct {
+	*Lifecycle
 	pcmFrameProvider FrameProvider
 	volumeProvider   func() float32
 }
@@ -21,10 +27,7 @@ func (p *pcmVolumeFrameProvider) ProvidePCMFrame() ([]int16, error) {
 	return frame, nil
 }
 
-func (p *pcmVolumeFrameProvider) Close() {
-	p.pcmFrameProvider.Close()
-}
-
+// applyVolume scales the PCM frame in place and clamps it to the int16 range, a Meter placed afterwards counts the clamped samples as clipped.
 func applyVolume(pcm []int16, newVolume float32) {
 	if newVolume == 1 {
 		return
@@ -0,0 +1,91 @@
+// Package pipeline builds pcm.FrameProvider and pcm.FrameReceiver chains from stages.
+// A builder carries the pcm.Format through each stage, inserts channel conversion and resampling where the formats of two stages differ and reports mismatches when the pipeline is built.
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/disgoorg/audio/channelconverter"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// opusSampleRates are the sample rates supported by the Opus encoder and decoder.
+var opusSampleRates = [...]int{8000, 12000, 16000, 24000, 48000}
+
+// validateFormat validates the pcm.Format of a pcm.FrameProvider or pcm.FrameReceiver stage which processes int16 samples.
+func validateFormat(format pcm.Format) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+	if format.SampleType != pcm.SampleTypeInt16 {
+		return fmt.Errorf("%w: pcm stages only support %s samples, got %s", pcm.ErrInvalidFormat, pcm.SampleTypeInt16, format.SampleType)
+	}
+	return nil
+}
+
+// validateOpusFormat validates that the Opus encoder or decoder supports the pcm.Format.
+func validateOpusFormat(format pcm.Format) error {
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	if format.Channels > 2 {
+		return fmt.Errorf("%w: opus supports 1 or 2 channels, got %d", pcm.ErrInvalidFormat, format.Channels)
+	}
+	for _, rate := range opusSampleRates {
+		if format.SampleRate == rate {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: opus doesn't support a sample rate of %dhz", pcm.ErrInvalidFormat, format.SampleRate)
+}
+
+// channelConverter returns a *channelconverter.ChannelConverter from the input to the output channels or an error if there is no downmix or upmix between them.
+func channelConverter(inputChannels int, outputChannels int) (*channelconverter.ChannelConverter, error) {
+	converter := channelconverter.CreateChannelConverter(inputChannels, outputChannels)
+	if converter.Matrix() == nil {
+		return nil, fmt.Errorf("%w: can't convert %d to %d channels", pcm.ErrInvalidFormat, inputChannels, outputChannels)
+	}
+	return converter, nil
+}
+
+// conversion describes the stages needed to convert between two pcm.Format(s).
+// The channels are converted before resampling if the output has fewer channels, so the resampler processes as few channels as possible.
+type conversion struct {
+	input          pcm.Format
+	output         pcm.Format
+	converter      *channelconverter.ChannelConverter
+	resample       bool
+	channelsFirst  bool
+	resampleFormat pcm.Format
+}
+
+func newConversion(input pcm.Format, output pcm.Format) (*conversion, error) {
+	if err := validateFormat(output); err != nil {
+		return nil, err
+	}
+	c := &conversion{
+		input:         input,
+		output:        output,
+		resample:      input.SampleRate != output.SampleRate,
+		channelsFirst: output.Channels < input.Channels,
+	}
+	if input.Channels != output.Channels {
+		var err error
+		if c.converter, err = channelConverter(input.Channels, output.Channels); err != nil {
+			return nil, err
+		}
+	}
+	c.resampleFormat = input
+	if c.channelsFirst {
+		c.resampleFormat.Channels = output.Channels
+	}
+	return c, nil
+}
+
+// channelRate returns the sample rate at which the channels are converted.
+func (c *conversion) channelRate() int {
+	if c.channelsFirst {
+		return c.input.SampleRate
+	}
+	return c.output.SampleRate
+}
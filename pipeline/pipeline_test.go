@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/disgoorg/audio/pcm"
+)
+
+func TestNewConversion(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		input          pcm.Format
+		output         pcm.Format
+		resample       bool
+		channelsFirst  bool
+		converter      bool
+		resampleFormat pcm.Format
+	}{
+		{
+			name:   "same format",
+			input:  pcm.DefaultFormat(),
+			output: pcm.DefaultFormat(),
+		},
+		{
+			name:           "downmix before resampling",
+			input:          pcm.Format{SampleRate: 44100, Channels: 6},
+			output:         pcm.DefaultFormat(),
+			resample:       true,
+			channelsFirst:  true,
+			converter:      true,
+			resampleFormat: pcm.Format{SampleRate: 44100, Channels: 2},
+		},
+		{
+			name:           "upmix after resampling",
+			input:          pcm.Format{SampleRate: 44100, Channels: 1},
+			output:         pcm.DefaultFormat(),
+			resample:       true,
+			converter:      true,
+			resampleFormat: pcm.Format{SampleRate: 44100, Channels: 1},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newConversion(tt.input, tt.output)
+			if err != nil {
+				t.Fatalf("conversion: %v", err)
+			}
+			if c.resample != tt.resample || c.channelsFirst != tt.channelsFirst || (c.converter != nil) != tt.converter {
+				t.Fatalf("got resample %t, channels first %t, converter %t", c.resample, c.channelsFirst, c.converter != nil)
+			}
+			if tt.resample && c.resampleFormat != tt.resampleFormat {
+				t.Fatalf("resampling %s, want %s", c.resampleFormat, tt.resampleFormat)
+			}
+		})
+	}
+}
+
+func TestNewConversionInvalid(t *testing.T) {
+	if _, err := newConversion(pcm.DefaultFormat(), pcm.Format{SampleRate: 48000, Channels: 9}); !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v for 2 to 9 channels, want %v", err, pcm.ErrInvalidFormat)
+	}
+	if _, err := newConversion(pcm.DefaultFormat(), pcm.Format{SampleRate: 48000, Channels: 2, SampleType: pcm.SampleTypeFloat32}); !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v for float32 samples, want %v", err, pcm.ErrInvalidFormat)
+	}
+}
+
+func TestValidateOpusFormat(t *testing.T) {
+	if err := validateOpusFormat(pcm.Format{SampleRate: 16000, Channels: 1}); err != nil {
+		t.Fatalf("16khz mono: %v", err)
+	}
+	if err := validateOpusFormat(pcm.Format{SampleRate: 44100, Channels: 2}); !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v for 44.1khz, want %v", err, pcm.ErrInvalidFormat)
+	}
+	if err := validateOpusFormat(pcm.Format{SampleRate: 48000, Channels: 6}); !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v for 6 channels, want %v", err, pcm.ErrInvalidFormat)
+	}
+}
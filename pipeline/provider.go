@@ -0,0 +1,188 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+	"github.com/disgoorg/audio/samplerate"
+	"github.com/disgoorg/disgo/voice"
+)
+
+// NewProviderBuilder creates a new *ProviderBuilder which starts with the given pcm.FrameProvider providing PCM frames in the given pcm.Format.
+//
+//	provider, err := pipeline.NewProviderBuilder(mp3Provider, pcm.Format{SampleRate: 44100, Channels: 2}).
+//		Volume(volumeFunc).
+//		Filter(pcm.NewPanFilter(-0.5)).
+//		Build(opus.MusicEncoderConfig())
+func NewProviderBuilder(source pcm.FrameProvider, format pcm.Format) *ProviderBuilder {
+	b := &ProviderBuilder{
+		provider:      source,
+		format:        format,
+		converterType: samplerate.ConverterTypeSincBestQuality,
+	}
+	if source == nil {
+		b.err = fmt.Errorf("source pcm.FrameProvider is nil")
+	} else if err := validateFormat(format); err != nil {
+		b.err = fmt.Errorf("invalid source format: %w", err)
+	}
+	return b
+}
+
+// ProviderBuilder builds a pcm.FrameProvider chain stage by stage. Each stage wraps the previous one.
+// The first error stops the builder and is returned by Build or BuildPCM, which then close the source and all stages. Stages added after an error are ignored.
+type ProviderBuilder struct {
+	provider      pcm.FrameProvider
+	format        pcm.Format
+	converterType samplerate.ConverterType
+	err           error
+}
+
+// Format returns the pcm.Format of the PCM frames after the last stage.
+func (b *ProviderBuilder) Format() pcm.Format {
+	return b.format
+}
+
+// ResamplerType sets the samplerate.ConverterType of the resamplers inserted by later stages. Defaults to samplerate.ConverterTypeSincBestQuality.
+func (b *ProviderBuilder) ResamplerType(converterType samplerate.ConverterType) *ProviderBuilder {
+	b.converterType = converterType
+	return b
+}
+
+// Stage adds a custom stage which wraps the pcm.FrameProvider and returns the pcm.Format of the wrapping pcm.FrameProvider.
+// A stage returning an error must not close the given pcm.FrameProvider, it is closed by Build or BuildPCM.
+func (b *ProviderBuilder) Stage(stage func(provider pcm.FrameProvider, format pcm.Format) (pcm.FrameProvider, pcm.Format, error)) *ProviderBuilder {
+	if b.err != nil {
+		return b
+	}
+	provider, format, err := stage(b.provider, b.format)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if err = validateFormat(format); err != nil {
+		b.err = fmt.Errorf("invalid stage format: %w", err)
+		return b
+	}
+	b.provider, b.format = provider, format
+	return b
+}
+
+// wrap adds a stage which doesn't change the pcm.Format.
+func (b *ProviderBuilder) wrap(stage func(provider pcm.FrameProvider) pcm.FrameProvider) *ProviderBuilder {
+	return b.Stage(func(provider pcm.FrameProvider, format pcm.Format) (pcm.FrameProvider, pcm.Format, error) {
+		return stage(provider), format, nil
+	})
+}
+
+// Convert converts the PCM frames into the given pcm.Format by inserting channel conversion and resampling stages as needed.
+func (b *ProviderBuilder) Convert(format pcm.Format) *ProviderBuilder {
+	return b.Stage(func(provider pcm.FrameProvider, input pcm.Format) (pcm.FrameProvider, pcm.Format, error) {
+		c, err := newConversion(input, format)
+		if err != nil {
+			return nil, pcm.Format{}, err
+		}
+		if c.converter != nil && c.channelsFirst {
+			provider = pcm.NewCustomPCMFrameChannelConverterProvider(provider, c.channelRate(), c.converter)
+		}
+		if c.resample {
+			resampler := samplerate.CreateResampler(b.converterType, c.resampleFormat.Channels)
+			provider = samplerate.NewPCMFrameResamplerProvider(resampler, input.SampleRate, format.SampleRate, c.resampleFormat.Channels, provider)
+		}
+		if c.converter != nil && !c.channelsFirst {
+			provider = pcm.NewCustomPCMFrameChannelConverterProvider(provider, c.channelRate(), c.converter)
+		}
+		return provider, format, nil
+	})
+}
+
+// Filter applies the given pcm.Filter(s) in order, see pcm.NewFilterFrameProvider.
+func (b *ProviderBuilder) Filter(filters ...pcm.Filter) *ProviderBuilder {
+	return b.wrap(func(provider pcm.FrameProvider) pcm.FrameProvider {
+		return pcm.NewFilterFrameProvider(provider, filters...)
+	})
+}
+
+// FilterFunc applies the pcm.Filter created for the current pcm.Format, for filters which need to know the sample rate or channels.
+func (b *ProviderBuilder) FilterFunc(filterFunc func(format pcm.Format) pcm.Filter) *ProviderBuilder {
+	return b.wrap(func(provider pcm.FrameProvider) pcm.FrameProvider {
+		return pcm.NewFilterFrameProvider(provider, filterFunc(b.format))
+	})
+}
+
+// Volume applies the volume returned by volumeFunc, see pcm.NewPCMVolumeFrameProvider.
+func (b *ProviderBuilder) Volume(volumeFunc func() float32) *ProviderBuilder {
+	return b.wrap(func(provider pcm.FrameProvider) pcm.FrameProvider {
+		return pcm.NewPCMVolumeFrameProvider(provider, volumeFunc)
+	})
+}
+
+// Pauseable provides no frames while pausedFunc returns true, see pcm.NewPauseablePCMFrameProvider.
+func (b *ProviderBuilder) Pauseable(pausedFunc func() bool) *ProviderBuilder {
+	return b.wrap(func(provider pcm.FrameProvider) pcm.FrameProvider {
+		return pcm.NewPauseablePCMFrameProvider(provider, pausedFunc)
+	})
+}
+
+// Buffer reads the PCM frames in a background goroutine which stops once ctx is done, see pcm.NewBufferPCMProviderContext.
+func (b *ProviderBuilder) Buffer(ctx context.Context) *ProviderBuilder {
+	return b.wrap(func(provider pcm.FrameProvider) pcm.FrameProvider {
+		return pcm.NewBufferPCMProviderContext(ctx, provider)
+	})
+}
+
+// ErrorPolicy handles the errors of the previous stages with the given pcm.ErrorPolicy, see pcm.NewErrorPolicyFrameProvider.
+func (b *ProviderBuilder) ErrorPolicy(policy pcm.ErrorPolicy) *ProviderBuilder {
+	return b.wrap(func(provider pcm.FrameProvider) pcm.FrameProvider {
+		return pcm.NewErrorPolicyFrameProvider(provider, policy)
+	})
+}
+
+// Meter measures the PCM frames with the given *pcm.Meter, which must be created for the current pcm.Format.
+func (b *ProviderBuilder) Meter(meter *pcm.Meter) *ProviderBuilder {
+	return b.Filter(meter)
+}
+
+// BuildPCM returns the pcm.FrameProvider and its pcm.Format or the first error of the builder.
+func (b *ProviderBuilder) BuildPCM() (pcm.FrameProvider, pcm.Format, error) {
+	if b.err != nil {
+		b.shutdown()
+		return nil, pcm.Format{}, b.err
+	}
+	return b.provider, b.format, nil
+}
+
+// shutdown closes the source and all stages built so far.
+func (b *ProviderBuilder) shutdown() {
+	if b.provider != nil {
+		_ = pcm.Shutdown(context.Background(), b.provider)
+		b.provider = nil
+	}
+}
+
+// Build converts the PCM frames into the pcm.Format of the opus.EncoderConfig and returns a voice.OpusFrameProvider encoding them, see pcm.NewConfiguredOpusProvider.
+func (b *ProviderBuilder) Build(config opus.EncoderConfig, opts ...pcm.OpusProviderConfigOpt) (voice.OpusFrameProvider, error) {
+	format := pcm.Format{
+		SampleRate: config.SampleRate,
+		Channels:   config.Channels,
+		SampleType: pcm.SampleTypeInt16,
+	}
+	if err := validateOpusFormat(format); err != nil {
+		b.shutdown()
+		return nil, fmt.Errorf("invalid encoder format: %w", err)
+	}
+	if b.format != format {
+		b.Convert(format)
+	}
+	provider, _, err := b.BuildPCM()
+	if err != nil {
+		return nil, err
+	}
+	opusProvider, err := pcm.NewConfiguredOpusProvider(config, provider, opts...)
+	if err != nil {
+		b.shutdown()
+		return nil, err
+	}
+	return opusProvider, nil
+}
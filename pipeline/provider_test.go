@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+)
+
+type testProvider struct {
+	frame  []int16
+	closed int
+}
+
+func (p *testProvider) ProvidePCMFrame() ([]int16, error) {
+	return p.frame, nil
+}
+
+func (p *testProvider) Close() {
+	p.closed++
+}
+
+func TestProviderBuilderStagesInOrder(t *testing.T) {
+	source := &testProvider{frame: make([]int16, 1920)}
+	var order []string
+	provider, format, err := NewProviderBuilder(source, pcm.DefaultFormat()).
+		Filter(pcm.FilterFunc(func([]int16) { order = append(order, "first") })).
+		FilterFunc(func(format pcm.Format) pcm.Filter {
+			if format != pcm.DefaultFormat() {
+				t.Errorf("filter created for %s, want %s", format, pcm.DefaultFormat())
+			}
+			return pcm.FilterFunc(func([]int16) { order = append(order, "second") })
+		}).
+		BuildPCM()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if format != pcm.DefaultFormat() {
+		t.Fatalf("format is %s, want %s", format, pcm.DefaultFormat())
+	}
+
+	if _, err = provider.ProvidePCMFrame(); err != nil {
+		t.Fatalf("provide: %v", err)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("filters ran in order %v, want [first second]", order)
+	}
+
+	provider.Close()
+	if source.closed != 1 {
+		t.Fatalf("source closed %d times, want 1", source.closed)
+	}
+}
+
+func TestProviderBuilderConvertChannels(t *testing.T) {
+	mono := pcm.Format{SampleRate: 48000, Channels: 1}
+	frame := make([]int16, 960)
+	for i := range frame {
+		frame[i] = int16(i)
+	}
+	provider, format, err := NewProviderBuilder(&testProvider{frame: frame}, mono).
+		Convert(pcm.DefaultFormat()).
+		BuildPCM()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer provider.Close()
+	if format != pcm.DefaultFormat() {
+		t.Fatalf("format is %s, want %s", format, pcm.DefaultFormat())
+	}
+
+	stereo, err := provider.ProvidePCMFrame()
+	if err != nil {
+		t.Fatalf("provide: %v", err)
+	}
+	if len(stereo) != 1920 {
+		t.Fatalf("frame has %d samples, want 1920", len(stereo))
+	}
+	for i, sample := range frame {
+		if stereo[i*2] != sample || stereo[i*2+1] != sample {
+			t.Fatalf("sample %d is %d/%d, want %d", i, stereo[i*2], stereo[i*2+1], sample)
+		}
+	}
+}
+
+func TestProviderBuilderClosesSourceOnError(t *testing.T) {
+	stageErr := errors.New("stage failed")
+	for name, build := range map[string]func(b *ProviderBuilder) error{
+		"stage": func(b *ProviderBuilder) error {
+			_, _, err := b.Stage(func(provider pcm.FrameProvider, format pcm.Format) (pcm.FrameProvider, pcm.Format, error) {
+				return nil, pcm.Format{}, stageErr
+			}).BuildPCM()
+			return err
+		},
+		"stage format": func(b *ProviderBuilder) error {
+			_, _, err := b.Stage(func(provider pcm.FrameProvider, format pcm.Format) (pcm.FrameProvider, pcm.Format, error) {
+				return provider, pcm.Format{SampleRate: 48000, Channels: 2, SampleType: pcm.SampleTypeFloat32}, nil
+			}).BuildPCM()
+			return err
+		},
+		"convert": func(b *ProviderBuilder) error {
+			_, _, err := b.Convert(pcm.Format{SampleRate: 48000, Channels: 9}).BuildPCM()
+			return err
+		},
+		"encoder format": func(b *ProviderBuilder) error {
+			config := opus.DefaultEncoderConfig()
+			config.Channels = 6
+			_, err := b.Build(config)
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			source := &testProvider{}
+			if err := build(NewProviderBuilder(source, pcm.DefaultFormat()).Volume(func() float32 { return 1 })); err == nil {
+				t.Fatal("build succeeded")
+			}
+			if source.closed != 1 {
+				t.Fatalf("source closed %d times, want 1", source.closed)
+			}
+		})
+	}
+}
+
+func TestProviderBuilderInvalidSource(t *testing.T) {
+	if _, _, err := NewProviderBuilder(nil, pcm.DefaultFormat()).BuildPCM(); err == nil {
+		t.Fatal("nil source accepted")
+	}
+
+	source := &testProvider{}
+	_, _, err := NewProviderBuilder(source, pcm.Format{SampleRate: 48000}).BuildPCM()
+	if !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v, want %v", err, pcm.ErrInvalidFormat)
+	}
+	if source.closed != 1 {
+		t.Fatalf("source closed %d times, want 1", source.closed)
+	}
+}
+
+func TestProviderBuilderBufferContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := &testProvider{frame: make([]int16, 1920)}
+	provider, _, err := NewProviderBuilder(source, pcm.DefaultFormat()).
+		Buffer(ctx).
+		BuildPCM()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if err = provider.(pcm.Shutdowner).Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if source.closed != 1 {
+		t.Fatalf("source closed %d times, want 1", source.closed)
+	}
+}
@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+	"github.com/disgoorg/audio/samplerate"
+	"github.com/disgoorg/disgo/voice"
+	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// NewReceiverBuilder creates a new *ReceiverBuilder which decodes the received Opus frames into PCM frames of the given pcm.Format.
+//
+//	receiver, err := pipeline.NewReceiverBuilder(pcm.DefaultFormat()).
+//		Meter(meters).
+//		Build(pcm.NewWriter(w, nil), pcm.Format{SampleRate: 16000, Channels: 1})
+func NewReceiverBuilder(format pcm.Format) *ReceiverBuilder {
+	b := &ReceiverBuilder{
+		decodeFormat:  format,
+		format:        format,
+		converterType: samplerate.ConverterTypeSincBestQuality,
+	}
+	if err := validateOpusFormat(format); err != nil {
+		b.err = fmt.Errorf("invalid decoder format: %w", err)
+	}
+	return b
+}
+
+// receiverStage wraps the next pcm.FrameReceiver.
+type receiverStage func(next pcm.FrameReceiver) pcm.FrameReceiver
+
+// ReceiverBuilder builds a voice.OpusFrameReceiver followed by a pcm.FrameReceiver chain stage by stage. PCM frames pass the stages in the order they are added.
+// The first error stops the builder and is returned by Build or BuildCombined. Stages added after an error are ignored.
+type ReceiverBuilder struct {
+	decodeFormat  pcm.Format
+	format        pcm.Format
+	converterType samplerate.ConverterType
+	userFilter    voice.UserFilterFunc
+	opusOpts      []pcm.OpusReceiverConfigOpt
+	stages        []receiverStage
+	err           error
+}
+
+// Format returns the pcm.Format of the PCM frames after the last stage.
+func (b *ReceiverBuilder) Format() pcm.Format {
+	return b.format
+}
+
+// ResamplerType sets the samplerate.ConverterType of the resamplers inserted by later stages. Defaults to samplerate.ConverterTypeSincBestQuality.
+func (b *ReceiverBuilder) ResamplerType(converterType samplerate.ConverterType) *ReceiverBuilder {
+	b.converterType = converterType
+	return b
+}
+
+// UserFilter only receives the users the voice.UserFilterFunc returns true for.
+func (b *ReceiverBuilder) UserFilter(userFilter voice.UserFilterFunc) *ReceiverBuilder {
+	b.userFilter = userFilter
+	return b
+}
+
+// OpusOptions sets the pcm.OpusReceiverConfigOpt(s) of the Opus decoding stage, like pcm.WithOpusReceiverMetrics or pcm.WithOpusReceiverErrorPolicy.
+func (b *ReceiverBuilder) OpusOptions(opts ...pcm.OpusReceiverConfigOpt) *ReceiverBuilder {
+	b.opusOpts = append(b.opusOpts, opts...)
+	return b
+}
+
+// Stage adds a custom stage which wraps the next pcm.FrameReceiver. The stage receives PCM frames in the current pcm.Format and passes them on in the given output pcm.Format.
+func (b *ReceiverBuilder) Stage(output pcm.Format, stage func(next pcm.FrameReceiver) pcm.FrameReceiver) *ReceiverBuilder {
+	if b.err != nil {
+		return b
+	}
+	if err := validateFormat(output); err != nil {
+		b.err = fmt.Errorf("invalid stage format: %w", err)
+		return b
+	}
+	b.stages = append(b.stages, stage)
+	b.format = output
+	return b
+}
+
+// wrap adds a stage which doesn't change the pcm.Format.
+func (b *ReceiverBuilder) wrap(stage func(next pcm.FrameReceiver) pcm.FrameReceiver) *ReceiverBuilder {
+	return b.Stage(b.format, stage)
+}
+
+// Convert converts the PCM frames into the given pcm.Format by inserting channel conversion and resampling stages as needed.
+// Each user gets its own resampler, as resamplers keep state between frames.
+func (b *ReceiverBuilder) Convert(format pcm.Format) *ReceiverBuilder {
+	if b.err != nil {
+		return b
+	}
+	c, err := newConversion(b.format, format)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	converterType := b.converterType
+	return b.Stage(format, func(next pcm.FrameReceiver) pcm.FrameReceiver {
+		if c.converter != nil && !c.channelsFirst {
+			next = pcm.NewCustomPCMFrameChannelConverterReceiver(next, c.channelRate(), c.converter)
+		}
+		if c.resample {
+			next = newUserResamplerReceiver(converterType, c.input.SampleRate, c.output.SampleRate, c.resampleFormat.Channels, next)
+		}
+		if c.converter != nil && c.channelsFirst {
+			next = pcm.NewCustomPCMFrameChannelConverterReceiver(next, c.channelRate(), c.converter)
+		}
+		return next
+	})
+}
+
+// Filter applies the pcm.Filter returned by filterFunc per user, see pcm.NewFilterReceiver.
+func (b *ReceiverBuilder) Filter(filterFunc func(userID snowflake.ID) pcm.Filter) *ReceiverBuilder {
+	return b.wrap(func(next pcm.FrameReceiver) pcm.FrameReceiver {
+		return pcm.NewFilterReceiver(next, filterFunc)
+	})
+}
+
+// Meter measures the PCM frames of each user with the given *pcm.Meters, which must be created for the current pcm.Format. See pcm.NewMeterReceiver.
+func (b *ReceiverBuilder) Meter(meters *pcm.Meters) *ReceiverBuilder {
+	return b.wrap(func(next pcm.FrameReceiver) pcm.FrameReceiver {
+		return pcm.NewMeterReceiver(next, meters)
+	})
+}
+
+// Build converts the PCM frames into the given pcm.Format of the sink and returns a voice.OpusFrameReceiver which decodes the Opus frames and passes them through all stages into the sink.
+func (b *ReceiverBuilder) Build(sink pcm.FrameReceiver, format pcm.Format) (voice.OpusFrameReceiver, error) {
+	if sink == nil {
+		return nil, fmt.Errorf("sink pcm.FrameReceiver is nil")
+	}
+	if b.err == nil && b.format != format {
+		b.Convert(format)
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	receiver := sink
+	for i := len(b.stages) - 1; i >= 0; i-- {
+		receiver = b.stages[i](receiver)
+	}
+	decodeFormat := b.decodeFormat
+	decoderCreateFunc := func() (*opus.Decoder, error) {
+		return opus.NewDecoder(decodeFormat.SampleRate, decodeFormat.Channels)
+	}
+	return pcm.NewPCMOpusReceiver(decoderCreateFunc, receiver, b.userFilter, b.opusOpts...), nil
+}
+
+// BuildCombined builds a voice.OpusFrameReceiver like Build which combines the PCM frames of all users with pcm.NewPCMCombinerReceiver and passes them to the given pcm.CombinedFrameReceiver in the given pcm.Format.
+// The channels of the combined PCM frames are converted after combining. The sample rate is converted per user before combining.
+func (b *ReceiverBuilder) BuildCombined(sink pcm.CombinedFrameReceiver, format pcm.Format, logger log.Logger, opts ...pcm.CombinerConfigOpt) (voice.OpusFrameReceiver, error) {
+	if sink == nil {
+		return nil, fmt.Errorf("sink pcm.CombinedFrameReceiver is nil")
+	}
+	if err := validateFormat(format); err != nil {
+		return nil, fmt.Errorf("invalid sink format: %w", err)
+	}
+	if b.err == nil && b.format.SampleRate != format.SampleRate {
+		b.Convert(pcm.Format{SampleRate: format.SampleRate, Channels: b.format.Channels, SampleType: format.SampleType})
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.format.Channels != format.Channels {
+		converter, err := channelConverter(b.format.Channels, format.Channels)
+		if err != nil {
+			return nil, err
+		}
+		sink = pcm.NewCustomFrameChannelConverterCombinedReceiver(sink, format.SampleRate, converter)
+	}
+	return b.Build(pcm.NewPCMCombinerReceiver(logger, sink, opts...), b.format)
+}
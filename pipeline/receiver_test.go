@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/disgoorg/audio/pcm"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+type testReceiver struct {
+	packets  []*pcm.Packet
+	cleanups []snowflake.ID
+	closed   int
+}
+
+func (r *testReceiver) ReceivePCMFrame(_ snowflake.ID, packet *pcm.Packet) error {
+	r.packets = append(r.packets, packet)
+	return nil
+}
+
+func (r *testReceiver) CleanupUser(userID snowflake.ID) {
+	r.cleanups = append(r.cleanups, userID)
+}
+
+func (r *testReceiver) Close() {
+	r.closed++
+}
+
+type testCombinedReceiver struct {
+	closed int
+}
+
+func (r *testCombinedReceiver) ReceiveCombinedPCMFrame([]snowflake.ID, *pcm.CombinedPacket) error {
+	return nil
+}
+
+func (r *testCombinedReceiver) Close() {
+	r.closed++
+}
+
+func TestReceiverBuilderFormat(t *testing.T) {
+	mono := pcm.Format{SampleRate: 48000, Channels: 1}
+	b := NewReceiverBuilder(pcm.DefaultFormat()).Convert(mono)
+	if b.Format() != mono {
+		t.Fatalf("format is %s, want %s", b.Format(), mono)
+	}
+
+	sink := &testReceiver{}
+	receiver, err := b.Build(sink, mono)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	receiver.Close()
+	if sink.closed != 1 {
+		t.Fatalf("sink closed %d times, want 1", sink.closed)
+	}
+}
+
+func TestReceiverBuilderStages(t *testing.T) {
+	mono := pcm.Format{SampleRate: 48000, Channels: 1}
+	sink := &testReceiver{}
+	var stages []pcm.FrameReceiver
+	_, err := NewReceiverBuilder(pcm.DefaultFormat()).
+		Stage(pcm.DefaultFormat(), func(next pcm.FrameReceiver) pcm.FrameReceiver {
+			stages = append(stages, next)
+			return next
+		}).
+		Build(sink, mono)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(stages) != 1 || stages[0] == pcm.FrameReceiver(sink) {
+		t.Fatal("the stage doesn't pass frames through the automatic channel conversion")
+	}
+
+	// the channel conversion passes the frames on in the sink format
+	if err = stages[0].ReceivePCMFrame(1, &pcm.Packet{PCM: make([]int16, 1920)}); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if len(sink.packets) != 1 || len(sink.packets[0].PCM) != 960 {
+		t.Fatal("sink didn't receive a mono frame")
+	}
+	stages[0].CleanupUser(1)
+	if len(sink.cleanups) != 1 {
+		t.Fatal("cleanup wasn't passed to the sink")
+	}
+}
+
+func TestReceiverBuilderErrors(t *testing.T) {
+	if _, err := NewReceiverBuilder(pcm.Format{SampleRate: 44100, Channels: 2}).Build(&testReceiver{}, pcm.DefaultFormat()); !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v for a 44.1khz decoder, want %v", err, pcm.ErrInvalidFormat)
+	}
+	if _, err := NewReceiverBuilder(pcm.DefaultFormat()).Build(&testReceiver{}, pcm.Format{SampleRate: 48000, Channels: 9}); !errors.Is(err, pcm.ErrInvalidFormat) {
+		t.Fatalf("got %v for a 9 channel sink, want %v", err, pcm.ErrInvalidFormat)
+	}
+	if _, err := NewReceiverBuilder(pcm.DefaultFormat()).Build(nil, pcm.DefaultFormat()); err == nil {
+		t.Fatal("nil sink accepted")
+	}
+}
+
+func TestReceiverBuilderBuildCombined(t *testing.T) {
+	sink := &testCombinedReceiver{}
+	receiver, err := NewReceiverBuilder(pcm.DefaultFormat()).BuildCombined(sink, pcm.Format{SampleRate: 48000, Channels: 1}, nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	receiver.Close()
+	if sink.closed != 1 {
+		t.Fatalf("sink closed %d times, want 1", sink.closed)
+	}
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/disgoorg/audio/pcm"
+	"github.com/disgoorg/audio/samplerate"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// newUserResamplerReceiver creates a new pcm.FrameReceiver which resamples the PCM frames of each user with its own resampler, see samplerate.NewPCMFrameResamplerReceiver.
+func newUserResamplerReceiver(converterType samplerate.ConverterType, inputSampleRate int, outputSampleRate int, channels int, next pcm.FrameReceiver) pcm.FrameReceiver {
+	r := &userResamplerReceiver{
+		converterType:    converterType,
+		inputSampleRate:  inputSampleRate,
+		outputSampleRate: outputSampleRate,
+		channels:         channels,
+		next:             next,
+		receivers:        map[snowflake.ID]pcm.FrameReceiver{},
+	}
+	r.Lifecycle = pcm.NewLifecycle(nil, r.close)
+	return r
+}
+
+type userResamplerReceiver struct {
+	*pcm.Lifecycle
+	converterType    samplerate.ConverterType
+	inputSampleRate  int
+	outputSampleRate int
+	channels         int
+	next             pcm.FrameReceiver
+	receivers        map[snowflake.ID]pcm.FrameReceiver
+	mu               sync.Mutex
+}
+
+func (r *userResamplerReceiver) ReceivePCMFrame(userID snowflake.ID, packet *pcm.Packet) error {
+	r.mu.Lock()
+	receiver, ok := r.receivers[userID]
+	if !ok {
+		resampler := samplerate.CreateResampler(r.converterType, r.channels)
+		receiver = samplerate.NewPCMFrameResamplerReceiver(resampler, r.inputSampleRate, r.outputSampleRate, r.channels, sharedReceiver{r.next})
+		r.receivers[userID] = receiver
+	}
+	r.mu.Unlock()
+	return receiver.ReceivePCMFrame(userID, packet)
+}
+
+func (r *userResamplerReceiver) CleanupUser(userID snowflake.ID) {
+	r.mu.Lock()
+	receiver, ok := r.receivers[userID]
+	delete(r.receivers, userID)
+	r.mu.Unlock()
+	if ok {
+		receiver.Close()
+	}
+	r.next.CleanupUser(userID)
+}
+
+func (r *userResamplerReceiver) close() error {
+	r.mu.Lock()
+	for userID, receiver := range r.receivers {
+		receiver.Close()
+		delete(r.receivers, userID)
+	}
+	r.mu.Unlock()
+	return pcm.Shutdown(context.Background(), r.next)
+}
+
+// sharedReceiver passes the PCM frames to a pcm.FrameReceiver shared by all users, which is cleaned up and closed by the userResamplerReceiver itself.
+type sharedReceiver struct {
+	pcm.FrameReceiver
+}
+
+func (sharedReceiver) CleanupUser(snowflake.ID) {}
+
+func (sharedReceiver) Close() {}
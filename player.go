@@ -1,15 +1,19 @@
 package audio
 
 import (
+	"context"
 	"io"
 	"sync"
 
+	"github.com/disgoorg/audio/metrics"
 	"github.com/disgoorg/audio/pcm"
 	"github.com/disgoorg/disgo/voice"
 )
 
 type Player interface {
 	voice.OpusFrameProvider
+	// Shutdown closes the Player like Close and returns the first error which occurred while closing the pcm.FrameProvider chain.
+	pcm.Shutdowner
 
 	Volume() float32
 	SetVolume(volume float32)
@@ -17,23 +21,78 @@ type Player interface {
 	SetPaused(paused bool)
 }
 
+// BufferingPlayer is a Player which reports whether its current pcm.FrameProvider is buffering.
+// The Player(s) created by NewPlayer and NewConfiguredPlayer implement it.
+type BufferingPlayer interface {
+	Player
+
+	// Buffering returns whether the current pcm.BufferingFrameProvider is waiting for more data.
+	Buffering() bool
+}
+
+// MeteredPlayer is a Player which measures its output after the volume and filters.
+// The Player(s) created by NewPlayer and NewConfiguredPlayer implement it.
+type MeteredPlayer interface {
+	Player
+
+	// Meter returns the pcm.Meter which measures the output of the Player or nil if the Player wasn't created with WithMeter.
+	Meter() *pcm.Meter
+}
+
 func NewPlayer(providerFunc func() pcm.FrameProvider, listeners ...Listener) (Player, error) {
+	return NewConfiguredPlayer(providerFunc, WithListeners(listeners...))
+}
+
+// NewConfiguredPlayer creates a new Player configured by the given PlayerConfigOpt(s).
+func NewConfiguredPlayer(providerFunc func() pcm.FrameProvider, opts ...PlayerConfigOpt) (Player, error) {
+	config := DefaultPlayerConfig()
+	config.Apply(opts)
+	encoderConfig := config.encoderConfig()
+
 	player := &defaultPlayer{
-		listeners: listeners,
-		volume:    1,
-		paused:    false,
+		providerFunc: providerFunc,
+		listeners:    config.Listeners,
+		volume:       1,
+		paused:       false,
+		generation:   1,
+		underruns:    config.Metrics.Counter(metrics.Underruns),
+		errors:       config.Metrics.Counter(metrics.PlayerErrors),
+	}
+	player.Lifecycle = pcm.NewLifecycle(config.Context, player.close)
+
+	errorPolicy := config.errorPolicy()
+	onError := errorPolicy.OnError
+	errorPolicy.OnError = func(err error, class pcm.ErrorClass, action pcm.ErrorAction) {
+		if onError != nil {
+			onError(err, class, action)
+		}
+		// aborted errors are reported by ProvideOpusFrame
+		if action != pcm.ErrorActionAbort && action != pcm.ErrorActionRetry && class != pcm.ErrorClassExhausted {
+			player.errors.Add(1)
+			player.emit(func(l Listener) {
+				l.OnError(player, err)
+			})
+		}
 	}
+	sourceProvider := pcm.NewErrorPolicyFrameProvider(pcm.NewVariablePCMFrameProvider(providerFunc), errorPolicy)
 
-	pauseableProvider := pcm.NewPauseablePCMFrameProvider(pcm.NewVariablePCMFrameProvider(providerFunc), func() bool {
+	pauseableProvider := pcm.NewPauseablePCMFrameProvider(sourceProvider, func() bool {
 		return player.paused
 	})
 
-	volumeProvider := pcm.NewPCMVolumeFrameProvider(pauseableProvider, func() float32 {
+	outputProvider := pcm.NewPCMVolumeFrameProvider(pauseableProvider, func() float32 {
 		return player.volume
 	})
+	filters := config.Filters
+	if config.Meter {
+		// the meter comes last to count the samples clipped by the volume and filters
+		player.meter = pcm.NewMeter(encoderConfig.SampleRate, encoderConfig.Channels)
+		filters = append(filters[:len(filters):len(filters)], player.meter)
+	}
+	outputProvider = pcm.NewFilterFrameProvider(outputProvider, filters...)
 
 	var err error
-	if player.opusFrameProvider, err = pcm.NewOpusProvider(nil, volumeProvider); err != nil {
+	if player.opusFrameProvider, err = pcm.NewConfiguredOpusProvider(encoderConfig, outputProvider, pcm.WithOpusProviderMetrics(config.Metrics)); err != nil {
 		return nil, err
 	}
 
@@ -41,12 +100,23 @@ func NewPlayer(providerFunc func() pcm.FrameProvider, listeners ...Listener) (Pl
 }
 
 type defaultPlayer struct {
+	*pcm.Lifecycle
+	providerFunc      func() pcm.FrameProvider
 	opusFrameProvider voice.OpusFrameProvider
 	volume            float32
+	meter             *pcm.Meter
 	paused            bool
 	playing           bool
-	mu                sync.Mutex
+	// generation advances with every frame or error and OnEnd is emitted once per generation.
+	// A pcm.FrameProvider which ends right after the previous one without providing a frame or error shares its generation and emits no OnEnd.
+	generation      uint64
+	endedGeneration uint64
+	buffering       bool
+	title           string
+	mu              sync.Mutex
 
+	underruns metrics.Counter
+	errors    metrics.Counter
 	listeners []Listener
 }
 
@@ -62,6 +132,10 @@ func (p *defaultPlayer) SetVolume(volume float32) {
 	p.volume = volume
 }
 
+func (p *defaultPlayer) Meter() *pcm.Meter {
+	return p.meter
+}
+
 func (p *defaultPlayer) Paused() bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -87,17 +161,74 @@ func (p *defaultPlayer) SetPaused(paused bool) {
 	}
 }
 
+func (p *defaultPlayer) Buffering() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buffering
+}
+
+func (p *defaultPlayer) setBuffering(buffering bool) {
+	p.mu.Lock()
+	if p.buffering == buffering {
+		p.mu.Unlock()
+		return
+	}
+	p.buffering = buffering
+	p.mu.Unlock()
+	if buffering {
+		p.underruns.Add(1)
+	}
+	p.emit(func(l Listener) {
+		bufferingListener, ok := l.(BufferingListener)
+		if !ok {
+			return
+		}
+		if buffering {
+			bufferingListener.OnBufferingStart(p)
+		} else {
+			bufferingListener.OnBufferingEnd(p)
+		}
+	})
+}
+
 func (p *defaultPlayer) ProvideOpusFrame() ([]byte, error) {
-	frame, err := p.opusFrameProvider.ProvideOpusFrame()
+	if err := p.Context().Err(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	provider := p.providerFunc()
+	var (
+		frame []byte
+		err   error
+	)
+	if cachedProvider, ok := provider.(CachedFrameProvider); ok && cachedProvider.Cached() {
+		// cached frames are already encoded
+		if !p.Paused() {
+			frame, err = cachedProvider.ProvideOpusFrame()
+		}
+	} else {
+		frame, err = p.opusFrameProvider.ProvideOpusFrame()
+		if recorder, ok := provider.(opusFrameRecorder); ok {
+			frame, err = recorder.recordOpusFrame(frame, err)
+		}
+	}
 	if err == io.EOF {
-		p.playing = false
-		p.emit(func(l Listener) {
-			l.OnEnd(p)
-		})
+		// emit OnEnd once per generation, even if the pcm.FrameProvider ended before providing a frame
+		if p.endedGeneration != p.generation {
+			p.playing = false
+			p.endedGeneration = p.generation
+			p.emit(func(l Listener) {
+				l.OnEnd(p)
+			})
+		}
 	} else if err != nil {
+		p.generation++
+		p.errors.Add(1)
 		p.emit(func(l Listener) {
 			l.OnError(p, err)
 		})
+	} else if frame != nil {
+		p.generation++
 	}
 	if frame != nil && !p.playing {
 		p.playing = true
@@ -105,14 +236,32 @@ func (p *defaultPlayer) ProvideOpusFrame() ([]byte, error) {
 			l.OnStart(p)
 		})
 	}
+	if bufferingProvider, ok := provider.(pcm.BufferingFrameProvider); ok {
+		p.setBuffering(bufferingProvider.Buffering())
+	} else {
+		p.setBuffering(false)
+	}
+	if titleProvider, ok := provider.(pcm.TitleFrameProvider); ok {
+		if title := titleProvider.Title(); title != p.title {
+			p.title = title
+			p.emit(func(l Listener) {
+				if titleListener, ok := l.(TitleListener); ok {
+					titleListener.OnTitleChange(p, title)
+				}
+			})
+		}
+	} else {
+		p.title = ""
+	}
 	return frame, err
 }
 
-func (p *defaultPlayer) Close() {
-	p.opusFrameProvider.Close()
+func (p *defaultPlayer) close() error {
+	err := pcm.Shutdown(context.Background(), p.opusFrameProvider)
 	p.emit(func(l Listener) {
 		l.OnClose(p)
 	})
+	return err
 }
 
 func (p *defaultPlayer) emit(l func(l Listener)) {
@@ -129,3 +278,14 @@ type Listener interface {
 	OnError(player Player, err error)
 	OnClose(player Player)
 }
+
+// BufferingListener can be implemented by a Listener to get notified when the current pcm.BufferingFrameProvider starts or stops buffering.
+type BufferingListener interface {
+	OnBufferingStart(player Player)
+	OnBufferingEnd(player Player)
+}
+
+// TitleListener can be implemented by a Listener to get notified when the title of the current pcm.TitleFrameProvider changes, like the now playing title of an internet radio stream.
+type TitleListener interface {
+	OnTitleChange(player Player, title string)
+}
@@ -0,0 +1,151 @@
+package audio
+
+import (
+	"context"
+
+	"github.com/disgoorg/audio/metrics"
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// DefaultPlayerConfig returns a PlayerConfig with sensible defaults.
+func DefaultPlayerConfig() *PlayerConfig {
+	return &PlayerConfig{
+		Context:       context.Background(),
+		EncoderConfig: opus.DefaultEncoderConfig(),
+		ErrorPolicy:   pcm.DefaultErrorPolicy(),
+		Metrics:       metrics.Noop,
+	}
+}
+
+// PlayerConfig is used to configure a Player created by NewConfiguredPlayer.
+type PlayerConfig struct {
+	// Context closes the Player once it is done.
+	Context   context.Context
+	Listeners []Listener
+
+	// EncoderConfig is used to create the opus.Encoder of the Player.
+	EncoderConfig opus.EncoderConfig
+
+	// AdaptiveStatsFunc enables adapting the opus.Encoder with opus.DefaultAdaptiveConfig, limited to the bitrate of the EncoderConfig.
+	AdaptiveStatsFunc opus.NetworkStatsFunc
+	// AdaptiveConfig replaces the opus.AdaptiveConfig of the EncoderConfig and takes precedence over AdaptiveStatsFunc.
+	AdaptiveConfig *opus.AdaptiveConfig
+
+	// Filters are applied in order to the PCM frames after the volume.
+	Filters []pcm.Filter
+	// Meter enables the pcm.Meter returned by MeteredPlayer.Meter.
+	Meter bool
+
+	// ErrorPolicy handles the errors of the current pcm.FrameProvider. Handled errors are reported to Listener.OnError, aborted errors are returned.
+	ErrorPolicy pcm.ErrorPolicy
+	// SkipOnFatalError overrides ErrorPolicy.Fatal with pcm.ErrorActionEnd.
+	SkipOnFatalError bool
+
+	// Metrics records metrics.Underruns, metrics.PlayerErrors and the metrics of the Opus encoder.
+	Metrics metrics.Metrics
+}
+
+// PlayerConfigOpt is used to functionally configure a PlayerConfig.
+type PlayerConfigOpt func(config *PlayerConfig)
+
+// Apply applies the PlayerConfigOpt(s) to the PlayerConfig.
+func (c *PlayerConfig) Apply(opts []PlayerConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.Metrics = metrics.OrNoop(c.Metrics)
+}
+
+// encoderConfig returns the EncoderConfig with the adaptive settings merged in.
+func (c *PlayerConfig) encoderConfig() opus.EncoderConfig {
+	encoderConfig := c.EncoderConfig
+	if c.AdaptiveConfig != nil {
+		encoderConfig.Adaptive = c.AdaptiveConfig
+	} else if c.AdaptiveStatsFunc != nil {
+		encoderConfig.Adaptive = opus.DefaultAdaptiveConfig(c.AdaptiveStatsFunc, encoderConfig.Bitrate)
+	}
+	return encoderConfig
+}
+
+// errorPolicy returns the ErrorPolicy with SkipOnFatalError applied.
+func (c *PlayerConfig) errorPolicy() pcm.ErrorPolicy {
+	policy := c.ErrorPolicy
+	if c.SkipOnFatalError {
+		policy.Fatal = pcm.ErrorActionEnd
+	}
+	return policy
+}
+
+// WithListeners adds the given Listener(s) to the PlayerConfig.
+func WithListeners(listeners ...Listener) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.Listeners = append(config.Listeners, listeners...)
+	}
+}
+
+// WithEncoderConfig sets the opus.EncoderConfig used to create the opus.Encoder of the Player.
+// See opus.VoiceEncoderConfig, opus.MusicEncoderConfig and opus.HighQualityMusicEncoderConfig for presets.
+func WithEncoderConfig(encoderConfig opus.EncoderConfig) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.EncoderConfig = encoderConfig
+	}
+}
+
+// WithAdaptiveEncoding enables adjusting the bitrate, inband FEC and packet loss percentage of the opus.Encoder based on the opus.NetworkStats returned by the given opus.NetworkStatsFunc.
+// The bitrate never exceeds the bitrate of the opus.EncoderConfig, regardless of whether WithEncoderConfig is applied before or after.
+func WithAdaptiveEncoding(statsFunc opus.NetworkStatsFunc) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.AdaptiveStatsFunc = statsFunc
+	}
+}
+
+// WithAdaptiveConfig sets the opus.AdaptiveConfig used to adapt the opus.Encoder at runtime.
+func WithAdaptiveConfig(adaptiveConfig opus.AdaptiveConfig) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.AdaptiveConfig = &adaptiveConfig
+	}
+}
+
+// WithFilters adds the given pcm.Filter(s) to the output of the Player, for example a pcm.PanFilter or pcm.AutoPanFilter.
+func WithFilters(filters ...pcm.Filter) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.Filters = append(config.Filters, filters...)
+	}
+}
+
+// WithMeter enables measuring the output of the Player after the volume and filters with a pcm.Meter, see MeteredPlayer.
+func WithMeter() PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.Meter = true
+	}
+}
+
+// WithMetrics sets the metrics.Metrics of the Player, see metrics.NewExpvarMetrics for an expvar based implementation.
+func WithMetrics(m metrics.Metrics) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.Metrics = m
+	}
+}
+
+// WithContext sets the context.Context which closes the Player once it is done.
+func WithContext(ctx context.Context) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.Context = ctx
+	}
+}
+
+// WithErrorPolicy sets the pcm.ErrorPolicy which handles the errors of the current pcm.FrameProvider.
+func WithErrorPolicy(policy pcm.ErrorPolicy) PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.ErrorPolicy = policy
+	}
+}
+
+// WithSkipOnFatalError ends the current track on fatal errors of its pcm.FrameProvider, so Listener.OnEnd can skip to the next track.
+// It applies to the pcm.ErrorPolicy set by WithErrorPolicy, regardless of the order of both options.
+func WithSkipOnFatalError() PlayerConfigOpt {
+	return func(config *PlayerConfig) {
+		config.SkipOnFatalError = true
+	}
+}
@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/audio/pcm"
+)
+
+func TestAdaptiveEncodingIndependentOfOptionOrder(t *testing.T) {
+	statsFunc := func() opus.NetworkStats {
+		return opus.NetworkStats{}
+	}
+	encoderConfig := opus.MusicEncoderConfig()
+
+	for name, opts := range map[string][]PlayerConfigOpt{
+		"adaptive first": {WithAdaptiveEncoding(statsFunc), WithEncoderConfig(encoderConfig)},
+		"adaptive last":  {WithEncoderConfig(encoderConfig), WithAdaptiveEncoding(statsFunc)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultPlayerConfig()
+			config.Apply(opts)
+
+			adaptive := config.encoderConfig().Adaptive
+			if adaptive == nil {
+				t.Fatal("adaptive encoding is disabled")
+			}
+			if adaptive.MaxBitrate != encoderConfig.Bitrate {
+				t.Fatalf("max bitrate is %d, want %d", adaptive.MaxBitrate, encoderConfig.Bitrate)
+			}
+		})
+	}
+}
+
+func TestAdaptiveConfigTakesPrecedence(t *testing.T) {
+	adaptiveConfig := *opus.DefaultAdaptiveConfig(nil, 32000)
+	config := DefaultPlayerConfig()
+	config.Apply([]PlayerConfigOpt{
+		WithAdaptiveConfig(adaptiveConfig),
+		WithAdaptiveEncoding(func() opus.NetworkStats {
+			return opus.NetworkStats{}
+		}),
+		WithEncoderConfig(opus.MusicEncoderConfig()),
+	})
+
+	if adaptive := config.encoderConfig().Adaptive; adaptive == nil || adaptive.MaxBitrate != 32000 {
+		t.Fatalf("got %+v, want the AdaptiveConfig", adaptive)
+	}
+}
+
+func TestEncoderConfigAdaptiveKept(t *testing.T) {
+	encoderConfig := opus.MusicEncoderConfig()
+	encoderConfig.Adaptive = opus.DefaultAdaptiveConfig(nil, 48000)
+	config := DefaultPlayerConfig()
+	config.Apply([]PlayerConfigOpt{WithEncoderConfig(encoderConfig)})
+
+	if config.encoderConfig().Adaptive != encoderConfig.Adaptive {
+		t.Fatal("the AdaptiveConfig of the EncoderConfig was dropped")
+	}
+}
+
+func TestSkipOnFatalErrorIndependentOfOptionOrder(t *testing.T) {
+	policy := pcm.ErrorPolicy{
+		Transient: pcm.ErrorActionSkip,
+		Fatal:     pcm.ErrorActionAbort,
+	}
+
+	for name, opts := range map[string][]PlayerConfigOpt{
+		"skip first": {WithSkipOnFatalError(), WithErrorPolicy(policy)},
+		"skip last":  {WithErrorPolicy(policy), WithSkipOnFatalError()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultPlayerConfig()
+			config.Apply(opts)
+
+			got := config.errorPolicy()
+			if got.Fatal != pcm.ErrorActionEnd {
+				t.Fatalf("fatal action is %s, want %s", got.Fatal, pcm.ErrorActionEnd)
+			}
+			if got.Transient != pcm.ErrorActionSkip {
+				t.Fatalf("transient action is %s, want %s", got.Transient, pcm.ErrorActionSkip)
+			}
+		})
+	}
+}
+
+func TestMeterIsOptIn(t *testing.T) {
+	config := DefaultPlayerConfig()
+	config.Apply(nil)
+	if config.Meter {
+		t.Fatal("the meter is enabled by default")
+	}
+	config.Apply([]PlayerConfigOpt{WithMeter()})
+	if !config.Meter {
+		t.Fatal("WithMeter didn't enable the meter")
+	}
+}
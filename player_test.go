@@ -0,0 +1,110 @@
+package audio
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/audio/pcm"
+)
+
+type silenceProvider struct {
+	mu     sync.Mutex
+	closed int
+}
+
+func (p *silenceProvider) ProvidePCMFrame() ([]int16, error) {
+	return make([]int16, 1920), nil
+}
+
+func (p *silenceProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed++
+}
+
+type closeListener struct {
+	Listener
+	mu     sync.Mutex
+	closed int
+}
+
+func (l *closeListener) OnStart(Player) {}
+
+func (l *closeListener) OnClose(Player) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed++
+}
+
+func TestPlayerContextCancelClosesPlayer(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	provider := &silenceProvider{}
+	listener := &closeListener{}
+	player, err := NewConfiguredPlayer(func() pcm.FrameProvider {
+		return provider
+	}, WithContext(ctx), WithListeners(listener))
+	if err != nil {
+		t.Fatalf("create player: %v", err)
+	}
+
+	if frame, err := player.ProvideOpusFrame(); err != nil || frame == nil {
+		t.Fatalf("got %v, %v before cancel, want a frame", frame, err)
+	}
+
+	cancel()
+	if _, err = player.ProvideOpusFrame(); err != context.Canceled {
+		t.Fatalf("got %v after cancel, want %v", err, context.Canceled)
+	}
+
+	// closing again is a no-op
+	if err = player.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	player.Close()
+
+	if provider.closed != 1 {
+		t.Fatalf("provider closed %d times, want 1", provider.closed)
+	}
+	if listener.closed != 1 {
+		t.Fatalf("OnClose emitted %d times, want 1", listener.closed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("leaked %d goroutine(s)", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPlayerConcurrentShutdown(t *testing.T) {
+	provider := &silenceProvider{}
+	player, err := NewPlayer(func() pcm.FrameProvider {
+		return provider
+	})
+	if err != nil {
+		t.Fatalf("create player: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := player.Shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if provider.closed != 1 {
+		t.Fatalf("provider closed %d times, want 1", provider.closed)
+	}
+}
@@ -1,6 +1,10 @@
 package samplerate
 
 import (
+	"context"
+	"io"
+	"sync"
+
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/audio/pcm"
 )
@@ -14,21 +18,27 @@ func NewPCMFrameResamplerProvider(resampler *Resampler, inputSampleRate int, out
 	if resampler == nil {
 		resampler = CreateResampler(ConverterTypeSincBestQuality, channels)
 	}
-	return &sampleRateProvider{
+	provider := &sampleRateProvider{
 		resampler:        resampler,
 		pcmFrameProvider: pcmFrameProvider,
 		inputSampleRate:  inputSampleRate,
 		outputSampleRate: outputSampleRate,
 		newPCM:           make([]int16, opus.GetOutputBuffSize(outputSampleRate, channels)),
 	}
+	provider.Lifecycle = pcm.NewLifecycle(nil, provider.close)
+	return provider
 }
 
 type sampleRateProvider struct {
+	*pcm.Lifecycle
 	resampler        *Resampler
 	pcmFrameProvider pcm.FrameProvider
 	inputSampleRate  int
 	outputSampleRate int
 	newPCM           []int16
+
+	mu     sync.Mutex
+	closed bool
 }
 
 func (p *sampleRateProvider) ProvidePCMFrame() ([]int16, error) {
@@ -37,6 +47,12 @@ func (p *sampleRateProvider) ProvidePCMFrame() ([]int16, error) {
 		return nil, err
 	}
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, io.EOF
+	}
+
 	var (
 		inputFrames  int64
 		outputFrames int64
@@ -48,7 +64,12 @@ func (p *sampleRateProvider) ProvidePCMFrame() ([]int16, error) {
 	return p.newPCM, nil
 }
 
-func (p *sampleRateProvider) Close() {
+// close closes the FrameProvider first to release a ProvidePCMFrame call waiting for it before the resampler is destroyed.
+func (p *sampleRateProvider) close() error {
+	err := pcm.Shutdown(context.Background(), p.pcmFrameProvider)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
 	p.resampler.Destroy()
-	p.pcmFrameProvider.Close()
+	return err
 }
@@ -1,6 +1,10 @@
 package samplerate
 
 import (
+	"context"
+	"io"
+	"sync"
+
 	"github.com/disgoorg/audio/opus"
 	"github.com/disgoorg/audio/pcm"
 	"github.com/disgoorg/snowflake/v2"
@@ -15,24 +19,35 @@ func NewPCMFrameResamplerReceiver(resampler *Resampler, inputSampleRate int, out
 	if resampler == nil {
 		resampler = CreateResampler(ConverterTypeSincBestQuality, channels)
 	}
-	return &sampleRateReceiver{
+	receiver := &sampleRateReceiver{
 		resampler:        resampler,
 		pcmFrameReceiver: pcmFrameReceiver,
 		inputSampleRate:  inputSampleRate,
 		outputSampleRate: outputSampleRate,
 		newPCM:           make([]int16, opus.GetOutputBuffSize(outputSampleRate, channels)),
 	}
+	receiver.Lifecycle = pcm.NewLifecycle(nil, receiver.close)
+	return receiver
 }
 
 type sampleRateReceiver struct {
+	*pcm.Lifecycle
 	resampler        *Resampler
 	pcmFrameReceiver pcm.FrameReceiver
 	inputSampleRate  int
 	outputSampleRate int
 	newPCM           []int16
+
+	mu     sync.Mutex
+	closed bool
 }
 
 func (p *sampleRateReceiver) ReceivePCMFrame(userID snowflake.ID, packet *pcm.Packet) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return io.ErrClosedPipe
+	}
 
 	var (
 		inputFrames  int64
@@ -50,7 +65,10 @@ func (p *sampleRateReceiver) CleanupUser(userID snowflake.ID) {
 	p.pcmFrameReceiver.CleanupUser(userID)
 }
 
-func (p *sampleRateReceiver) Close() {
+func (p *sampleRateReceiver) close() error {
+	p.mu.Lock()
+	p.closed = true
 	p.resampler.Destroy()
-	p.pcmFrameReceiver.Close()
+	p.mu.Unlock()
+	return pcm.Shutdown(context.Background(), p.pcmFrameReceiver)
 }
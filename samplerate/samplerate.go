@@ -37,7 +37,9 @@ type Resampler struct {
 
 func (r *Resampler) Process(in []int16, out []int16, inputSampleRate int, outputSampleRate int, endOfInput int, inputFrames *int64, outputFrames *int64) error {
 	inFloat := make([]float32, len(in))
-	Int16ToFloat32Slice(in, inFloat)
+	if len(in) > 0 {
+		Int16ToFloat32Slice(in, inFloat)
+	}
 
 	outFloat := make([]float32, cap(out))
 	if err := r.ProcessFloat(inFloat, outFloat, inputSampleRate, outputSampleRate, endOfInput, inputFrames, outputFrames); err != nil {
@@ -47,11 +49,17 @@ func (r *Resampler) Process(in []int16, out []int16, inputSampleRate int, output
 	return nil
 }
 
+// ProcessFloat resamples in into out. Pass an endOfInput of 1 with an empty in to flush the samples buffered inside the Resampler.
 func (r *Resampler) ProcessFloat(in []float32, out []float32, inputSampleRate int, outputSampleRate int, endOfInput int, inputFrames *int64, outputFrames *int64) error {
+	inputLen := len(in)
+	if cap(in) == 0 {
+		// libsamplerate needs a valid pointer even without input
+		in = make([]float32, 0, 1)
+	}
 	if err := C.bridge_src_process(r.resampler,
-		(*C.float)(&in[0]),
+		(*C.float)(&in[:1][0]),
 		(*C.float)(&out[0]),
-		C.long(len(in))/C.long(r.channels),
+		C.long(inputLen)/C.long(r.channels),
 		C.long(cap(out))/C.long(r.channels),
 		C.int(endOfInput),
 		C.float(float64(outputSampleRate)/float64(inputSampleRate)),
@@ -63,6 +71,14 @@ func (r *Resampler) ProcessFloat(in []float32, out []float32, inputSampleRate in
 	return nil
 }
 
+// Reset resets the internal state of the Resampler. Call it when the input is not continuous anymore, e.g. after seeking.
+func (r *Resampler) Reset() error {
+	if err := C.src_reset(r.resampler); err != 0 {
+		return Error(err)
+	}
+	return nil
+}
+
 func (r *Resampler) Channels() int {
 	return r.channels
 }
@@ -0,0 +1,220 @@
+package vorbis
+
+/*
+#cgo pkg-config: vorbisfile
+#include <errno.h>
+#include <stdint.h>
+#include <stdlib.h>
+#include <vorbis/vorbisfile.h>
+
+extern long goVorbisRead(void *buf, size_t size, void *handle);
+extern int goVorbisSeek(void *handle, ogg_int64_t offset, int whence);
+extern long goVorbisTell(void *handle);
+
+static size_t vorbis_read(void *ptr, size_t size, size_t nmemb, void *handle) {
+	if (size == 0) {
+		return 0;
+	}
+	long n = goVorbisRead(ptr, size * nmemb, handle);
+	if (n < 0) {
+		// vorbisfile reports a read error if 0 is returned with errno set
+		errno = EIO;
+		return 0;
+	}
+	return n / size;
+}
+
+static int vorbis_open_go_reader(OggVorbis_File *vf, uintptr_t handle, int seekable) {
+	ov_callbacks callbacks = {vorbis_read, NULL, NULL, NULL};
+	if (seekable) {
+		callbacks.seek_func = goVorbisSeek;
+		callbacks.tell_func = goVorbisTell;
+	}
+	errno = 0;
+	return ov_open_callbacks((void *)handle, vf, NULL, 0, callbacks);
+}
+
+static long vorbis_read_s16(OggVorbis_File *vf, char *buffer, int length, int *bitstream) {
+	errno = 0;
+	return ov_read(vf, buffer, length, 0, 2, 1, bitstream);
+}
+*/
+import "C"
+import (
+	"errors"
+	"io"
+	"runtime/cgo"
+	"strings"
+	"unsafe"
+)
+
+// ErrAlreadyOpen is returned when Open is called on a Decoder which already decodes a stream.
+var ErrAlreadyOpen = errors.New("vorbis: decoder is already open")
+
+// CreateDecoder creates a new Decoder. Call Open to start decoding a stream.
+func CreateDecoder() (*Decoder, error) {
+	file := (*C.OggVorbis_File)(C.calloc(1, C.sizeof_OggVorbis_File))
+	if file == nil {
+		return nil, ErrFault
+	}
+	return &Decoder{
+		file: file,
+	}, nil
+}
+
+// Decoder decodes an Ogg Vorbis stream using libvorbisfile.
+type Decoder struct {
+	file      *C.OggVorbis_File
+	handle    cgo.Handle
+	reader    io.Reader
+	bitstream C.int
+}
+
+// Open starts decoding the given io.Reader and reads the headers of the first logical bitstream.
+// If the io.Reader also implements io.Seeker, the Decoder supports seeking and chained streams are scanned upfront.
+func (d *Decoder) Open(r io.Reader) error {
+	if d.handle != 0 {
+		return ErrAlreadyOpen
+	}
+	_, seekable := r.(io.Seeker)
+	d.reader = r
+	d.handle = cgo.NewHandle(d)
+
+	var cSeekable C.int
+	if seekable {
+		cSeekable = 1
+	}
+	if err := C.vorbis_open_go_reader(d.file, C.uintptr_t(d.handle), cSeekable); err != 0 {
+		// ov_open_callbacks does not need ov_clear on failure
+		d.handle.Delete()
+		d.handle = 0
+		return Error(err)
+	}
+	return nil
+}
+
+// Read reads interleaved signed 16 bit little endian PCM. It returns io.EOF at the end of the stream.
+// ErrHole is returned if data is missing, decoding can continue afterwards.
+// The format may change when a chained stream starts a new logical bitstream, check Info after each Read.
+func (d *Decoder) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n := C.vorbis_read_s16(d.file, (*C.char)(unsafe.Pointer(&p[0])), C.int(len(p)), &d.bitstream)
+	if n == 0 {
+		return 0, io.EOF
+	}
+	if n < 0 {
+		return 0, Error(n)
+	}
+	return int(n), nil
+}
+
+// Info returns the rate and channel count of the current logical bitstream.
+func (d *Decoder) Info() (int, int) {
+	info := C.ov_info(d.file, -1)
+	if info == nil {
+		return 0, 0
+	}
+	return int(info.rate), int(info.channels)
+}
+
+// Bitrate returns the nominal bitrate of the current logical bitstream in bits per second.
+func (d *Decoder) Bitrate() int {
+	info := C.ov_info(d.file, -1)
+	if info == nil {
+		return 0
+	}
+	return int(info.bitrate_nominal)
+}
+
+// Comment returns the Vorbis comments of the current logical bitstream.
+func (d *Decoder) Comment() Comment {
+	comment := C.ov_comment(d.file, -1)
+	if comment == nil {
+		return Comment{}
+	}
+	c := Comment{
+		Vendor: C.GoString(comment.vendor),
+	}
+	if comment.comments == 0 {
+		return c
+	}
+	entries := unsafe.Slice(comment.user_comments, int(comment.comments))
+	lengths := unsafe.Slice(comment.comment_lengths, int(comment.comments))
+	c.Comments = make([]string, len(entries))
+	for i := range entries {
+		c.Comments[i] = C.GoStringN(entries[i], lengths[i])
+	}
+	return c
+}
+
+// Seekable returns whether the Decoder supports seeking.
+func (d *Decoder) Seekable() bool {
+	return C.ov_seekable(d.file) != 0
+}
+
+// Length returns the total number of samples per channel of all logical bitstreams.
+func (d *Decoder) Length() (int64, error) {
+	length := C.ov_pcm_total(d.file, -1)
+	if length < 0 {
+		return 0, Error(length)
+	}
+	return int64(length), nil
+}
+
+// SeekSample seeks to the given sample per channel.
+func (d *Decoder) SeekSample(sample int64) error {
+	if err := C.ov_pcm_seek(d.file, C.ogg_int64_t(sample)); err != 0 {
+		return Error(err)
+	}
+	return nil
+}
+
+// TellSample returns the current sample per channel.
+func (d *Decoder) TellSample() int64 {
+	return int64(C.ov_pcm_tell(d.file))
+}
+
+// Close frees the Decoder.
+func (d *Decoder) Close() error {
+	if d.file == nil {
+		return nil
+	}
+	if d.handle != 0 {
+		C.ov_clear(d.file)
+		d.handle.Delete()
+		d.handle = 0
+	}
+	C.free(unsafe.Pointer(d.file))
+	d.file = nil
+	return nil
+}
+
+// Comment holds the Vorbis comments of a stream.
+type Comment struct {
+	Vendor string
+	// Comments are the raw "FIELD=value" entries.
+	Comments []string
+}
+
+// Get returns the first value of the given field. Field names are case-insensitive.
+func (c Comment) Get(field string) string {
+	values := c.GetAll(field)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// GetAll returns all values of the given field. Field names are case-insensitive.
+func (c Comment) GetAll(field string) []string {
+	var values []string
+	for _, comment := range c.Comments {
+		name, value, ok := strings.Cut(comment, "=")
+		if ok && strings.EqualFold(name, field) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,47 @@
+package vorbis
+
+import (
+	"testing"
+)
+
+func TestComment(t *testing.T) {
+	comment := Comment{
+		Vendor: "Xiph.Org libVorbis I 20200704",
+		Comments: []string{
+			"TITLE=Title",
+			"artist=First",
+			"Artist=Second",
+			"DESCRIPTION=a=b",
+			"INVALID",
+		},
+	}
+
+	for _, tt := range []struct {
+		field string
+		want  []string
+	}{
+		{field: "title", want: []string{"Title"}},
+		{field: "ARTIST", want: []string{"First", "Second"}},
+		{field: "DESCRIPTION", want: []string{"a=b"}},
+		{field: "INVALID"},
+		{field: "ALBUM"},
+	} {
+		got := comment.GetAll(tt.field)
+		if len(got) != len(tt.want) {
+			t.Fatalf("got %q for %s, want %q", got, tt.field, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("got %q for %s, want %q", got, tt.field, tt.want)
+			}
+		}
+
+		var first string
+		if len(tt.want) > 0 {
+			first = tt.want[0]
+		}
+		if got := comment.Get(tt.field); got != first {
+			t.Fatalf("got %q for %s, want %q", got, tt.field, first)
+		}
+	}
+}
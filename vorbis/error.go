@@ -0,0 +1,55 @@
+package vorbis
+
+/*
+#cgo pkg-config: vorbisfile
+#include <vorbis/vorbisfile.h>
+*/
+import "C"
+import "fmt"
+
+var _ error = Error(0)
+
+// Error is an error code returned by libvorbisfile.
+type Error int
+
+func (e Error) Error() string {
+	if message, ok := errorMessages[e]; ok {
+		return fmt.Sprintf("vorbis: %s", message)
+	}
+	return fmt.Sprintf("vorbis: unknown error %d", int(e))
+}
+
+const (
+	ErrFalse     Error = C.OV_FALSE
+	ErrEOF       Error = C.OV_EOF
+	ErrHole      Error = C.OV_HOLE
+	ErrRead      Error = C.OV_EREAD
+	ErrFault     Error = C.OV_EFAULT
+	ErrImpl      Error = C.OV_EIMPL
+	ErrInval     Error = C.OV_EINVAL
+	ErrNotVorbis Error = C.OV_ENOTVORBIS
+	ErrBadHeader Error = C.OV_EBADHEADER
+	ErrVersion   Error = C.OV_EVERSION
+	ErrNotAudio  Error = C.OV_ENOTAUDIO
+	ErrBadPacket Error = C.OV_EBADPACKET
+	ErrBadLink   Error = C.OV_EBADLINK
+	ErrNoSeek    Error = C.OV_ENOSEEK
+)
+
+// libvorbisfile has no strerror equivalent
+var errorMessages = map[Error]string{
+	ErrFalse:     "not true or no data available",
+	ErrEOF:       "end of file",
+	ErrHole:      "interruption in the data",
+	ErrRead:      "read error",
+	ErrFault:     "internal logic fault",
+	ErrImpl:      "feature not implemented",
+	ErrInval:     "invalid argument",
+	ErrNotVorbis: "not vorbis data",
+	ErrBadHeader: "invalid vorbis header",
+	ErrVersion:   "vorbis version mismatch",
+	ErrNotAudio:  "packet is not audio",
+	ErrBadPacket: "invalid packet",
+	ErrBadLink:   "invalid stream section",
+	ErrNoSeek:    "stream is not seekable",
+}
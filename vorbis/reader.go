@@ -0,0 +1,53 @@
+package vorbis
+
+/*
+#cgo pkg-config: vorbisfile
+#include <vorbis/vorbisfile.h>
+*/
+import "C"
+import (
+	"io"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/disgoorg/audio/internal/readutil"
+)
+
+// goVorbisRead is the read callback passed to ov_open_callbacks. It returns 0 on EOF and -1 on error, including repeated empty reads.
+//
+//export goVorbisRead
+func goVorbisRead(buf unsafe.Pointer, size C.size_t, handle unsafe.Pointer) C.long {
+	r := cgo.Handle(uintptr(handle)).Value().(*Decoder).reader
+	p := unsafe.Slice((*byte)(buf), int(size))
+	n, err := readutil.ReadSome(r, p)
+	if n > 0 {
+		return C.long(n)
+	}
+	if err == io.EOF {
+		return 0
+	}
+	return -1
+}
+
+// goVorbisSeek is the seek callback passed to ov_open_callbacks. It is only used if the reader is an io.Seeker.
+//
+//export goVorbisSeek
+func goVorbisSeek(handle unsafe.Pointer, offset C.ogg_int64_t, whence C.int) C.int {
+	s := cgo.Handle(uintptr(handle)).Value().(*Decoder).reader.(io.Seeker)
+	if _, err := s.Seek(int64(offset), int(whence)); err != nil {
+		return -1
+	}
+	return 0
+}
+
+// goVorbisTell is the tell callback passed to ov_open_callbacks. It is only used if the reader is an io.Seeker.
+//
+//export goVorbisTell
+func goVorbisTell(handle unsafe.Pointer) C.long {
+	s := cgo.Handle(uintptr(handle)).Value().(*Decoder).reader.(io.Seeker)
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	return C.long(offset)
+}
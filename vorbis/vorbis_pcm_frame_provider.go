@@ -0,0 +1,105 @@
+package vorbis
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/disgoorg/audio/internal/pcmconv"
+	"github.com/disgoorg/audio/pcm"
+)
+
+// NewPCMFrameProvider returns a SeekableFrameProvider that reads Ogg Vorbis from the given io.Reader and converts it into pcm frames.
+// Seeking is only supported if the io.Reader also implements io.Seeker.
+func NewPCMFrameProvider(decoder *Decoder, r io.Reader) (pcm.SeekableFrameProvider, error) {
+	return NewCustomPCMFrameProvider(decoder, r, 48000, 2)
+}
+
+// NewCustomPCMFrameProvider returns a SeekableFrameProvider that reads Ogg Vorbis from the given io.Reader and converts it into pcm frames.
+// You can specify the rate and channels of the output PCM frames. The stream is resampled and channel converted as needed, even if a chained stream changes its format.
+func NewCustomPCMFrameProvider(decoder *Decoder, r io.Reader, rate int, channels int) (pcm.SeekableFrameProvider, error) {
+	if decoder == nil {
+		var err error
+		decoder, err = CreateDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create vorbis decoder: %w", err)
+		}
+	}
+
+	if err := decoder.Open(r); err != nil {
+		_ = decoder.Close()
+		return nil, fmt.Errorf("failed to open vorbis decoder: %w", err)
+	}
+
+	return &pcmFrameProvider{
+		decoder:     decoder,
+		converter:   pcmconv.NewConverter(rate, channels, nil),
+		bytePCMBuff: make([]byte, 4096),
+	}, nil
+}
+
+type pcmFrameProvider struct {
+	decoder     *Decoder
+	converter   *pcmconv.Converter
+	bytePCMBuff []byte
+}
+
+func (p *pcmFrameProvider) ProvidePCMFrame() ([]int16, error) {
+	for !p.converter.Ready() {
+		n, err := p.decoder.Read(p.bytePCMBuff)
+		if err == ErrHole {
+			// some data is missing, vorbisfile resyncs on the next read
+			continue
+		}
+		if err == io.EOF {
+			return p.converter.Flush()
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the format of the current logical bitstream can change in chained streams
+		rate, channels := p.decoder.Info()
+		if rate <= 0 || channels <= 0 {
+			return nil, fmt.Errorf("invalid vorbis format: %dhz %d channels", rate, channels)
+		}
+		if err = p.converter.ConvertBytes(p.bytePCMBuff[:n], rate, channels); err != nil {
+			return nil, err
+		}
+	}
+	return p.converter.Next(), nil
+}
+
+func (p *pcmFrameProvider) Seek(position time.Duration) error {
+	if !p.decoder.Seekable() {
+		return pcm.ErrNotSeekable
+	}
+	rate, _ := p.decoder.Info()
+	if err := p.decoder.SeekSample(int64(position.Seconds() * float64(rate))); err != nil {
+		return fmt.Errorf("failed to seek vorbis decoder: %w", err)
+	}
+	return p.converter.Reset()
+}
+
+func (p *pcmFrameProvider) Position() time.Duration {
+	rate, _ := p.decoder.Info()
+	if rate == 0 {
+		return 0
+	}
+	position := pcmconv.SamplesToDuration(p.decoder.TellSample(), rate)
+	// subtract what was decoded but not returned yet
+	return position - p.converter.Pending()
+}
+
+func (p *pcmFrameProvider) Duration() time.Duration {
+	rate, _ := p.decoder.Info()
+	length, err := p.decoder.Length()
+	if err != nil || rate == 0 {
+		return 0
+	}
+	return pcmconv.SamplesToDuration(length, rate)
+}
+
+func (p *pcmFrameProvider) Close() {
+	p.converter.Close()
+	_ = p.decoder.Close()
+}
@@ -0,0 +1,588 @@
+package webm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNotWebM is returned when the stream does not start with an EBML header of a webm or matroska document.
+	ErrNotWebM = errors.New("webm: not a webm or matroska stream")
+
+	// ErrNoAudioTrack is returned when the stream contains no audio track.
+	ErrNoAudioTrack = errors.New("webm: no audio track found")
+
+	// ErrNotSeekable is returned when seeking a Demuxer whose reader is not an io.Seeker.
+	ErrNotSeekable = errors.New("webm: reader is not seekable")
+)
+
+// maxElementSize limits the size of elements which are read into memory.
+const maxElementSize = 64 << 20
+
+// Packet is a frame of the selected Track.
+type Packet struct {
+	// Time is the presentation time of the Packet. Laced frames share the time of their block.
+	Time     time.Duration
+	Data     []byte
+	Keyframe bool
+}
+
+// cuePoint is a CuePoint of the Cues element.
+type cuePoint struct {
+	Time  time.Duration
+	Track int
+	// Position is the offset of the cluster relative to the segment data.
+	Position int64
+}
+
+// Open reads the headers of the given webm/mka stream until the Tracks element and selects the first Opus audio track.
+// If the io.Reader also implements io.Seeker, the Demuxer supports seeking using the Cues element.
+func Open(r io.Reader) (*Demuxer, error) {
+	d := &Demuxer{
+		src:           r,
+		r:             bufio.NewReader(r),
+		timecodeScale: time.Millisecond,
+		cuesPosition:  -1,
+	}
+	if err := d.readHeader(); err != nil {
+		return nil, err
+	}
+
+	for d.tracks == nil {
+		packet, err := d.next()
+		if err == io.EOF {
+			return nil, ErrNoAudioTrack
+		}
+		if err != nil {
+			return nil, err
+		}
+		if packet {
+			return nil, fmt.Errorf("webm: block found before tracks")
+		}
+	}
+	d.dataOffset = d.offset
+
+	for _, track := range d.tracks {
+		if track.Type == TrackTypeAudio && track.CodecID == CodecOpus {
+			d.track = track
+			break
+		}
+	}
+	if d.track == nil {
+		for _, track := range d.tracks {
+			if track.Type == TrackTypeAudio {
+				d.track = track
+				break
+			}
+		}
+	}
+	if d.track == nil {
+		return nil, ErrNoAudioTrack
+	}
+	return d, nil
+}
+
+// Demuxer reads the packets of a single track of a webm/mka stream.
+type Demuxer struct {
+	src io.Reader
+	r   *bufio.Reader
+	// offset is the offset of r in the stream
+	offset int64
+	// segmentOffset is the offset of the segment data, which positions in SeekHead and Cues are relative to
+	segmentOffset int64
+	// dataOffset is the offset after the Tracks element, where Open stopped reading
+	dataOffset int64
+
+	docType       string
+	timecodeScale time.Duration
+	metadata      Metadata
+	tracks        []*Track
+	track         *Track
+	cues          []cuePoint
+	cuesPosition  int64
+
+	clusterTime int64
+	packet      Packet
+	// laced holds the remaining frames of a laced block
+	laced [][]byte
+	// unread is set when the current packet should be returned again by ReadPacket
+	unread bool
+	buff   []byte
+}
+
+// DocType returns the document type, either "webm" or "matroska".
+func (d *Demuxer) DocType() string {
+	return d.docType
+}
+
+// Tracks returns all tracks of the stream.
+func (d *Demuxer) Tracks() []*Track {
+	return d.tracks
+}
+
+// Track returns the selected Track.
+func (d *Demuxer) Track() *Track {
+	return d.track
+}
+
+// SelectTrack selects the track to read packets from.
+func (d *Demuxer) SelectTrack(track *Track) {
+	d.track = track
+	d.laced = nil
+	d.unread = false
+}
+
+// Metadata returns the title, duration and tags read so far. Tags stored after the clusters are only available once they were read.
+func (d *Demuxer) Metadata() Metadata {
+	return d.metadata
+}
+
+// Duration returns the duration of the segment or 0 if unknown.
+func (d *Demuxer) Duration() time.Duration {
+	return d.metadata.Duration
+}
+
+// ReadPacket reads the next Packet of the selected Track. Laced blocks are returned frame by frame. It returns io.EOF at the end of the stream.
+// The returned Packet is only valid until the next call to ReadPacket.
+func (d *Demuxer) ReadPacket() (*Packet, error) {
+	if d.unread {
+		d.unread = false
+		return &d.packet, nil
+	}
+	if len(d.laced) > 0 {
+		d.packet.Data = d.laced[0]
+		d.laced = d.laced[1:]
+		return &d.packet, nil
+	}
+	for {
+		packet, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if packet {
+			return &d.packet, nil
+		}
+	}
+}
+
+// Seek seeks to the first packet of the selected Track at or after the given position.
+// It jumps to the closest cluster listed in the Cues element and reads forward from there.
+// Streams without Cues are read from the first cluster.
+func (d *Demuxer) Seek(position time.Duration) error {
+	if _, ok := d.src.(io.Seeker); !ok {
+		return ErrNotSeekable
+	}
+	if d.cues == nil && d.cuesPosition >= 0 {
+		if err := d.readCues(); err != nil {
+			return fmt.Errorf("failed to read cues: %w", err)
+		}
+	}
+
+	offset := d.dataOffset
+	if i := d.findCue(position); i >= 0 {
+		offset = d.segmentOffset + d.cues[i].Position
+	}
+	if err := d.jump(offset); err != nil {
+		return err
+	}
+
+	for {
+		packet, err := d.ReadPacket()
+		if err != nil {
+			return err
+		}
+		if packet.Time >= position {
+			d.unread = true
+			return nil
+		}
+	}
+}
+
+// findCue returns the index of the last cue point of the selected Track at or before the given position or -1 if there is none.
+func (d *Demuxer) findCue(position time.Duration) int {
+	i := sort.Search(len(d.cues), func(i int) bool {
+		return d.cues[i].Time > position
+	})
+	for i--; i >= 0; i-- {
+		if d.cues[i].Track == 0 || d.cues[i].Track == d.track.Number {
+			return i
+		}
+	}
+	return -1
+}
+
+// jump moves the reader to the given offset, which has to be the start of an element.
+func (d *Demuxer) jump(offset int64) error {
+	if _, err := d.src.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	d.r.Reset(d.src)
+	d.offset = offset
+	d.laced = nil
+	d.unread = false
+	return nil
+}
+
+// readCues reads the Cues element located by the SeekHead.
+func (d *Demuxer) readCues() error {
+	if err := d.jump(d.segmentOffset + d.cuesPosition); err != nil {
+		return err
+	}
+	id, size, err := d.readElementHeader()
+	if err != nil {
+		return err
+	}
+	if id != idCues || size == unknownSize {
+		// the SeekHead is broken, fall back to reading from the start
+		d.cuesPosition = -1
+		return nil
+	}
+	body, err := d.readBody(size)
+	if err != nil {
+		return err
+	}
+	return d.parseCues(body)
+}
+
+func (d *Demuxer) readHeader() error {
+	id, size, err := d.readElementHeader()
+	if err != nil {
+		return err
+	}
+	if id != idEBML || size == unknownSize {
+		return ErrNotWebM
+	}
+	body, err := d.readBody(size)
+	if err != nil {
+		return err
+	}
+	if err = walkElements(body, func(id uint32, body []byte) error {
+		if id == idDocType {
+			d.docType = readString(body)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if d.docType != "webm" && d.docType != "matroska" {
+		return ErrNotWebM
+	}
+	return nil
+}
+
+// next reads the next element and returns whether it was a block of the selected Track.
+// Master elements containing blocks are entered, all other elements are either parsed or skipped.
+func (d *Demuxer) next() (bool, error) {
+	id, size, err := d.readElementHeader()
+	if err != nil {
+		return false, err
+	}
+
+	switch id {
+	case idSegment:
+		d.segmentOffset = d.offset
+		return false, nil
+	case idCluster, idBlockGroup:
+		return false, nil
+	}
+	if size == unknownSize {
+		return false, ErrInvalidElement
+	}
+
+	switch id {
+	case idTimecode, idInfo, idTracks, idTags, idSeekHead, idCues, idSimpleBlock, idBlock:
+	default:
+		return false, d.skip(size)
+	}
+
+	body, err := d.readBody(size)
+	if err != nil {
+		return false, err
+	}
+	switch id {
+	case idTimecode:
+		d.clusterTime = int64(readUint(body))
+	case idInfo:
+		return false, d.parseInfo(body)
+	case idTracks:
+		return false, d.parseTracks(body)
+	case idTags:
+		return false, d.metadata.parseTags(body)
+	case idSeekHead:
+		return false, d.parseSeekHead(body)
+	case idCues:
+		return false, d.parseCues(body)
+	case idSimpleBlock, idBlock:
+		return d.parseBlock(body, id == idSimpleBlock)
+	}
+	return false, nil
+}
+
+func (d *Demuxer) parseInfo(body []byte) error {
+	var duration float64
+	err := walkElements(body, func(id uint32, body []byte) error {
+		switch id {
+		case idTimecodeScale:
+			d.timecodeScale = time.Duration(readUint(body))
+		case idDuration:
+			duration = readFloat(body)
+		case idTitle:
+			d.metadata.Title = readString(body)
+		}
+		return nil
+	})
+	// the duration is stored in timecode scale units
+	d.metadata.Duration = time.Duration(duration * float64(d.timecodeScale))
+	return err
+}
+
+func (d *Demuxer) parseTracks(body []byte) error {
+	var tracks []*Track
+	err := walkElements(body, func(id uint32, body []byte) error {
+		if id != idTrackEntry {
+			return nil
+		}
+		track, err := parseTrackEntry(body)
+		if err != nil {
+			return err
+		}
+		tracks = append(tracks, track)
+		return nil
+	})
+	d.tracks = tracks
+	return err
+}
+
+func (d *Demuxer) parseSeekHead(body []byte) error {
+	return walkElements(body, func(id uint32, body []byte) error {
+		if id != idSeek {
+			return nil
+		}
+		var (
+			seekID   uint32
+			position int64 = -1
+		)
+		if err := walkElements(body, func(id uint32, body []byte) error {
+			switch id {
+			case idSeekID:
+				seekID = uint32(readUint(body))
+			case idSeekPosition:
+				position = int64(readUint(body))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if seekID == idCues {
+			d.cuesPosition = position
+		}
+		return nil
+	})
+}
+
+func (d *Demuxer) parseCues(body []byte) error {
+	var cues []cuePoint
+	err := walkElements(body, func(id uint32, body []byte) error {
+		if id != idCuePoint {
+			return nil
+		}
+		var (
+			cueTime   time.Duration
+			positions []cuePoint
+		)
+		err := walkElements(body, func(id uint32, body []byte) error {
+			switch id {
+			case idCueTime:
+				cueTime = time.Duration(readUint(body)) * d.timecodeScale
+			case idCueTrackPositions:
+				var position cuePoint
+				if err := walkElements(body, func(id uint32, body []byte) error {
+					switch id {
+					case idCueTrack:
+						position.Track = int(readUint(body))
+					case idCueClusterPosition:
+						position.Position = int64(readUint(body))
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+				positions = append(positions, position)
+			}
+			return nil
+		})
+		for _, position := range positions {
+			position.Time = cueTime
+			cues = append(cues, position)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(cues, func(i, j int) bool {
+		return cues[i].Time < cues[j].Time
+	})
+	d.cues = cues
+	return nil
+}
+
+func (d *Demuxer) parseBlock(body []byte, simple bool) (bool, error) {
+	trackNumber, length, _ := parseVint(body)
+	if length == 0 || len(body) < length+3 {
+		return false, ErrInvalidElement
+	}
+	if d.track == nil || int(trackNumber) != d.track.Number {
+		return false, nil
+	}
+	timecode := int64(int16(uint16(body[length])<<8 | uint16(body[length+1])))
+	flags := body[length+2]
+
+	frames, err := parseLacing(body[length+3:], flags>>1&0x03, d.laced[:0])
+	if err != nil {
+		return false, err
+	}
+	d.packet = Packet{
+		Time: time.Duration(d.clusterTime+timecode) * d.timecodeScale,
+		Data: frames[0],
+		// blocks in block groups carry their keyframe state in ReferenceBlock elements, audio frames are always keyframes
+		Keyframe: !simple || flags&0x80 != 0,
+	}
+	d.laced = frames[1:]
+	return true, nil
+}
+
+// lacing types of the block flags
+const (
+	lacingNone  = 0
+	lacingXiph  = 1
+	lacingFixed = 2
+	lacingEBML  = 3
+)
+
+// parseLacing splits the data of a block into its frames.
+func parseLacing(data []byte, lacing byte, frames [][]byte) ([][]byte, error) {
+	if lacing == lacingNone {
+		return append(frames, data), nil
+	}
+	if len(data) == 0 {
+		return nil, ErrInvalidElement
+	}
+	count := int(data[0]) + 1
+	data = data[1:]
+
+	sizes := make([]int, count-1)
+	switch lacing {
+	case lacingXiph:
+		for i := range sizes {
+			for {
+				if len(data) == 0 {
+					return nil, ErrInvalidElement
+				}
+				b := data[0]
+				data = data[1:]
+				sizes[i] += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+	case lacingFixed:
+		if len(data)%count != 0 {
+			return nil, ErrInvalidElement
+		}
+		for i := range sizes {
+			sizes[i] = len(data) / count
+		}
+	case lacingEBML:
+		for i := range sizes {
+			value, n, _ := parseVint(data)
+			if n == 0 {
+				return nil, ErrInvalidElement
+			}
+			data = data[n:]
+			if i == 0 {
+				sizes[i] = int(value)
+				continue
+			}
+			// following sizes are stored as signed differences to the previous size
+			diff := int64(value) - (int64(1)<<(7*n-1) - 1)
+			sizes[i] = sizes[i-1] + int(diff)
+		}
+	}
+
+	for _, size := range sizes {
+		if size < 0 || size > len(data) {
+			return nil, ErrInvalidElement
+		}
+		frames = append(frames, data[:size])
+		data = data[size:]
+	}
+	return append(frames, data), nil
+}
+
+func (d *Demuxer) readElementHeader() (uint32, int64, error) {
+	first, err := d.r.Peek(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	idLength := vintLength(first[0])
+	if idLength == 0 || idLength > 4 {
+		return 0, 0, ErrInvalidElement
+	}
+	header, err := d.r.Peek(idLength + 1)
+	if err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+	sizeLength := vintLength(header[idLength])
+	if sizeLength == 0 {
+		return 0, 0, ErrInvalidElement
+	}
+	header, err = d.r.Peek(idLength + sizeLength)
+	if err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+	id, _ := parseID(header)
+	size, _, unknown := parseVint(header[idLength:])
+	if _, err = d.r.Discard(idLength + sizeLength); err != nil {
+		return 0, 0, err
+	}
+	d.offset += int64(idLength + sizeLength)
+	if unknown {
+		return id, unknownSize, nil
+	}
+	return id, int64(size), nil
+}
+
+func (d *Demuxer) readBody(size int64) ([]byte, error) {
+	if size > maxElementSize {
+		return nil, fmt.Errorf("webm: element of %d bytes is too large", size)
+	}
+	if int64(cap(d.buff)) < size {
+		d.buff = make([]byte, size)
+	}
+	d.buff = d.buff[:size]
+	n, err := io.ReadFull(d.r, d.buff)
+	d.offset += int64(n)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return d.buff, nil
+}
+
+func (d *Demuxer) skip(size int64) error {
+	n, err := io.CopyN(io.Discard, d.r, size)
+	d.offset += n
+	return unexpectedEOF(err)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
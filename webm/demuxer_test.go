@@ -0,0 +1,353 @@
+package webm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// unknownSizeData marks a master element whose size is not known.
+var unknownSizeData = []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+func element(id uint32, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	return append(elementHeader(id, sizeData(len(body))), body...)
+}
+
+func elementHeader(id uint32, size []byte) []byte {
+	var data []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(data) > 0 {
+			data = append(data, b)
+		}
+	}
+	return append(data, size...)
+}
+
+func sizeData(size int) []byte {
+	for length := 1; length < 8; length++ {
+		if size < 1<<(7*length)-1 {
+			data := make([]byte, length)
+			for i := range data {
+				data[i] = byte(size >> (8 * (length - 1 - i)))
+			}
+			data[0] |= 0x80 >> (length - 1)
+			return data
+		}
+	}
+	panic("size too large")
+}
+
+func uintElement(id uint32, value uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, value)
+	return element(id, data)
+}
+
+func floatElement(id uint32, value float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(value))
+	return element(id, data)
+}
+
+func block(id uint32, track int, timecode int16, flags byte, data ...[]byte) []byte {
+	header := []byte{0x80 | byte(track), byte(uint16(timecode) >> 8), byte(timecode), flags}
+	return element(id, append([][]byte{header}, data...)...)
+}
+
+func ebmlHeader(docType string) []byte {
+	return element(idEBML, element(idDocType, []byte(docType)))
+}
+
+func testTracks() []byte {
+	return element(idTracks,
+		element(idTrackEntry,
+			uintElement(idTrackNumber, 1),
+			uintElement(idTrackType, uint64(TrackTypeVideo)),
+			element(idCodecID, []byte("V_VP9")),
+		),
+		element(idTrackEntry,
+			uintElement(idTrackNumber, 2),
+			uintElement(idTrackType, uint64(TrackTypeAudio)),
+			element(idCodecID, []byte(CodecOpus)),
+			element(idCodecPrivate, []byte("OpusHead")),
+			uintElement(idCodecDelay, uint64(6500*time.Microsecond)),
+			element(idAudio,
+				floatElement(idSamplingFrequency, 48000),
+				uintElement(idChannels, 2),
+			),
+		),
+	)
+}
+
+// testWebM builds a live webm stream with unknown sizes, a video track and an Opus track.
+func testWebM() []byte {
+	return bytes.Join([][]byte{
+		ebmlHeader("webm"),
+		elementHeader(idSegment, unknownSizeData),
+		element(idInfo,
+			uintElement(idTimecodeScale, uint64(time.Millisecond)),
+			floatElement(idDuration, 60),
+			element(idTitle, []byte("Title\x00\x00")),
+		),
+		testTracks(),
+		elementHeader(idCluster, unknownSizeData),
+		uintElement(idTimecode, 1000),
+		block(idSimpleBlock, 2, 0, 0x80, []byte{1}),
+		block(idSimpleBlock, 1, 0, 0x80, []byte{0xff}),
+		block(idSimpleBlock, 2, 20, 0, []byte{2, 2}),
+		element(idBlockGroup, block(idBlock, 2, 40, 0, []byte{3, 3, 3})),
+		element(idTags, element(idTag, element(idSimpleTag,
+			element(idTagName, []byte("ARTIST")),
+			element(idTagString, []byte("Artist")),
+		))),
+	}, nil)
+}
+
+func TestOpen(t *testing.T) {
+	d, err := Open(bytes.NewReader(testWebM()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if d.DocType() != "webm" {
+		t.Fatalf("got doc type %q, want %q", d.DocType(), "webm")
+	}
+	if len(d.Tracks()) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(d.Tracks()))
+	}
+	track := d.Track()
+	if track.Number != 2 || track.CodecID != CodecOpus {
+		t.Fatalf("got track %d with codec %q, want the opus track 2", track.Number, track.CodecID)
+	}
+	if track.SampleRate != 48000 || track.Channels != 2 || track.CodecDelay != 6500*time.Microsecond {
+		t.Fatalf("got %v Hz, %d channels, codec delay %s, want 48000 Hz, 2 channels, codec delay 6.5ms", track.SampleRate, track.Channels, track.CodecDelay)
+	}
+	if !bytes.Equal(track.CodecPrivate, []byte("OpusHead")) {
+		t.Fatalf("got codec private %q, want %q", track.CodecPrivate, "OpusHead")
+	}
+	metadata := d.Metadata()
+	if metadata.Title != "Title" || metadata.Duration != 60*time.Millisecond {
+		t.Fatalf("got title %q and duration %s, want %q and 60ms", metadata.Title, metadata.Duration, "Title")
+	}
+}
+
+func TestDemuxerReadPacket(t *testing.T) {
+	d, err := Open(bytes.NewReader(testWebM()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	for i, want := range []Packet{
+		{Time: time.Second, Data: []byte{1}, Keyframe: true},
+		{Time: time.Second + 20*time.Millisecond, Data: []byte{2, 2}},
+		{Time: time.Second + 40*time.Millisecond, Data: []byte{3, 3, 3}, Keyframe: true},
+	} {
+		packet, err := d.ReadPacket()
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if packet.Time != want.Time || !bytes.Equal(packet.Data, want.Data) || packet.Keyframe != want.Keyframe {
+			t.Fatalf("got packet %d %+v, want %+v", i, *packet, want)
+		}
+	}
+	if _, err = d.ReadPacket(); err != io.EOF {
+		t.Fatalf("got %v after the last packet, want io.EOF", err)
+	}
+	if got := d.Metadata().Tags["ARTIST"]; got != "Artist" {
+		t.Fatalf("got tag ARTIST %q after reading the tags, want %q", got, "Artist")
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "empty", data: nil, err: io.EOF},
+		{name: "no ebml header", data: element(idInfo), err: ErrNotWebM},
+		{name: "unknown doc type", data: ebmlHeader("mp4"), err: ErrNotWebM},
+		{name: "invalid id", data: []byte{0x00, 0x80}, err: ErrInvalidElement},
+		{name: "no tracks", data: append(ebmlHeader("matroska"), element(idSegment)...), err: ErrNoAudioTrack},
+		{name: "no audio track", data: append(ebmlHeader("matroska"), element(idSegment, element(idTracks, element(idTrackEntry, uintElement(idTrackType, uint64(TrackTypeVideo)))))...), err: ErrNoAudioTrack},
+		{name: "truncated", data: append(ebmlHeader("webm"), element(idSegment, element(idInfo, []byte{1, 2, 3}))[:10]...), err: io.ErrUnexpectedEOF},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Open(bytes.NewReader(tt.data)); err != tt.err {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseVint(t *testing.T) {
+	for _, tt := range []struct {
+		data    []byte
+		value   uint64
+		length  int
+		allOnes bool
+	}{
+		{data: []byte{0x81}, value: 1, length: 1},
+		{data: []byte{0x40, 0x02}, value: 2, length: 2},
+		{data: []byte{0x10, 0x00, 0x01, 0x00}, value: 256, length: 4},
+		{data: []byte{0xff}, value: 127, length: 1, allOnes: true},
+		{data: unknownSizeData, value: 1<<56 - 1, length: 8, allOnes: true},
+		{data: []byte{0x40}, length: 0},
+		{data: []byte{0x00}, length: 0},
+	} {
+		value, length, allOnes := parseVint(tt.data)
+		if value != tt.value || length != tt.length || allOnes != tt.allOnes {
+			t.Fatalf("got %d, %d, %t for %x, want %d, %d, %t", value, length, allOnes, tt.data, tt.value, tt.length, tt.allOnes)
+		}
+	}
+}
+
+// testSeekableWebM builds a webm file with a SeekHead pointing to the Cues after two clusters starting at 0s and 1s.
+func testSeekableWebM() []byte {
+	clusters := [][]byte{
+		element(idCluster,
+			uintElement(idTimecode, 0),
+			block(idSimpleBlock, 2, 0, 0x80, []byte{1}),
+			block(idSimpleBlock, 2, 20, 0x80, []byte{2}),
+		),
+		element(idCluster,
+			uintElement(idTimecode, 1000),
+			block(idSimpleBlock, 2, 0, 0x80, []byte{3}),
+			block(idSimpleBlock, 2, 20, 0x80, []byte{4}),
+		),
+	}
+	seekHead := func(cuesPosition uint64) []byte {
+		return element(idSeekHead, element(idSeek,
+			element(idSeekID, []byte{0x1C, 0x53, 0xBB, 0x6B}),
+			uintElement(idSeekPosition, cuesPosition),
+		))
+	}
+	header := bytes.Join([][]byte{seekHead(0), element(idInfo, uintElement(idTimecodeScale, uint64(time.Millisecond))), testTracks()}, nil)
+
+	cuePoint := func(cueTime uint64, position int) []byte {
+		return element(idCuePoint,
+			uintElement(idCueTime, cueTime),
+			element(idCueTrackPositions, uintElement(idCueTrack, 2), uintElement(idCueClusterPosition, uint64(position))),
+		)
+	}
+	cues := element(idCues,
+		cuePoint(0, len(header)),
+		cuePoint(1000, len(header)+len(clusters[0])),
+	)
+
+	segment := bytes.Join([][]byte{seekHead(uint64(len(header) + len(clusters[0]) + len(clusters[1]))), header[len(seekHead(0)):], clusters[0], clusters[1], cues}, nil)
+	return append(ebmlHeader("webm"), element(idSegment, segment)...)
+}
+
+func TestDemuxerSeek(t *testing.T) {
+	d, err := Open(bytes.NewReader(testSeekableWebM()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	for _, tt := range []struct {
+		position time.Duration
+		data     byte
+	}{
+		{position: 1020 * time.Millisecond, data: 4},
+		{position: 10 * time.Millisecond, data: 2},
+		{position: 0, data: 1},
+		{position: 500 * time.Millisecond, data: 3},
+		{position: time.Second, data: 3},
+	} {
+		if err = d.Seek(tt.position); err != nil {
+			t.Fatalf("seek to %s: %v", tt.position, err)
+		}
+		packet, err := d.ReadPacket()
+		if err != nil {
+			t.Fatalf("read after seeking to %s: %v", tt.position, err)
+		}
+		if packet.Data[0] != tt.data {
+			t.Fatalf("got packet %v after seeking to %s, want [%d]", packet.Data, tt.position, tt.data)
+		}
+	}
+	if len(d.cues) != 2 {
+		t.Fatalf("got %d cue points, want 2", len(d.cues))
+	}
+
+	if err = d.Seek(2 * time.Second); err != io.EOF {
+		t.Fatalf("got %v seeking past the end, want io.EOF", err)
+	}
+}
+
+func TestDemuxerSeekNotSeekable(t *testing.T) {
+	d, err := Open(struct{ io.Reader }{bytes.NewReader(testSeekableWebM())})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err = d.Seek(time.Second); err != ErrNotSeekable {
+		t.Fatalf("got %v, want %v", err, ErrNotSeekable)
+	}
+}
+
+func TestDemuxerReadLacedBlock(t *testing.T) {
+	data := bytes.Join([][]byte{
+		ebmlHeader("webm"),
+		elementHeader(idSegment, unknownSizeData),
+		testTracks(),
+		elementHeader(idCluster, unknownSizeData),
+		uintElement(idTimecode, 0),
+		block(idSimpleBlock, 2, 20, 0x80|lacingXiph<<1, []byte{2, 1, 2}, []byte{1}, []byte{2, 2}, []byte{3, 3, 3}),
+	}, nil)
+	d, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		packet, err := d.ReadPacket()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if len(packet.Data) != i || packet.Time != 20*time.Millisecond {
+			t.Fatalf("got frame %d %v at %s, want %d bytes at 20ms", i, packet.Data, packet.Time, i)
+		}
+	}
+	if _, err = d.ReadPacket(); err != io.EOF {
+		t.Fatalf("got %v after the last frame, want io.EOF", err)
+	}
+}
+
+func TestParseLacing(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		lacing byte
+		data   []byte
+		frames [][]byte
+		err    error
+	}{
+		{name: "none", lacing: lacingNone, data: []byte{1, 2, 3}, frames: [][]byte{{1, 2, 3}}},
+		{name: "xiph", lacing: lacingXiph, data: []byte{2, 1, 2, 1, 2, 2, 3, 3, 3}, frames: [][]byte{{1}, {2, 2}, {3, 3, 3}}},
+		{name: "xiph 255", lacing: lacingXiph, data: append([]byte{1, 255, 0}, make([]byte, 256)...), frames: [][]byte{make([]byte, 255), {0}}},
+		{name: "fixed", lacing: lacingFixed, data: []byte{2, 1, 1, 2, 2, 3, 3}, frames: [][]byte{{1, 1}, {2, 2}, {3, 3}}},
+		{name: "ebml", lacing: lacingEBML, data: []byte{2, 0x81, 0xC0, 1, 2, 2, 3, 3, 3}, frames: [][]byte{{1}, {2, 2}, {3, 3, 3}}},
+		{name: "ebml negative", lacing: lacingEBML, data: []byte{2, 0x82, 0xBE, 1, 1, 2, 3}, frames: [][]byte{{1, 1}, {2}, {3}}},
+		{name: "fixed uneven", lacing: lacingFixed, data: []byte{1, 1, 2, 3}, err: ErrInvalidElement},
+		{name: "xiph truncated", lacing: lacingXiph, data: []byte{1, 5, 1}, err: ErrInvalidElement},
+		{name: "empty", lacing: lacingEBML, err: ErrInvalidElement},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, err := parseLacing(tt.data, tt.lacing, nil)
+			if err != tt.err {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if len(frames) != len(tt.frames) {
+				t.Fatalf("got %d frames, want %d", len(frames), len(tt.frames))
+			}
+			for i := range frames {
+				if !bytes.Equal(frames[i], tt.frames[i]) {
+					t.Fatalf("got frame %d %v, want %v", i, frames[i], tt.frames[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package webm
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// ErrInvalidElement is returned when an EBML element is truncated or malformed.
+var ErrInvalidElement = errors.New("webm: invalid ebml element")
+
+// unknownSize is the size of master elements whose size is not known, e.g. in live streams.
+const unknownSize = -1
+
+// element ids used by the Demuxer
+const (
+	idEBML    = 0x1A45DFA3
+	idDocType = 0x4282
+
+	idSegment = 0x18538067
+
+	idSeekHead     = 0x114D9B74
+	idSeek         = 0x4DBB
+	idSeekID       = 0x53AB
+	idSeekPosition = 0x53AC
+
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idDuration      = 0x4489
+	idTitle         = 0x7BA9
+
+	idTracks            = 0x1654AE6B
+	idTrackEntry        = 0xAE
+	idTrackNumber       = 0xD7
+	idTrackType         = 0x83
+	idCodecID           = 0x86
+	idCodecPrivate      = 0x63A2
+	idCodecDelay        = 0x56AA
+	idSeekPreRoll       = 0x56BB
+	idAudio             = 0xE1
+	idSamplingFrequency = 0xB5
+	idChannels          = 0x9F
+
+	idCluster     = 0x1F43B675
+	idTimecode    = 0xE7
+	idSimpleBlock = 0xA3
+	idBlockGroup  = 0xA0
+	idBlock       = 0xA1
+
+	idCues               = 0x1C53BB6B
+	idCuePoint           = 0xBB
+	idCueTime            = 0xB3
+	idCueTrackPositions  = 0xB7
+	idCueTrack           = 0xF7
+	idCueClusterPosition = 0xF1
+
+	idTags      = 0x1254C367
+	idTag       = 0x7373
+	idSimpleTag = 0x67C8
+	idTagName   = 0x45A3
+	idTagString = 0x4487
+)
+
+// vintLength returns the length of a variable size integer by its first byte or 0 if it is invalid.
+func vintLength(b byte) int {
+	for i := 0; i < 8; i++ {
+		if b&(0x80>>i) != 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// parseVint parses a variable size integer and returns its value with the length marker removed, its length and whether all value bits are set.
+func parseVint(data []byte) (uint64, int, bool) {
+	if len(data) == 0 {
+		return 0, 0, false
+	}
+	length := vintLength(data[0])
+	if length == 0 || len(data) < length {
+		return 0, 0, false
+	}
+	value := uint64(data[0] & (0xff >> length))
+	allOnes := value == uint64(0xff>>length)
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xff
+	}
+	return value, length, allOnes
+}
+
+// parseID parses an element id, which keeps its length marker.
+func parseID(data []byte) (uint32, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	length := vintLength(data[0])
+	if length == 0 || length > 4 || len(data) < length {
+		return 0, 0
+	}
+	var id uint32
+	for _, b := range data[:length] {
+		id = id<<8 | uint32(b)
+	}
+	return id, length
+}
+
+// walkElements calls fn for each element in data.
+func walkElements(data []byte, fn func(id uint32, body []byte) error) error {
+	for len(data) > 0 {
+		id, idLength := parseID(data)
+		if idLength == 0 {
+			return ErrInvalidElement
+		}
+		size, sizeLength, unknown := parseVint(data[idLength:])
+		if sizeLength == 0 {
+			return ErrInvalidElement
+		}
+		data = data[idLength+sizeLength:]
+		if unknown || size > uint64(len(data)) {
+			size = uint64(len(data))
+		}
+		if err := fn(id, data[:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func readUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
+
+func readString(data []byte) string {
+	// strings may be zero padded
+	for i, b := range data {
+		if b == 0 {
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
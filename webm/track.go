@@ -0,0 +1,106 @@
+package webm
+
+import (
+	"time"
+)
+
+// TrackType is the type of a Track.
+type TrackType int
+
+const (
+	TrackTypeVideo    TrackType = 1
+	TrackTypeAudio    TrackType = 2
+	TrackTypeSubtitle TrackType = 17
+)
+
+// CodecOpus is the codec id of Opus tracks.
+const CodecOpus = "A_OPUS"
+
+// Track is a TrackEntry of the Tracks element.
+type Track struct {
+	Number  int
+	Type    TrackType
+	CodecID string
+	// CodecPrivate is the OpusHead of Opus tracks.
+	CodecPrivate []byte
+	// CodecDelay is the amount of audio the decoder has to discard at the start.
+	CodecDelay time.Duration
+	// SeekPreRoll is the amount of audio the decoder has to decode before the seek target.
+	SeekPreRoll time.Duration
+	SampleRate  float64
+	Channels    int
+}
+
+func parseTrackEntry(body []byte) (*Track, error) {
+	track := &Track{
+		Channels: 1,
+	}
+	err := walkElements(body, func(id uint32, body []byte) error {
+		switch id {
+		case idTrackNumber:
+			track.Number = int(readUint(body))
+		case idTrackType:
+			track.Type = TrackType(readUint(body))
+		case idCodecID:
+			track.CodecID = readString(body)
+		case idCodecPrivate:
+			track.CodecPrivate = append([]byte(nil), body...)
+		case idCodecDelay:
+			track.CodecDelay = time.Duration(readUint(body))
+		case idSeekPreRoll:
+			track.SeekPreRoll = time.Duration(readUint(body))
+		case idAudio:
+			return walkElements(body, func(id uint32, body []byte) error {
+				switch id {
+				case idSamplingFrequency:
+					track.SampleRate = readFloat(body)
+				case idChannels:
+					track.Channels = int(readUint(body))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return track, err
+}
+
+// Metadata holds the segment title, duration and simple tags.
+type Metadata struct {
+	Title    string
+	Duration time.Duration
+	// Tags holds the simple tags by their name, e.g. "ARTIST".
+	Tags map[string]string
+}
+
+func (m *Metadata) parseTags(body []byte) error {
+	return walkElements(body, func(id uint32, body []byte) error {
+		if id != idTag {
+			return nil
+		}
+		return walkElements(body, func(id uint32, body []byte) error {
+			if id != idSimpleTag {
+				return nil
+			}
+			var name, value string
+			if err := walkElements(body, func(id uint32, body []byte) error {
+				switch id {
+				case idTagName:
+					name = readString(body)
+				case idTagString:
+					value = readString(body)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if name != "" {
+				if m.Tags == nil {
+					m.Tags = map[string]string{}
+				}
+				m.Tags[name] = value
+			}
+			return nil
+		})
+	})
+}
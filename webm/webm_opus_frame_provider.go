@@ -0,0 +1,136 @@
+package webm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/disgoorg/audio/opus"
+	"github.com/disgoorg/disgo/voice"
+)
+
+// ErrNotOpus is returned when the selected Track does not contain Opus.
+var ErrNotOpus = errors.New("webm: track does not contain opus")
+
+// OpusFrameProvider is a voice.OpusFrameProvider which supports seeking.
+type OpusFrameProvider interface {
+	voice.OpusFrameProvider
+
+	// Seek seeks to the first packet at or after the given position. It returns ErrNotSeekable if the reader is not an io.Seeker.
+	Seek(position time.Duration) error
+
+	// Position returns the position of the next packet.
+	Position() time.Duration
+
+	// Duration returns the duration of the stream or 0 if unknown.
+	Duration() time.Duration
+}
+
+// NewOpusFrameProvider returns an OpusFrameProvider which passes the Opus packets of the given webm/mka stream through without decoding them.
+// The packets are normalized to 20ms packets, see NewDemuxerOpusFrameProvider.
+func NewOpusFrameProvider(r io.Reader) (OpusFrameProvider, error) {
+	demuxer, err := Open(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open webm demuxer: %w", err)
+	}
+	return NewDemuxerOpusFrameProvider(demuxer)
+}
+
+// NewDemuxerOpusFrameProvider returns an OpusFrameProvider which passes the Opus packets of the selected Track of the given Demuxer through.
+// The packets are normalized to 20ms packets using opus.NewRepacketizingFrameProvider, invalid packets are dropped.
+func NewDemuxerOpusFrameProvider(demuxer *Demuxer) (OpusFrameProvider, error) {
+	if demuxer.Track().CodecID != CodecOpus {
+		return nil, ErrNotOpus
+	}
+	packets := &opusPacketProvider{demuxer: demuxer}
+	provider, err := opus.NewRepacketizingFrameProvider(packets)
+	if err != nil {
+		return nil, err
+	}
+	return &opusFrameProvider{
+		demuxer:  demuxer,
+		packets:  packets,
+		provider: provider,
+	}, nil
+}
+
+type opusFrameProvider struct {
+	demuxer  *Demuxer
+	packets  *opusPacketProvider
+	provider voice.OpusFrameProvider
+	position time.Duration
+}
+
+func (p *opusFrameProvider) ProvideOpusFrame() ([]byte, error) {
+	packet, err := p.provider.ProvideOpusFrame()
+	if err != nil || packet == nil {
+		return packet, err
+	}
+	// the gaps are skipped before the packets following them, the repacketizer may have read ahead by up to 20ms
+	p.position += p.packets.skipped
+	p.packets.skipped = 0
+	if info, err := opus.ParsePacket(packet); err == nil {
+		p.position += info.Duration()
+	}
+	return packet, nil
+}
+
+func (p *opusFrameProvider) Seek(position time.Duration) error {
+	if err := p.demuxer.Seek(position); err != nil {
+		return err
+	}
+	// drop the frames pending in the repacketizer
+	provider, err := opus.NewRepacketizingFrameProvider(p.packets)
+	if err != nil {
+		return err
+	}
+	p.provider.Close()
+	p.provider = provider
+	p.packets.end = position
+	p.packets.skipped = 0
+	p.position = position
+	return nil
+}
+
+func (p *opusFrameProvider) Position() time.Duration {
+	return p.position
+}
+
+func (p *opusFrameProvider) Duration() time.Duration {
+	return p.demuxer.Duration()
+}
+
+func (p *opusFrameProvider) Close() {
+	p.provider.Close()
+}
+
+// opusPacketProvider provides the valid Opus packets of a Demuxer and keeps track of the gaps between them.
+type opusPacketProvider struct {
+	demuxer *Demuxer
+	// end is the time after the last packet and skipped the duration of the gaps which were not added to the position yet.
+	end     time.Duration
+	skipped time.Duration
+}
+
+func (p *opusPacketProvider) ProvideOpusFrame() ([]byte, error) {
+	for {
+		packet, err := p.demuxer.ReadPacket()
+		if err != nil {
+			return nil, err
+		}
+		info, err := opus.ParsePacket(packet.Data)
+		if err != nil {
+			continue
+		}
+		if packet.Time > p.end {
+			p.skipped += packet.Time - p.end
+			p.end = packet.Time
+		}
+		p.end += info.Duration()
+		return packet.Data, nil
+	}
+}
+
+// Close does nothing as the Demuxer is owned by the opusFrameProvider.
+func (p *opusPacketProvider) Close() {}
@@ -0,0 +1,107 @@
+package webm
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/audio/opus"
+)
+
+func TestOpusFrameProviderSplitsPackets(t *testing.T) {
+	data := bytes.Join([][]byte{
+		ebmlHeader("webm"),
+		elementHeader(idSegment, unknownSizeData),
+		testTracks(),
+		elementHeader(idCluster, unknownSizeData),
+		uintElement(idTimecode, 0),
+		// 3 frames of 20ms
+		block(idSimpleBlock, 2, 0, 0x80, []byte{0xfb, 0x03, 1, 1, 2, 2, 3, 3}),
+		// 4 frames of 10ms
+		block(idSimpleBlock, 2, 60, 0x80, []byte{0xf3, 0x04, 4, 5, 6, 7}),
+		// a single 20ms frame is passed through
+		block(idSimpleBlock, 2, 100, 0x80, []byte{0xf8, 8}),
+	}, nil)
+	provider, err := NewOpusFrameProvider(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	defer provider.Close()
+
+	for i, want := range []struct {
+		frames   [][]byte
+		position time.Duration
+	}{
+		{frames: [][]byte{{1, 1}}, position: 20 * time.Millisecond},
+		{frames: [][]byte{{2, 2}}, position: 40 * time.Millisecond},
+		{frames: [][]byte{{3, 3}}, position: 60 * time.Millisecond},
+		{frames: [][]byte{{4}, {5}}, position: 80 * time.Millisecond},
+		{frames: [][]byte{{6}, {7}}, position: 100 * time.Millisecond},
+		{frames: [][]byte{{8}}, position: 120 * time.Millisecond},
+	} {
+		packet, err := provider.ProvideOpusFrame()
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		info, err := opus.ParsePacket(packet)
+		if err != nil {
+			t.Fatalf("parse packet %d: %v", i, err)
+		}
+		if info.Duration() != 20*time.Millisecond {
+			t.Fatalf("got packet %d with %s of audio, want 20ms", i, info.Duration())
+		}
+		frames := info.Frames
+		if len(frames) != len(want.frames) {
+			t.Fatalf("got %d frames in packet %d, want %d", len(frames), i, len(want.frames))
+		}
+		for j := range frames {
+			if !bytes.Equal(frames[j], want.frames[j]) {
+				t.Fatalf("got frame %d of packet %d %v, want %v", j, i, frames[j], want.frames[j])
+			}
+		}
+		if got := provider.Position(); got != want.position {
+			t.Fatalf("got position %s after packet %d, want %s", got, i, want.position)
+		}
+	}
+	if _, err = provider.ProvideOpusFrame(); err != io.EOF {
+		t.Fatalf("got %v after the last packet, want io.EOF", err)
+	}
+}
+
+func TestOpusFrameProviderSeek(t *testing.T) {
+	provider, err := NewOpusFrameProvider(bytes.NewReader(testSeekableWebM()))
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	defer provider.Close()
+
+	if err = provider.Seek(time.Second); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if got := provider.Position(); got != time.Second {
+		t.Fatalf("got position %s after seeking, want 1s", got)
+	}
+	// the invalid packet [3] at 1s is dropped
+	packet, err := provider.ProvideOpusFrame()
+	if err != nil {
+		t.Fatalf("provide after seeking: %v", err)
+	}
+	if !bytes.Equal(packet, []byte{4}) {
+		t.Fatalf("got packet %v after seeking, want [4]", packet)
+	}
+	if got := provider.Position(); got != time.Second+30*time.Millisecond {
+		t.Fatalf("got position %s after the first packet, want 1.03s", got)
+	}
+}
+
+func TestNewDemuxerOpusFrameProviderNotOpus(t *testing.T) {
+	d, err := Open(bytes.NewReader(testWebM()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.SelectTrack(d.Tracks()[0])
+	if _, err = NewDemuxerOpusFrameProvider(d); err != ErrNotOpus {
+		t.Fatalf("got %v, want %v", err, ErrNotOpus)
+	}
+}